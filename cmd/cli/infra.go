@@ -19,6 +19,7 @@ var (
 		"GITHUB_CLIENT_ID",
 		"GITHUB_CLIENT_SECRET",
 		"JWT_SECRET",
+		"AUDIT_SIGNING_KEY",
 		"GITHUB_REDIRECT_URL",
 	}
 )
@@ -63,6 +64,7 @@ func runDeployInfra(cmd *cobra.Command, args []string) error {
 	githubClientID := strings.TrimSpace(os.Getenv("GITHUB_CLIENT_ID"))
 	githubClientSecret := strings.TrimSpace(os.Getenv("GITHUB_CLIENT_SECRET"))
 	jwtSecret := strings.TrimSpace(os.Getenv("JWT_SECRET"))
+	auditSigningKey := strings.TrimSpace(os.Getenv("AUDIT_SIGNING_KEY"))
 	cosmosDatabase := strings.TrimSpace(os.Getenv("COSMOS_DATABASE"))
 	githubRedirectURL := strings.TrimSpace(os.Getenv("GITHUB_REDIRECT_URL"))
 	customDomainName := strings.TrimSpace(os.Getenv("CUSTOM_DOMAIN_NAME"))
@@ -95,7 +97,7 @@ func runDeployInfra(cmd *cobra.Command, args []string) error {
 
 	// Deploy Bicep template
 	color.New(color.FgCyan, color.Bold).Printf("\nDeploying infrastructure for environment: %s\n", env)
-	return deployBicep(bicepPath, env, resourceGroup, githubClientID, githubClientSecret, jwtSecret, githubRedirectURL, customDomainName)
+	return deployBicep(bicepPath, env, resourceGroup, githubClientID, githubClientSecret, jwtSecret, auditSigningKey, githubRedirectURL, customDomainName)
 }
 
 // validateRequiredEnvVars checks for required environment variables and returns list of missing ones
@@ -169,7 +171,7 @@ func ensureResourceGroup(resourceGroup, environment string) error {
 	return nil
 }
 
-func deployBicep(bicepPath string, env string, resourceGroup string, githubClientID, githubClientSecret, jwtSecret, githubRedirectURL, customDomainName string) error {
+func deployBicep(bicepPath string, env string, resourceGroup string, githubClientID, githubClientSecret, jwtSecret, auditSigningKey, githubRedirectURL, customDomainName string) error {
 	deploymentName := fmt.Sprintf("baluster-%s-%d", env, os.Getpid())
 
 	args := []string{
@@ -182,6 +184,7 @@ func deployBicep(bicepPath string, env string, resourceGroup string, githubClien
 		fmt.Sprintf("githubClientId=%s", githubClientID),
 		fmt.Sprintf("githubClientSecret=%s", githubClientSecret),
 		fmt.Sprintf("jwtSecret=%s", jwtSecret),
+		fmt.Sprintf("auditSigningKey=%s", auditSigningKey),
 		fmt.Sprintf("githubRedirectUrl=%s", githubRedirectURL),
 	}
 
@@ -2,45 +2,64 @@ package handlers
 
 import (
 	"context"
+	"errors"
 
 	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/brianfromlife/baluster/internal/auth"
 	"github.com/brianfromlife/baluster/internal/core"
 	v1 "github.com/brianfromlife/baluster/internal/gen"
 	"github.com/brianfromlife/baluster/internal/storage"
 )
 
+// ServiceKeyRepository is what the access handler needs from the service key storage
+type ServiceKeyRepository interface {
+	core.ServiceKeyTokenFinder
+	core.ServiceKeyEventLister
+}
+
 // AccessHandler implements the AccessService
 type AccessHandler struct {
-	serviceKeyRepo *storage.ServiceKeyRepository
+	serviceKeyRepo ServiceKeyRepository
+	accessLog      core.AccessRecorder
+	closed         context.Context
+	closeStreams   context.CancelFunc
 }
 
-// NewAccessHandler creates a new access handler
-func NewAccessHandler(serviceKeyRepo *storage.ServiceKeyRepository) *AccessHandler {
+// NewAccessHandler creates a new access handler that records validation decisions in accessLog
+func NewAccessHandler(serviceKeyRepo ServiceKeyRepository, accessLog core.AccessRecorder) *AccessHandler {
+	closed, closeStreams := context.WithCancel(context.Background())
 	return &AccessHandler{
 		serviceKeyRepo: serviceKeyRepo,
+		accessLog:      accessLog,
+		closed:         closed,
+		closeStreams:   closeStreams,
 	}
 }
 
+// Close ends every open WatchServiceKeys stream
+func (h *AccessHandler) Close() {
+	h.closeStreams()
+}
+
 // ValidateAccess validates a service key for a specific application and returns its permissions
 func (h *AccessHandler) ValidateAccess(
 	ctx context.Context,
 	req *connect.Request[v1.ValidateAccessRequest],
 ) (*connect.Response[v1.ValidateAccessResponse], error) {
-	// Extract organization ID from request message or metadata header (x-org-id)
-	// Prefer the message field, but fall back to header for backwards compatibility
-	orgID := req.Msg.OrganizationId
-	if orgID == "" {
-		orgID = req.Header().Get("x-org-id")
-	}
-	if orgID == "" {
+	// The organization is the API key's, ApiKeyAuthInterceptor already rejected a mismatched
+	// organization_id message field or x-org-id header
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
 		return connect.NewResponse(&v1.ValidateAccessResponse{
 			Valid: false,
-		}), connect.NewError(connect.CodeInvalidArgument, nil)
+		}), connect.NewError(connect.CodeUnauthenticated, nil)
 	}
 
 	input := &core.ValidateAccessInput{
 		Token:           req.Msg.Token,
+		ApplicationID:   req.Msg.ApplicationId,
 		ApplicationName: req.Msg.ApplicationName,
 		OrganizationID:  orgID,
 	}
@@ -51,9 +70,150 @@ func (h *AccessHandler) ValidateAccess(
 			Valid: false,
 		}), nil
 	}
+	h.accessLog.Record(core.NewAccessLog(ctx, orgID, output))
 
 	return connect.NewResponse(&v1.ValidateAccessResponse{
 		Valid:       output.Valid,
 		Permissions: output.Permissions,
 	}), nil
 }
+
+// BatchValidateAccess validates many (token, application, permission) checks in one call
+func (h *AccessHandler) BatchValidateAccess(
+	ctx context.Context,
+	req *connect.Request[v1.BatchValidateAccessRequest],
+) (*connect.Response[v1.BatchValidateAccessResponse], error) {
+	// The organization is the API key's, ApiKeyAuthInterceptor already rejected a mismatched one
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, nil)
+	}
+
+	input := &core.BatchValidateAccessInput{
+		OrganizationID: orgID,
+	}
+	for _, check := range req.Msg.Checks {
+		input.Checks = append(input.Checks, core.AccessCheck{
+			Token:           check.Token,
+			ApplicationID:   check.ApplicationId,
+			ApplicationName: check.ApplicationName,
+			Permission:      check.Permission,
+		})
+	}
+
+	output, err := core.BatchValidateAccess(ctx, h.serviceKeyRepo, input)
+	if err != nil {
+		if errors.Is(err, core.ErrNoChecks) || errors.Is(err, core.ErrTooManyChecks) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	results := make([]*v1.ValidateAccessResponse, len(output.Results))
+	for i, result := range output.Results {
+		h.accessLog.Record(core.NewAccessLog(ctx, orgID, result))
+		results[i] = &v1.ValidateAccessResponse{
+			Valid:       result.Valid,
+			Permissions: result.Permissions,
+		}
+	}
+
+	return connect.NewResponse(&v1.BatchValidateAccessResponse{
+		Results: results,
+	}), nil
+}
+
+// permissionMatches maps the protobuf enum to the core match
+var permissionMatches = map[v1.PermissionMatch]core.PermissionMatch{
+	v1.PermissionMatch_PERMISSION_MATCH_UNSPECIFIED: core.PermissionMatchAllOf,
+	v1.PermissionMatch_PERMISSION_MATCH_ALL_OF:      core.PermissionMatchAllOf,
+	v1.PermissionMatch_PERMISSION_MATCH_ANY_OF:      core.PermissionMatchAnyOf,
+}
+
+// CheckPermission answers whether a service key has the required permissions for an application
+func (h *AccessHandler) CheckPermission(
+	ctx context.Context,
+	req *connect.Request[v1.CheckPermissionRequest],
+) (*connect.Response[v1.CheckPermissionResponse], error) {
+	// The organization is the API key's, ApiKeyAuthInterceptor already rejected a mismatched one
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, nil)
+	}
+
+	match, ok := permissionMatches[req.Msg.Match]
+	if !ok {
+		return nil, connect.NewError(connect.CodeInvalidArgument, core.ErrInvalidPermissionMatch)
+	}
+
+	input := &core.CheckPermissionInput{
+		Token:           req.Msg.Token,
+		ApplicationID:   req.Msg.ApplicationId,
+		ApplicationName: req.Msg.ApplicationName,
+		OrganizationID:  orgID,
+		Permissions:     req.Msg.Permissions,
+		Match:           match,
+	}
+
+	output, err := core.CheckPermission(ctx, h.serviceKeyRepo, input)
+	if err != nil {
+		if errors.Is(err, core.ErrNoPermissions) || errors.Is(err, core.ErrInvalidPermissionMatch) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.CheckPermissionResponse{
+		Allowed:            output.Allowed,
+		DenialReason:       string(output.DenialReason),
+		MissingPermissions: output.MissingPermissions,
+	}), nil
+}
+
+// WatchServiceKeys streams the changes to the organization's service keys until the client disconnects
+func (h *AccessHandler) WatchServiceKeys(
+	ctx context.Context,
+	req *connect.Request[v1.WatchServiceKeysRequest],
+	stream *connect.ServerStream[v1.WatchServiceKeysResponse],
+) error {
+	// The organization is the API key's, ApiKeyAuthInterceptor already rejected a mismatched one
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return connect.NewError(connect.CodeUnauthenticated, nil)
+	}
+
+	// The stream also ends when the handler is closed
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(h.closed, cancel)
+	defer stop()
+
+	input := &core.WatchServiceKeysInput{
+		OrganizationID: orgID,
+		Cursor:         req.Msg.Cursor,
+	}
+
+	err := core.WatchServiceKeys(ctx, h.serviceKeyRepo, input, func(event *core.ServiceKeyEvent) error {
+		return stream.Send(&v1.WatchServiceKeysResponse{
+			Event: &v1.ServiceKeyEvent{
+				Cursor:            event.Cursor,
+				ServiceKeyId:      event.ServiceKeyID,
+				Action:            string(event.Action),
+				ChangedAt:         timestamppb.New(event.ChangedAt),
+				ChangedByUsername: event.ChangedByUsername,
+			},
+		})
+	})
+	if err != nil {
+		// The client went away, there is nobody left to tell
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			return connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return connect.NewError(connect.CodeInternal, err)
+	}
+
+	return nil
+}
@@ -19,7 +19,8 @@ import (
 	balusterv1connect "github.com/brianfromlife/baluster/internal/gen/balusterv1connect"
 	httputil "github.com/brianfromlife/baluster/internal/http"
 	"github.com/brianfromlife/baluster/internal/server"
-	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/joho/godotenv"
 )
 
@@ -36,36 +37,40 @@ func main() {
 	}
 
 	ctx := context.Background()
-	cosmosClient, err := storage.NewClient(ctx, storage.Config{
-		Endpoint: cfg.CosmosEndpoint,
-		Key:      cfg.CosmosKey,
-		Database: cfg.CosmosDatabase,
-	})
+	repos, err := server.NewRepositories(ctx, cfg)
 	if err != nil {
-		logger.Error("failed to initialize Cosmos DB client", "error", err)
+		logger.Error("failed to initialize storage", "backend", cfg.StorageBackend, "error", err)
 		os.Exit(1)
 	}
+	tokenCaches := server.WithTokenCaches(repos, cfg)
+	accessLog := server.NewAccessLogRecorder(repos, cfg)
 
-	serviceKeyRepo, err := storage.NewServiceKeyRepository(cosmosClient)
+	// Shared cache invalidations between replicas, optional
+	rdb, err := server.NewRedis(ctx, cfg)
 	if err != nil {
-		logger.Error("failed to initialize service key repository", "error", err)
+		logger.Error("failed to initialize Redis", "error", err)
 		os.Exit(1)
 	}
-
-	apiKeyRepo, err := storage.NewApiKeyRepository(cosmosClient)
-	if err != nil {
-		logger.Error("failed to initialize API key repository", "error", err)
-		os.Exit(1)
+	if rdb != nil {
+		tokenCaches.ShareInvalidations(rdb.Invalidator)
 	}
 
+	serviceKeyRepo := repos.ServiceKeys
+	apiKeyRepo := repos.ApiKeys
+
 	apiKeyValidator := auth.NewApiKeyValidator(apiKeyRepo)
-	accessHandler := handlers.NewAccessHandler(serviceKeyRepo)
+	accessHandler := handlers.NewAccessHandler(serviceKeyRepo, accessLog)
 
 	mux := http.NewServeMux()
 
+	// Validating service keys only needs the validate access scope. The watch stream is not limited to the
+	// key's applications, so it needs its own scope that application-bound keys are not given by default.
+	accessScopes := auth.ProcedureScopes{
+		balusterv1connect.AccessServiceWatchServiceKeysProcedure: types.ApiKeyScopeWatchServiceKeys,
+	}
 	accessPath, accessHandlerHTTP := balusterv1connect.NewAccessServiceHandler(
 		accessHandler,
-		connect.WithInterceptors(auth.ApiKeyAuthInterceptor(apiKeyValidator)),
+		connect.WithInterceptors(auth.ApiKeyAuthInterceptor(apiKeyValidator, types.ApiKeyScopeValidateAccess, accessScopes)),
 	)
 
 	mux.Handle(accessPath, accessHandlerHTTP)
@@ -75,13 +80,31 @@ func main() {
 		_, _ = w.Write([]byte("OK"))
 	})
 
+	// Internal counters, served on METRICS_PORT only
+	metricsMux := http.NewServeMux()
+
+	// Token cache hit and miss counters
+	metricsMux.HandleFunc("GET /health/cache", func(w http.ResponseWriter, r *http.Request) {
+		httputil.Success(w, http.StatusOK, tokenCaches.Stats())
+	})
+
+	// Access log recorded, dropped and failed counters
+	metricsMux.HandleFunc("GET /health/access-log", func(w http.ResponseWriter, r *http.Request) {
+		httputil.Success(w, http.StatusOK, accessLog.Stats())
+	})
+
+	// The access log records each caller's IP, taken from X-Forwarded-For or X-Real-IP behind a proxy
+	handler := middleware.RealIP(httputil.RequestMetadata(httputil.CORS()(mux)))
+
 	srv := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      h2c.NewHandler(httputil.CORS()(mux), &http2.Server{}),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:        ":" + cfg.Port,
+		Handler:     h2c.NewHandler(handler, &http2.Server{}),
+		ReadTimeout: 15 * time.Second,
+		// No write timeout, WatchServiceKeys streams stay open for as long as the client listens
+		IdleTimeout: 60 * time.Second,
 	}
+	// Open streams end when the server shuts down, clients resume them from their last cursor
+	srv.RegisterOnShutdown(accessHandler.Close)
 
 	go func() {
 		logger.Info("starting gRPC server", "port", cfg.Port)
@@ -91,6 +114,17 @@ func main() {
 		}
 	}()
 
+	metricsSrv := server.NewMetricsServer(cfg, metricsMux)
+	if metricsSrv != nil {
+		go func() {
+			logger.Info("starting metrics server", "port", cfg.MetricsPort)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("metrics server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -102,4 +136,15 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("server forced to shutdown", "error", err)
 	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			logger.Error("metrics server forced to shutdown", "error", err)
+		}
+	}
+
+	// Stop the cache janitors once no request can use the caches anymore
+	tokenCaches.Close()
+	// Write the access decisions still queued
+	accessLog.Close()
+	rdb.Close()
 }
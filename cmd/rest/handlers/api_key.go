@@ -3,19 +3,24 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/brianfromlife/baluster/internal/core/admin"
 	httputil "github.com/brianfromlife/baluster/internal/http"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
 	"github.com/go-chi/chi/v5"
 )
 
 // CreateApiKeyRequest represents the HTTP request to create an API key
 type CreateApiKeyRequest struct {
-	ApplicationID string     `json:"application_id"`
-	Name          string     `json:"name"`
-	ExpiresAt     *time.Time `json:"expires_at"`
+	ApplicationID  string              `json:"application_id"`
+	Name           string              `json:"name"`
+	ExpiresAt      *time.Time          `json:"expires_at"`
+	Scopes         []types.ApiKeyScope `json:"scopes"`
+	ApplicationIDs []string            `json:"application_ids"`
 }
 
 // Validate validates the CreateApiKeyRequest
@@ -36,7 +41,7 @@ type CreateApiKeyResponse struct {
 }
 
 // CreateApiKey creates a new API key
-func CreateApiKey(apiKeyRepo admin.ApiKeyCreator) http.HandlerFunc {
+func CreateApiKey(apiKeyRepo admin.ApiKeyCreator, appRepo admin.ApplicationFinder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		req, err := httputil.Decode[CreateApiKeyRequest](r)
 		if err != nil {
@@ -45,17 +50,23 @@ func CreateApiKey(apiKeyRepo admin.ApiKeyCreator) http.HandlerFunc {
 		}
 
 		input := &admin.CreateApiKeyInput{
-			ApplicationID: req.ApplicationID,
-			Name:          req.Name,
-			ExpiresAt:     req.ExpiresAt,
+			ApplicationID:  req.ApplicationID,
+			Name:           req.Name,
+			ExpiresAt:      req.ExpiresAt,
+			Scopes:         req.Scopes,
+			ApplicationIDs: req.ApplicationIDs,
 		}
 
-		output, err := admin.CreateApiKey(r.Context(), apiKeyRepo, input)
+		output, err := admin.CreateApiKey(r.Context(), apiKeyRepo, appRepo, input)
 		if err != nil {
 			if errors.Is(err, admin.ErrUserInfoNotFound) {
 				httputil.Error(w, http.StatusUnauthorized, err)
 				return
 			}
+			if errors.Is(err, admin.ErrInvalidApiKeyScope) || errors.Is(err, admin.ErrInvalidApiKeyApplication) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
 			if errors.Is(err, admin.ErrApiKeyLimitExceeded) {
 				httputil.Error(w, http.StatusForbidden, err)
 				return
@@ -96,6 +107,7 @@ func GetApiKey(apiKeyRepo admin.ApiKeyGetter) http.HandlerFunc {
 
 		// Clear the hashed value from response
 		output.Token.TokenValue = ""
+		output.Token.PreviousTokenValue = ""
 
 		httputil.Success(w, http.StatusOK, output.Token)
 	}
@@ -140,6 +152,7 @@ func ListApiKeys(apiKeyRepo admin.ApiKeyLister) http.HandlerFunc {
 		// Clear token values from response
 		for _, token := range output.Tokens {
 			token.TokenValue = ""
+			token.PreviousTokenValue = ""
 		}
 
 		httputil.Success(w, http.StatusOK, output.Tokens)
@@ -148,8 +161,10 @@ func ListApiKeys(apiKeyRepo admin.ApiKeyLister) http.HandlerFunc {
 
 // UpdateApiKeyRequest represents the HTTP request to update an API key
 type UpdateApiKeyRequest struct {
-	Name      string     `json:"name"`
-	ExpiresAt *time.Time `json:"expires_at"`
+	Name           string              `json:"name"`
+	ExpiresAt      *time.Time          `json:"expires_at"`
+	Scopes         []types.ApiKeyScope `json:"scopes"`
+	ApplicationIDs []string            `json:"application_ids"`
 }
 
 // Validate validates the UpdateApiKeyRequest
@@ -158,7 +173,7 @@ func (r UpdateApiKeyRequest) Validate() error {
 }
 
 // UpdateApiKey updates an API key
-func UpdateApiKey(apiKeyRepo admin.ApiKeyUpdater) http.HandlerFunc {
+func UpdateApiKey(apiKeyRepo admin.ApiKeyUpdater, appRepo admin.ApplicationFinder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tokenID := chi.URLParam(r, "token_id")
 		if tokenID == "" {
@@ -173,24 +188,158 @@ func UpdateApiKey(apiKeyRepo admin.ApiKeyUpdater) http.HandlerFunc {
 		}
 
 		input := &admin.UpdateApiKeyInput{
-			ID:        tokenID,
-			Name:      req.Name,
-			ExpiresAt: req.ExpiresAt,
+			ID:             tokenID,
+			Name:           req.Name,
+			ExpiresAt:      req.ExpiresAt,
+			Scopes:         req.Scopes,
+			ApplicationIDs: req.ApplicationIDs,
 		}
 
-		output, err := admin.UpdateApiKey(r.Context(), apiKeyRepo, input)
+		output, err := admin.UpdateApiKey(r.Context(), apiKeyRepo, appRepo, input)
 		if err != nil {
+			if errors.Is(err, admin.ErrInvalidApiKeyScope) || errors.Is(err, admin.ErrInvalidApiKeyApplication) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
 			httputil.Error(w, http.StatusInternalServerError, err)
 			return
 		}
 
 		// Clear the hashed value from response
 		output.Token.TokenValue = ""
+		output.Token.PreviousTokenValue = ""
+
+		httputil.Success(w, http.StatusOK, output.Token)
+	}
+}
+
+// RotateApiKeyRequest represents the HTTP request to rotate an API key
+type RotateApiKeyRequest struct {
+	// GracePeriodSeconds overrides how long the replaced token stays valid
+	GracePeriodSeconds *int `json:"grace_period_seconds"`
+}
+
+// Validate validates the RotateApiKeyRequest
+func (r RotateApiKeyRequest) Validate() error {
+	if r.GracePeriodSeconds != nil && *r.GracePeriodSeconds < 0 {
+		return fmt.Errorf("grace_period_seconds must not be negative")
+	}
+	return nil
+}
+
+// RotateApiKeyResponse represents the HTTP response with the new token value
+type RotateApiKeyResponse struct {
+	Token                  any        `json:"token"` // *types.ApiKey
+	TokenValue             string     `json:"token_value"`
+	PreviousTokenExpiresAt *time.Time `json:"previous_token_expires_at"`
+}
+
+// RotateApiKey issues a new token for an API key, keeping the old one valid for a grace period
+func RotateApiKey(apiKeyRepo admin.ApiKeyRotator, defaultGracePeriod time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenID := chi.URLParam(r, "token_id")
+		if tokenID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("token_id is required"))
+			return
+		}
+
+		// The body is optional, an empty one uses the default grace period
+		req, err := httputil.Decode[RotateApiKeyRequest](r)
+		if err != nil && !errors.Is(err, io.EOF) {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		input := &admin.RotateApiKeyInput{
+			ID:          tokenID,
+			GracePeriod: defaultGracePeriod,
+		}
+		if req.GracePeriodSeconds != nil {
+			input.GracePeriod = time.Duration(*req.GracePeriodSeconds) * time.Second
+		}
+
+		output, err := admin.RotateApiKey(r.Context(), apiKeyRepo, input)
+		if err != nil {
+			respondApiKeyError(w, err)
+			return
+		}
+
+		// Clear the hashed values from response
+		responseToken := output.Token
+		responseToken.TokenValue = ""
+		responseToken.PreviousTokenValue = ""
+
+		httputil.Success(w, http.StatusOK, RotateApiKeyResponse{
+			Token:                  responseToken,
+			TokenValue:             output.TokenValue,
+			PreviousTokenExpiresAt: responseToken.PreviousTokenExpiresAt,
+		})
+	}
+}
+
+// RevokeApiKeyRequest represents the HTTP request to revoke an API key
+type RevokeApiKeyRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Validate validates the RevokeApiKeyRequest
+func (r RevokeApiKeyRequest) Validate() error {
+	if r.Reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	return nil
+}
+
+// RevokeApiKey revokes an API key immediately while keeping its record
+func RevokeApiKey(apiKeyRepo admin.ApiKeyRevoker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenID := chi.URLParam(r, "token_id")
+		if tokenID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("token_id is required"))
+			return
+		}
+
+		req, err := httputil.Decode[RevokeApiKeyRequest](r)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		input := &admin.RevokeApiKeyInput{
+			ID:     tokenID,
+			Reason: req.Reason,
+		}
+
+		output, err := admin.RevokeApiKey(r.Context(), apiKeyRepo, input)
+		if err != nil {
+			respondApiKeyError(w, err)
+			return
+		}
+
+		// Clear the hashed values from response
+		output.Token.TokenValue = ""
+		output.Token.PreviousTokenValue = ""
 
 		httputil.Success(w, http.StatusOK, output.Token)
 	}
 }
 
+// respondApiKeyError maps errors from rotating or revoking an API key to HTTP statuses
+func respondApiKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, admin.ErrUserInfoNotFound):
+		httputil.Error(w, http.StatusUnauthorized, err)
+	case errors.Is(err, admin.ErrInvalidGracePeriod):
+		httputil.Error(w, http.StatusBadRequest, err)
+	case errors.Is(err, storage.ErrNotFound):
+		httputil.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrApiKeyRevoked):
+		httputil.Error(w, http.StatusConflict, err)
+	default:
+		httputil.Error(w, http.StatusInternalServerError, err)
+	}
+}
+
 // DeleteApiKey deletes an API key
 func DeleteApiKey(apiKeyRepo admin.ApiKeyDeleter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
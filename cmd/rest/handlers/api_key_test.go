@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/storage"
 	"github.com/brianfromlife/baluster/internal/types"
 )
 
@@ -52,6 +55,35 @@ func TestCreateApiKey(t *testing.T) {
 			repo:           &mockApiKeyRepo{},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "additional applications in the organization",
+			body: CreateApiKeyRequest{
+				ApplicationID:  "app-1",
+				Name:           "Test API Key",
+				ApplicationIDs: []string{"app-2"},
+			},
+			repo:           &mockApiKeyRepo{},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "application in another organization",
+			body: CreateApiKeyRequest{
+				ApplicationID:  "app-1",
+				Name:           "Test API Key",
+				ApplicationIDs: []string{"app-other-org"},
+			},
+			repo:           &mockApiKeyRepo{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown application",
+			body: CreateApiKeyRequest{
+				ApplicationID: "app-unknown",
+				Name:          "Test API Key",
+			},
+			repo:           &mockApiKeyRepo{},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "API key limit exceeded",
 			body: CreateApiKeyRequest{
@@ -76,14 +108,12 @@ func TestCreateApiKey(t *testing.T) {
 					}
 				}
 			}
-			handler := CreateApiKey(tt.repo)
+			handler := CreateApiKey(tt.repo, newApiKeyApplicationRepo())
 
 			req := newTestRequest(http.MethodPost, "/api-keys", tt.body)
-			// Add user context and organization context for valid requests (they need auth and org membership)
-			if tt.expectedStatus == http.StatusCreated || tt.expectedStatus == http.StatusForbidden {
-				req = withUserContext(req, "user-1", "github-123", "testuser")
-				req = withOrgContext(req, "org-1")
-			}
+			// Add user context and organization context, requests that fail validation are rejected before they are read
+			req = withUserContext(req, "user-1", "github-123", "testuser")
+			req = withOrgContext(req, "org-1")
 			rr := httptest.NewRecorder()
 
 			handler.ServeHTTP(rr, req)
@@ -95,6 +125,15 @@ func TestCreateApiKey(t *testing.T) {
 	}
 }
 
+// newApiKeyApplicationRepo holds the applications API keys in the tests may be scoped to
+func newApiKeyApplicationRepo() *mockApplicationRepo {
+	return &mockApplicationRepo{applications: []*types.Application{
+		{ID: "app-1", OrganizationID: "org-1", Name: "orders"},
+		{ID: "app-2", OrganizationID: "org-1", Name: "billing"},
+		{ID: "app-other-org", OrganizationID: "org-2", Name: "orders"},
+	}}
+}
+
 func TestGetApiKey(t *testing.T) {
 	repo := &mockApiKeyRepo{
 		apiKeys: []*types.ApiKey{
@@ -152,19 +191,25 @@ func TestUpdateApiKey(t *testing.T) {
 		},
 	}
 
-	handler := UpdateApiKey(repo)
-	req := newTestRequest(http.MethodPut, "/api-keys/key-1", UpdateApiKeyRequest{
-		Name: "New Name",
-	})
-	req = withURLParam(req, "token_id", "key-1")
-	req = withUserContext(req, "user-1", "github-123", "testuser")
-	req = withOrgContext(req, "org-1")
-	rr := httptest.NewRecorder()
-
-	handler.ServeHTTP(rr, req)
+	handler := UpdateApiKey(repo, newApiKeyApplicationRepo())
+	update := func(body UpdateApiKeyRequest) int {
+		req := newTestRequest(http.MethodPut, "/api-keys/key-1", body)
+		req = withURLParam(req, "token_id", "key-1")
+		req = withUserContext(req, "user-1", "github-123", "testuser")
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
 
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+	if code := update(UpdateApiKeyRequest{Name: "New Name", ApplicationIDs: []string{"app-2"}}); code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if code := update(UpdateApiKeyRequest{Name: "New Name", ApplicationIDs: []string{"app-other-org"}}); code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an application in another organization, got %d", http.StatusBadRequest, code)
+	}
+	if ids := repo.apiKeys[0].ApplicationIDs; len(ids) != 1 || ids[0] != "app-2" {
+		t.Errorf("expected the rejected update to leave the applications unchanged, got %v", ids)
 	}
 }
 
@@ -188,3 +233,75 @@ func TestDeleteApiKey(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusNoContent, rr.Code)
 	}
 }
+
+func TestRotateAndRevokeApiKey(t *testing.T) {
+	repo := &mockApiKeyRepo{
+		apiKeys: []*types.ApiKey{
+			{ID: "key-1", OrganizationID: "org-1", Name: "Test Key", TokenValue: storage.HashToken("old-token")},
+		},
+	}
+	validator := auth.NewApiKeyValidator(repo)
+
+	adminRequest := func(path string, body any) *http.Request {
+		req := newTestRequest(http.MethodPost, path, body)
+		req = withURLParam(req, "token_id", "key-1")
+		req = withUserContext(req, "user-1", "github-123", "testuser")
+		return withOrgContext(req, "org-1")
+	}
+
+	valid := func(token string) bool {
+		t.Helper()
+		_, ok, err := validator.Validate(context.Background(), token)
+		if err != nil {
+			t.Fatalf("validate: %v", err)
+		}
+		return ok
+	}
+
+	rr := httptest.NewRecorder()
+	RotateApiKey(repo, time.Hour).ServeHTTP(rr, adminRequest("/api-keys/key-1/rotate", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var rotated RotateApiKeyResponse
+	if err := json.NewDecoder(rr.Body).Decode(&rotated); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !valid(rotated.TokenValue) || !valid("old-token") {
+		t.Errorf("expected both tokens to be valid during the grace period")
+	}
+
+	rr = httptest.NewRecorder()
+	RevokeApiKey(repo).ServeHTTP(rr, adminRequest("/api-keys/key-1/revoke", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d without a reason, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	RevokeApiKey(repo).ServeHTTP(rr, adminRequest("/api-keys/key-1/revoke", RevokeApiKeyRequest{Reason: "leaked in CI logs"}))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var revoked types.ApiKey
+	if err := json.NewDecoder(rr.Body).Decode(&revoked); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if revoked.RevokedAt == nil || revoked.RevokedReason != "leaked in CI logs" {
+		t.Errorf("expected revocation to be recorded, got %+v", revoked)
+	}
+	if valid(rotated.TokenValue) || valid("old-token") {
+		t.Errorf("expected revoked key to be rejected for every token")
+	}
+
+	// A revoked key can neither be rotated back to life nor revoked twice
+	rr = httptest.NewRecorder()
+	RotateApiKey(repo, time.Hour).ServeHTTP(rr, adminRequest("/api-keys/key-1/rotate", nil))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status %d when rotating a revoked key, got %d", http.StatusConflict, rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	RevokeApiKey(repo).ServeHTTP(rr, adminRequest("/api-keys/key-1/revoke", RevokeApiKeyRequest{Reason: "again"}))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status %d when revoking twice, got %d", http.StatusConflict, rr.Code)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/brianfromlife/baluster/internal/core/admin"
 	httputil "github.com/brianfromlife/baluster/internal/http"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -136,8 +139,14 @@ func (r UpdateApplicationRequest) Validate() error {
 	return nil
 }
 
+// UpdateApplicationResponse is the updated application plus the service keys whose grants no longer match it
+type UpdateApplicationResponse struct {
+	*types.Application
+	AffectedServiceKeys []admin.AffectedServiceKey `json:"affected_service_keys,omitempty"`
+}
+
 // UpdateApplication updates an application
-func UpdateApplication(appRepo admin.ApplicationUpdater) http.HandlerFunc {
+func UpdateApplication(appRepo admin.ApplicationUpdater, serviceKeyRepo admin.ServiceKeyGrantUpdater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		applicationID := chi.URLParam(r, "application_id")
 		if applicationID == "" {
@@ -158,12 +167,72 @@ func UpdateApplication(appRepo admin.ApplicationUpdater) http.HandlerFunc {
 			Permissions: req.Permissions,
 		}
 
-		output, err := admin.UpdateApplication(r.Context(), appRepo, input)
+		output, err := admin.UpdateApplication(r.Context(), appRepo, serviceKeyRepo, input)
 		if err != nil {
 			httputil.Error(w, http.StatusInternalServerError, err)
 			return
 		}
 
-		httputil.Success(w, http.StatusOK, output.Application)
+		httputil.Success(w, http.StatusOK, UpdateApplicationResponse{
+			Application:         output.Application,
+			AffectedServiceKeys: output.AffectedServiceKeys,
+		})
+	}
+}
+
+// DeleteApplicationResponse lists the service keys whose grants were, or in a dry run would be, removed
+type DeleteApplicationResponse struct {
+	DryRun             bool                      `json:"dry_run"`
+	UpdatedServiceKeys []admin.UpdatedServiceKey `json:"updated_service_keys"`
+}
+
+// DeleteApplication deletes an application and removes its grants from service keys.
+// With ?dry_run=true nothing is changed and the response lists what would be.
+func DeleteApplication(appRepo admin.ApplicationDeleter, serviceKeyRepo admin.ServiceKeyGrantUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		applicationID := chi.URLParam(r, "application_id")
+		if applicationID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("application_id is required"))
+			return
+		}
+
+		dryRun := false
+		if value := r.URL.Query().Get("dry_run"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				httputil.Error(w, http.StatusBadRequest, fmt.Errorf("dry_run must be true or false"))
+				return
+			}
+			dryRun = parsed
+		}
+
+		input := &admin.DeleteApplicationInput{
+			ID:     applicationID,
+			DryRun: dryRun,
+		}
+
+		output, err := admin.DeleteApplication(r.Context(), appRepo, serviceKeyRepo, input)
+		if err != nil {
+			if errors.Is(err, admin.ErrUserInfoNotFound) {
+				httputil.Error(w, http.StatusUnauthorized, err)
+				return
+			}
+			if errors.Is(err, storage.ErrNotFound) {
+				httputil.Error(w, http.StatusNotFound, fmt.Errorf("application not found"))
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		updated := output.UpdatedServiceKeys
+		if updated == nil {
+			updated = []admin.UpdatedServiceKey{}
+		}
+
+		httputil.Success(w, http.StatusOK, DeleteApplicationResponse{
+			DryRun:             output.DryRun,
+			UpdatedServiceKeys: updated,
+		})
 	}
 }
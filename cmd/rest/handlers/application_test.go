@@ -123,3 +123,219 @@ func TestListApplications(t *testing.T) {
 		t.Errorf("expected 2 applications, got %d", len(apps))
 	}
 }
+
+func TestUpdateApplicationReportsAffectedServiceKeys(t *testing.T) {
+	appRepo := &mockApplicationRepo{
+		applications: []*types.Application{
+			{ID: "app-1", OrganizationID: "org-1", Name: "orders", Permissions: []string{"orders:read", "orders:write"}},
+		},
+	}
+	serviceKeyRepo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				Name:           "writer",
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"orders:read", "orders:write"}},
+				},
+			},
+			{
+				ID:             "sk-2",
+				OrganizationID: "org-1",
+				Name:           "reader",
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"orders:read"}},
+				},
+			},
+		},
+	}
+
+	req := newTestRequest(http.MethodPut, "/applications/app-1", UpdateApplicationRequest{
+		Name:        "orders",
+		Permissions: []string{"orders:read"},
+	})
+	req = withURLParam(req, "application_id", "app-1")
+	req = withUserContext(req, "user-1", "github-123", "testuser")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	UpdateApplication(appRepo, serviceKeyRepo).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var resp UpdateApplicationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.Application == nil || resp.ID != "app-1" {
+		t.Fatalf("expected the updated application in the response")
+	}
+	if len(resp.AffectedServiceKeys) != 1 {
+		t.Fatalf("expected 1 affected service key, got %d", len(resp.AffectedServiceKeys))
+	}
+	affected := resp.AffectedServiceKeys[0]
+	if affected.ServiceKeyID != "sk-1" {
+		t.Errorf("expected service key sk-1, got %s", affected.ServiceKeyID)
+	}
+	if len(affected.InvalidGrants) != 1 || affected.InvalidGrants[0].Permission != "orders:write" {
+		t.Errorf("expected orders:write to be reported, got %+v", affected.InvalidGrants)
+	}
+}
+
+func TestDeleteApplication(t *testing.T) {
+	newRepos := func() (*mockApplicationRepo, *mockServiceKeyRepo) {
+		appRepo := &mockApplicationRepo{
+			applications: []*types.Application{
+				{ID: "app-1", OrganizationID: "org-1", Name: "orders", Permissions: []string{"orders:read"}},
+			},
+		}
+		serviceKeyRepo := &mockServiceKeyRepo{
+			serviceKeys: []*types.ServiceKey{
+				{
+					ID:             "sk-1",
+					OrganizationID: "org-1",
+					Name:           "orders-and-billing",
+					Applications: []types.ApplicationAccess{
+						{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"orders:read"}},
+						{ApplicationID: "app-2", ApplicationName: "billing", Permissions: []string{"billing:read"}},
+					},
+				},
+				{
+					ID:             "sk-2",
+					OrganizationID: "org-1",
+					Name:           "billing-only",
+					Applications: []types.ApplicationAccess{
+						{ApplicationID: "app-2", ApplicationName: "billing", Permissions: []string{"billing:read"}},
+					},
+				},
+			},
+		}
+		return appRepo, serviceKeyRepo
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		dryRun bool
+	}{
+		{name: "delete", target: "/applications/app-1"},
+		{name: "dry run", target: "/applications/app-1?dry_run=true", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appRepo, serviceKeyRepo := newRepos()
+
+			req := newTestRequest(http.MethodDelete, tt.target, nil)
+			req = withURLParam(req, "application_id", "app-1")
+			req = withUserContext(req, "user-1", "github-123", "testuser")
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			DeleteApplication(appRepo, serviceKeyRepo).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+			}
+
+			var resp DeleteApplicationResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if resp.DryRun != tt.dryRun {
+				t.Errorf("expected dry_run %v, got %v", tt.dryRun, resp.DryRun)
+			}
+			if len(resp.UpdatedServiceKeys) != 1 || resp.UpdatedServiceKeys[0].ServiceKeyID != "sk-1" {
+				t.Fatalf("expected only sk-1 to be updated, got %+v", resp.UpdatedServiceKeys)
+			}
+
+			grants := len(serviceKeyRepo.serviceKeys[0].Applications)
+			if tt.dryRun {
+				if len(appRepo.applications) != 1 {
+					t.Errorf("expected the application to be kept on a dry run")
+				}
+				if grants != 2 {
+					t.Errorf("expected sk-1 to keep 2 grants on a dry run, got %d", grants)
+				}
+				return
+			}
+
+			if len(appRepo.applications) != 0 {
+				t.Errorf("expected the application to be deleted")
+			}
+			if grants != 1 || serviceKeyRepo.serviceKeys[0].Applications[0].ApplicationID != "app-2" {
+				t.Errorf("expected sk-1 to keep only its billing grant, got %+v", serviceKeyRepo.serviceKeys[0].Applications)
+			}
+		})
+	}
+}
+
+func TestDeleteApplicationNotFound(t *testing.T) {
+	req := newTestRequest(http.MethodDelete, "/applications/app-1", nil)
+	req = withURLParam(req, "application_id", "app-1")
+	req = withUserContext(req, "user-1", "github-123", "testuser")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	DeleteApplication(&mockApplicationRepo{}, &mockServiceKeyRepo{}).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestUpdateApplicationPropagatesRename(t *testing.T) {
+	appRepo := &mockApplicationRepo{
+		applications: []*types.Application{
+			{ID: "app-1", OrganizationID: "org-1", Name: "orders", Permissions: []string{"read"}},
+		},
+	}
+	serviceKeyRepo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"read"}},
+					{ApplicationID: "app-2", ApplicationName: "billing", Permissions: []string{"read"}},
+				},
+			},
+		},
+	}
+
+	req := newTestRequest(http.MethodPut, "/applications/app-1", UpdateApplicationRequest{
+		Name:        "order_service",
+		Permissions: []string{"read"},
+	})
+	req = withURLParam(req, "application_id", "app-1")
+	req = withUserContext(req, "user-1", "github-123", "testuser")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	UpdateApplication(appRepo, serviceKeyRepo).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var resp UpdateApplicationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.AffectedServiceKeys) != 0 {
+		t.Errorf("expected a rename to leave no invalid grants, got %+v", resp.AffectedServiceKeys)
+	}
+
+	grants := serviceKeyRepo.serviceKeys[0].Applications
+	if grants[0].ApplicationName != "order_service" {
+		t.Errorf("expected the grant to be renamed to order_service, got %s", grants[0].ApplicationName)
+	}
+	if grants[1].ApplicationName != "billing" {
+		t.Errorf("expected the other grant to keep its name, got %s", grants[1].ApplicationName)
+	}
+}
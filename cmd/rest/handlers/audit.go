@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/core/admin"
+	httputil "github.com/brianfromlife/baluster/internal/http"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// auditCSVHeader names the columns of a CSV audit log export
+var auditCSVHeader = []string{
+	"id",
+	"created_at",
+	"organization_id",
+	"resource_type",
+	"entity_id",
+	"action",
+	"actor_user_id",
+	"actor_github_id",
+	"actor_username",
+	"request_id",
+	"client_ip",
+	"user_agent",
+	"changes",
+	"sequence",
+	"previous_hash",
+	"hash",
+}
+
+// SearchAuditLog searches the organization's audit log, newest first.
+// ?actor_user_id=, ?actor_username=, ?resource_type=, ?action=, ?from= and ?to= filter the events,
+// ?cursor= continues from a previous page's next_cursor and ?limit= sets the page size, 100 by default and at most 1000.
+func SearchAuditLog(auditRepo admin.AuditSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				httputil.Error(w, http.StatusBadRequest, fmt.Errorf("limit must be a positive number"))
+				return
+			}
+			limit = parsed
+		}
+
+		input := &admin.SearchAuditLogInput{
+			Filter: filter,
+			Cursor: r.URL.Query().Get("cursor"),
+			Limit:  limit,
+		}
+
+		output, err := admin.SearchAuditLog(r.Context(), auditRepo, input)
+		if err != nil {
+			if errors.Is(err, admin.ErrInvalidAuditFilter) || errors.Is(err, storage.ErrInvalidCursor) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		events := output.Events
+		if events == nil {
+			events = []*storage.AuditEvent{}
+		}
+
+		httputil.Success(w, http.StatusOK, map[string]any{
+			"events":      events,
+			"next_cursor": output.NextCursor,
+		})
+	}
+}
+
+// ExportAuditLog streams every event of the organization's audit log matching the same filters as SearchAuditLog.
+// ?format= is ndjson (one JSON event per line, the default) or csv.
+func ExportAuditLog(auditRepo admin.AuditSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "ndjson"
+		}
+
+		var (
+			contentType string
+			write       func(*storage.AuditEvent) error
+			flush       func() error
+		)
+		switch format {
+		case "ndjson":
+			contentType = "application/x-ndjson"
+			encoder := json.NewEncoder(w)
+			write = func(event *storage.AuditEvent) error { return encoder.Encode(event) }
+			flush = func() error { return nil }
+		case "csv":
+			contentType = "text/csv; charset=utf-8"
+			writer := csv.NewWriter(w)
+			write = func(event *storage.AuditEvent) error { return writer.Write(auditCSVRecord(event)) }
+			flush = func() error {
+				writer.Flush()
+				return writer.Error()
+			}
+		default:
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("format must be ndjson or csv"))
+			return
+		}
+
+		// Headers are only sent with the first event so a failed search can still return an error
+		started := false
+		start := func() error {
+			started = true
+			filename := fmt.Sprintf("audit-log-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			w.WriteHeader(http.StatusOK)
+			if format == "csv" {
+				return write(nil)
+			}
+			return nil
+		}
+
+		// Large exports outlast the server's write timeout
+		http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+		input := &admin.ExportAuditLogInput{Filter: filter}
+
+		err = admin.ExportAuditLog(r.Context(), auditRepo, input, func(event *storage.AuditEvent) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			return write(event)
+		})
+		if err != nil {
+			if started {
+				// The status is already sent, so the export just ends early
+				return
+			}
+			if errors.Is(err, admin.ErrInvalidAuditFilter) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !started {
+			if err := start(); err != nil {
+				return
+			}
+		}
+		flush()
+	}
+}
+
+// VerifyAuditChainRequest represents the request body for verifying the audit chains against a checkpoint
+type VerifyAuditChainRequest struct {
+	Checkpoint *types.AuditCheckpoint `json:"checkpoint"`
+}
+
+// Validate validates the VerifyAuditChainRequest
+func (r VerifyAuditChainRequest) Validate() error {
+	if r.Checkpoint == nil {
+		return fmt.Errorf("checkpoint is required")
+	}
+	return nil
+}
+
+// VerifyAuditChain walks the organization's audit chains and reports records that were changed or removed.
+// A POST body with a checkpoint from CreateAuditCheckpoint also checks the chains still contain it.
+func VerifyAuditChain(auditRepo admin.AuditChainReader, signer admin.CheckpointSigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := &admin.VerifyAuditChainInput{}
+		if r.Method == http.MethodPost {
+			req, err := httputil.Decode[VerifyAuditChainRequest](r)
+			if err != nil {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			input.Checkpoint = req.Checkpoint
+		}
+
+		output, err := admin.VerifyAuditChain(r.Context(), auditRepo, signer, input)
+		if err != nil {
+			if errors.Is(err, admin.ErrInvalidCheckpoint) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, map[string]any{
+			"valid":  output.Valid,
+			"chains": output.Chains,
+		})
+	}
+}
+
+// CreateAuditCheckpoint returns the signed heads of the organization's audit chains
+func CreateAuditCheckpoint(auditRepo admin.AuditChainReader, signer admin.CheckpointSigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		output, err := admin.CreateAuditCheckpoint(r.Context(), auditRepo, signer, &admin.CreateAuditCheckpointInput{})
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, output.Checkpoint)
+	}
+}
+
+// parseAuditFilter reads an audit log filter from the query string.
+// resource_type and action may be repeated or comma separated, from and to are RFC 3339 times.
+func parseAuditFilter(r *http.Request) (storage.AuditFilter, error) {
+	query := r.URL.Query()
+
+	filter := storage.AuditFilter{
+		ActorUserID:   query.Get("actor_user_id"),
+		ActorUsername: query.Get("actor_username"),
+	}
+	for _, value := range queryList(query["resource_type"]) {
+		filter.ResourceTypes = append(filter.ResourceTypes, types.AuditResourceType(value))
+	}
+	for _, value := range queryList(query["action"]) {
+		filter.Actions = append(filter.Actions, types.AuditAction(value))
+	}
+
+	if value := query.Get("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("from must be an RFC 3339 time")
+		}
+		filter.From = from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("to must be an RFC 3339 time")
+		}
+		filter.To = to
+	}
+
+	return filter, nil
+}
+
+// queryList splits repeated and comma separated query values into one list
+func queryList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// auditCSVRecord returns the CSV columns of an event, or the header when event is nil
+func auditCSVRecord(event *storage.AuditEvent) []string {
+	if event == nil {
+		return auditCSVHeader
+	}
+
+	changes := ""
+	if len(event.Changes) > 0 {
+		data, _ := json.Marshal(event.Changes)
+		changes = string(data)
+	}
+
+	return []string{
+		event.ID,
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		event.OrganizationID,
+		string(event.ResourceType),
+		event.EntityID,
+		string(event.Action),
+		event.CreatedByUserID,
+		event.CreatedByGitHubID,
+		event.CreatedByUsername,
+		event.RequestID,
+		event.ClientIP,
+		event.UserAgent,
+		changes,
+		strconv.FormatInt(event.Sequence, 10),
+		event.PreviousHash,
+		event.Hash,
+	}
+}
@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/checkpoint"
+	"github.com/brianfromlife/baluster/internal/core/admin"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+func newTestAuditRepo() *mockAuditRepo {
+	now := time.Now()
+	event := func(id, orgID string, resourceType types.AuditResourceType, action types.AuditAction, username string, age time.Duration) *storage.AuditEvent {
+		return &storage.AuditEvent{
+			AuditHistory: &types.AuditHistory{
+				ID:                id,
+				EntityID:          "entity-" + id,
+				OrganizationID:    orgID,
+				Action:            action,
+				CreatedByUserID:   "user-" + username,
+				CreatedByUsername: username,
+				CreatedAt:         now.Add(-age),
+			},
+			ResourceType: resourceType,
+		}
+	}
+	return &mockAuditRepo{
+		events: []*storage.AuditEvent{
+			event("ah-1", "org-1", types.AuditResourceServiceKey, types.AuditActionCreated, "octocat", 4*time.Hour),
+			event("ah-2", "org-1", types.AuditResourceApiKey, types.AuditActionCreated, "octocat", 3*time.Hour),
+			event("ah-3", "org-1", types.AuditResourceApiKey, types.AuditActionRevoked, "hubot", 2*time.Hour),
+			event("ah-4", "org-1", types.AuditResourceApplication, types.AuditActionUpdated, "octocat", time.Hour),
+			event("ah-5", "org-2", types.AuditResourceApiKey, types.AuditActionCreated, "octocat", time.Hour),
+		},
+	}
+}
+
+func TestSearchAuditLog(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []string
+	}{
+		{
+			name:           "all events newest first",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"ah-4", "ah-3", "ah-2", "ah-1"},
+		},
+		{
+			name:           "actor username",
+			query:          "actor_username=OCTOCAT",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"ah-4", "ah-2", "ah-1"},
+		},
+		{
+			name:           "resource types comma separated",
+			query:          "resource_type=service_key,application",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"ah-4", "ah-1"},
+		},
+		{
+			name:           "repeated actions",
+			query:          "action=revoked&action=updated",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"ah-4", "ah-3"},
+		},
+		{
+			name:           "time range",
+			query:          "from=" + time.Now().Add(-150*time.Minute).UTC().Format(time.RFC3339) + "&to=" + time.Now().Add(-90*time.Minute).UTC().Format(time.RFC3339),
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"ah-3"},
+		},
+		{
+			name:           "unknown resource type",
+			query:          "resource_type=webhook",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown action",
+			query:          "action=exploded",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid time",
+			query:          "from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty time range",
+			query:          "from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid limit",
+			query:          "limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid cursor",
+			query:          "cursor=nope",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := SearchAuditLog(newTestAuditRepo())
+
+			req := newTestRequest(http.MethodGet, "/organizations/org-1/audit?"+tt.query, nil)
+			req = withURLParam(req, "organization_id", "org-1")
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Events []*storage.AuditEvent `json:"events"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var ids []string
+			for _, event := range response.Events {
+				ids = append(ids, event.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.expectedIDs, ",") {
+				t.Errorf("expected events %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestSearchAuditLogPagination(t *testing.T) {
+	handler := SearchAuditLog(newTestAuditRepo())
+
+	var ids []string
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 3 {
+			t.Fatalf("expected the pages to end")
+		}
+
+		req := newTestRequest(http.MethodGet, "/organizations/org-1/audit?limit=3&cursor="+cursor, nil)
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+
+		var response struct {
+			Events     []*storage.AuditEvent `json:"events"`
+			NextCursor string                `json:"next_cursor"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		for _, event := range response.Events {
+			ids = append(ids, event.ID)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		cursor = response.NextCursor
+	}
+
+	if strings.Join(ids, ",") != "ah-4,ah-3,ah-2,ah-1" {
+		t.Errorf("expected every event once across the pages, got %v", ids)
+	}
+}
+
+func TestExportAuditLog(t *testing.T) {
+	t.Run("ndjson", func(t *testing.T) {
+		handler := ExportAuditLog(newTestAuditRepo())
+
+		req := newTestRequest(http.MethodGet, "/organizations/org-1/audit/export?resource_type=api_key", nil)
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if rr.Header().Get("Content-Type") != "application/x-ndjson" || !strings.Contains(rr.Header().Get("Content-Disposition"), ".ndjson") {
+			t.Errorf("unexpected headers %v", rr.Header())
+		}
+
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines, got %d: %s", len(lines), rr.Body.String())
+		}
+		var event storage.AuditEvent
+		if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+			t.Fatalf("failed to decode line: %v", err)
+		}
+		if event.ID != "ah-3" || event.ResourceType != types.AuditResourceApiKey {
+			t.Errorf("expected the newest API key event first, got %+v", event)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		handler := ExportAuditLog(newTestAuditRepo())
+
+		req := newTestRequest(http.MethodGet, "/organizations/org-1/audit/export?format=csv&actor_username=hubot", nil)
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+			t.Errorf("unexpected content type %q", rr.Header().Get("Content-Type"))
+		}
+
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to read CSV: %v", err)
+		}
+		if len(records) != 2 || records[0][0] != "id" || records[1][0] != "ah-3" || records[1][3] != "api_key" || records[1][8] != "hubot" {
+			t.Errorf("expected a header and ah-3, got %v", records)
+		}
+	})
+
+	t.Run("empty export still has a header", func(t *testing.T) {
+		handler := ExportAuditLog(newTestAuditRepo())
+
+		req := newTestRequest(http.MethodGet, "/organizations/org-1/audit/export?format=csv&actor_username=nobody", nil)
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != strings.Join(auditCSVHeader, ",") {
+			t.Errorf("expected only the header, got %d: %q", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		handler := ExportAuditLog(newTestAuditRepo())
+
+		req := newTestRequest(http.MethodGet, "/organizations/org-1/audit/export?format=xml", nil)
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		handler := ExportAuditLog(newTestAuditRepo())
+
+		req := newTestRequest(http.MethodGet, "/organizations/org-1/audit/export?action=exploded", nil)
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+// newTestAuditChainRepo returns newTestAuditRepo with the org-1 events linked into audit chains
+func newTestAuditChainRepo(t *testing.T) *mockAuditRepo {
+	t.Helper()
+	repo := newTestAuditRepo()
+	chainTestAuditRepo(t, repo)
+	return repo
+}
+
+// chainTestAuditRepo links the org-1 events into audit chains in the order they are listed, as the storage backends do
+func chainTestAuditRepo(t *testing.T, repo *mockAuditRepo) {
+	t.Helper()
+	repo.heads = make(map[types.AuditResourceType]*types.AuditChainHead)
+	for _, event := range repo.events {
+		if event.OrganizationID != "org-1" {
+			continue
+		}
+		head, ok := repo.heads[event.ResourceType]
+		if !ok {
+			head = storage.NewAuditChainHead("org-1")
+			repo.heads[event.ResourceType] = head
+		}
+		if err := storage.ChainAudit(head, event.AuditHistory); err != nil {
+			t.Fatalf("failed to chain audit history: %v", err)
+		}
+	}
+}
+
+func newTestCheckpointSigner(t *testing.T) *checkpoint.Signer {
+	t.Helper()
+	signer, err := checkpoint.NewSigner(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("failed to create checkpoint signer: %v", err)
+	}
+	return signer
+}
+
+type verifyAuditChainResponse struct {
+	Valid  bool                      `json:"valid"`
+	Chains []*admin.AuditChainReport `json:"chains"`
+}
+
+// apiKeyChain returns the API key chain's report, the chain the tests tamper with
+func (r verifyAuditChainResponse) apiKeyChain(t *testing.T) *admin.AuditChainReport {
+	t.Helper()
+	for _, chain := range r.Chains {
+		if chain.ResourceType == types.AuditResourceApiKey {
+			return chain
+		}
+	}
+	t.Fatalf("expected an api_key chain in %+v", r.Chains)
+	return nil
+}
+
+func verifyTestAuditChain(t *testing.T, repo *mockAuditRepo, signer *checkpoint.Signer, body any) (*httptest.ResponseRecorder, verifyAuditChainResponse) {
+	t.Helper()
+	method := http.MethodGet
+	if body != nil {
+		method = http.MethodPost
+	}
+	req := newTestRequest(method, "/organizations/org-1/audit/verify", body)
+	req = withURLParam(req, "organization_id", "org-1")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	VerifyAuditChain(repo, signer).ServeHTTP(rr, req)
+
+	var response verifyAuditChainResponse
+	if rr.Code == http.StatusOK {
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return rr, response
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	tests := []struct {
+		name            string
+		tamper          func(repo *mockAuditRepo)
+		expectedValid   bool
+		expectedProblem admin.AuditChainProblem
+	}{
+		{
+			name:          "intact chains",
+			tamper:        func(repo *mockAuditRepo) {},
+			expectedValid: true,
+		},
+		{
+			name: "modified record",
+			tamper: func(repo *mockAuditRepo) {
+				repo.events[2].CreatedByUsername = "mallory"
+			},
+			expectedProblem: admin.AuditChainProblem{Kind: admin.AuditChainModified, Sequence: 2, RecordID: "ah-3"},
+		},
+		{
+			name: "record deleted from the middle",
+			tamper: func(repo *mockAuditRepo) {
+				repo.events = slices.Delete(repo.events, 1, 2)
+			},
+			expectedProblem: admin.AuditChainProblem{Kind: admin.AuditChainMissing, Sequence: 1, ToSequence: 1},
+		},
+		{
+			name: "record deleted from the end",
+			tamper: func(repo *mockAuditRepo) {
+				repo.events = slices.Delete(repo.events, 2, 3)
+			},
+			expectedProblem: admin.AuditChainProblem{Kind: admin.AuditChainMissing, Sequence: 2, ToSequence: 2},
+		},
+		{
+			name: "records swapped",
+			tamper: func(repo *mockAuditRepo) {
+				repo.events[1].Sequence, repo.events[2].Sequence = repo.events[2].Sequence, repo.events[1].Sequence
+			},
+			expectedProblem: admin.AuditChainProblem{Kind: admin.AuditChainBrokenLink, Sequence: 1, RecordID: "ah-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestAuditChainRepo(t)
+			tt.tamper(repo)
+
+			rr, response := verifyTestAuditChain(t, repo, newTestCheckpointSigner(t), nil)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+			}
+			if response.Valid != tt.expectedValid || len(response.Chains) != len(types.AuditResourceTypes) {
+				t.Fatalf("expected valid=%v for every chain, got %+v", tt.expectedValid, response)
+			}
+
+			chain := response.apiKeyChain(t)
+			if tt.expectedValid {
+				if !chain.Valid || chain.Records != 2 || chain.HeadSequence != 2 || len(chain.Problems) != 0 {
+					t.Errorf("expected an intact api_key chain, got %+v", chain)
+				}
+				return
+			}
+			if chain.Valid || !slices.Contains(chain.Problems, tt.expectedProblem) {
+				t.Errorf("expected problem %+v, got %+v", tt.expectedProblem, chain.Problems)
+			}
+		})
+	}
+}
+
+func TestAuditCheckpoint(t *testing.T) {
+	repo := newTestAuditChainRepo(t)
+	signer := newTestCheckpointSigner(t)
+
+	req := newTestRequest(http.MethodGet, "/organizations/org-1/audit/checkpoint", nil)
+	req = withURLParam(req, "organization_id", "org-1")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+	CreateAuditCheckpoint(repo, signer).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var cp types.AuditCheckpoint
+	if err := json.NewDecoder(rr.Body).Decode(&cp); err != nil {
+		t.Fatalf("failed to decode checkpoint: %v", err)
+	}
+	if cp.OrganizationID != "org-1" || len(cp.Chains) != len(types.AuditResourceTypes) || cp.PublicKey != signer.PublicKey() {
+		t.Fatalf("unexpected checkpoint %+v", cp)
+	}
+	if err := checkpoint.Verify(signer.PublicKey(), &cp); err != nil {
+		t.Fatalf("expected a verifiable checkpoint: %v", err)
+	}
+
+	// The chain keeps growing after the checkpoint
+	event := &storage.AuditEvent{
+		AuditHistory: &types.AuditHistory{ID: "ah-6", EntityID: "entity-ah-6", OrganizationID: "org-1", Action: types.AuditActionDeleted, CreatedAt: time.Now()},
+		ResourceType: types.AuditResourceApiKey,
+	}
+	if err := storage.ChainAudit(repo.heads[types.AuditResourceApiKey], event.AuditHistory); err != nil {
+		t.Fatalf("failed to chain audit history: %v", err)
+	}
+	repo.events = append(repo.events, event)
+
+	rr, response := verifyTestAuditChain(t, repo, signer, VerifyAuditChainRequest{Checkpoint: &cp})
+	if rr.Code != http.StatusOK || !response.Valid {
+		t.Fatalf("expected the chains to still contain the checkpoint, got %d: %+v", rr.Code, response)
+	}
+
+	// Rewriting a record and every hash after it passes the chain checks but not the checkpoint
+	repo.events[1].CreatedByUsername = "mallory"
+	chainTestAuditRepo(t, repo)
+	if _, response := verifyTestAuditChain(t, repo, signer, nil); !response.Valid {
+		t.Fatalf("expected a consistently rewritten chain to pass without a checkpoint, got %+v", response)
+	}
+	rr, response = verifyTestAuditChain(t, repo, signer, VerifyAuditChainRequest{Checkpoint: &cp})
+	if rr.Code != http.StatusOK || response.Valid {
+		t.Fatalf("expected the rewritten chain to fail the checkpoint, got %d: %+v", rr.Code, response)
+	}
+	if chain := response.apiKeyChain(t); !slices.Contains(chain.Problems, admin.AuditChainProblem{Kind: admin.AuditChainCheckpointMismatch, Sequence: 2, RecordID: "ah-3"}) {
+		t.Errorf("expected a checkpoint mismatch, got %+v", chain.Problems)
+	}
+
+	tampered := cp
+	tampered.Chains = slices.Clone(cp.Chains)
+	tampered.Chains[0].Sequence++
+	if rr, _ := verifyTestAuditChain(t, repo, signer, VerifyAuditChainRequest{Checkpoint: &tampered}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a changed checkpoint to be rejected, got %d", rr.Code)
+	}
+	other := cp
+	other.OrganizationID = "org-2"
+	if rr, _ := verifyTestAuditChain(t, repo, signer, VerifyAuditChainRequest{Checkpoint: &other}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected another organization's checkpoint to be rejected, got %d", rr.Code)
+	}
+	if rr, _ := verifyTestAuditChain(t, repo, signer, VerifyAuditChainRequest{}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a missing checkpoint to be rejected, got %d", rr.Code)
+	}
+}
@@ -17,7 +17,7 @@ type AuthHandler struct {
 	githubOAuth *oauth2.Config
 	stateCache  *auth.StateCache
 	jwtConfig   auth.JWTConfig
-	userRepo    *storage.UserRepository
+	userRepo    storage.UserRepository
 	orgRepo     admin.OrganizationMemberLister
 }
 
@@ -25,7 +25,7 @@ func NewAuthHandler(
 	githubOAuth *oauth2.Config,
 	stateCache *auth.StateCache,
 	jwtConfig auth.JWTConfig,
-	userRepo *storage.UserRepository,
+	userRepo storage.UserRepository,
 	orgRepo admin.OrganizationMemberLister,
 ) *AuthHandler {
 	return &AuthHandler{
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/brianfromlife/baluster/internal/core/admin"
+	httputil "github.com/brianfromlife/baluster/internal/http"
+	"github.com/brianfromlife/baluster/internal/types"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateInvitationRequest represents the HTTP request to invite a GitHub user to an organization
+type CreateInvitationRequest struct {
+	GitHubUsername string     `json:"github_username"`
+	Role           types.Role `json:"role"`
+}
+
+// Validate validates the CreateInvitationRequest
+func (r CreateInvitationRequest) Validate() error {
+	if r.GitHubUsername == "" {
+		return fmt.Errorf("github_username is required")
+	}
+	if len(r.GitHubUsername) > 39 {
+		return fmt.Errorf("github_username must be at most 39 characters")
+	}
+	if !r.Role.IsValid() {
+		return fmt.Errorf("role must be one of owner, admin, developer or viewer")
+	}
+	return nil
+}
+
+// CreateInvitation invites a GitHub user to an organization
+func CreateInvitation(invitationRepo admin.InvitationCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := httputil.Decode[CreateInvitationRequest](r)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		input := &admin.CreateInvitationInput{
+			GitHubUsername: req.GitHubUsername,
+			Role:           req.Role,
+		}
+
+		output, err := admin.CreateInvitation(r.Context(), invitationRepo, input)
+		if err != nil {
+			if errors.Is(err, admin.ErrUserInfoNotFound) {
+				httputil.Error(w, http.StatusUnauthorized, err)
+				return
+			}
+			if errors.Is(err, admin.ErrInvalidRole) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			if errors.Is(err, admin.ErrInvitationExists) {
+				httputil.Error(w, http.StatusConflict, err)
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusCreated, output.Invitation)
+	}
+}
+
+// ListInvitations lists the invitations sent by an organization
+func ListInvitations(invitationRepo admin.InvitationLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := &admin.ListInvitationsInput{}
+
+		output, err := admin.ListInvitations(r.Context(), invitationRepo, input)
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, output.Invitations)
+	}
+}
+
+// ListMyInvitations lists the pending invitations sent to the current user
+func ListMyInvitations(invitationRepo admin.UserInvitationLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := &admin.ListMyInvitationsInput{}
+
+		output, err := admin.ListMyInvitations(r.Context(), invitationRepo, input)
+		if err != nil {
+			if errors.Is(err, admin.ErrUserInfoNotFound) {
+				httputil.Error(w, http.StatusUnauthorized, err)
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, output.Invitations)
+	}
+}
+
+// AcceptInvitation accepts an invitation and joins the organization
+func AcceptInvitation(invitationRepo admin.InvitationAccepter, memberRepo admin.OrganizationMembershipChecker, cache admin.MembershipCacheInvalidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitationID := chi.URLParam(r, "invitation_id")
+		if invitationID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("invitation_id is required"))
+			return
+		}
+
+		input := &admin.RespondInvitationInput{
+			ID: invitationID,
+		}
+
+		output, err := admin.AcceptInvitation(r.Context(), invitationRepo, memberRepo, cache, input)
+		if err != nil {
+			respondInvitationError(w, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, output.Invitation)
+	}
+}
+
+// DeclineInvitation declines an invitation
+func DeclineInvitation(invitationRepo admin.InvitationDecliner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitationID := chi.URLParam(r, "invitation_id")
+		if invitationID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("invitation_id is required"))
+			return
+		}
+
+		input := &admin.RespondInvitationInput{
+			ID: invitationID,
+		}
+
+		output, err := admin.DeclineInvitation(r.Context(), invitationRepo, input)
+		if err != nil {
+			respondInvitationError(w, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, output.Invitation)
+	}
+}
+
+// respondInvitationError maps errors from accepting or declining an invitation to HTTP statuses
+func respondInvitationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, admin.ErrUserInfoNotFound):
+		httputil.Error(w, http.StatusUnauthorized, err)
+	case errors.Is(err, admin.ErrInvitationNotFound):
+		httputil.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, admin.ErrInvitationNotPending), errors.Is(err, admin.ErrAlreadyMember):
+		httputil.Error(w, http.StatusConflict, err)
+	case errors.Is(err, admin.ErrInvitationExpired):
+		httputil.Error(w, http.StatusGone, err)
+	default:
+		httputil.Error(w, http.StatusInternalServerError, err)
+	}
+}
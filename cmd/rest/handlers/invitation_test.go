@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+func TestCreateInvitation(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           CreateInvitationRequest
+		existing       []*types.Invitation
+		expectedStatus int
+	}{
+		{
+			name:           "valid request",
+			body:           CreateInvitationRequest{GitHubUsername: "octocat", Role: types.RoleDeveloper},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing username",
+			body:           CreateInvitationRequest{Role: types.RoleDeveloper},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid role",
+			body:           CreateInvitationRequest{GitHubUsername: "octocat", Role: "superuser"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "pending invitation exists",
+			body: CreateInvitationRequest{GitHubUsername: "Octocat", Role: types.RoleViewer},
+			existing: []*types.Invitation{
+				{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "octocat", Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(time.Hour)},
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "expired invitation can be replaced",
+			body: CreateInvitationRequest{GitHubUsername: "octocat", Role: types.RoleViewer},
+			existing: []*types.Invitation{
+				{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "octocat", Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(-time.Hour)},
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockInvitationRepo{invitations: tt.existing}
+			handler := CreateInvitation(repo)
+
+			req := newTestRequest(http.MethodPost, "/invitations", tt.body)
+			req = withUserContext(req, "user-1", "github-123", "testuser")
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedStatus == http.StatusCreated {
+				created := repo.invitations[len(repo.invitations)-1]
+				if created.Status != types.InvitationStatusPending || !created.ExpiresAt.After(time.Now()) {
+					t.Errorf("expected a pending invitation with a future expiry, got %+v", created)
+				}
+			}
+		})
+	}
+}
+
+func TestListMyInvitations(t *testing.T) {
+	repo := &mockInvitationRepo{
+		invitations: []*types.Invitation{
+			{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "OctoCat", Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(time.Hour)},
+			{ID: "inv-2", OrganizationID: "org-2", GitHubUsername: "octocat", Status: types.InvitationStatusDeclined, ExpiresAt: time.Now().Add(time.Hour)},
+			{ID: "inv-3", OrganizationID: "org-3", GitHubUsername: "octocat", Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(-time.Hour)},
+			{ID: "inv-4", OrganizationID: "org-1", GitHubUsername: "someone", Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(time.Hour)},
+		},
+	}
+
+	handler := ListMyInvitations(repo)
+	req := newTestRequest(http.MethodGet, "/me/invitations", nil)
+	req = withUserContext(req, "user-1", "github-123", "octocat")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var invitations []types.Invitation
+	if err := json.NewDecoder(rr.Body).Decode(&invitations); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(invitations) != 1 || invitations[0].ID != "inv-1" {
+		t.Errorf("expected only the pending unexpired invitation, got %+v", invitations)
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	tests := []struct {
+		name           string
+		invitation     *types.Invitation
+		members        []*types.OrganizationMember
+		username       string
+		expectedStatus int
+	}{
+		{
+			name:           "accepts invitation",
+			invitation:     &types.Invitation{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "octocat", Role: types.RoleDeveloper, Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(time.Hour)},
+			username:       "octocat",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invitation for another user",
+			invitation:     &types.Invitation{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "someone", Role: types.RoleDeveloper, Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(time.Hour)},
+			username:       "octocat",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "expired invitation",
+			invitation:     &types.Invitation{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "octocat", Role: types.RoleDeveloper, Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(-time.Hour)},
+			username:       "octocat",
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:           "already declined",
+			invitation:     &types.Invitation{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "octocat", Role: types.RoleDeveloper, Status: types.InvitationStatusDeclined, ExpiresAt: time.Now().Add(time.Hour)},
+			username:       "octocat",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "already a member",
+			invitation:     &types.Invitation{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "octocat", Role: types.RoleDeveloper, Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(time.Hour)},
+			members:        []*types.OrganizationMember{{OrganizationID: "org-1", UserID: "user-1"}},
+			username:       "octocat",
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockInvitationRepo{invitations: []*types.Invitation{tt.invitation}}
+			memberRepo := &mockOrganizationMemberRepo{members: tt.members}
+			cache := &mockMembershipCache{}
+			handler := AcceptInvitation(repo, memberRepo, cache)
+
+			req := newTestRequest(http.MethodPost, "/invitations/inv-1/accept", nil)
+			req = withURLParam(req, "invitation_id", "inv-1")
+			req = withUserContext(req, "user-1", "github-123", tt.username)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				if repo.accepted["inv-1"] != "user-1" {
+					t.Errorf("expected invitation to be accepted by user-1")
+				}
+				if tt.invitation.Status != types.InvitationStatusAccepted || tt.invitation.RespondedAt == nil {
+					t.Errorf("expected invitation to be marked accepted, got %+v", tt.invitation)
+				}
+				if len(cache.invalidated) != 1 || cache.invalidated[0] != "org-1:user-1" {
+					t.Errorf("expected cached membership to be invalidated, got %v", cache.invalidated)
+				}
+			}
+		})
+	}
+}
+
+func TestDeclineInvitation(t *testing.T) {
+	invitation := &types.Invitation{ID: "inv-1", OrganizationID: "org-1", GitHubUsername: "octocat", Role: types.RoleViewer, Status: types.InvitationStatusPending, ExpiresAt: time.Now().Add(time.Hour)}
+	repo := &mockInvitationRepo{invitations: []*types.Invitation{invitation}}
+	handler := DeclineInvitation(repo)
+
+	req := newTestRequest(http.MethodPost, "/invitations/inv-1/decline", nil)
+	req = withURLParam(req, "invitation_id", "inv-1")
+	req = withUserContext(req, "user-1", "github-123", "octocat")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if invitation.Status != types.InvitationStatusDeclined {
+		t.Errorf("expected invitation to be declined, got %q", invitation.Status)
+	}
+	if len(repo.accepted) != 0 {
+		t.Errorf("expected declined invitation not to add a member")
+	}
+}
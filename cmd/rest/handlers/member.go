@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/brianfromlife/baluster/internal/core/admin"
+	httputil "github.com/brianfromlife/baluster/internal/http"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListMembers lists the members of an organization
+func ListMembers(memberRepo admin.MemberLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := &admin.ListMembersInput{}
+
+		output, err := admin.ListMembers(r.Context(), memberRepo, input)
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, output.Members)
+	}
+}
+
+// RemoveMember removes a member from an organization
+func RemoveMember(memberRepo admin.OrganizationMemberRemover, cache admin.MembershipCacheInvalidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "user_id")
+		if userID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("user_id is required"))
+			return
+		}
+
+		input := &admin.RemoveMemberInput{
+			UserID: userID,
+		}
+
+		if err := admin.RemoveMember(r.Context(), memberRepo, cache, input); err != nil {
+			if errors.Is(err, admin.ErrMemberNotFound) {
+				httputil.Error(w, http.StatusNotFound, err)
+				return
+			}
+			if errors.Is(err, admin.ErrLastOwner) {
+				httputil.Error(w, http.StatusConflict, err)
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+func TestListMembers(t *testing.T) {
+	repo := &mockOrganizationMemberRepo{
+		members: []*types.OrganizationMember{
+			{OrganizationID: "org-1", UserID: "user-1"},
+			{OrganizationID: "org-1", UserID: "user-2", Role: types.RoleViewer},
+			{OrganizationID: "org-2", UserID: "user-3", Role: types.RoleAdmin},
+		},
+	}
+
+	handler := ListMembers(repo)
+	req := newTestRequest(http.MethodGet, "/organizations/org-1/members", nil)
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if repo.members[0].Role != types.RoleOwner {
+		t.Errorf("expected legacy member to be reported as owner, got %q", repo.members[0].Role)
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	tests := []struct {
+		name             string
+		userID           string
+		members          []*types.OrganizationMember
+		expectedStatus   int
+		expectInvalidate bool
+	}{
+		{
+			name:   "removes member",
+			userID: "user-2",
+			members: []*types.OrganizationMember{
+				{OrganizationID: "org-1", UserID: "user-1", Role: types.RoleOwner},
+				{OrganizationID: "org-1", UserID: "user-2", Role: types.RoleDeveloper},
+			},
+			expectedStatus:   http.StatusNoContent,
+			expectInvalidate: true,
+		},
+		{
+			name:   "member not found",
+			userID: "user-3",
+			members: []*types.OrganizationMember{
+				{OrganizationID: "org-1", UserID: "user-1", Role: types.RoleOwner},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "last owner",
+			userID: "user-1",
+			members: []*types.OrganizationMember{
+				{OrganizationID: "org-1", UserID: "user-1", Role: types.RoleOwner},
+				{OrganizationID: "org-1", UserID: "user-2", Role: types.RoleAdmin},
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "one of several owners",
+			userID: "user-1",
+			members: []*types.OrganizationMember{
+				{OrganizationID: "org-1", UserID: "user-1", Role: types.RoleOwner},
+				{OrganizationID: "org-1", UserID: "user-2", Role: types.RoleOwner},
+			},
+			expectedStatus:   http.StatusNoContent,
+			expectInvalidate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockOrganizationMemberRepo{members: tt.members}
+			cache := &mockMembershipCache{}
+			handler := RemoveMember(repo, cache)
+
+			req := newTestRequest(http.MethodDelete, "/members/"+tt.userID, nil)
+			req = withURLParam(req, "user_id", tt.userID)
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if invalidated := len(cache.invalidatedOrganizations) > 0; invalidated != tt.expectInvalidate {
+				t.Errorf("expected organization cache invalidation %v, got %v", tt.expectInvalidate, invalidated)
+			}
+		})
+	}
+}
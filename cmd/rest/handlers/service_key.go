@@ -3,12 +3,16 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/brianfromlife/baluster/internal/auth"
 	"github.com/brianfromlife/baluster/internal/core"
 	"github.com/brianfromlife/baluster/internal/core/admin"
 	httputil "github.com/brianfromlife/baluster/internal/http"
+	"github.com/brianfromlife/baluster/internal/storage"
 	"github.com/brianfromlife/baluster/internal/types"
 	"github.com/go-chi/chi/v5"
 )
@@ -42,8 +46,29 @@ type CreateServiceKeyResponse struct {
 	OrganizationID string `json:"organization_id"`
 }
 
+// InvalidGrantsResponse represents the HTTP error response listing each invalid grant of a service key
+type InvalidGrantsResponse struct {
+	httputil.ErrorResponse
+	InvalidGrants []admin.InvalidGrant `json:"invalid_grants"`
+}
+
+// respondInvalidGrants writes a 400 response that lists each grant that does not match its application
+func respondInvalidGrants(w http.ResponseWriter, err error) {
+	resp := InvalidGrantsResponse{
+		ErrorResponse: httputil.ErrorResponse{
+			Error:   http.StatusText(http.StatusBadRequest),
+			Message: err.Error(),
+		},
+	}
+	var grantsErr *admin.InvalidGrantsError
+	if errors.As(err, &grantsErr) {
+		resp.InvalidGrants = grantsErr.Grants
+	}
+	httputil.JSON(w, http.StatusBadRequest, resp)
+}
+
 // CreateServiceKey creates a new service key
-func CreateServiceKey(serviceKeyRepo admin.ServiceKeyCreator) http.HandlerFunc {
+func CreateServiceKey(serviceKeyRepo admin.ServiceKeyCreator, appRepo admin.ApplicationFinder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		req, err := httputil.Decode[CreateServiceKeyRequest](r)
 		if err != nil {
@@ -67,12 +92,16 @@ func CreateServiceKey(serviceKeyRepo admin.ServiceKeyCreator) http.HandlerFunc {
 			ExpiresAt:    req.ExpiresAt,
 		}
 
-		output, err := admin.CreateServiceKey(r.Context(), serviceKeyRepo, input)
+		output, err := admin.CreateServiceKey(r.Context(), serviceKeyRepo, appRepo, input)
 		if err != nil {
 			if errors.Is(err, admin.ErrUserInfoNotFound) {
 				httputil.Error(w, http.StatusUnauthorized, err)
 				return
 			}
+			if errors.Is(err, admin.ErrInvalidPermissionGrant) {
+				respondInvalidGrants(w, err)
+				return
+			}
 			if errors.Is(err, admin.ErrServiceKeyLimitExceeded) {
 				httputil.Error(w, http.StatusForbidden, err)
 				return
@@ -93,8 +122,14 @@ func CreateServiceKey(serviceKeyRepo admin.ServiceKeyCreator) http.HandlerFunc {
 	}
 }
 
+// GetServiceKeyResponse is the service key with how often it was validated
+type GetServiceKeyResponse struct {
+	*types.ServiceKey
+	Usage *types.ServiceKeyUsage `json:"usage"`
+}
+
 // GetServiceKey gets a service key by ID
-func GetServiceKey(serviceKeyRepo admin.ServiceKeyGetter) http.HandlerFunc {
+func GetServiceKey(serviceKeyRepo admin.ServiceKeyGetter, usageRepo admin.ServiceKeyUsageGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		serviceKeyID := chi.URLParam(r, "service_key_id")
 		if serviceKeyID == "" {
@@ -106,7 +141,7 @@ func GetServiceKey(serviceKeyRepo admin.ServiceKeyGetter) http.HandlerFunc {
 			ID: serviceKeyID,
 		}
 
-		output, err := admin.GetServiceKey(r.Context(), serviceKeyRepo, input)
+		output, err := admin.GetServiceKey(r.Context(), serviceKeyRepo, usageRepo, input)
 		if err != nil {
 			httputil.Error(w, http.StatusInternalServerError, err)
 			return
@@ -114,8 +149,57 @@ func GetServiceKey(serviceKeyRepo admin.ServiceKeyGetter) http.HandlerFunc {
 
 		// Clear the hashed value from response
 		output.ServiceKey.TokenValue = ""
+		output.ServiceKey.PreviousTokenValue = ""
 
-		httputil.Success(w, http.StatusOK, output.ServiceKey)
+		httputil.Success(w, http.StatusOK, GetServiceKeyResponse{
+			ServiceKey: output.ServiceKey,
+			Usage:      output.Usage,
+		})
+	}
+}
+
+// ListServiceKeyAccessLog lists the access validation decisions made about a service key, newest first.
+// ?before= (RFC 3339) pages back from a time and ?limit= sets how many are returned, 100 by default and at most 1000.
+func ListServiceKeyAccessLog(accessLogRepo admin.ServiceKeyAccessLogLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serviceKeyID := chi.URLParam(r, "service_key_id")
+		if serviceKeyID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("service_key_id is required"))
+			return
+		}
+
+		input := &admin.ListServiceKeyAccessLogInput{
+			ID: serviceKeyID,
+		}
+		if value := r.URL.Query().Get("before"); value != "" {
+			before, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				httputil.Error(w, http.StatusBadRequest, fmt.Errorf("before must be an RFC 3339 time"))
+				return
+			}
+			input.Before = before
+		}
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit <= 0 {
+				httputil.Error(w, http.StatusBadRequest, fmt.Errorf("limit must be a positive number"))
+				return
+			}
+			input.Limit = limit
+		}
+
+		output, err := admin.ListServiceKeyAccessLog(r.Context(), accessLogRepo, input)
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		accessLog := output.AccessLog
+		if accessLog == nil {
+			accessLog = []*types.AccessLog{}
+		}
+
+		httputil.Success(w, http.StatusOK, map[string]any{"access_log": accessLog})
 	}
 }
 
@@ -158,6 +242,7 @@ func ListServiceKeys(serviceKeyRepo admin.ServiceKeyLister) http.HandlerFunc {
 		// Clear token values from response
 		for _, serviceKey := range output.ServiceKeys {
 			serviceKey.TokenValue = ""
+			serviceKey.PreviousTokenValue = ""
 		}
 
 		httputil.Success(w, http.StatusOK, output.ServiceKeys)
@@ -180,7 +265,7 @@ func (r UpdateServiceKeyRequest) Validate() error {
 }
 
 // UpdateServiceKey updates a service key
-func UpdateServiceKey(serviceKeyRepo admin.ServiceKeyUpdater) http.HandlerFunc {
+func UpdateServiceKey(serviceKeyRepo admin.ServiceKeyUpdater, appRepo admin.ApplicationFinder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		serviceKeyID := chi.URLParam(r, "service_key_id")
 		if serviceKeyID == "" {
@@ -210,19 +295,99 @@ func UpdateServiceKey(serviceKeyRepo admin.ServiceKeyUpdater) http.HandlerFunc {
 			ExpiresAt:    req.ExpiresAt,
 		}
 
-		output, err := admin.UpdateServiceKey(r.Context(), serviceKeyRepo, input)
+		output, err := admin.UpdateServiceKey(r.Context(), serviceKeyRepo, appRepo, input)
 		if err != nil {
+			if errors.Is(err, admin.ErrInvalidPermissionGrant) {
+				respondInvalidGrants(w, err)
+				return
+			}
 			httputil.Error(w, http.StatusInternalServerError, err)
 			return
 		}
 
 		// Clear the hashed value from response
 		output.ServiceKey.TokenValue = ""
+		output.ServiceKey.PreviousTokenValue = ""
 
 		httputil.Success(w, http.StatusOK, output.ServiceKey)
 	}
 }
 
+// RotateServiceKeyRequest represents the HTTP request to rotate a service key
+type RotateServiceKeyRequest struct {
+	// GracePeriodSeconds overrides how long the replaced token stays valid
+	GracePeriodSeconds *int `json:"grace_period_seconds"`
+}
+
+// Validate validates the RotateServiceKeyRequest
+func (r RotateServiceKeyRequest) Validate() error {
+	if r.GracePeriodSeconds != nil && *r.GracePeriodSeconds < 0 {
+		return fmt.Errorf("grace_period_seconds must not be negative")
+	}
+	return nil
+}
+
+type RotateServiceKeyResponse struct {
+	ServiceKey             any        `json:"service_key"`
+	TokenValue             string     `json:"token_value"`
+	PreviousTokenExpiresAt *time.Time `json:"previous_token_expires_at"`
+}
+
+// RotateServiceKey issues a new token for a service key, keeping the old one valid for a grace period
+func RotateServiceKey(serviceKeyRepo admin.ServiceKeyRotator, defaultGracePeriod time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serviceKeyID := chi.URLParam(r, "service_key_id")
+		if serviceKeyID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("service_key_id is required"))
+			return
+		}
+
+		// The body is optional, an empty one uses the default grace period
+		req, err := httputil.Decode[RotateServiceKeyRequest](r)
+		if err != nil && !errors.Is(err, io.EOF) {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		input := &admin.RotateServiceKeyInput{
+			ID:          serviceKeyID,
+			GracePeriod: defaultGracePeriod,
+		}
+		if req.GracePeriodSeconds != nil {
+			input.GracePeriod = time.Duration(*req.GracePeriodSeconds) * time.Second
+		}
+
+		output, err := admin.RotateServiceKey(r.Context(), serviceKeyRepo, input)
+		if err != nil {
+			if errors.Is(err, admin.ErrUserInfoNotFound) {
+				httputil.Error(w, http.StatusUnauthorized, err)
+				return
+			}
+			if errors.Is(err, admin.ErrInvalidGracePeriod) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			if errors.Is(err, storage.ErrNotFound) {
+				httputil.Error(w, http.StatusNotFound, err)
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Clear the hashed values from response
+		responseServiceKey := output.ServiceKey
+		responseServiceKey.TokenValue = ""
+		responseServiceKey.PreviousTokenValue = ""
+
+		httputil.Success(w, http.StatusOK, RotateServiceKeyResponse{
+			ServiceKey:             responseServiceKey,
+			TokenValue:             output.TokenValue,
+			PreviousTokenExpiresAt: responseServiceKey.PreviousTokenExpiresAt,
+		})
+	}
+}
+
 // DeleteServiceKey deletes a service key
 func DeleteServiceKey(serviceKeyRepo admin.ServiceKeyDeleter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -245,9 +410,10 @@ func DeleteServiceKey(serviceKeyRepo admin.ServiceKeyDeleter) http.HandlerFunc {
 	}
 }
 
-// ValidateAccessRequest
+// ValidateAccessRequest identifies the application by application_id or by its current application_name
 type ValidateAccessRequest struct {
 	Token           string `json:"token"`
+	ApplicationID   string `json:"application_id"`
 	ApplicationName string `json:"application_name"`
 }
 
@@ -255,30 +421,34 @@ func (r ValidateAccessRequest) Validate() error {
 	if r.Token == "" {
 		return fmt.Errorf("token is required")
 	}
-	if r.ApplicationName == "" {
-		return fmt.Errorf("application_name is required")
+	if r.ApplicationID == "" && r.ApplicationName == "" {
+		return errApplicationRequired
 	}
 	return nil
 }
 
-// ValidateAccess validates a service key for a specific application
-func ValidateAccess(serviceKeyRepo core.ServiceKeyTokenFinder) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract organization ID from header
-		orgID := r.Header.Get("x-org-id")
-		if orgID == "" {
-			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("x-org-id header is required"))
-			return
-		}
+// errApplicationRequired is returned when a request names neither an application ID nor a name
+var errApplicationRequired = errors.New("application_id or application_name is required")
 
+// ValidateAccess validates a service key for a specific application and records the decision in the access log
+func ValidateAccess(serviceKeyRepo core.ServiceKeyTokenFinder, accessLog core.AccessRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		req, err := httputil.Decode[ValidateAccessRequest](r)
 		if err != nil {
 			httputil.Error(w, http.StatusBadRequest, err)
 			return
 		}
 
+		// The organization is the API key's, ApiKeyAuthMiddleware already rejected a mismatched x-org-id
+		orgID, ok := auth.GetOrganizationID(r.Context())
+		if !ok {
+			httputil.Error(w, http.StatusUnauthorized, fmt.Errorf("organization ID not found in context"))
+			return
+		}
+
 		input := &core.ValidateAccessInput{
 			Token:           req.Token,
+			ApplicationID:   req.ApplicationID,
 			ApplicationName: req.ApplicationName,
 			OrganizationID:  orgID,
 		}
@@ -288,7 +458,173 @@ func ValidateAccess(serviceKeyRepo core.ServiceKeyTokenFinder) http.HandlerFunc
 			httputil.Error(w, http.StatusInternalServerError, err)
 			return
 		}
+		accessLog.Record(core.NewAccessLog(r.Context(), orgID, output))
 
 		httputil.Success(w, http.StatusOK, output)
 	}
 }
+
+// AccessCheckRequest is a single check within a batch
+type AccessCheckRequest struct {
+	Token           string `json:"token"`
+	ApplicationID   string `json:"application_id"`
+	ApplicationName string `json:"application_name"`
+	Permission      string `json:"permission"`
+}
+
+// BatchValidateAccessRequest represents the HTTP request to validate many checks at once
+type BatchValidateAccessRequest struct {
+	Checks []AccessCheckRequest `json:"checks"`
+}
+
+// Validate validates the BatchValidateAccessRequest
+func (r BatchValidateAccessRequest) Validate() error {
+	if len(r.Checks) == 0 {
+		return core.ErrNoChecks
+	}
+	if len(r.Checks) > core.MaxBatchValidateAccessChecks {
+		return core.ErrTooManyChecks
+	}
+	for i, check := range r.Checks {
+		if check.Token == "" {
+			return fmt.Errorf("checks[%d]: token is required", i)
+		}
+		if check.ApplicationID == "" && check.ApplicationName == "" {
+			return fmt.Errorf("checks[%d]: %w", i, errApplicationRequired)
+		}
+	}
+	return nil
+}
+
+// AccessCheckResponse is the result of a single check, in the same order as the request
+type AccessCheckResponse struct {
+	Valid       bool     `json:"valid"`
+	Permissions []string `json:"permissions"`
+}
+
+// BatchValidateAccessResponse represents the HTTP response with one result per check
+type BatchValidateAccessResponse struct {
+	Results []AccessCheckResponse `json:"results"`
+}
+
+// BatchValidateAccess validates many (token, application, permission) checks in one request
+// and records each decision in the access log
+func BatchValidateAccess(serviceKeyRepo core.ServiceKeyTokenFinder, accessLog core.AccessRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := httputil.Decode[BatchValidateAccessRequest](r)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// The organization is the API key's, ApiKeyAuthMiddleware already rejected a mismatched x-org-id
+		orgID, ok := auth.GetOrganizationID(r.Context())
+		if !ok {
+			httputil.Error(w, http.StatusUnauthorized, fmt.Errorf("organization ID not found in context"))
+			return
+		}
+
+		input := &core.BatchValidateAccessInput{
+			OrganizationID: orgID,
+		}
+		for _, check := range req.Checks {
+			input.Checks = append(input.Checks, core.AccessCheck{
+				Token:           check.Token,
+				ApplicationID:   check.ApplicationID,
+				ApplicationName: check.ApplicationName,
+				Permission:      check.Permission,
+			})
+		}
+
+		output, err := core.BatchValidateAccess(r.Context(), serviceKeyRepo, input)
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		results := make([]AccessCheckResponse, len(output.Results))
+		for i, result := range output.Results {
+			accessLog.Record(core.NewAccessLog(r.Context(), orgID, result))
+			results[i] = AccessCheckResponse{
+				Valid:       result.Valid,
+				Permissions: result.Permissions,
+			}
+		}
+
+		httputil.Success(w, http.StatusOK, BatchValidateAccessResponse{
+			Results: results,
+		})
+	}
+}
+
+// CheckPermissionRequest represents the HTTP request to check a service key's permissions
+type CheckPermissionRequest struct {
+	Token           string               `json:"token"`
+	ApplicationID   string               `json:"application_id"`
+	ApplicationName string               `json:"application_name"`
+	Permissions     []string             `json:"permissions"`
+	Match           core.PermissionMatch `json:"match"`
+}
+
+// Validate validates the CheckPermissionRequest
+func (r CheckPermissionRequest) Validate() error {
+	if r.Token == "" {
+		return fmt.Errorf("token is required")
+	}
+	if r.ApplicationID == "" && r.ApplicationName == "" {
+		return errApplicationRequired
+	}
+	if len(r.Permissions) == 0 {
+		return core.ErrNoPermissions
+	}
+	if r.Match != "" && r.Match != core.PermissionMatchAllOf && r.Match != core.PermissionMatchAnyOf {
+		return core.ErrInvalidPermissionMatch
+	}
+	return nil
+}
+
+// CheckPermissionResponse represents the HTTP response with the decision and the reason for a denial
+type CheckPermissionResponse struct {
+	Allowed            bool              `json:"allowed"`
+	DenialReason       core.DenialReason `json:"denial_reason,omitempty"`
+	MissingPermissions []string          `json:"missing_permissions,omitempty"`
+}
+
+// CheckPermission answers whether a service key has the required permissions for an application
+func CheckPermission(serviceKeyRepo core.ServiceKeyTokenFinder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := httputil.Decode[CheckPermissionRequest](r)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// The organization is the API key's, ApiKeyAuthMiddleware already rejected a mismatched x-org-id
+		orgID, ok := auth.GetOrganizationID(r.Context())
+		if !ok {
+			httputil.Error(w, http.StatusUnauthorized, fmt.Errorf("organization ID not found in context"))
+			return
+		}
+
+		input := &core.CheckPermissionInput{
+			Token:           req.Token,
+			ApplicationID:   req.ApplicationID,
+			ApplicationName: req.ApplicationName,
+			OrganizationID:  orgID,
+			Permissions:     req.Permissions,
+			Match:           req.Match,
+		}
+
+		output, err := core.CheckPermission(r.Context(), serviceKeyRepo, input)
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, CheckPermissionResponse{
+			Allowed:            output.Allowed,
+			DenialReason:       output.DenialReason,
+			MissingPermissions: output.MissingPermissions,
+		})
+	}
+}
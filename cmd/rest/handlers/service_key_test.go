@@ -1,16 +1,29 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/core"
+	"github.com/brianfromlife/baluster/internal/core/admin"
+	"github.com/brianfromlife/baluster/internal/storage"
 	"github.com/brianfromlife/baluster/internal/types"
 )
 
 func TestCreateServiceKey(t *testing.T) {
+	appRepo := &mockApplicationRepo{
+		applications: []*types.Application{
+			{ID: "app-1", OrganizationID: "org-1", Name: "test_app", Permissions: []string{"read", "write"}},
+		},
+	}
+
 	tests := []struct {
 		name           string
 		body           CreateServiceKeyRequest
@@ -73,7 +86,7 @@ func TestCreateServiceKey(t *testing.T) {
 					}
 				}
 			}
-			handler := CreateServiceKey(tt.repo)
+			handler := CreateServiceKey(tt.repo, appRepo)
 
 			req := newTestRequest(http.MethodPost, "/service-keys", tt.body)
 			// Add user context for valid requests (they need auth)
@@ -92,14 +105,122 @@ func TestCreateServiceKey(t *testing.T) {
 	}
 }
 
+func TestCreateServiceKeyValidatesGrants(t *testing.T) {
+	appRepo := &mockApplicationRepo{
+		applications: []*types.Application{
+			{ID: "app-1", OrganizationID: "org-1", Name: "orders", Permissions: []string{"orders:read", "orders:write"}},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		applications   []ApplicationAccessRequest
+		expectedStatus int
+	}{
+		{
+			name:           "wildcard covering declared permissions",
+			applications:   []ApplicationAccessRequest{{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"orders:*"}}},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "global wildcard",
+			applications:   []ApplicationAccessRequest{{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"*"}}},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "wildcard matching no declared permission",
+			applications:   []ApplicationAccessRequest{{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"ordres:*"}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "undeclared permission",
+			applications:   []ApplicationAccessRequest{{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"orders:read", "orders:delete"}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "application name mismatch",
+			applications:   []ApplicationAccessRequest{{ApplicationID: "app-1", ApplicationName: "billing", Permissions: []string{"orders:read"}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown application",
+			applications:   []ApplicationAccessRequest{{ApplicationID: "app-2", ApplicationName: "billing", Permissions: []string{"billing:read"}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(http.MethodPost, "/service-keys", CreateServiceKeyRequest{
+				Name:         "Test Service Key",
+				Applications: tt.applications,
+			})
+			req = withUserContext(req, "user-1", "github-123", "testuser")
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			CreateServiceKey(&mockServiceKeyRepo{}, appRepo).ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestCreateServiceKeyListsInvalidGrants(t *testing.T) {
+	appRepo := &mockApplicationRepo{
+		applications: []*types.Application{
+			{ID: "app-1", OrganizationID: "org-1", Name: "orders", Permissions: []string{"orders:read"}},
+		},
+	}
+
+	req := newTestRequest(http.MethodPost, "/service-keys", CreateServiceKeyRequest{
+		Name: "Test Service Key",
+		Applications: []ApplicationAccessRequest{
+			{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"orders:read", "orders:write", "ordres:*"}},
+			{ApplicationID: "app-2", ApplicationName: "billing", Permissions: []string{"billing:read"}},
+		},
+	})
+	req = withUserContext(req, "user-1", "github-123", "testuser")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	CreateServiceKey(&mockServiceKeyRepo{}, appRepo).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	var resp InvalidGrantsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	expected := []admin.InvalidGrant{
+		{ApplicationID: "app-1", ApplicationName: "orders", Permission: "orders:write", Reason: admin.InvalidGrantPermissionNotDeclared},
+		{ApplicationID: "app-1", ApplicationName: "orders", Permission: "ordres:*", Reason: admin.InvalidGrantPermissionNotDeclared},
+		{ApplicationID: "app-2", ApplicationName: "billing", Reason: admin.InvalidGrantApplicationNotFound},
+	}
+	if !slices.Equal(resp.InvalidGrants, expected) {
+		t.Errorf("expected invalid grants %+v, got %+v", expected, resp.InvalidGrants)
+	}
+}
+
 func TestGetServiceKey(t *testing.T) {
 	repo := &mockServiceKeyRepo{
 		serviceKeys: []*types.ServiceKey{
-			{ID: "sk-1", OrganizationID: "org-1", Name: "Test Service Key"},
+			{ID: "sk-1", OrganizationID: "org-1", Name: "Test Service Key", TokenValue: "hashed"},
+		},
+	}
+	lastUsedAt := time.Now().Add(-time.Hour)
+	accessLogRepo := &mockAccessLogRepo{
+		usages: []*types.ServiceKeyUsage{
+			{OrganizationID: "org-1", ServiceKeyID: "sk-1", AllowedCount: 3, DeniedCount: 1, LastUsedAt: &lastUsedAt},
 		},
 	}
 
-	handler := GetServiceKey(repo)
+	handler := GetServiceKey(repo, accessLogRepo)
 	req := newTestRequest(http.MethodGet, "/service-keys/sk-1?organization_id=org-1", nil)
 	req = withURLParam(req, "service_key_id", "sk-1")
 	req = withOrgContext(req, "org-1")
@@ -108,7 +229,79 @@ func TestGetServiceKey(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusOK {
-		t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var resp struct {
+		ID         string                 `json:"id"`
+		TokenValue string                 `json:"token_value"`
+		Usage      *types.ServiceKeyUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.ID != "sk-1" || resp.TokenValue != "" {
+		t.Errorf("expected the service key without its token, got %+v", resp)
+	}
+	if resp.Usage == nil || resp.Usage.AllowedCount != 3 || resp.Usage.DeniedCount != 1 || resp.Usage.LastUsedAt == nil {
+		t.Errorf("expected the key's usage, got %+v", resp.Usage)
+	}
+}
+
+func TestListServiceKeyAccessLog(t *testing.T) {
+	now := time.Now()
+	repo := &mockAccessLogRepo{
+		logs: []*types.AccessLog{
+			{ID: "al-1", OrganizationID: "org-1", ServiceKeyID: "sk-1", Allowed: true, CreatedAt: now.Add(-2 * time.Hour)},
+			{ID: "al-2", OrganizationID: "org-1", ServiceKeyID: "sk-1", DenialReason: "missing_permission", CreatedAt: now.Add(-time.Hour)},
+			{ID: "al-3", OrganizationID: "org-1", ServiceKeyID: "sk-2", Allowed: true, CreatedAt: now},
+			{ID: "al-4", OrganizationID: "org-2", ServiceKeyID: "sk-1", Allowed: true, CreatedAt: now},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []string
+	}{
+		{name: "newest first", expectedStatus: http.StatusOK, expectedIDs: []string{"al-2", "al-1"}},
+		{name: "limit", query: "limit=1", expectedStatus: http.StatusOK, expectedIDs: []string{"al-2"}},
+		{name: "before", query: "before=" + now.Add(-90*time.Minute).UTC().Format(time.RFC3339), expectedStatus: http.StatusOK, expectedIDs: []string{"al-1"}},
+		{name: "invalid before", query: "before=yesterday", expectedStatus: http.StatusBadRequest},
+		{name: "invalid limit", query: "limit=-1", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(http.MethodGet, "/service-keys/sk-1/access-log?"+tt.query, nil)
+			req = withURLParam(req, "service_key_id", "sk-1")
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			ListServiceKeyAccessLog(repo).ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				AccessLog []*types.AccessLog `json:"access_log"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var ids []string
+			for _, log := range resp.AccessLog {
+				ids = append(ids, log.ID)
+			}
+			if !slices.Equal(ids, tt.expectedIDs) {
+				t.Errorf("expected %v, got %v", tt.expectedIDs, ids)
+			}
+		})
 	}
 }
 
@@ -150,7 +343,7 @@ func TestUpdateServiceKey(t *testing.T) {
 		},
 	}
 
-	handler := UpdateServiceKey(repo)
+	handler := UpdateServiceKey(repo, &mockApplicationRepo{})
 	req := newTestRequest(http.MethodPut, "/service-keys/sk-1?organization_id=org-1", UpdateServiceKeyRequest{
 		Name: "New Name",
 	})
@@ -187,6 +380,96 @@ func TestDeleteServiceKey(t *testing.T) {
 	}
 }
 
+func TestRotateServiceKey(t *testing.T) {
+	repo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				Name:           "Test Service Key",
+				TokenValue:     storage.HashToken("old-token"),
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "test_app", Permissions: []string{"read"}},
+				},
+			},
+		},
+	}
+
+	rotate := func(body any) (*httptest.ResponseRecorder, RotateServiceKeyResponse) {
+		t.Helper()
+		req := newTestRequest(http.MethodPost, "/service-keys/sk-1/rotate", body)
+		req = withURLParam(req, "service_key_id", "sk-1")
+		req = withUserContext(req, "user-1", "github-123", "testuser")
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+
+		RotateServiceKey(repo, time.Hour).ServeHTTP(rr, req)
+
+		var resp RotateServiceKeyResponse
+		if rr.Code == http.StatusOK {
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return rr, resp
+	}
+
+	validate := func(token string) bool {
+		t.Helper()
+		req := newTestRequest(http.MethodPost, "/api/v1/access", ValidateAccessRequest{Token: token, ApplicationName: "test_app"})
+		req = withOrgContext(req, "org-1")
+		rr := httptest.NewRecorder()
+
+		ValidateAccess(repo, &mockAccessRecorder{}).ServeHTTP(rr, req)
+
+		var output core.ValidateAccessOutput
+		if err := json.NewDecoder(rr.Body).Decode(&output); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return output.Valid
+	}
+
+	// Without a body the configured grace period applies
+	rr, resp := rotate(nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if resp.TokenValue == "" || resp.TokenValue == "old-token" {
+		t.Fatalf("expected a new token value, got %q", resp.TokenValue)
+	}
+	if resp.PreviousTokenExpiresAt == nil || time.Until(*resp.PreviousTokenExpiresAt) < 59*time.Minute {
+		t.Errorf("expected the previous token to stay valid for about an hour, got %v", resp.PreviousTokenExpiresAt)
+	}
+	if !validate(resp.TokenValue) {
+		t.Errorf("expected the new token to be valid")
+	}
+	if !validate("old-token") {
+		t.Errorf("expected the old token to be valid during the grace period")
+	}
+
+	// A zero grace period retires the replaced token at once
+	newToken := resp.TokenValue
+	zero := 0
+	rr, resp = rotate(RotateServiceKeyRequest{GracePeriodSeconds: &zero})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if validate(newToken) {
+		t.Errorf("expected the replaced token to be rejected once its grace period ended")
+	}
+	if validate("old-token") {
+		t.Errorf("expected a token replaced two rotations ago to be rejected")
+	}
+	if !validate(resp.TokenValue) {
+		t.Errorf("expected the newest token to be valid")
+	}
+
+	negative := -1
+	if rr, _ := rotate(RotateServiceKeyRequest{GracePeriodSeconds: &negative}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a negative grace period, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
 func TestValidateAccess(t *testing.T) {
 	expiry := time.Now().Add(24 * time.Hour)
 	repo := &mockServiceKeyRepo{
@@ -194,7 +477,7 @@ func TestValidateAccess(t *testing.T) {
 			{
 				ID:             "sk-1",
 				OrganizationID: "org-1",
-				TokenValue:     "valid-token",
+				TokenValue:     storage.HashToken("valid-token"),
 				ExpiresAt:      &expiry,
 				Applications: []types.ApplicationAccess{
 					{ApplicationID: "app-1", ApplicationName: "test_app", Permissions: []string{"read", "write"}},
@@ -234,7 +517,7 @@ func TestValidateAccess(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := ValidateAccess(repo)
+			handler := ValidateAccess(repo, &mockAccessRecorder{})
 
 			req := newTestRequest(http.MethodPost, "/api/validate/access", tt.body)
 			// ValidateAccess requires x-org-id header
@@ -251,3 +534,429 @@ func TestValidateAccess(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAccessByApplicationID(t *testing.T) {
+	repo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				TokenValue:     storage.HashToken("valid-token"),
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"read"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		body          ValidateAccessRequest
+		expectedValid bool
+	}{
+		{name: "by id", body: ValidateAccessRequest{Token: "valid-token", ApplicationID: "app-1"}, expectedValid: true},
+		{name: "by current name", body: ValidateAccessRequest{Token: "valid-token", ApplicationName: "orders"}, expectedValid: true},
+		{name: "id takes precedence over name", body: ValidateAccessRequest{Token: "valid-token", ApplicationID: "app-2", ApplicationName: "orders"}},
+		{name: "unknown id", body: ValidateAccessRequest{Token: "valid-token", ApplicationID: "app-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(http.MethodPost, "/api/v1/access", tt.body)
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			ValidateAccess(repo, &mockAccessRecorder{}).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+			}
+
+			var resp core.ValidateAccessOutput
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Valid != tt.expectedValid {
+				t.Errorf("expected valid %v, got %v", tt.expectedValid, resp.Valid)
+			}
+		})
+	}
+}
+
+func TestValidateAccessRespectsApiKeyScope(t *testing.T) {
+	repo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				TokenValue:     storage.HashToken("valid-token"),
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "test_app", Permissions: []string{"read"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		apiKey        *types.ApiKey
+		expectedValid bool
+	}{
+		{name: "key bound to the application", apiKey: &types.ApiKey{OrganizationID: "org-1", ApplicationID: "app-1"}, expectedValid: true},
+		{name: "key scoped to the application", apiKey: &types.ApiKey{OrganizationID: "org-1", ApplicationID: "app-2", ApplicationIDs: []string{"app-1"}}, expectedValid: true},
+		{name: "key for another application", apiKey: &types.ApiKey{OrganizationID: "org-1", ApplicationID: "app-2"}, expectedValid: false},
+		{name: "key from another organization", apiKey: &types.ApiKey{OrganizationID: "org-2", ApplicationID: "app-1"}, expectedValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(http.MethodPost, "/api/v1/access", ValidateAccessRequest{Token: "valid-token", ApplicationName: "test_app"})
+			req = withOrgContext(req, "org-1")
+			req = req.WithContext(context.WithValue(req.Context(), auth.ApiKeyKey, tt.apiKey))
+			rr := httptest.NewRecorder()
+
+			ValidateAccess(repo, &mockAccessRecorder{}).ServeHTTP(rr, req)
+
+			var output core.ValidateAccessOutput
+			if err := json.NewDecoder(rr.Body).Decode(&output); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if output.Valid != tt.expectedValid {
+				t.Errorf("expected valid %v, got %v", tt.expectedValid, output.Valid)
+			}
+		})
+	}
+}
+
+func TestValidateAccessRecordsDecision(t *testing.T) {
+	repo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				TokenValue:     storage.HashToken("valid-token"),
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "test_app", Permissions: []string{"read"}},
+				},
+			},
+		},
+	}
+
+	req := newTestRequest(http.MethodPost, "/api/v1/access", ValidateAccessRequest{Token: "valid-token", ApplicationName: "test_app"})
+	req = withOrgContext(req, "org-1")
+	ctx := context.WithValue(req.Context(), auth.ApiKeyKey, &types.ApiKey{ID: "ak-1", OrganizationID: "org-1", ApplicationID: "app-2"})
+	ctx = storage.WithRequestMetadata(ctx, storage.RequestMetadata{ClientIP: "203.0.113.7"})
+	req = req.WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	recorder := &mockAccessRecorder{}
+	ValidateAccess(repo, recorder).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "application_not_in_api_key_scope") {
+		t.Errorf("expected the denial reason to stay out of the response, got %s", rr.Body.String())
+	}
+	if len(recorder.logs) != 1 {
+		t.Fatalf("expected 1 access log record, got %d", len(recorder.logs))
+	}
+	log := recorder.logs[0]
+	if log.Allowed || log.DenialReason != string(core.DenialReasonApplicationNotInScope) || log.ServiceKeyID != "sk-1" ||
+		log.ApplicationID != "app-1" || log.ApiKeyID != "ak-1" || log.ClientIP != "203.0.113.7" || log.ID == "" {
+		t.Errorf("unexpected access log record %+v", log)
+	}
+}
+
+func TestBatchValidateAccess(t *testing.T) {
+	repo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				TokenValue:     storage.HashToken("valid-token"),
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"read", "write"}},
+					{ApplicationID: "app-2", ApplicationName: "billing", Permissions: []string{"read"}},
+				},
+			},
+		},
+	}
+
+	req := newTestRequest(http.MethodPost, "/api/v1/access/batch", BatchValidateAccessRequest{
+		Checks: []AccessCheckRequest{
+			{Token: "valid-token", ApplicationName: "orders", Permission: "write"},
+			{Token: "valid-token", ApplicationName: "billing", Permission: "write"},
+			{Token: "valid-token", ApplicationName: "billing"},
+			{Token: "valid-token", ApplicationName: "shipping"},
+			{Token: "unknown-token", ApplicationName: "orders"},
+			{Token: "unknown-token", ApplicationName: "billing"},
+		},
+	})
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	recorder := &mockAccessRecorder{}
+	BatchValidateAccess(repo, recorder).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var resp BatchValidateAccessResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	expected := []bool{true, false, true, false, false, false}
+	if len(resp.Results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(resp.Results))
+	}
+	for i, valid := range expected {
+		if resp.Results[i].Valid != valid {
+			t.Errorf("result %d: expected valid %v, got %v", i, valid, resp.Results[i].Valid)
+		}
+	}
+
+	// Every check is recorded with why it was denied
+	reasons := []core.DenialReason{
+		"",
+		core.DenialReasonMissingPermission,
+		"",
+		core.DenialReasonNoApplicationAccess,
+		core.DenialReasonInvalidToken,
+		core.DenialReasonInvalidToken,
+	}
+	if len(recorder.logs) != len(reasons) {
+		t.Fatalf("expected %d access log records, got %d", len(reasons), len(recorder.logs))
+	}
+	for i, reason := range reasons {
+		log := recorder.logs[i]
+		if log.Allowed != expected[i] || log.DenialReason != string(reason) || log.OrganizationID != "org-1" {
+			t.Errorf("record %d: expected allowed %v and reason %q, got %+v", i, expected[i], reason, log)
+		}
+	}
+	if recorder.logs[1].ServiceKeyID != "sk-1" || recorder.logs[1].ApplicationID != "app-2" || recorder.logs[4].ServiceKeyID != "" {
+		t.Errorf("expected matched keys and applications to be recorded, got %+v and %+v", recorder.logs[1], recorder.logs[4])
+	}
+
+	// One lookup per distinct token, including tokens that were not found
+	if repo.findCalls != 2 {
+		t.Errorf("expected 2 token lookups, got %d", repo.findCalls)
+	}
+}
+
+func TestBatchValidateAccessRejectsInvalidBatches(t *testing.T) {
+	tooMany := make([]AccessCheckRequest, core.MaxBatchValidateAccessChecks+1)
+	for i := range tooMany {
+		tooMany[i] = AccessCheckRequest{Token: "valid-token", ApplicationName: "orders"}
+	}
+
+	tests := []struct {
+		name string
+		body BatchValidateAccessRequest
+	}{
+		{name: "no checks", body: BatchValidateAccessRequest{}},
+		{name: "too many checks", body: BatchValidateAccessRequest{Checks: tooMany}},
+		{name: "check without application", body: BatchValidateAccessRequest{Checks: []AccessCheckRequest{{Token: "valid-token"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(http.MethodPost, "/api/v1/access/batch", tt.body)
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			BatchValidateAccess(&mockServiceKeyRepo{}, &mockAccessRecorder{}).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+			}
+		})
+	}
+}
+
+func TestCheckPermission(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	repo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				TokenValue:     storage.HashToken("valid-token"),
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"read", "write"}},
+				},
+			},
+			{
+				ID:             "sk-2",
+				OrganizationID: "org-1",
+				TokenValue:     storage.HashToken("expired-token"),
+				ExpiresAt:      &expired,
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"read"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		body            CheckPermissionRequest
+		expectedAllowed bool
+		expectedReason  core.DenialReason
+		expectedMissing []string
+	}{
+		{
+			name:            "all of granted",
+			body:            CheckPermissionRequest{Token: "valid-token", ApplicationName: "orders", Permissions: []string{"read", "write"}},
+			expectedAllowed: true,
+		},
+		{
+			name:            "all of missing one",
+			body:            CheckPermissionRequest{Token: "valid-token", ApplicationName: "orders", Permissions: []string{"read", "delete"}},
+			expectedReason:  core.DenialReasonMissingPermission,
+			expectedMissing: []string{"delete"},
+		},
+		{
+			name:            "any of granted",
+			body:            CheckPermissionRequest{Token: "valid-token", ApplicationName: "orders", Permissions: []string{"delete", "write"}, Match: core.PermissionMatchAnyOf},
+			expectedAllowed: true,
+		},
+		{
+			name:            "any of missing all",
+			body:            CheckPermissionRequest{Token: "valid-token", ApplicationName: "orders", Permissions: []string{"delete", "admin"}, Match: core.PermissionMatchAnyOf},
+			expectedReason:  core.DenialReasonMissingPermission,
+			expectedMissing: []string{"delete", "admin"},
+		},
+		{
+			name:           "unknown token",
+			body:           CheckPermissionRequest{Token: "unknown-token", ApplicationName: "orders", Permissions: []string{"read"}},
+			expectedReason: core.DenialReasonInvalidToken,
+		},
+		{
+			name:           "expired token",
+			body:           CheckPermissionRequest{Token: "expired-token", ApplicationName: "orders", Permissions: []string{"read"}},
+			expectedReason: core.DenialReasonExpired,
+		},
+		{
+			name:           "no application access",
+			body:           CheckPermissionRequest{Token: "valid-token", ApplicationName: "billing", Permissions: []string{"read"}},
+			expectedReason: core.DenialReasonNoApplicationAccess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(http.MethodPost, "/api/v1/permissions/check", tt.body)
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			CheckPermission(repo).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+			}
+
+			var resp CheckPermissionResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if resp.Allowed != tt.expectedAllowed {
+				t.Errorf("expected allowed %v, got %v", tt.expectedAllowed, resp.Allowed)
+			}
+			if resp.DenialReason != tt.expectedReason {
+				t.Errorf("expected denial reason %q, got %q", tt.expectedReason, resp.DenialReason)
+			}
+			if !slices.Equal(resp.MissingPermissions, tt.expectedMissing) {
+				t.Errorf("expected missing permissions %v, got %v", tt.expectedMissing, resp.MissingPermissions)
+			}
+		})
+	}
+}
+
+func TestCheckPermissionRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		body CheckPermissionRequest
+	}{
+		{name: "no permissions", body: CheckPermissionRequest{Token: "valid-token", ApplicationName: "orders"}},
+		{name: "unknown match", body: CheckPermissionRequest{Token: "valid-token", ApplicationName: "orders", Permissions: []string{"read"}, Match: "some_of"}},
+		{name: "no application", body: CheckPermissionRequest{Token: "valid-token", Permissions: []string{"read"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(http.MethodPost, "/api/v1/permissions/check", tt.body)
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			CheckPermission(&mockServiceKeyRepo{}).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+			}
+		})
+	}
+}
+
+func TestCheckPermissionMatchesWildcardGrants(t *testing.T) {
+	repo := &mockServiceKeyRepo{
+		serviceKeys: []*types.ServiceKey{
+			{
+				ID:             "sk-1",
+				OrganizationID: "org-1",
+				TokenValue:     storage.HashToken("orders-token"),
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"orders:*", "billing:invoices:read"}},
+				},
+			},
+			{
+				ID:             "sk-2",
+				OrganizationID: "org-1",
+				TokenValue:     storage.HashToken("global-token"),
+				Applications: []types.ApplicationAccess{
+					{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"*"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		token           string
+		permission      string
+		expectedAllowed bool
+	}{
+		{name: "prefix wildcard", token: "orders-token", permission: "orders:read", expectedAllowed: true},
+		{name: "prefix wildcard nested", token: "orders-token", permission: "orders:read:own", expectedAllowed: true},
+		{name: "prefix wildcard other resource", token: "orders-token", permission: "billing:read"},
+		{name: "prefix wildcard without segment", token: "orders-token", permission: "orders"},
+		{name: "exact hierarchical grant", token: "orders-token", permission: "billing:invoices:read", expectedAllowed: true},
+		{name: "hierarchical grant is not a prefix", token: "orders-token", permission: "billing:invoices"},
+		{name: "global wildcard", token: "global-token", permission: "anything:at:all", expectedAllowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(http.MethodPost, "/api/v1/permissions/check", CheckPermissionRequest{
+				Token:           tt.token,
+				ApplicationName: "orders",
+				Permissions:     []string{tt.permission},
+			})
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			CheckPermission(repo).ServeHTTP(rr, req)
+
+			var resp CheckPermissionResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Allowed != tt.expectedAllowed {
+				t.Errorf("expected allowed %v, got %v", tt.expectedAllowed, resp.Allowed)
+			}
+		})
+	}
+}
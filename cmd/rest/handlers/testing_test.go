@@ -4,34 +4,70 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"sort"
+	"time"
 
 	"github.com/brianfromlife/baluster/internal/auth"
 	"github.com/brianfromlife/baluster/internal/core"
 	"github.com/brianfromlife/baluster/internal/core/admin"
+	"github.com/brianfromlife/baluster/internal/storage"
 	"github.com/brianfromlife/baluster/internal/types"
+	"github.com/brianfromlife/baluster/internal/webhook"
 	"github.com/go-chi/chi/v5"
 )
 
 // Ensure mocks implement the interfaces
 var (
-	_ admin.OrganizationCreator  = (*mockOrganizationRepo)(nil)
-	_ admin.ApplicationCreator   = (*mockApplicationRepo)(nil)
-	_ admin.ApplicationLister    = (*mockApplicationRepo)(nil)
-	_ admin.ApplicationGetter    = (*mockApplicationRepo)(nil)
-	_ admin.ApplicationUpdater   = (*mockApplicationRepo)(nil)
-	_ admin.ApiKeyCreator        = (*mockApiKeyRepo)(nil)
-	_ admin.ApiKeyGetter         = (*mockApiKeyRepo)(nil)
-	_ admin.ApiKeyLister         = (*mockApiKeyRepo)(nil)
-	_ admin.ApiKeyUpdater        = (*mockApiKeyRepo)(nil)
-	_ admin.ApiKeyDeleter        = (*mockApiKeyRepo)(nil)
-	_ admin.ServiceKeyCreator    = (*mockServiceKeyRepo)(nil)
-	_ admin.ServiceKeyGetter     = (*mockServiceKeyRepo)(nil)
-	_ admin.ServiceKeyLister     = (*mockServiceKeyRepo)(nil)
-	_ admin.ServiceKeyUpdater    = (*mockServiceKeyRepo)(nil)
-	_ admin.ServiceKeyDeleter    = (*mockServiceKeyRepo)(nil)
-	_ core.ServiceKeyTokenFinder = (*mockServiceKeyRepo)(nil)
+	_ admin.OrganizationCreator    = (*mockOrganizationRepo)(nil)
+	_ admin.ApplicationCreator     = (*mockApplicationRepo)(nil)
+	_ admin.ApplicationLister      = (*mockApplicationRepo)(nil)
+	_ admin.ApplicationGetter      = (*mockApplicationRepo)(nil)
+	_ admin.ApplicationUpdater     = (*mockApplicationRepo)(nil)
+	_ admin.ApplicationDeleter     = (*mockApplicationRepo)(nil)
+	_ admin.ApplicationFinder      = (*mockApplicationRepo)(nil)
+	_ admin.ApiKeyCreator          = (*mockApiKeyRepo)(nil)
+	_ admin.ApiKeyGetter           = (*mockApiKeyRepo)(nil)
+	_ admin.ApiKeyLister           = (*mockApiKeyRepo)(nil)
+	_ admin.ApiKeyUpdater          = (*mockApiKeyRepo)(nil)
+	_ admin.ApiKeyDeleter          = (*mockApiKeyRepo)(nil)
+	_ admin.ApiKeyRotator          = (*mockApiKeyRepo)(nil)
+	_ admin.ApiKeyRevoker          = (*mockApiKeyRepo)(nil)
+	_ auth.ApiKeyTokenFinder       = (*mockApiKeyRepo)(nil)
+	_ admin.ServiceKeyCreator      = (*mockServiceKeyRepo)(nil)
+	_ admin.ServiceKeyGetter       = (*mockServiceKeyRepo)(nil)
+	_ admin.ServiceKeyLister       = (*mockServiceKeyRepo)(nil)
+	_ admin.ServiceKeyGrantUpdater = (*mockServiceKeyRepo)(nil)
+	_ admin.ServiceKeyUpdater      = (*mockServiceKeyRepo)(nil)
+	_ admin.ServiceKeyDeleter      = (*mockServiceKeyRepo)(nil)
+	_ admin.ServiceKeyRotator      = (*mockServiceKeyRepo)(nil)
+	_ core.ServiceKeyTokenFinder   = (*mockServiceKeyRepo)(nil)
+
+	_ admin.MemberLister                  = (*mockOrganizationMemberRepo)(nil)
+	_ admin.OrganizationMemberRemover     = (*mockOrganizationMemberRepo)(nil)
+	_ admin.OrganizationMembershipChecker = (*mockOrganizationMemberRepo)(nil)
+	_ admin.InvitationCreator             = (*mockInvitationRepo)(nil)
+	_ admin.InvitationAccepter            = (*mockInvitationRepo)(nil)
+	_ admin.InvitationDecliner            = (*mockInvitationRepo)(nil)
+	_ admin.UserInvitationLister          = (*mockInvitationRepo)(nil)
+	_ admin.MembershipCacheInvalidator    = (*mockMembershipCache)(nil)
+
+	_ admin.WebhookCreator            = (*mockWebhookRepo)(nil)
+	_ admin.WebhookLister             = (*mockWebhookRepo)(nil)
+	_ admin.WebhookGetter             = (*mockWebhookRepo)(nil)
+	_ admin.WebhookDeliveryLister     = (*mockWebhookRepo)(nil)
+	_ admin.WebhookUpdater            = (*mockWebhookRepo)(nil)
+	_ admin.WebhookDeleter            = (*mockWebhookRepo)(nil)
+	_ admin.WebhookTestSender         = (*mockWebhookSender)(nil)
+	_ admin.AuditSearcher             = (*mockAuditRepo)(nil)
+	_ admin.AuditChainReader          = (*mockAuditRepo)(nil)
+	_ admin.ServiceKeyUsageGetter     = (*mockAccessLogRepo)(nil)
+	_ admin.ServiceKeyAccessLogLister = (*mockAccessLogRepo)(nil)
+	_ core.AccessRecorder             = (*mockAccessRecorder)(nil)
+	_ webhook.Resolver                = (mockResolver)(nil)
 )
 
 // Mock Organization Repository
@@ -52,10 +88,11 @@ func (m *mockOrganizationRepo) Create(ctx context.Context, org *types.Organizati
 // Mock Organization Member Repository
 
 type mockOrganizationMemberRepo struct {
+	members      []*types.OrganizationMember
 	addMemberErr error
 }
 
-func (m *mockOrganizationMemberRepo) AddMember(ctx context.Context, orgID, userID string) error {
+func (m *mockOrganizationMemberRepo) AddMember(ctx context.Context, orgID, userID string, role types.Role) error {
 	if m.addMemberErr != nil {
 		return m.addMemberErr
 	}
@@ -66,6 +103,103 @@ func (m *mockOrganizationMemberRepo) CreateOrganizationWithMember(ctx context.Co
 	return nil
 }
 
+func (m *mockOrganizationMemberRepo) IsMember(ctx context.Context, orgID, userID string) (bool, error) {
+	for _, member := range m.members {
+		if member.OrganizationID == orgID && member.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockOrganizationMemberRepo) ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error) {
+	var result []*types.OrganizationMember
+	for _, member := range m.members {
+		if member.OrganizationID == orgID {
+			result = append(result, member)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockOrganizationMemberRepo) RemoveMember(ctx context.Context, orgID, userID string) error {
+	for i, member := range m.members {
+		if member.OrganizationID == orgID && member.UserID == userID {
+			m.members = append(m.members[:i], m.members[i+1:]...)
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+// Mock Invitation Repository
+
+type mockInvitationRepo struct {
+	invitations []*types.Invitation
+	accepted    map[string]string
+}
+
+func (m *mockInvitationRepo) Create(ctx context.Context, invitation *types.Invitation) error {
+	m.invitations = append(m.invitations, invitation)
+	return nil
+}
+
+func (m *mockInvitationRepo) Get(ctx context.Context, id string) (*types.Invitation, error) {
+	for _, invitation := range m.invitations {
+		if invitation.ID == id {
+			return invitation, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (m *mockInvitationRepo) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Invitation, error) {
+	var result []*types.Invitation
+	for _, invitation := range m.invitations {
+		if invitation.OrganizationID == organizationID {
+			result = append(result, invitation)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockInvitationRepo) ListByUsername(ctx context.Context, username string) ([]*types.Invitation, error) {
+	var result []*types.Invitation
+	for _, invitation := range m.invitations {
+		if invitation.IsFor(username) {
+			result = append(result, invitation)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockInvitationRepo) Update(ctx context.Context, invitation *types.Invitation) error {
+	return nil
+}
+
+func (m *mockInvitationRepo) Accept(ctx context.Context, invitation *types.Invitation, userID string) error {
+	if m.accepted == nil {
+		m.accepted = make(map[string]string)
+	}
+	m.accepted[invitation.ID] = userID
+	return nil
+}
+
+// Mock Membership Cache
+
+type mockMembershipCache struct {
+	invalidated              []string
+	invalidatedOrganizations []string
+}
+
+func (m *mockMembershipCache) Invalidate(orgID, userID string) {
+	m.invalidated = append(m.invalidated, orgID+":"+userID)
+}
+
+func (m *mockMembershipCache) InvalidateOrganization(orgID string) {
+	m.invalidatedOrganizations = append(m.invalidatedOrganizations, orgID)
+}
+
 // Mock Application Repository
 
 type mockApplicationRepo struct {
@@ -115,7 +249,7 @@ func (m *mockApplicationRepo) Get(ctx context.Context, organizationID, id string
 			return app, nil
 		}
 	}
-	return nil, nil
+	return nil, storage.ErrNotFound
 }
 
 func (m *mockApplicationRepo) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
@@ -132,6 +266,16 @@ func (m *mockApplicationRepo) Update(ctx context.Context, app *types.Application
 	return nil
 }
 
+func (m *mockApplicationRepo) Delete(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	for i, existingApp := range m.applications {
+		if existingApp.ID == app.ID {
+			m.applications = append(m.applications[:i], m.applications[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 // Mock API Key Repository
 
 type mockApiKeyRepo struct {
@@ -207,6 +351,28 @@ func (m *mockApiKeyRepo) Update(ctx context.Context, apiKey *types.ApiKey, userI
 	return nil
 }
 
+func (m *mockApiKeyRepo) Rotate(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	apiKey.TokenValue = storage.HashToken(apiKey.TokenValue)
+	// Store a copy, the handler clears the hashes on the returned key like a real repository response
+	stored := *apiKey
+	return m.Update(ctx, &stored, userID, githubID, username)
+}
+
+func (m *mockApiKeyRepo) Revoke(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	stored := *apiKey
+	return m.Update(ctx, &stored, userID, githubID, username)
+}
+
+func (m *mockApiKeyRepo) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ApiKey, error) {
+	hashed := storage.HashToken(tokenValue)
+	for _, key := range m.apiKeys {
+		if key.TokenValue == hashed || key.PreviousTokenValue == hashed {
+			return key, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
 func (m *mockApiKeyRepo) Delete(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
 	if m.deleteErr != nil {
 		return m.deleteErr
@@ -231,6 +397,7 @@ type mockServiceKeyRepo struct {
 	updateErr   error
 	deleteErr   error
 	findErr     error
+	findCalls   int
 }
 
 func (m *mockServiceKeyRepo) Create(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
@@ -296,6 +463,13 @@ func (m *mockServiceKeyRepo) Update(ctx context.Context, serviceKey *types.Servi
 	return nil
 }
 
+func (m *mockServiceKeyRepo) Rotate(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
+	serviceKey.TokenValue = storage.HashToken(serviceKey.TokenValue)
+	// Store a copy, the handler clears the hashes on the returned key like a real repository response
+	stored := *serviceKey
+	return m.Update(ctx, &stored, userID, githubID, username)
+}
+
 func (m *mockServiceKeyRepo) Delete(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
 	if m.deleteErr != nil {
 		return m.deleteErr
@@ -313,24 +487,205 @@ func (m *mockServiceKeyRepo) FindByTokenValue(ctx context.Context, tokenValue st
 	if m.findErr != nil {
 		return nil, m.findErr
 	}
+	hashed := storage.HashToken(tokenValue)
 	for _, key := range m.serviceKeys {
-		if key.TokenValue == tokenValue {
+		if key.TokenValue == hashed || key.PreviousTokenValue == hashed {
 			return key, nil
 		}
 	}
-	return nil, nil
+	return nil, storage.ErrNotFound
 }
 
 func (m *mockServiceKeyRepo) FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error) {
+	m.findCalls++
 	if m.findErr != nil {
 		return nil, m.findErr
 	}
+	hashed := storage.HashToken(tokenValue)
 	for _, key := range m.serviceKeys {
-		if key.TokenValue == tokenValue && key.OrganizationID == organizationID {
+		if (key.TokenValue == hashed || key.PreviousTokenValue == hashed) && key.OrganizationID == organizationID {
 			return key, nil
 		}
 	}
-	return nil, nil
+	return nil, storage.ErrNotFound
+}
+
+// Mock Webhook Repository
+
+type mockWebhookRepo struct {
+	webhooks   []*types.Webhook
+	deliveries []*types.WebhookDelivery
+}
+
+func (m *mockWebhookRepo) Create(ctx context.Context, webhook *types.Webhook) error {
+	m.webhooks = append(m.webhooks, webhook)
+	return nil
+}
+
+func (m *mockWebhookRepo) Get(ctx context.Context, organizationID, id string) (*types.Webhook, error) {
+	for _, webhook := range m.webhooks {
+		if webhook.ID == id && webhook.OrganizationID == organizationID {
+			return webhook, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (m *mockWebhookRepo) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Webhook, error) {
+	var result []*types.Webhook
+	for _, webhook := range m.webhooks {
+		if webhook.OrganizationID == organizationID {
+			result = append(result, webhook)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockWebhookRepo) Update(ctx context.Context, webhook *types.Webhook) error {
+	for i, existing := range m.webhooks {
+		if existing.ID == webhook.ID {
+			m.webhooks[i] = webhook
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (m *mockWebhookRepo) Delete(ctx context.Context, organizationID, id string) error {
+	for i, webhook := range m.webhooks {
+		if webhook.ID == id && webhook.OrganizationID == organizationID {
+			m.webhooks = append(m.webhooks[:i], m.webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (m *mockWebhookRepo) ListDeliveries(ctx context.Context, organizationID, webhookID string, limit int) ([]*types.WebhookDelivery, error) {
+	var result []*types.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID && delivery.OrganizationID == organizationID && len(result) < limit {
+			result = append(result, delivery)
+		}
+	}
+	return result, nil
+}
+
+type mockWebhookSender struct {
+	sent []*types.Webhook
+}
+
+func (m *mockWebhookSender) SendTest(ctx context.Context, webhook *types.Webhook) (*types.WebhookDelivery, error) {
+	m.sent = append(m.sent, webhook)
+	return &types.WebhookDelivery{
+		ID:             "whd-test",
+		OrganizationID: webhook.OrganizationID,
+		WebhookID:      webhook.ID,
+		Event:          types.WebhookEventTest,
+		Status:         types.WebhookDeliveryStatusSucceeded,
+		Attempts:       1,
+	}, nil
+}
+
+// Mock Resolver
+
+// mockResolver resolves the hosts it lists and fails for the rest, example.com is public
+type mockResolver map[string][]netip.Addr
+
+func newMockResolver() mockResolver {
+	return mockResolver{
+		"example.com":          {netip.MustParseAddr("93.184.215.14")},
+		"internal.example.com": {netip.MustParseAddr("93.184.215.14"), netip.MustParseAddr("10.0.0.5")},
+	}
+}
+
+func (m mockResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	addrs, ok := m[host]
+	if !ok {
+		return nil, fmt.Errorf("no such host %q", host)
+	}
+	return addrs, nil
+}
+
+// Mock Audit Repository
+
+type mockAuditRepo struct {
+	events []*storage.AuditEvent
+	heads  map[types.AuditResourceType]*types.AuditChainHead
+}
+
+func (m *mockAuditRepo) Search(ctx context.Context, organizationID string, filter storage.AuditFilter, cursor string, limit int) ([]*storage.AuditEvent, error) {
+	var events []*storage.AuditEvent
+	for _, event := range m.events {
+		if event.OrganizationID == organizationID {
+			events = append(events, event)
+		}
+	}
+	return storage.SearchHistory(events, filter, cursor, limit)
+}
+
+func (m *mockAuditRepo) GetChainHead(ctx context.Context, organizationID string, resourceType types.AuditResourceType) (*types.AuditChainHead, error) {
+	if head, ok := m.heads[resourceType]; ok && head.OrganizationID == organizationID {
+		return head, nil
+	}
+	return storage.NewAuditChainHead(organizationID), nil
+}
+
+func (m *mockAuditRepo) ListChain(ctx context.Context, organizationID string, resourceType types.AuditResourceType, afterSequence int64, limit int) ([]*types.AuditHistory, error) {
+	var history []*types.AuditHistory
+	for _, event := range m.events {
+		if event.OrganizationID == organizationID && event.ResourceType == resourceType && event.Sequence > afterSequence {
+			history = append(history, event.AuditHistory)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Sequence < history[j].Sequence
+	})
+	if len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+// Mock Access Log Repository
+
+type mockAccessLogRepo struct {
+	logs   []*types.AccessLog
+	usages []*types.ServiceKeyUsage
+}
+
+func (m *mockAccessLogRepo) GetUsage(ctx context.Context, organizationID, serviceKeyID string) (*types.ServiceKeyUsage, error) {
+	for _, usage := range m.usages {
+		if usage.OrganizationID == organizationID && usage.ServiceKeyID == serviceKeyID {
+			return usage, nil
+		}
+	}
+	return storage.NewServiceKeyUsage(organizationID, serviceKeyID), nil
+}
+
+func (m *mockAccessLogRepo) ListByServiceKey(ctx context.Context, organizationID, serviceKeyID string, before time.Time, limit int) ([]*types.AccessLog, error) {
+	var logs []*types.AccessLog
+	for _, log := range m.logs {
+		if log.OrganizationID == organizationID && log.ServiceKeyID == serviceKeyID && (before.IsZero() || log.CreatedAt.Before(before)) {
+			logs = append(logs, log)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].CreatedAt.After(logs[j].CreatedAt)
+	})
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+// mockAccessRecorder keeps recorded decisions in memory
+type mockAccessRecorder struct {
+	logs []*types.AccessLog
+}
+
+func (m *mockAccessRecorder) Record(log *types.AccessLog) {
+	m.logs = append(m.logs, log)
 }
 
 // Test helpers
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/brianfromlife/baluster/internal/core/admin"
+	httputil "github.com/brianfromlife/baluster/internal/http"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+	"github.com/brianfromlife/baluster/internal/webhook"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateWebhookRequest represents the HTTP request to create a webhook
+type CreateWebhookRequest struct {
+	URL         string               `json:"url"`
+	Description string               `json:"description"`
+	Events      []types.WebhookEvent `json:"events"` // Empty subscribes to every event
+}
+
+// Validate validates the CreateWebhookRequest
+func (r CreateWebhookRequest) Validate() error {
+	if r.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	return nil
+}
+
+// CreateWebhookResponse represents the HTTP response with the signing secret
+type CreateWebhookResponse struct {
+	Webhook *types.Webhook `json:"webhook"`
+	Secret  string         `json:"secret"`
+}
+
+// CreateWebhook creates a new webhook, the signing secret is only returned here
+func CreateWebhook(webhookRepo admin.WebhookCreator, resolver webhook.Resolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := httputil.Decode[CreateWebhookRequest](r)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		input := &admin.CreateWebhookInput{
+			URL:         req.URL,
+			Description: req.Description,
+			Events:      req.Events,
+		}
+
+		output, err := admin.CreateWebhook(r.Context(), webhookRepo, resolver, input)
+		if err != nil {
+			if errors.Is(err, admin.ErrUserInfoNotFound) {
+				httputil.Error(w, http.StatusUnauthorized, err)
+				return
+			}
+			if errors.Is(err, admin.ErrInvalidWebhookURL) || errors.Is(err, admin.ErrInvalidWebhookEvent) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			if errors.Is(err, admin.ErrWebhookLimitExceeded) {
+				httputil.Error(w, http.StatusForbidden, err)
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Clear the secret from the webhook, it is returned on its own
+		responseWebhook := output.Webhook
+		responseWebhook.Secret = ""
+
+		httputil.Success(w, http.StatusCreated, CreateWebhookResponse{
+			Webhook: responseWebhook,
+			Secret:  output.Secret,
+		})
+	}
+}
+
+// ListWebhooks lists webhooks for an organization
+func ListWebhooks(webhookRepo admin.WebhookLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := &admin.ListWebhooksInput{}
+
+		output, err := admin.ListWebhooks(r.Context(), webhookRepo, input)
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Clear secrets from response
+		for _, webhook := range output.Webhooks {
+			webhook.Secret = ""
+		}
+
+		httputil.Success(w, http.StatusOK, map[string]any{"webhooks": output.Webhooks})
+	}
+}
+
+// GetWebhook gets a webhook by ID
+func GetWebhook(webhookRepo admin.WebhookGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhook_id")
+		if webhookID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("webhook_id is required"))
+			return
+		}
+
+		input := &admin.GetWebhookInput{
+			ID: webhookID,
+		}
+
+		output, err := admin.GetWebhook(r.Context(), webhookRepo, input)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				httputil.Error(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Clear the secret from response
+		output.Webhook.Secret = ""
+
+		httputil.Success(w, http.StatusOK, output.Webhook)
+	}
+}
+
+// ListWebhookDeliveries lists the latest deliveries made to a webhook, newest first.
+// ?limit= sets how many are returned, 50 by default and at most 100.
+func ListWebhookDeliveries(webhookRepo admin.WebhookDeliveryLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhook_id")
+		if webhookID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("webhook_id is required"))
+			return
+		}
+
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				httputil.Error(w, http.StatusBadRequest, fmt.Errorf("limit must be a positive number"))
+				return
+			}
+			limit = parsed
+		}
+
+		input := &admin.ListWebhookDeliveriesInput{
+			ID:    webhookID,
+			Limit: limit,
+		}
+
+		output, err := admin.ListWebhookDeliveries(r.Context(), webhookRepo, input)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				httputil.Error(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		deliveries := output.Deliveries
+		if deliveries == nil {
+			deliveries = []*types.WebhookDelivery{}
+		}
+
+		httputil.Success(w, http.StatusOK, map[string]any{"deliveries": deliveries})
+	}
+}
+
+// UpdateWebhookRequest represents the HTTP request to update a webhook
+type UpdateWebhookRequest struct {
+	URL         string               `json:"url"`
+	Description string               `json:"description"`
+	Events      []types.WebhookEvent `json:"events"`
+	Active      *bool                `json:"active"` // Optional, leave out to keep the current state
+}
+
+// Validate validates the UpdateWebhookRequest
+func (r UpdateWebhookRequest) Validate() error {
+	if r.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	return nil
+}
+
+// UpdateWebhook updates a webhook
+func UpdateWebhook(webhookRepo admin.WebhookUpdater, resolver webhook.Resolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhook_id")
+		if webhookID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("webhook_id is required"))
+			return
+		}
+
+		req, err := httputil.Decode[UpdateWebhookRequest](r)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, err)
+			return
+		}
+
+		input := &admin.UpdateWebhookInput{
+			ID:          webhookID,
+			URL:         req.URL,
+			Description: req.Description,
+			Events:      req.Events,
+			Active:      req.Active,
+		}
+
+		output, err := admin.UpdateWebhook(r.Context(), webhookRepo, resolver, input)
+		if err != nil {
+			if errors.Is(err, admin.ErrInvalidWebhookURL) || errors.Is(err, admin.ErrInvalidWebhookEvent) {
+				httputil.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			if errors.Is(err, storage.ErrNotFound) {
+				httputil.Error(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Clear the secret from response
+		output.Webhook.Secret = ""
+
+		httputil.Success(w, http.StatusOK, output.Webhook)
+	}
+}
+
+// DeleteWebhook deletes a webhook
+func DeleteWebhook(webhookRepo admin.WebhookDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhook_id")
+		if webhookID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("webhook_id is required"))
+			return
+		}
+
+		input := &admin.DeleteWebhookInput{
+			ID: webhookID,
+		}
+
+		if err := admin.DeleteWebhook(r.Context(), webhookRepo, input); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				httputil.Error(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusNoContent, nil)
+	}
+}
+
+// SendWebhookTestEvent sends a webhook.test event to a webhook and returns the resulting delivery
+func SendWebhookTestEvent(webhookRepo admin.WebhookGetter, sender admin.WebhookTestSender) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhook_id")
+		if webhookID == "" {
+			httputil.Error(w, http.StatusBadRequest, fmt.Errorf("webhook_id is required"))
+			return
+		}
+
+		input := &admin.SendWebhookTestEventInput{
+			ID: webhookID,
+		}
+
+		output, err := admin.SendWebhookTestEvent(r.Context(), webhookRepo, sender, input)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				httputil.Error(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+				return
+			}
+			httputil.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.Success(w, http.StatusOK, output.Delivery)
+	}
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+func TestCreateWebhook(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           CreateWebhookRequest
+		existing       int
+		expectedStatus int
+	}{
+		{
+			name: "valid request",
+			body: CreateWebhookRequest{
+				URL:    "https://example.com/hooks",
+				Events: []types.WebhookEvent{types.WebhookEventServiceKeyCreated},
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing url",
+			body:           CreateWebhookRequest{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "url without a scheme",
+			body:           CreateWebhookRequest{URL: "example.com/hooks"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "http url",
+			body:           CreateWebhookRequest{URL: "http://example.com/hooks"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "loopback address",
+			body:           CreateWebhookRequest{URL: "https://127.0.0.1:8080/hooks"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "cloud metadata address",
+			body:           CreateWebhookRequest{URL: "https://169.254.169.254/latest/meta-data"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "host resolving to a private address",
+			body:           CreateWebhookRequest{URL: "https://internal.example.com/hooks"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "host that does not resolve",
+			body:           CreateWebhookRequest{URL: "https://unknown.example.com/hooks"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown event",
+			body: CreateWebhookRequest{
+				URL:    "https://example.com/hooks",
+				Events: []types.WebhookEvent{"service_key.exploded"},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "test event cannot be subscribed to",
+			body: CreateWebhookRequest{
+				URL:    "https://example.com/hooks",
+				Events: []types.WebhookEvent{types.WebhookEventTest},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "webhook limit exceeded",
+			body:           CreateWebhookRequest{URL: "https://example.com/hooks"},
+			existing:       10,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockWebhookRepo{}
+			for range tt.existing {
+				repo.webhooks = append(repo.webhooks, &types.Webhook{ID: "wh-existing", OrganizationID: "org-1"})
+			}
+			handler := CreateWebhook(repo, newMockResolver())
+
+			req := newTestRequest(http.MethodPost, "/webhooks", tt.body)
+			req = withUserContext(req, "user-1", "github-123", "testuser")
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusCreated {
+				return
+			}
+
+			var response struct {
+				Webhook map[string]any `json:"webhook"`
+				Secret  string         `json:"secret"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Secret == "" {
+				t.Errorf("expected the secret to be returned on create")
+			}
+			if response.Webhook["secret"] != "" {
+				t.Errorf("expected the secret to be cleared from the webhook, got %v", response.Webhook["secret"])
+			}
+			if len(repo.webhooks) != 1 || !repo.webhooks[0].Active {
+				t.Errorf("expected an active webhook to be stored, got %+v", repo.webhooks)
+			}
+		})
+	}
+}
+
+func TestListWebhooks(t *testing.T) {
+	repo := &mockWebhookRepo{
+		webhooks: []*types.Webhook{
+			{ID: "wh-1", OrganizationID: "org-1", Secret: "secret-1"},
+			{ID: "wh-2", OrganizationID: "org-2", Secret: "secret-2"},
+		},
+	}
+
+	handler := ListWebhooks(repo)
+	req := newTestRequest(http.MethodGet, "/organizations/org-1/webhooks", nil)
+	req = withURLParam(req, "organization_id", "org-1")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response struct {
+		Webhooks []*types.Webhook `json:"webhooks"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Webhooks) != 1 || response.Webhooks[0].ID != "wh-1" || response.Webhooks[0].Secret != "" {
+		t.Errorf("expected org-1's webhook without its secret, got %+v", response.Webhooks)
+	}
+}
+
+func TestGetWebhookNotFound(t *testing.T) {
+	repo := &mockWebhookRepo{
+		webhooks: []*types.Webhook{
+			{ID: "wh-1", OrganizationID: "org-2"},
+		},
+	}
+
+	handler := GetWebhook(repo)
+	req := newTestRequest(http.MethodGet, "/webhooks/wh-1", nil)
+	req = withURLParam(req, "webhook_id", "wh-1")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestUpdateWebhook(t *testing.T) {
+	repo := &mockWebhookRepo{
+		webhooks: []*types.Webhook{
+			{ID: "wh-1", OrganizationID: "org-1", URL: "https://example.com/old", Active: true, Secret: "secret-1"},
+		},
+	}
+
+	// Leaving out active keeps the webhook enabled
+	handler := UpdateWebhook(repo, newMockResolver())
+	req := newTestRequest(http.MethodPut, "/webhooks/wh-1", UpdateWebhookRequest{URL: "https://example.com/new"})
+	req = withURLParam(req, "webhook_id", "wh-1")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if webhook := repo.webhooks[0]; webhook.URL != "https://example.com/new" || !webhook.Active {
+		t.Errorf("expected the URL to change and the webhook to stay active, got %+v", webhook)
+	}
+
+	active := false
+	req = newTestRequest(http.MethodPut, "/webhooks/wh-1", UpdateWebhookRequest{URL: "https://example.com/new", Active: &active})
+	req = withURLParam(req, "webhook_id", "wh-1")
+	req = withOrgContext(req, "org-1")
+	rr = httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if repo.webhooks[0].Active {
+		t.Errorf("expected the webhook to be disabled")
+	}
+
+	// Updating cannot point the webhook at a private address either
+	req = newTestRequest(http.MethodPut, "/webhooks/wh-1", UpdateWebhookRequest{URL: "https://10.0.0.5/hooks"})
+	req = withURLParam(req, "webhook_id", "wh-1")
+	req = withOrgContext(req, "org-1")
+	rr = httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if repo.webhooks[0].URL != "https://example.com/new" {
+		t.Errorf("expected the URL to stay unchanged, got %q", repo.webhooks[0].URL)
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	repo := &mockWebhookRepo{
+		webhooks: []*types.Webhook{
+			{ID: "wh-1", OrganizationID: "org-1"},
+		},
+	}
+
+	handler := DeleteWebhook(repo)
+	req := newTestRequest(http.MethodDelete, "/webhooks/wh-1", nil)
+	req = withURLParam(req, "webhook_id", "wh-1")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if len(repo.webhooks) != 0 {
+		t.Errorf("expected the webhook to be deleted")
+	}
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	repo := &mockWebhookRepo{
+		webhooks: []*types.Webhook{
+			{ID: "wh-1", OrganizationID: "org-1"},
+		},
+		deliveries: []*types.WebhookDelivery{
+			{ID: "whd-2", OrganizationID: "org-1", WebhookID: "wh-1", Status: types.WebhookDeliveryStatusFailed},
+			{ID: "whd-1", OrganizationID: "org-1", WebhookID: "wh-1", Status: types.WebhookDeliveryStatusSucceeded},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{name: "default limit", expectedStatus: http.StatusOK, expectedCount: 2},
+		{name: "with limit", query: "?limit=1", expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "invalid limit", query: "?limit=none", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := ListWebhookDeliveries(repo)
+			req := newTestRequest(http.MethodGet, "/webhooks/wh-1/deliveries"+tt.query, nil)
+			req = withURLParam(req, "webhook_id", "wh-1")
+			req = withOrgContext(req, "org-1")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Deliveries []*types.WebhookDelivery `json:"deliveries"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.Deliveries) != tt.expectedCount {
+				t.Errorf("expected %d deliveries, got %d", tt.expectedCount, len(response.Deliveries))
+			}
+		})
+	}
+}
+
+func TestSendWebhookTestEvent(t *testing.T) {
+	repo := &mockWebhookRepo{
+		webhooks: []*types.Webhook{
+			{ID: "wh-1", OrganizationID: "org-1"},
+		},
+	}
+	sender := &mockWebhookSender{}
+
+	handler := SendWebhookTestEvent(repo, sender)
+	req := newTestRequest(http.MethodPost, "/webhooks/wh-1/test", nil)
+	req = withURLParam(req, "webhook_id", "wh-1")
+	req = withOrgContext(req, "org-1")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].ID != "wh-1" {
+		t.Errorf("expected a test event to be sent to wh-1, got %+v", sender.sent)
+	}
+
+	var delivery types.WebhookDelivery
+	if err := json.NewDecoder(rr.Body).Decode(&delivery); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if delivery.Event != types.WebhookEventTest || delivery.Status != types.WebhookDeliveryStatusSucceeded {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+}
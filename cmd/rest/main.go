@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,7 +18,7 @@ import (
 	"github.com/brianfromlife/baluster/internal/auth"
 	httputil "github.com/brianfromlife/baluster/internal/http"
 	"github.com/brianfromlife/baluster/internal/server"
-	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
 )
 
 func main() {
@@ -32,54 +33,40 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Cosmos
+	// Storage
 	ctx := context.Background()
-	cosmosClient, err := storage.NewClient(ctx, storage.Config{
-		Endpoint: cfg.CosmosEndpoint,
-		Key:      cfg.CosmosKey,
-		Database: cfg.CosmosDatabase,
-	})
-	if err != nil {
-		logger.Error("failed to initialize Cosmos DB client", "error", err)
-		os.Exit(1)
-	}
-
-	// Repositories
-	orgRepo, err := storage.NewOrganizationRepository(cosmosClient)
+	repos, err := server.NewRepositories(ctx, cfg)
 	if err != nil {
-		logger.Error("failed to initialize organization repository", "error", err)
+		logger.Error("failed to initialize storage", "backend", cfg.StorageBackend, "error", err)
 		os.Exit(1)
 	}
+	tokenCaches := server.WithTokenCaches(repos, cfg)
+	webhooks := server.WithWebhooks(repos, cfg)
+	accessLog := server.NewAccessLogRecorder(repos, cfg)
 
-	appRepo, err := storage.NewApplicationRepository(cosmosClient)
+	// Shared state between replicas, optional
+	rdb, err := server.NewRedis(ctx, cfg)
 	if err != nil {
-		logger.Error("failed to initialize application repository", "error", err)
+		logger.Error("failed to initialize Redis", "error", err)
 		os.Exit(1)
 	}
 
-	serviceKeyRepo, err := storage.NewServiceKeyRepository(cosmosClient)
+	checkpointSigner, err := server.NewCheckpointSigner(cfg)
 	if err != nil {
-		logger.Error("failed to initialize service key repository", "error", err)
+		logger.Error("failed to initialize audit checkpoint signing", "error", err)
 		os.Exit(1)
 	}
 
-	apiKeyRepo, err := storage.NewApiKeyRepository(cosmosClient)
-	if err != nil {
-		logger.Error("failed to initialize API key repository", "error", err)
-		os.Exit(1)
-	}
-
-	userRepo, err := storage.NewUserRepository(cosmosClient)
-	if err != nil {
-		logger.Error("failed to initialize user repository", "error", err)
-		os.Exit(1)
-	}
-
-	orgMemberRepo, err := storage.NewOrganizationMemberRepository(cosmosClient)
-	if err != nil {
-		logger.Error("failed to initialize organization member repository", "error", err)
-		os.Exit(1)
-	}
+	orgRepo := repos.Organizations
+	appRepo := repos.Applications
+	serviceKeyRepo := repos.ServiceKeys
+	apiKeyRepo := repos.ApiKeys
+	userRepo := repos.Users
+	orgMemberRepo := repos.OrganizationMembers
+	invitationRepo := repos.Invitations
+	webhookRepo := repos.Webhooks
+	auditRepo := repos.Audit
+	accessLogRepo := repos.AccessLogs
 
 	jwtConfig := auth.JWTConfig{
 		Secret:     cfg.JWTSecret,
@@ -94,12 +81,17 @@ func main() {
 	}
 
 	githubOAuth := auth.NewGitHubOAuth(githubConfig)
-	stateCache := auth.NewStateCache()
+	stateCache := rdb.NewStateCache()
 	membershipCache := auth.NewMembershipCache(5 * time.Minute)
+	if rdb != nil {
+		membershipCache.ShareInvalidations(rdb.Invalidator)
+		tokenCaches.ShareInvalidations(rdb.Invalidator)
+	}
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(httputil.RequestMetadata)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(httputil.CORS(httputil.CORSOptions{
@@ -113,6 +105,22 @@ func main() {
 		_, _ = w.Write([]byte("OK"))
 	})
 
+	// Internal counters, served on METRICS_PORT only
+	metricsMux := http.NewServeMux()
+
+	// Cache hit and miss counters
+	metricsMux.HandleFunc("GET /health/cache", func(w http.ResponseWriter, r *http.Request) {
+		stats := tokenCaches.Stats()
+		stats["oauth_states"] = stateCache.Stats()
+		stats["memberships"] = membershipCache.Stats()
+		httputil.Success(w, http.StatusOK, stats)
+	})
+
+	// Access log recorded, dropped and failed counters
+	metricsMux.HandleFunc("GET /health/access-log", func(w http.ResponseWriter, r *http.Request) {
+		httputil.Success(w, http.StatusOK, accessLog.Stats())
+	})
+
 	// Initialize validators
 	apiKeyValidator := auth.NewApiKeyValidator(apiKeyRepo)
 
@@ -129,41 +137,79 @@ func main() {
 		// Organization routes (no org context needed for creation)
 		r.Post("/organizations", handlers.CreateOrganization(orgRepo, orgMemberRepo))
 
+		// Invitations sent to the current user, who is not a member yet
+		r.Get("/me/invitations", handlers.ListMyInvitations(invitationRepo))
+		r.Post("/invitations/{invitation_id}/accept", handlers.AcceptInvitation(invitationRepo, orgMemberRepo, membershipCache))
+		r.Post("/invitations/{invitation_id}/decline", handlers.DeclineInvitation(invitationRepo))
+
 		// Routes that require organization membership
 		r.Group(func(r chi.Router) {
 			r.Use(auth.OrganizationMembershipMiddleware(orgMemberRepo, membershipCache))
 
+			// Every route declares the minimum role it requires
+			viewer := auth.RequireRole(types.RoleViewer)
+			developer := auth.RequireRole(types.RoleDeveloper)
+			admin := auth.RequireRole(types.RoleAdmin)
+			owner := auth.RequireRole(types.RoleOwner)
+
+			// Membership routes
+			r.With(viewer).Get("/organizations/{organization_id}/members", handlers.ListMembers(orgMemberRepo))
+			r.With(owner).Delete("/members/{user_id}", handlers.RemoveMember(orgMemberRepo, membershipCache))
+			r.With(owner).Get("/organizations/{organization_id}/invitations", handlers.ListInvitations(invitationRepo))
+			r.With(owner).Post("/invitations", handlers.CreateInvitation(invitationRepo))
+
 			// Organization-scoped list routes (these URLs still have org_id for clarity, but middleware validates)
-			r.Get("/organizations/{organization_id}/applications", handlers.ListApplications(appRepo))
-			r.Get("/organizations/{organization_id}/service-keys", handlers.ListServiceKeys(serviceKeyRepo))
-			r.Get("/organizations/{organization_id}/api-keys", handlers.ListApiKeys(apiKeyRepo))
+			r.With(viewer).Get("/organizations/{organization_id}/applications", handlers.ListApplications(appRepo))
+			r.With(viewer).Get("/organizations/{organization_id}/service-keys", handlers.ListServiceKeys(serviceKeyRepo))
+			r.With(viewer).Get("/organizations/{organization_id}/api-keys", handlers.ListApiKeys(apiKeyRepo))
+			r.With(admin).Get("/organizations/{organization_id}/webhooks", handlers.ListWebhooks(webhookRepo))
+			r.With(admin).Get("/organizations/{organization_id}/audit", handlers.SearchAuditLog(auditRepo))
+			r.With(admin).Get("/organizations/{organization_id}/audit/export", handlers.ExportAuditLog(auditRepo))
+			r.With(admin).Get("/organizations/{organization_id}/audit/verify", handlers.VerifyAuditChain(auditRepo, checkpointSigner))
+			r.With(admin).Post("/organizations/{organization_id}/audit/verify", handlers.VerifyAuditChain(auditRepo, checkpointSigner))
+			r.With(admin).Get("/organizations/{organization_id}/audit/checkpoint", handlers.CreateAuditCheckpoint(auditRepo, checkpointSigner))
 
 			// Application routes
-			r.Post("/applications", handlers.CreateApplication(appRepo))
-			r.Get("/applications/{application_id}", handlers.GetApplication(appRepo))
-			r.Get("/applications/{application_id}/history", handlers.GetApplicationHistory(appRepo))
-			r.Put("/applications/{application_id}", handlers.UpdateApplication(appRepo))
+			r.With(developer).Post("/applications", handlers.CreateApplication(appRepo))
+			r.With(viewer).Get("/applications/{application_id}", handlers.GetApplication(appRepo))
+			r.With(viewer).Get("/applications/{application_id}/history", handlers.GetApplicationHistory(appRepo))
+			r.With(developer).Put("/applications/{application_id}", handlers.UpdateApplication(appRepo, serviceKeyRepo))
+			r.With(admin).Delete("/applications/{application_id}", handlers.DeleteApplication(appRepo, serviceKeyRepo))
 
 			// Service key routes
-			r.Post("/service-keys", handlers.CreateServiceKey(serviceKeyRepo))
-			r.Get("/service-keys/{service_key_id}", handlers.GetServiceKey(serviceKeyRepo))
-			r.Get("/service-keys/{service_key_id}/history", handlers.GetServiceKeyHistory(serviceKeyRepo))
-			r.Put("/service-keys/{service_key_id}", handlers.UpdateServiceKey(serviceKeyRepo))
-			r.Delete("/service-keys/{service_key_id}", handlers.DeleteServiceKey(serviceKeyRepo))
+			r.With(admin).Post("/service-keys", handlers.CreateServiceKey(serviceKeyRepo, appRepo))
+			r.With(viewer).Get("/service-keys/{service_key_id}", handlers.GetServiceKey(serviceKeyRepo, accessLogRepo))
+			r.With(viewer).Get("/service-keys/{service_key_id}/access-log", handlers.ListServiceKeyAccessLog(accessLogRepo))
+			r.With(viewer).Get("/service-keys/{service_key_id}/history", handlers.GetServiceKeyHistory(serviceKeyRepo))
+			r.With(admin).Put("/service-keys/{service_key_id}", handlers.UpdateServiceKey(serviceKeyRepo, appRepo))
+			r.With(admin).Post("/service-keys/{service_key_id}/rotate", handlers.RotateServiceKey(serviceKeyRepo, cfg.ServiceKeyRotationGracePeriod))
+			r.With(admin).Delete("/service-keys/{service_key_id}", handlers.DeleteServiceKey(serviceKeyRepo))
 
 			// API key routes
-			r.Post("/api-keys", handlers.CreateApiKey(apiKeyRepo))
-			r.Get("/api-keys/{token_id}", handlers.GetApiKey(apiKeyRepo))
-			r.Get("/api-keys/{token_id}/history", handlers.GetApiKeyHistory(apiKeyRepo))
-			r.Put("/api-keys/{token_id}", handlers.UpdateApiKey(apiKeyRepo))
-			r.Delete("/api-keys/{token_id}", handlers.DeleteApiKey(apiKeyRepo))
+			r.With(admin).Post("/api-keys", handlers.CreateApiKey(apiKeyRepo, appRepo))
+			r.With(viewer).Get("/api-keys/{token_id}", handlers.GetApiKey(apiKeyRepo))
+			r.With(viewer).Get("/api-keys/{token_id}/history", handlers.GetApiKeyHistory(apiKeyRepo))
+			r.With(admin).Put("/api-keys/{token_id}", handlers.UpdateApiKey(apiKeyRepo, appRepo))
+			r.With(admin).Post("/api-keys/{token_id}/rotate", handlers.RotateApiKey(apiKeyRepo, cfg.ApiKeyRotationGracePeriod))
+			r.With(admin).Post("/api-keys/{token_id}/revoke", handlers.RevokeApiKey(apiKeyRepo))
+			r.With(admin).Delete("/api-keys/{token_id}", handlers.DeleteApiKey(apiKeyRepo))
+
+			// Webhook routes
+			r.With(admin).Post("/webhooks", handlers.CreateWebhook(webhookRepo, net.DefaultResolver))
+			r.With(admin).Get("/webhooks/{webhook_id}", handlers.GetWebhook(webhookRepo))
+			r.With(admin).Get("/webhooks/{webhook_id}/deliveries", handlers.ListWebhookDeliveries(webhookRepo))
+			r.With(admin).Put("/webhooks/{webhook_id}", handlers.UpdateWebhook(webhookRepo, net.DefaultResolver))
+			r.With(admin).Post("/webhooks/{webhook_id}/test", handlers.SendWebhookTestEvent(webhookRepo, webhooks))
+			r.With(admin).Delete("/webhooks/{webhook_id}", handlers.DeleteWebhook(webhookRepo))
 		})
 	})
 
 	// Service key validation
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(auth.ApiKeyAuthMiddleware(apiKeyValidator))
-		r.Post("/access", handlers.ValidateAccess(serviceKeyRepo))
+		r.Use(auth.ApiKeyAuthMiddleware(apiKeyValidator, types.ApiKeyScopeValidateAccess))
+		r.Post("/access", handlers.ValidateAccess(serviceKeyRepo, accessLog))
+		r.Post("/access/batch", handlers.BatchValidateAccess(serviceKeyRepo, accessLog))
+		r.Post("/permissions/check", handlers.CheckPermission(serviceKeyRepo))
 	})
 
 	// Server
@@ -183,6 +229,17 @@ func main() {
 		}
 	}()
 
+	metricsSrv := server.NewMetricsServer(cfg, metricsMux)
+	if metricsSrv != nil {
+		go func() {
+			logger.Info("starting metrics server", "port", cfg.MetricsPort)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("metrics server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -194,4 +251,19 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("server forced to shutdown", "error", err)
 	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			logger.Error("metrics server forced to shutdown", "error", err)
+		}
+	}
+
+	// Stop the cache janitors once no request can use the caches anymore
+	tokenCaches.Close()
+	// Deliveries still waiting for a retry stay pending in the delivery log and are resumed on the next start
+	webhooks.Close()
+	// Write the access decisions still queued
+	accessLog.Close()
+	stateCache.Close()
+	membershipCache.Close()
+	rdb.Close()
 }
@@ -4,10 +4,15 @@
 // for a specific application. It sends a POST request to the /api/v1/access endpoint
 // with a service key token and application name.
 //
-// IMPORTANT: The x-org-id header is REQUIRED when calling the /api/v1/access endpoint.
+// The organization is taken from the API key. The x-org-id header is optional, but when
+// it is set it must be the API key's organization or the request is rejected.
 // You can find your organization ID in the response when creating a service key,
 // or by checking your organization details in the Baluster dashboard.
 //
+// To check one service key against several applications in one round trip, POST
+// {"checks": [{"token": ..., "application_name": ..., "permission": ...}]} to
+// /api/v1/access/batch. The response holds one result per check, in order.
+//
 // Building:
 //
 //	go build -o bin/http_example ./examples/http
@@ -22,7 +27,7 @@
 //	- accessKey: Your API key (Bearer token) for authenticating with Baluster
 //	- serviceKey: The service key token to validate
 //	- applicationName: The name of the application to check access for
-//	- organizationID: Your organization ID (required in x-org-id header)
+//	- organizationID: Your organization ID (sent in the optional x-org-id header)
 //	- baseURL: Base URL of the API server (default: http://localhost:8080)
 //
 // HTTP Client Instructions:
@@ -0,0 +1,173 @@
+// Package accesslog records access validation decisions in the background,
+// so writing them never slows down the requests that make them.
+package accesslog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// Config configures how decisions are buffered and written
+type Config struct {
+	QueueSize     int           // Decisions waiting to be written, new ones are dropped when it is full
+	BatchSize     int           // Most decisions written at once
+	FlushInterval time.Duration // Longest a decision waits before its batch is written
+	WriteTimeout  time.Duration // How long writing a batch may take
+}
+
+// DefaultConfig returns the configuration used when a field is left at zero
+func DefaultConfig() Config {
+	return Config{
+		QueueSize:     10000,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		WriteTimeout:  10 * time.Second,
+	}
+}
+
+// Stats reports how the recorder is keeping up
+type Stats struct {
+	Recorded uint64 `json:"recorded"` // Decisions written
+	Dropped  uint64 `json:"dropped"`  // Decisions dropped because the queue was full
+	Failed   uint64 `json:"failed"`   // Decisions lost because their batch could not be written
+	Queued   int    `json:"queued"`   // Decisions waiting to be written
+}
+
+// Recorder writes access log records in batches from a single background goroutine.
+// Record never blocks: when the queue is full the record is dropped and counted instead.
+type Recorder struct {
+	repo   storage.AccessLogRepository
+	config Config
+
+	logs      chan *types.AccessLog
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	recorded atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+}
+
+// NewRecorder starts the background writer, call Close to stop it
+func NewRecorder(repo storage.AccessLogRepository, config Config) *Recorder {
+	defaults := DefaultConfig()
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = defaults.WriteTimeout
+	}
+
+	r := &Recorder{
+		repo:   repo,
+		config: config,
+		logs:   make(chan *types.AccessLog, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go r.run()
+
+	return r
+}
+
+// Record queues a decision to be written, dropping it when the queue is full or the recorder is closed
+func (r *Recorder) Record(log *types.AccessLog) {
+	select {
+	case <-r.done:
+		r.dropped.Add(1)
+		return
+	default:
+	}
+
+	select {
+	case r.logs <- log:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Stats returns the recorded, dropped and failed counters and the queue length
+func (r *Recorder) Stats() Stats {
+	return Stats{
+		Recorded: r.recorded.Load(),
+		Dropped:  r.dropped.Load(),
+		Failed:   r.failed.Load(),
+		Queued:   len(r.logs),
+	}
+}
+
+// Close writes the decisions still queued and stops the writer, it is safe to call more than once
+func (r *Recorder) Close() {
+	r.closeOnce.Do(func() {
+		close(r.done)
+	})
+	r.wg.Wait()
+}
+
+func (r *Recorder) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*types.AccessLog, 0, r.config.BatchSize)
+	for {
+		select {
+		case log := <-r.logs:
+			batch = append(batch, log)
+			if len(batch) == r.config.BatchSize {
+				batch = r.write(batch)
+			}
+		case <-ticker.C:
+			batch = r.write(batch)
+		case <-r.done:
+			// Drain what was queued before Close, Record no longer adds to the queue
+			for {
+				select {
+				case log := <-r.logs:
+					batch = append(batch, log)
+					if len(batch) == r.config.BatchSize {
+						batch = r.write(batch)
+					}
+				default:
+					r.write(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// write stores a batch and returns it emptied for reuse
+func (r *Recorder) write(batch []*types.AccessLog) []*types.AccessLog {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.WriteTimeout)
+	defer cancel()
+
+	if err := r.repo.Record(ctx, batch); err != nil {
+		r.failed.Add(uint64(len(batch)))
+		slog.Error("failed to write access log", "records", len(batch), "error", err)
+	} else {
+		r.recorded.Add(uint64(len(batch)))
+	}
+
+	// The repository may hold on to the records, so the next batch gets a new slice
+	return make([]*types.AccessLog, 0, r.config.BatchSize)
+}
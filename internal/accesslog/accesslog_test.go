@@ -0,0 +1,131 @@
+package accesslog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage/memory"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// blockingRepo holds every write until it is released, and fails them when asked to
+type blockingRepo struct {
+	release chan struct{}
+	fail    bool // Set before release is closed
+}
+
+func (r *blockingRepo) Record(ctx context.Context, logs []*types.AccessLog) error {
+	<-r.release
+	if r.fail {
+		return errors.New("storage unavailable")
+	}
+	return nil
+}
+
+func (r *blockingRepo) ListByServiceKey(ctx context.Context, organizationID, serviceKeyID string, before time.Time, limit int) ([]*types.AccessLog, error) {
+	return nil, nil
+}
+
+func (r *blockingRepo) GetUsage(ctx context.Context, organizationID, serviceKeyID string) (*types.ServiceKeyUsage, error) {
+	return nil, nil
+}
+
+func newLog(serviceKeyID string, allowed bool) *types.AccessLog {
+	return &types.AccessLog{
+		ID:             serviceKeyID + "-" + time.Now().Format(time.RFC3339Nano),
+		OrganizationID: "org-1",
+		ServiceKeyID:   serviceKeyID,
+		Allowed:        allowed,
+		CreatedAt:      time.Now(),
+	}
+}
+
+func TestRecorderWritesBatchesAndUsage(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	recorder := NewRecorder(repos.AccessLogs, Config{BatchSize: 2, FlushInterval: time.Hour})
+
+	recorder.Record(newLog("sk-1", true))
+	recorder.Record(newLog("sk-1", false))
+	recorder.Record(newLog("sk-1", true))
+	recorder.Record(newLog("", false))
+
+	// Close writes the last, partial batch even though the flush interval has not passed
+	recorder.Close()
+
+	stats := recorder.Stats()
+	if stats.Recorded != 4 || stats.Dropped != 0 || stats.Failed != 0 || stats.Queued != 0 {
+		t.Errorf("expected 4 recorded decisions, got %+v", stats)
+	}
+
+	usage, err := repos.AccessLogs.GetUsage(ctx, "org-1", "sk-1")
+	if err != nil {
+		t.Fatalf("get usage: %v", err)
+	}
+	if usage.AllowedCount != 2 || usage.DeniedCount != 1 || usage.LastUsedAt == nil || usage.LastDeniedAt == nil {
+		t.Errorf("expected 2 allowed and 1 denied decision, got %+v", usage)
+	}
+
+	logs, err := repos.AccessLogs.ListByServiceKey(ctx, "org-1", "sk-1", time.Time{}, 10)
+	if err != nil || len(logs) != 3 {
+		t.Errorf("expected 3 decisions about sk-1, got %d (err %v)", len(logs), err)
+	}
+
+	recorder.Record(newLog("sk-1", true))
+	if stats := recorder.Stats(); stats.Dropped != 1 {
+		t.Errorf("expected a decision recorded after Close to be dropped, got %+v", stats)
+	}
+}
+
+func TestRecorderFlushesOnInterval(t *testing.T) {
+	repos := memory.NewRepositories(memory.NewStore())
+	recorder := NewRecorder(repos.AccessLogs, Config{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer recorder.Close()
+
+	recorder.Record(newLog("sk-1", true))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for recorder.Stats().Recorded != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the decision to be written within the flush interval, got %+v", recorder.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRecorderDropsWhenFull(t *testing.T) {
+	repo := &blockingRepo{release: make(chan struct{})}
+	recorder := NewRecorder(repo, Config{QueueSize: 2, BatchSize: 1, FlushInterval: time.Hour})
+
+	// The first decision is taken by the writer, which then blocks on the repository
+	recorder.Record(newLog("sk-1", true))
+	deadline := time.Now().Add(5 * time.Second)
+	for recorder.Stats().Queued != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the writer to take the first decision")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Two more fill the queue, Record must not block on the rest
+	start := time.Now()
+	for range 5 {
+		recorder.Record(newLog("sk-1", true))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Record not to block, took %v", elapsed)
+	}
+	if stats := recorder.Stats(); stats.Dropped != 3 || stats.Queued != 2 {
+		t.Errorf("expected 3 dropped and 2 queued decisions, got %+v", stats)
+	}
+
+	repo.fail = true
+	close(repo.release)
+	recorder.Close()
+
+	if stats := recorder.Stats(); stats.Failed != 3 || stats.Recorded != 0 {
+		t.Errorf("expected the 3 written decisions to have failed, got %+v", stats)
+	}
+}
@@ -4,28 +4,41 @@ import (
 	"context"
 
 	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
 )
 
+// ApiKeyTokenFinder is an interface for looking up API keys by their token value
+type ApiKeyTokenFinder interface {
+	FindByTokenValue(ctx context.Context, tokenValue string) (*types.ApiKey, error)
+}
+
 type ApiKeyValidator struct {
-	apiKeyRepo *storage.ApiKeyRepository
+	apiKeyRepo ApiKeyTokenFinder
 }
 
-func NewApiKeyValidator(apiKeyRepo *storage.ApiKeyRepository) *ApiKeyValidator {
+func NewApiKeyValidator(apiKeyRepo ApiKeyTokenFinder) *ApiKeyValidator {
 	return &ApiKeyValidator{
 		apiKeyRepo: apiKeyRepo,
 	}
 }
 
-// Validate validates an API key and returns whether it's valid
-func (v *ApiKeyValidator) Validate(ctx context.Context, tokenValue string) (bool, error) {
+// Validate validates an API key and returns it along with whether it's valid.
+// Callers still have to check the key's scopes and organization.
+func (v *ApiKeyValidator) Validate(ctx context.Context, tokenValue string) (*types.ApiKey, bool, error) {
 	token, err := v.apiKeyRepo.FindByTokenValue(ctx, tokenValue)
 	if err != nil {
-		return false, nil
+		return nil, false, nil
+	}
+
+	// After a rotation the previous token is only accepted until its grace period ends
+	if !token.AcceptsToken(storage.HashToken(tokenValue)) {
+		return nil, false, nil
 	}
 
-	if token.IsExpired() {
-		return false, nil
+	// Revocation takes effect immediately, for the current and any previous token
+	if token.IsRevoked() || token.IsExpired() {
+		return nil, false, nil
 	}
 
-	return true, nil
+	return token, true, nil
 }
@@ -1,46 +1,107 @@
 package auth
 
 import (
+	"container/list"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // CacheEntry represents a cached value with expiration
 type CacheEntry[T any] struct {
+	Key       string
 	Value     T
 	ExpiresAt time.Time
 }
 
-// Cache provides a generic thread-safe in-memory cache with TTL support
+// CacheStats reports how well a cache is doing
+type CacheStats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`   // Entries dropped to stay within the maximum size
+	Expirations uint64 `json:"expirations"` // Expired entries removed on read or by the janitor
+	Entries     int    `json:"entries"`
+}
+
+// CacheOption configures a Cache
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	maxEntries      int
+	janitorInterval time.Duration
+}
+
+// WithMaxEntries bounds the cache, evicting the least recently used entry when it is full
+func WithMaxEntries(n int) CacheOption {
+	return func(o *cacheOptions) {
+		o.maxEntries = n
+	}
+}
+
+// WithJanitor removes expired entries in the background at the given interval until Close is called
+func WithJanitor(interval time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.janitorInterval = interval
+	}
+}
+
+// Cache provides a generic thread-safe in-memory cache with TTL support.
+// It is unbounded unless created WithMaxEntries, and only drops expired
+// entries when they are read unless created WithJanitor.
 type Cache[T any] struct {
-	mu    sync.RWMutex
-	items map[string]*CacheEntry[T]
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List                     // Front is the most recently used
+	groups     map[string]map[string]struct{} // Keys by group, see cacheGroup
+	version    uint64                         // Bumped by every deletion that is not an eviction or expiry
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+
+	stop      chan struct{}
+	closeOnce sync.Once
 }
 
 // NewCache creates a new generic cache
-func NewCache[T any]() *Cache[T] {
-	return &Cache[T]{
-		items: make(map[string]*CacheEntry[T]),
+func NewCache[T any](opts ...CacheOption) *Cache[T] {
+	var options cacheOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
+
+	c := &Cache[T]{
+		maxEntries: options.maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		groups:     make(map[string]map[string]struct{}),
+		stop:       make(chan struct{}),
+	}
+
+	if options.janitorInterval > 0 {
+		go c.janitor(options.janitorInterval)
+	}
+
+	return c
 }
 
 // Get retrieves a value from the cache
 // Returns (value, found) where found indicates if the key exists and is not expired
 func (c *Cache[T]) Get(key string) (T, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	entry, exists := c.items[key]
-	if !exists {
-		var zero T
-		return zero, false
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if time.Now().After(entry.ExpiresAt) {
+	entry, ok := c.lookup(key)
+	if !ok {
+		c.misses.Add(1)
 		var zero T
 		return zero, false
 	}
 
+	c.hits.Add(1)
 	return entry.Value, true
 }
 
@@ -49,10 +110,7 @@ func (c *Cache[T]) Set(key string, value T, expiresAt time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.items[key] = &CacheEntry[T]{
-		Value:     value,
-		ExpiresAt: expiresAt,
-	}
+	c.set(key, value, expiresAt)
 }
 
 // SetWithTTL stores a value in the cache with a TTL duration
@@ -60,12 +118,36 @@ func (c *Cache[T]) SetWithTTL(key string, value T, ttl time.Duration) {
 	c.Set(key, value, time.Now().Add(ttl))
 }
 
+// Version returns a value that changes whenever entries are deleted or invalidated
+func (c *Cache[T]) Version() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.version
+}
+
+// SetWithTTLIfVersion stores a value only if nothing was deleted since Version returned version,
+// so a value loaded before an invalidation cannot be cached after it
+func (c *Cache[T]) SetWithTTLIfVersion(key string, value T, ttl time.Duration, version uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.version != version {
+		return false
+	}
+	c.set(key, value, time.Now().Add(ttl))
+	return true
+}
+
 // Delete removes a key from the cache
 func (c *Cache[T]) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.items, key)
+	c.version++
+	if element, exists := c.items[key]; exists {
+		c.remove(element)
+	}
 }
 
 // GetAndDelete atomically retrieves and removes a value from the cache
@@ -74,30 +156,61 @@ func (c *Cache[T]) GetAndDelete(key string) (T, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	entry, exists := c.items[key]
-	if !exists {
+	entry, ok := c.lookup(key)
+	if !ok {
+		c.misses.Add(1)
 		var zero T
 		return zero, false
 	}
 
-	if time.Now().After(entry.ExpiresAt) {
-		delete(c.items, key)
-		var zero T
-		return zero, false
-	}
-
-	delete(c.items, key)
+	c.hits.Add(1)
+	c.version++
+	c.remove(c.items[key])
 	return entry.Value, true
 }
 
-// InvalidatePrefix removes all keys that start with the given prefix
+// DeleteFunc removes every entry for which match returns true
+func (c *Cache[T]) DeleteFunc(match func(key string, value T) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*CacheEntry[T])
+		if match(entry.Key, entry.Value) {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+// InvalidatePrefix removes all keys that start with the given prefix.
+// Keys are indexed by their group, the part up to and including the first ':',
+// so invalidating a group such as "org-1:" only touches the keys in it.
 func (c *Cache[T]) InvalidatePrefix(prefix string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	for key := range c.items {
-		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
-			delete(c.items, key)
+	c.version++
+
+	// A prefix that reaches into a group only needs that group's keys
+	if group, ok := cacheGroup(prefix); ok {
+		for key := range c.groups[group] {
+			if strings.HasPrefix(key, prefix) {
+				c.remove(c.items[key])
+			}
+		}
+		return
+	}
+
+	// A shorter prefix can span several groups, but still skips every other group
+	for group, keys := range c.groups {
+		if !strings.HasPrefix(group, prefix) {
+			continue
+		}
+		for key := range keys {
+			c.remove(c.items[key])
 		}
 	}
 }
@@ -107,5 +220,127 @@ func (c *Cache[T]) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.items = make(map[string]*CacheEntry[T])
+	c.version++
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.groups = make(map[string]map[string]struct{})
+}
+
+// Stats returns the hit, miss, eviction and expiration counters and the number of entries
+func (c *Cache[T]) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+		Entries:     entries,
+	}
+}
+
+// Close stops the janitor, it is safe to call more than once
+func (c *Cache[T]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+// RemoveExpired removes every expired entry, the janitor calls it at its interval
+func (c *Cache[T]) RemoveExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if now.After(element.Value.(*CacheEntry[T]).ExpiresAt) {
+			c.remove(element)
+			c.expirations.Add(1)
+		}
+		element = next
+	}
+}
+
+func (c *Cache[T]) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.RemoveExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// lookup returns a live entry and marks it as recently used, the caller must hold the lock
+func (c *Cache[T]) lookup(key string) (*CacheEntry[T], bool) {
+	element, exists := c.items[key]
+	if !exists {
+		return nil, false
+	}
+
+	entry := element.Value.(*CacheEntry[T])
+	if time.Now().After(entry.ExpiresAt) {
+		c.remove(element)
+		c.expirations.Add(1)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry, true
+}
+
+// set stores a value and evicts the least recently used entry when full, the caller must hold the lock
+func (c *Cache[T]) set(key string, value T, expiresAt time.Time) {
+	if element, exists := c.items[key]; exists {
+		entry := element.Value.(*CacheEntry[T])
+		entry.Value = value
+		entry.ExpiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	if c.maxEntries > 0 && c.order.Len() >= c.maxEntries {
+		c.remove(c.order.Back())
+		c.evictions.Add(1)
+	}
+
+	c.items[key] = c.order.PushFront(&CacheEntry[T]{
+		Key:       key,
+		Value:     value,
+		ExpiresAt: expiresAt,
+	})
+
+	group, _ := cacheGroup(key)
+	if c.groups[group] == nil {
+		c.groups[group] = make(map[string]struct{})
+	}
+	c.groups[group][key] = struct{}{}
+}
+
+// remove drops an element and its index entry, the caller must hold the lock
+func (c *Cache[T]) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*CacheEntry[T])
+	delete(c.items, entry.Key)
+
+	group, _ := cacheGroup(entry.Key)
+	delete(c.groups[group], entry.Key)
+	if len(c.groups[group]) == 0 {
+		delete(c.groups, group)
+	}
+}
+
+// cacheGroup returns the part of a key up to and including its first ':'.
+// Keys without a ':' form a group of their own and report false.
+func cacheGroup(key string) (string, bool) {
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		return key[:i+1], true
+	}
+	return key, false
 }
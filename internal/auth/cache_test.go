@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCache[int](WithMaxEntries(2))
+	cache.SetWithTTL("a", 1, time.Minute)
+	cache.SetWithTTL("b", 2, time.Minute)
+	cache.Get("a")
+	cache.SetWithTTL("c", 3, time.Minute)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Errorf("expected a to be kept")
+	}
+	if stats := cache.Stats(); stats.Evictions != 1 || stats.Entries != 2 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("expected 1 eviction, 2 entries, 2 hits and 1 miss, got %+v", stats)
+	}
+}
+
+func TestCacheJanitorRemovesExpiredEntries(t *testing.T) {
+	cache := NewCache[bool](WithJanitor(5 * time.Millisecond))
+	defer cache.Close()
+
+	cache.SetWithTTL("expired", true, time.Millisecond)
+	cache.SetWithTTL("live", true, time.Minute)
+
+	deadline := time.Now().Add(time.Second)
+	for cache.Stats().Entries != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the janitor to remove the expired entry, got %+v", cache.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := cache.Stats(); stats.Expirations != 1 {
+		t.Errorf("expected 1 expiration, got %+v", stats)
+	}
+
+	// Closing twice is fine
+	cache.Close()
+}
+
+func TestCacheInvalidatePrefix(t *testing.T) {
+	newCache := func() *Cache[int] {
+		cache := NewCache[int]()
+		for _, key := range []string{"org-1:alice", "org-1:bob", "org-10:alice", "org-2:alice", "plain"} {
+			cache.SetWithTTL(key, 1, time.Minute)
+		}
+		return cache
+	}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		removed []string
+	}{
+		{name: "group", prefix: "org-1:", removed: []string{"org-1:alice", "org-1:bob"}},
+		{name: "within a group", prefix: "org-1:a", removed: []string{"org-1:alice"}},
+		{name: "across groups", prefix: "org-1", removed: []string{"org-1:alice", "org-1:bob", "org-10:alice"}},
+		{name: "key without group", prefix: "pla", removed: []string{"plain"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newCache()
+			cache.InvalidatePrefix(tt.prefix)
+
+			if entries := cache.Stats().Entries; entries != 5-len(tt.removed) {
+				t.Errorf("expected %d entries, got %d", 5-len(tt.removed), entries)
+			}
+			for _, key := range tt.removed {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("expected %s to be removed", key)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheSetWithTTLIfVersion(t *testing.T) {
+	cache := NewCache[int]()
+
+	// A value loaded before an invalidation is not cached after it
+	version := cache.Version()
+	cache.Delete("a")
+	if cache.SetWithTTLIfVersion("a", 1, time.Minute, version) {
+		t.Errorf("expected a stale value to be rejected")
+	}
+	if !cache.SetWithTTLIfVersion("a", 1, time.Minute, cache.Version()) {
+		t.Errorf("expected a current value to be stored")
+	}
+}
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+
+	"github.com/brianfromlife/baluster/internal/types"
 )
 
 // GetUserID retrieves the user ID from context
@@ -38,3 +40,22 @@ func GetOrganizationID(ctx context.Context) (string, bool) {
 	orgID, ok := ctx.Value(OrganizationIDKey).(string)
 	return orgID, ok
 }
+
+// GetRole retrieves the user's role in the current organization from context
+func GetRole(ctx context.Context) (types.Role, bool) {
+	role, ok := ctx.Value(RoleKey).(types.Role)
+	return role, ok
+}
+
+// GetApiKey retrieves the API key that authenticated the request from context
+// Its ID, organization and applications identify the caller
+func GetApiKey(ctx context.Context) (*types.ApiKey, bool) {
+	apiKey, ok := ctx.Value(ApiKeyKey).(*types.ApiKey)
+	return apiKey, ok
+}
+
+// withApiKey adds the API key and its organization to context
+func withApiKey(ctx context.Context, apiKey *types.ApiKey) context.Context {
+	ctx = context.WithValue(ctx, ApiKeyKey, apiKey)
+	return context.WithValue(ctx, OrganizationIDKey, apiKey.OrganizationID)
+}
@@ -75,36 +75,65 @@ func GetGitHubUser(ctx context.Context, client *http.Client) (*GitHubUser, error
 	return &user, nil
 }
 
-// StateCache provides thread-safe in-memory caching for OAuth state tokens
+const (
+	// stateCacheMaxEntries bounds the in-memory state store so a flood of login attempts cannot exhaust memory
+	stateCacheMaxEntries = 10000
+	// stateCacheJanitorInterval is how often abandoned states are removed from the in-memory store
+	stateCacheJanitorInterval = time.Minute
+)
+
+// StateCache keeps the OAuth state tokens of logins in progress.
+// With a shared store the callback may reach another replica than the login.
 type StateCache struct {
-	cache *Cache[bool]
+	store Store
 }
 
-// NewStateCache creates a new state cache
+// NewStateCache creates a state cache in process memory,
+// expired states are removed in the background until Close is called
 func NewStateCache() *StateCache {
+	return NewSharedStateCache(NewMemoryStore(WithMaxEntries(stateCacheMaxEntries), WithJanitor(stateCacheJanitorInterval)))
+}
+
+// NewSharedStateCache creates a state cache on top of a store, such as a RedisStore shared by every replica
+func NewSharedStateCache(store Store) *StateCache {
 	return &StateCache{
-		cache: NewCache[bool](),
+		store: store,
 	}
 }
 
 // Set stores a state with expiration
-func (c *StateCache) Set(state string, expiresAt time.Time) {
-	c.cache.Set(state, true, expiresAt)
+func (c *StateCache) Set(ctx context.Context, state string, expiresAt time.Time) error {
+	return c.store.Set(ctx, state, "1", time.Until(expiresAt))
 }
 
 // Get checks if a state exists and is not expired
-func (c *StateCache) Get(state string) bool {
-	_, found := c.cache.Get(state)
-	return found
+func (c *StateCache) Get(ctx context.Context, state string) (bool, error) {
+	_, found, err := c.store.Get(ctx, state)
+	return found, err
 }
 
 // Delete removes a state
-func (c *StateCache) Delete(state string) {
-	c.cache.Delete(state)
+func (c *StateCache) Delete(ctx context.Context, state string) error {
+	return c.store.Delete(ctx, state)
 }
 
 // GetAndDelete atomically validates and removes a state
-func (c *StateCache) GetAndDelete(state string) bool {
-	_, found := c.cache.GetAndDelete(state)
-	return found
+func (c *StateCache) GetAndDelete(ctx context.Context, state string) (bool, error) {
+	_, found, err := c.store.GetAndDelete(ctx, state)
+	return found, err
+}
+
+// Stats returns the cache counters, they are empty for a store that does not count
+func (c *StateCache) Stats() CacheStats {
+	if store, ok := c.store.(interface{ Stats() CacheStats }); ok {
+		return store.Stats()
+	}
+	return CacheStats{}
+}
+
+// Close stops the in-memory store's janitor, a shared store is closed by its owner
+func (c *StateCache) Close() {
+	if store, ok := c.store.(*MemoryStore); ok {
+		store.Close()
+	}
 }
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Names of the caches that share invalidations between replicas
+const (
+	InvalidationCacheMemberships = "memberships"
+	InvalidationCacheServiceKeys = "service_keys"
+	InvalidationCacheApiKeys     = "api_keys"
+)
+
+// invalidationPublishTimeout bounds how long a write waits to tell other replicas
+const invalidationPublishTimeout = 2 * time.Second
+
+// Invalidation tells the other replicas to drop entries from one of their in-process caches
+type Invalidation struct {
+	Cache  string `json:"cache"`
+	Key    string `json:"key,omitempty"`    // A single entry, or for the token caches the ID of the changed key
+	Prefix string `json:"prefix,omitempty"` // Every entry starting with the prefix
+}
+
+// Invalidator shares cache invalidations between replicas
+type Invalidator interface {
+	// Publish sends an invalidation to every other replica
+	Publish(ctx context.Context, invalidation Invalidation) error
+	// Subscribe calls handle for every invalidation of the named cache published by another replica
+	Subscribe(cache string, handle func(Invalidation))
+}
+
+// publishInvalidation sends an invalidation when an invalidator is configured.
+// Failures are logged, the other replicas still drop the entry once it expires.
+func publishInvalidation(invalidator Invalidator, invalidation Invalidation) {
+	if invalidator == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), invalidationPublishTimeout)
+	defer cancel()
+
+	if err := invalidator.Publish(ctx, invalidation); err != nil {
+		slog.Error("failed to publish cache invalidation", "cache", invalidation.Cache, "error", err)
+	}
+}
@@ -2,46 +2,82 @@ package auth
 
 import (
 	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
 )
 
+// membershipCacheMaxEntries bounds the membership cache, the least recently used entries go first
+const membershipCacheMaxEntries = 10000
+
+// MembershipCache caches the role of a user in an organization.
+// Users that are not members are cached with an empty role.
 type MembershipCache struct {
-	cache *Cache[bool]
-	ttl   time.Duration
+	cache       *Cache[types.Role]
+	ttl         time.Duration
+	invalidator Invalidator
 }
 
-// NewMembershipCache creates a new membership cache with the specified TTL
+// NewMembershipCache creates a new membership cache with the specified TTL.
+// Expired entries are removed every TTL until Close is called.
 func NewMembershipCache(ttl time.Duration) *MembershipCache {
 	return &MembershipCache{
-		cache: NewCache[bool](),
+		cache: NewCache[types.Role](WithMaxEntries(membershipCacheMaxEntries), WithJanitor(ttl)),
 		ttl:   ttl,
 	}
 }
 
-// Get retrieves the membership status from cache
-func (c *MembershipCache) Get(orgID, userID string) (bool, bool) {
+// ShareInvalidations sends this cache's invalidations to the other replicas
+// and applies theirs, so a role change takes effect on every replica at once.
+// Call it before the cache is used.
+func (c *MembershipCache) ShareInvalidations(invalidator Invalidator) {
+	c.invalidator = invalidator
+	invalidator.Subscribe(InvalidationCacheMemberships, func(invalidation Invalidation) {
+		if invalidation.Prefix != "" {
+			c.cache.InvalidatePrefix(invalidation.Prefix)
+		}
+		if invalidation.Key != "" {
+			c.cache.Delete(invalidation.Key)
+		}
+	})
+}
+
+// Get retrieves the cached role, an empty role means the user is not a member
+func (c *MembershipCache) Get(orgID, userID string) (types.Role, bool) {
 	key := c.key(orgID, userID)
 	return c.cache.Get(key)
 }
 
-// Set stores the membership status in cache
-func (c *MembershipCache) Set(orgID, userID string, isMember bool) {
+// Set stores the role in cache, use an empty role for users that are not members
+func (c *MembershipCache) Set(orgID, userID string, role types.Role) {
 	key := c.key(orgID, userID)
-	c.cache.SetWithTTL(key, isMember, c.ttl)
+	c.cache.SetWithTTL(key, role, c.ttl)
 }
 
 // Invalidate removes a specific membership entry from cache
 func (c *MembershipCache) Invalidate(orgID, userID string) {
 	key := c.key(orgID, userID)
 	c.cache.Delete(key)
+	publishInvalidation(c.invalidator, Invalidation{Cache: InvalidationCacheMemberships, Key: key})
 }
 
 // InvalidateOrganization removes all membership entries for an organization
 func (c *MembershipCache) InvalidateOrganization(orgID string) {
 	prefix := orgID + ":"
 	c.cache.InvalidatePrefix(prefix)
+	publishInvalidation(c.invalidator, Invalidation{Cache: InvalidationCacheMemberships, Prefix: prefix})
 }
 
 // key generates a cache key from orgID and userID
 func (c *MembershipCache) key(orgID, userID string) string {
 	return orgID + ":" + userID
 }
+
+// Stats returns the cache counters
+func (c *MembershipCache) Stats() CacheStats {
+	return c.cache.Stats()
+}
+
+// Close stops the cache's janitor
+func (c *MembershipCache) Close() {
+	c.cache.Close()
+}
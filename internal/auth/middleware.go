@@ -18,8 +18,13 @@ const (
 	GitHubIDKey       ContextKey = "github_id"
 	UsernameKey       ContextKey = "username"
 	OrganizationIDKey ContextKey = "organization_id"
+	RoleKey           ContextKey = "role"
+	ApiKeyKey         ContextKey = "api_key"
 )
 
+// ErrApiKeyOrganizationMismatch is returned when a request names an organization other than the API key's
+var ErrApiKeyOrganizationMismatch = errors.New("API key does not belong to the requested organization")
+
 // TokenRefreshHeader is the header name for the refreshed token
 const TokenRefreshHeader = "X-Refreshed-Token"
 
@@ -30,7 +35,7 @@ type UserGetter interface {
 
 // OrganizationMemberChecker is an interface for checking organization membership
 type OrganizationMemberChecker interface {
-	IsMember(ctx context.Context, orgID, userID string) (bool, error)
+	GetMemberRole(ctx context.Context, orgID, userID string) (types.Role, bool, error)
 }
 
 // JWTAuthMiddleware creates middleware for JWT authentication with token refresh support
@@ -102,51 +107,158 @@ func JWTAuthMiddleware(cfg JWTConfig, userRepo UserGetter) func(http.Handler) ht
 	}
 }
 
+// organizationIDGetter is implemented by request messages that carry an organization ID
+type organizationIDGetter interface {
+	GetOrganizationId() string
+}
+
+// ProcedureScopes maps Connect procedures, e.g. /baluster.v1.AccessService/WatchServiceKeys,
+// to the scope they require instead of the interceptor's default
+type ProcedureScopes map[string]types.ApiKeyScope
+
 // ApiKeyAuthInterceptor creates a Connect interceptor that validates API keys
 // from the Authorization header. This token is used to authenticate gRPC requests to Baluster APIs.
-func ApiKeyAuthInterceptor(validator *ApiKeyValidator) connect.UnaryInterceptorFunc {
-	return func(next connect.UnaryFunc) connect.UnaryFunc {
-		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-			authHeader := req.Header().Get("Authorization")
-			if authHeader == "" {
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					nil,
-				)
-			}
+// The key must have the procedure's scope from procedures, or the required scope for any other procedure.
+// Its organization is put in context and a requested one may only confirm it.
+// Streaming handlers are authenticated before the first message, which is checked when it is received.
+func ApiKeyAuthInterceptor(validator *ApiKeyValidator, required types.ApiKeyScope, procedures ProcedureScopes) connect.Interceptor {
+	return &apiKeyAuthInterceptor{
+		validator:  validator,
+		required:   required,
+		procedures: procedures,
+	}
+}
 
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					nil,
-				)
-			}
+type apiKeyAuthInterceptor struct {
+	validator  *ApiKeyValidator
+	required   types.ApiKeyScope
+	procedures ProcedureScopes
+}
 
-			tokenValue := parts[1]
-			valid, err := validator.Validate(ctx, tokenValue)
-			if err != nil {
-				return nil, connect.NewError(
-					connect.CodeInternal,
-					err,
-				)
-			}
+// scopeFor returns the scope a procedure requires
+func (i *apiKeyAuthInterceptor) scopeFor(procedure string) types.ApiKeyScope {
+	if scope, ok := i.procedures[procedure]; ok {
+		return scope
+	}
+	return i.required
+}
 
-			if !valid {
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					nil,
-				)
-			}
+func (i *apiKeyAuthInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		apiKey, err := i.authenticate(ctx, req.Header(), i.scopeFor(req.Spec().Procedure))
+		if err != nil {
+			return nil, err
+		}
 
-			return next(ctx, req)
+		// The organization comes from the key, a requested one may only confirm it
+		requested := req.Header().Get("x-org-id")
+		if msg, ok := req.Any().(organizationIDGetter); ok && msg.GetOrganizationId() != "" {
+			requested = msg.GetOrganizationId()
 		}
+		if err := checkApiKeyOrganization(apiKey, requested); err != nil {
+			return nil, connect.NewError(connect.CodePermissionDenied, err)
+		}
+
+		return next(withApiKey(ctx, apiKey), req)
 	}
 }
 
+func (i *apiKeyAuthInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *apiKeyAuthInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		apiKey, err := i.authenticate(ctx, conn.RequestHeader(), i.scopeFor(conn.Spec().Procedure))
+		if err != nil {
+			return err
+		}
+
+		if err := checkApiKeyOrganization(apiKey, conn.RequestHeader().Get("x-org-id")); err != nil {
+			return connect.NewError(connect.CodePermissionDenied, err)
+		}
+
+		return next(withApiKey(ctx, apiKey), &apiKeyStreamingConn{StreamingHandlerConn: conn, apiKey: apiKey})
+	}
+}
+
+// authenticate validates the API key in the Authorization header and checks that it has the required scope
+func (i *apiKeyAuthInterceptor) authenticate(ctx context.Context, header http.Header, required types.ApiKeyScope) (*types.ApiKey, error) {
+	authHeader := header.Get("Authorization")
+	if authHeader == "" {
+		return nil, connect.NewError(
+			connect.CodeUnauthenticated,
+			nil,
+		)
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, connect.NewError(
+			connect.CodeUnauthenticated,
+			nil,
+		)
+	}
+
+	tokenValue := parts[1]
+	apiKey, valid, err := i.validator.Validate(ctx, tokenValue)
+	if err != nil {
+		return nil, connect.NewError(
+			connect.CodeInternal,
+			err,
+		)
+	}
+
+	if !valid {
+		return nil, connect.NewError(
+			connect.CodeUnauthenticated,
+			nil,
+		)
+	}
+
+	if !apiKey.HasScope(required) {
+		return nil, connect.NewError(
+			connect.CodePermissionDenied,
+			fmt.Errorf("API key is missing the %s scope", required),
+		)
+	}
+
+	return apiKey, nil
+}
+
+// apiKeyStreamingConn checks the organization of every message a streaming handler receives
+type apiKeyStreamingConn struct {
+	connect.StreamingHandlerConn
+	apiKey *types.ApiKey
+}
+
+func (c *apiKeyStreamingConn) Receive(msg any) error {
+	if err := c.StreamingHandlerConn.Receive(msg); err != nil {
+		return err
+	}
+
+	// The organization comes from the key, a requested one may only confirm it
+	if m, ok := msg.(organizationIDGetter); ok {
+		if err := checkApiKeyOrganization(c.apiKey, m.GetOrganizationId()); err != nil {
+			return connect.NewError(connect.CodePermissionDenied, err)
+		}
+	}
+	return nil
+}
+
+// checkApiKeyOrganization rejects a request for an organization other than the API key's.
+// An empty requested organization is allowed, the key's organization is used instead.
+func checkApiKeyOrganization(apiKey *types.ApiKey, requested string) error {
+	if requested != "" && requested != apiKey.OrganizationID {
+		return fmt.Errorf("%w: key belongs to %s, request is for %s", ErrApiKeyOrganizationMismatch, apiKey.OrganizationID, requested)
+	}
+	return nil
+}
+
 // ApiKeyAuthMiddleware creates middleware for API key-based authentication
 // Validates API keys from the Authorization header used to call Baluster APIs
-func ApiKeyAuthMiddleware(validator *ApiKeyValidator) func(http.Handler) http.Handler {
+// The key must have the required scope, its organization is put in context and the x-org-id header may only confirm it
+func ApiKeyAuthMiddleware(validator *ApiKeyValidator, required types.ApiKeyScope) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -162,7 +274,7 @@ func ApiKeyAuthMiddleware(validator *ApiKeyValidator) func(http.Handler) http.Ha
 			}
 
 			tokenValue := parts[1]
-			valid, err := validator.Validate(r.Context(), tokenValue)
+			apiKey, valid, err := validator.Validate(r.Context(), tokenValue)
 			if err != nil {
 				http.Error(w, "failed to validate token", http.StatusInternalServerError)
 				return
@@ -173,13 +285,24 @@ func ApiKeyAuthMiddleware(validator *ApiKeyValidator) func(http.Handler) http.Ha
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			if !apiKey.HasScope(required) {
+				http.Error(w, fmt.Sprintf("API key is missing the %s scope", required), http.StatusForbidden)
+				return
+			}
+
+			// The organization comes from the key, the x-org-id header may only confirm it
+			if err := checkApiKeyOrganization(apiKey, r.Header.Get("x-org-id")); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withApiKey(r.Context(), apiKey)))
 		})
 	}
 }
 
 // OrganizationMembershipMiddleware creates middleware that validates organization membership
-// It reads the x-org-id header, checks membership with caching, and adds org ID to context
+// It reads the x-org-id header, checks membership with caching, and adds the org ID and the user's role to context
 func OrganizationMembershipMiddleware(memberRepo OrganizationMemberChecker, cache *MembershipCache) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -201,27 +324,53 @@ func OrganizationMembershipMiddleware(memberRepo OrganizationMemberChecker, cach
 				return
 			}
 
-			isMember, found := cache.Get(orgID, userID)
+			role, found := cache.Get(orgID, userID)
 			if !found {
+				var isMember bool
 				var err error
-				isMember, err = memberRepo.IsMember(r.Context(), orgID, userID)
+				role, isMember, err = memberRepo.GetMemberRole(r.Context(), orgID, userID)
 				if err != nil {
 					http.Error(w, "failed to validate organization membership", http.StatusInternalServerError)
 					return
 				}
+				if !isMember {
+					role = ""
+				}
 
 				// Cache the result
-				cache.Set(orgID, userID, isMember)
+				cache.Set(orgID, userID, role)
 			}
 
-			if !isMember {
+			if role == "" {
 				http.Error(w, "user is not a member of this organization", http.StatusUnauthorized)
 				return
 			}
 
-			// Add organization ID to context
+			// Add organization ID and role to context
 			ctx := context.WithValue(r.Context(), OrganizationIDKey, orgID)
+			ctx = context.WithValue(ctx, RoleKey, role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// RequireRole creates middleware that only allows members with at least the required role
+// It must run after OrganizationMembershipMiddleware, which adds the role to context
+func RequireRole(required types.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			role, ok := GetRole(r.Context())
+			if !ok || !role.Satisfies(required) {
+				http.Error(w, fmt.Sprintf("requires the %s role", required), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type mockMemberChecker struct {
+	roles map[string]types.Role
+	calls int
+}
+
+func (m *mockMemberChecker) GetMemberRole(ctx context.Context, orgID, userID string) (types.Role, bool, error) {
+	m.calls++
+	role, ok := m.roles[orgID+":"+userID]
+	return role, ok, nil
+}
+
+func TestOrganizationMembershipMiddlewareRequireRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		required       types.Role
+		expectedStatus int
+	}{
+		{name: "owner can do everything", userID: "owner", required: types.RoleOwner, expectedStatus: http.StatusOK},
+		{name: "admin can manage keys", userID: "admin", required: types.RoleAdmin, expectedStatus: http.StatusOK},
+		{name: "developer cannot manage keys", userID: "developer", required: types.RoleAdmin, expectedStatus: http.StatusForbidden},
+		{name: "viewer can read", userID: "viewer", required: types.RoleViewer, expectedStatus: http.StatusOK},
+		{name: "viewer cannot write applications", userID: "viewer", required: types.RoleDeveloper, expectedStatus: http.StatusForbidden},
+		{name: "non-member is rejected", userID: "stranger", required: types.RoleViewer, expectedStatus: http.StatusUnauthorized},
+	}
+
+	checker := &mockMemberChecker{roles: map[string]types.Role{
+		"org-1:owner":     types.RoleOwner,
+		"org-1:admin":     types.RoleAdmin,
+		"org-1:developer": types.RoleDeveloper,
+		"org-1:viewer":    types.RoleViewer,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMembershipCache(time.Minute)
+			handler := OrganizationMembershipMiddleware(checker, cache)(
+				RequireRole(tt.required)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				})),
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("x-org-id", "org-1")
+			req = req.WithContext(context.WithValue(req.Context(), UserIDKey, tt.userID))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestOrganizationMembershipMiddlewareCachesRole(t *testing.T) {
+	checker := &mockMemberChecker{roles: map[string]types.Role{"org-1:user-1": types.RoleViewer}}
+	cache := NewMembershipCache(time.Minute)
+
+	var seen types.Role
+	handler := OrganizationMembershipMiddleware(checker, cache)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = GetRole(r.Context())
+	}))
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("x-org-id", "org-1")
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey, "user-1"))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if seen != types.RoleViewer {
+		t.Errorf("expected role %q in context, got %q", types.RoleViewer, seen)
+	}
+	if checker.calls != 1 {
+		t.Errorf("expected role to be cached after the first lookup, got %d lookups", checker.calls)
+	}
+
+	if role, found := cache.Get("org-1", "user-1"); !found || role != types.RoleViewer {
+		t.Errorf("expected cached role %q, got %q (found %v)", types.RoleViewer, role, found)
+	}
+}
+
+type mockApiKeyFinder struct {
+	keys map[string]*types.ApiKey
+}
+
+func (m *mockApiKeyFinder) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ApiKey, error) {
+	for _, key := range m.keys {
+		if key.TokenValue == storage.HashToken(tokenValue) {
+			return key, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func TestApiKeyAuthMiddlewareEnforcesScopes(t *testing.T) {
+	revokedAt := time.Now()
+	finder := &mockApiKeyFinder{keys: map[string]*types.ApiKey{
+		"legacy":    {OrganizationID: "org-1", ApplicationID: "app-1", TokenValue: storage.HashToken("legacy")},
+		"validator": {OrganizationID: "org-1", TokenValue: storage.HashToken("validator"), Scopes: []types.ApiKeyScope{types.ApiKeyScopeValidateAccess}},
+		"admin":     {OrganizationID: "org-1", TokenValue: storage.HashToken("admin"), Scopes: []types.ApiKeyScope{types.ApiKeyScopeAdmin}},
+		"revoked":   {OrganizationID: "org-1", TokenValue: storage.HashToken("revoked"), RevokedAt: &revokedAt},
+	}}
+	validator := NewApiKeyValidator(finder)
+
+	tests := []struct {
+		name           string
+		token          string
+		orgID          string
+		required       types.ApiKeyScope
+		expectedStatus int
+	}{
+		{name: "keys without scopes may validate access", token: "legacy", orgID: "org-1", required: types.ApiKeyScopeValidateAccess, expectedStatus: http.StatusOK},
+		{name: "keys without scopes are not admins", token: "legacy", orgID: "org-1", required: types.ApiKeyScopeAdmin, expectedStatus: http.StatusForbidden},
+		{name: "validate-only key cannot call admin operations", token: "validator", orgID: "org-1", required: types.ApiKeyScopeAdmin, expectedStatus: http.StatusForbidden},
+		{name: "admin scope allows every operation", token: "admin", orgID: "org-1", required: types.ApiKeyScopeValidateAccess, expectedStatus: http.StatusOK},
+		{name: "other organization is rejected", token: "validator", orgID: "org-2", required: types.ApiKeyScopeValidateAccess, expectedStatus: http.StatusForbidden},
+		{name: "missing organization falls back on the key's", token: "validator", orgID: "", required: types.ApiKeyScopeValidateAccess, expectedStatus: http.StatusOK},
+		{name: "revoked key is rejected", token: "revoked", orgID: "org-1", required: types.ApiKeyScopeValidateAccess, expectedStatus: http.StatusUnauthorized},
+		{name: "unknown key is rejected", token: "unknown", orgID: "org-1", required: types.ApiKeyScopeValidateAccess, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen *types.ApiKey
+			var seenOrgID string
+			handler := ApiKeyAuthMiddleware(validator, tt.required)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen, _ = GetApiKey(r.Context())
+				seenOrgID, _ = GetOrganizationID(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			req.Header.Set("x-org-id", tt.orgID)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Code == http.StatusOK && (seen != finder.keys[tt.token] || seenOrgID != "org-1") {
+				t.Errorf("expected the API key and its organization in context, got %v and %q", seen, seenOrgID)
+			}
+		})
+	}
+}
+
+func TestApiKeyAuthInterceptorAuthenticatesStreams(t *testing.T) {
+	finder := &mockApiKeyFinder{keys: map[string]*types.ApiKey{
+		"validator": {OrganizationID: "org-1", TokenValue: storage.HashToken("validator"), Scopes: []types.ApiKeyScope{types.ApiKeyScopeValidateAccess}},
+		"watcher":   {OrganizationID: "org-1", TokenValue: storage.HashToken("watcher"), Scopes: []types.ApiKeyScope{types.ApiKeyScopeWatchServiceKeys}},
+		"admin":     {OrganizationID: "org-1", TokenValue: storage.HashToken("admin"), Scopes: []types.ApiKeyScope{types.ApiKeyScopeAdmin}},
+	}}
+
+	// The stream requires the watch scope, every other procedure only validate access
+	const path = "/test.v1.TestService/Watch"
+	interceptor := ApiKeyAuthInterceptor(NewApiKeyValidator(finder), types.ApiKeyScopeValidateAccess, ProcedureScopes{path: types.ApiKeyScopeWatchServiceKeys})
+	var seenOrgID string
+	handler := connect.NewServerStreamHandler(
+		path,
+		func(ctx context.Context, req *connect.Request[emptypb.Empty], stream *connect.ServerStream[emptypb.Empty]) error {
+			seenOrgID, _ = GetOrganizationID(ctx)
+			return stream.Send(&emptypb.Empty{})
+		},
+		connect.WithInterceptors(interceptor),
+	)
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := connect.NewClient[emptypb.Empty, emptypb.Empty](server.Client(), server.URL+path)
+
+	tests := []struct {
+		name         string
+		token        string
+		orgID        string
+		expectedCode connect.Code // 0 when the stream is accepted
+	}{
+		{name: "valid key", token: "watcher", orgID: "org-1"},
+		{name: "admin scope allows the procedure", token: "admin", orgID: "org-1"},
+		{name: "key without the procedure's scope is rejected", token: "validator", orgID: "org-1", expectedCode: connect.CodePermissionDenied},
+		{name: "missing key is rejected", token: "", orgID: "org-1", expectedCode: connect.CodeUnauthenticated},
+		{name: "unknown key is rejected", token: "unknown", orgID: "org-1", expectedCode: connect.CodeUnauthenticated},
+		{name: "other organization is rejected", token: "watcher", orgID: "org-2", expectedCode: connect.CodePermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seenOrgID = ""
+			req := connect.NewRequest(&emptypb.Empty{})
+			if tt.token != "" {
+				req.Header().Set("Authorization", "Bearer "+tt.token)
+			}
+			req.Header().Set("x-org-id", tt.orgID)
+
+			stream, err := client.CallServerStream(context.Background(), req)
+			if err != nil {
+				t.Fatalf("failed to call stream: %v", err)
+			}
+			defer stream.Close()
+			for stream.Receive() {
+			}
+
+			if tt.expectedCode == 0 {
+				if err := stream.Err(); err != nil {
+					t.Fatalf("expected the stream to be accepted, got %v", err)
+				}
+				if seenOrgID != "org-1" {
+					t.Errorf("expected the key's organization in context, got %q", seenOrgID)
+				}
+				return
+			}
+			if code := connect.CodeOf(stream.Err()); code != tt.expectedCode {
+				t.Errorf("expected code %v, got %v", tt.expectedCode, code)
+			}
+		})
+	}
+}
+
+func TestApiKeyOrganizationMismatchError(t *testing.T) {
+	err := checkApiKeyOrganization(&types.ApiKey{OrganizationID: "org-1"}, "org-2")
+	if !errors.Is(err, ErrApiKeyOrganizationMismatch) {
+		t.Fatalf("expected ErrApiKeyOrganizationMismatch, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "org-1") || !strings.Contains(err.Error(), "org-2") {
+		t.Errorf("expected both organizations in the error, got %q", err)
+	}
+}
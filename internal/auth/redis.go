@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a Store shared by every replica connected to the same Redis-compatible server
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a store that keeps its keys under prefix, such as "baluster:oauth_state:"
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Get retrieves a value, found is false when the key is missing or expired
+func (s *RedisStore) Get(ctx context.Context, key string) (string, bool, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get %s: %w", s.prefix+key, err)
+	}
+	return value, true, nil
+}
+
+// Set stores a value with a TTL duration
+func (s *RedisStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set %s: %w", s.prefix+key, err)
+	}
+	return nil
+}
+
+// GetAndDelete atomically retrieves and removes a value, so only one replica can consume it
+func (s *RedisStore) GetAndDelete(ctx context.Context, key string) (string, bool, error) {
+	value, err := s.client.GetDel(ctx, s.prefix+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get and delete %s: %w", s.prefix+key, err)
+	}
+	return value, true, nil
+}
+
+// Delete removes a key
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", s.prefix+key, err)
+	}
+	return nil
+}
+
+// redisInvalidationMessage is an invalidation as sent over the channel
+type redisInvalidationMessage struct {
+	Invalidation
+	Origin string `json:"origin"` // The replica that published it
+}
+
+// RedisInvalidator shares cache invalidations over a Redis pub/sub channel.
+// Each replica ignores the invalidations it published, it already applied them.
+type RedisInvalidator struct {
+	client  *redis.Client
+	channel string
+	origin  string
+	pubsub  *redis.PubSub
+
+	mu       sync.RWMutex
+	handlers map[string][]func(Invalidation)
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRedisInvalidator subscribes to channel and returns once the subscription is active
+func NewRedisInvalidator(ctx context.Context, client *redis.Client, channel string) (*RedisInvalidator, error) {
+	origin := make([]byte, 16)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, fmt.Errorf("failed to generate origin: %w", err)
+	}
+
+	pubsub := client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+	}
+
+	i := &RedisInvalidator{
+		client:   client,
+		channel:  channel,
+		origin:   hex.EncodeToString(origin),
+		pubsub:   pubsub,
+		handlers: make(map[string][]func(Invalidation)),
+		done:     make(chan struct{}),
+	}
+	go i.receive()
+
+	return i, nil
+}
+
+// Publish sends an invalidation to every other replica
+func (i *RedisInvalidator) Publish(ctx context.Context, invalidation Invalidation) error {
+	payload, err := json.Marshal(redisInvalidationMessage{
+		Invalidation: invalidation,
+		Origin:       i.origin,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode invalidation: %w", err)
+	}
+
+	if err := i.client.Publish(ctx, i.channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish invalidation: %w", err)
+	}
+	return nil
+}
+
+// Subscribe calls handle for every invalidation of the named cache published by another replica
+func (i *RedisInvalidator) Subscribe(cache string, handle func(Invalidation)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.handlers[cache] = append(i.handlers[cache], handle)
+}
+
+// Close unsubscribes and waits for pending invalidations to be applied, it is safe to call more than once
+func (i *RedisInvalidator) Close() error {
+	var err error
+	i.closeOnce.Do(func() {
+		err = i.pubsub.Close()
+		<-i.done
+	})
+	return err
+}
+
+func (i *RedisInvalidator) receive() {
+	defer close(i.done)
+
+	for msg := range i.pubsub.Channel() {
+		var message redisInvalidationMessage
+		if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+			slog.Error("failed to decode cache invalidation", "channel", i.channel, "error", err)
+			continue
+		}
+		if message.Origin == i.origin {
+			continue
+		}
+
+		i.mu.RLock()
+		handlers := i.handlers[message.Cache]
+		i.mu.RUnlock()
+
+		for _, handle := range handlers {
+			handle(message.Invalidation)
+		}
+	}
+}
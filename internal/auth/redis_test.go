@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return server, client
+}
+
+func TestSharedStateCacheAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestRedis(t)
+
+	// Two replicas share the server, the login reaches one and the callback the other
+	login := NewSharedStateCache(NewRedisStore(client, "test:state:"))
+	callback := NewSharedStateCache(NewRedisStore(client, "test:state:"))
+
+	if err := login.Set(ctx, "state-1", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("failed to set state: %v", err)
+	}
+	if !server.Exists("test:state:state-1") {
+		t.Fatalf("expected the state to be stored under the prefix")
+	}
+
+	if valid, err := callback.GetAndDelete(ctx, "state-1"); err != nil || !valid {
+		t.Fatalf("expected the state to be valid on the other replica, got %v (%v)", valid, err)
+	}
+	// A state can only be used once
+	if valid, err := login.GetAndDelete(ctx, "state-1"); err != nil || valid {
+		t.Errorf("expected the state to be consumed, got %v (%v)", valid, err)
+	}
+
+	// Expired states are rejected
+	if err := login.Set(ctx, "state-2", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("failed to set state: %v", err)
+	}
+	server.FastForward(2 * time.Minute)
+	if valid, err := callback.GetAndDelete(ctx, "state-2"); err != nil || valid {
+		t.Errorf("expected the expired state to be rejected, got %v (%v)", valid, err)
+	}
+}
+
+func TestRedisStoreReportsConnectionErrors(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestRedis(t)
+	store := NewRedisStore(client, "test:")
+	server.Close()
+
+	if _, _, err := store.GetAndDelete(ctx, "key"); err == nil {
+		t.Errorf("expected an error once the server is gone")
+	}
+}
+
+func TestRedisInvalidatorSharesMembershipInvalidations(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestRedis(t)
+
+	replicas := make([]*MembershipCache, 2)
+	for i := range replicas {
+		invalidator, err := NewRedisInvalidator(ctx, client, "test:invalidations")
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		t.Cleanup(func() { _ = invalidator.Close() })
+
+		replicas[i] = NewMembershipCache(time.Minute)
+		replicas[i].ShareInvalidations(invalidator)
+		t.Cleanup(replicas[i].Close)
+
+		replicas[i].Set("org-1", "user-1", types.RoleAdmin)
+		replicas[i].Set("org-1", "user-2", types.RoleViewer)
+		replicas[i].Set("org-2", "user-1", types.RoleOwner)
+	}
+
+	// A role change on one replica drops the entry on the other
+	replicas[0].Invalidate("org-1", "user-1")
+	waitForInvalidation(t, func() bool {
+		_, found := replicas[1].Get("org-1", "user-1")
+		return !found
+	})
+	if _, found := replicas[1].Get("org-1", "user-2"); !found {
+		t.Errorf("expected other memberships to be kept")
+	}
+
+	// So does invalidating a whole organization
+	replicas[1].InvalidateOrganization("org-1")
+	waitForInvalidation(t, func() bool {
+		_, found := replicas[0].Get("org-1", "user-2")
+		return !found
+	})
+	if _, found := replicas[0].Get("org-2", "user-1"); !found {
+		t.Errorf("expected memberships of other organizations to be kept")
+	}
+}
+
+func waitForInvalidation(t *testing.T, applied func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !applied() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the invalidation to reach the other replica")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
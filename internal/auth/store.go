@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Store holds short-lived values that every replica must see, such as OAuth states.
+// MemoryStore keeps them in the process, RedisStore shares them between replicas.
+type Store interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	GetAndDelete(ctx context.Context, key string) (string, bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStore is a Store in process memory, only suitable for a single replica
+type MemoryStore struct {
+	cache *Cache[string]
+}
+
+// NewMemoryStore creates a new in-memory store, it accepts the same options as NewCache
+func NewMemoryStore(opts ...CacheOption) *MemoryStore {
+	return &MemoryStore{
+		cache: NewCache[string](opts...),
+	}
+}
+
+// Get retrieves a value, found is false when the key is missing or expired
+func (s *MemoryStore) Get(ctx context.Context, key string) (string, bool, error) {
+	value, found := s.cache.Get(key)
+	return value, found, nil
+}
+
+// Set stores a value with a TTL duration
+func (s *MemoryStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	s.cache.SetWithTTL(key, value, ttl)
+	return nil
+}
+
+// GetAndDelete atomically retrieves and removes a value
+func (s *MemoryStore) GetAndDelete(ctx context.Context, key string) (string, bool, error) {
+	value, found := s.cache.GetAndDelete(key)
+	return value, found, nil
+}
+
+// Delete removes a key
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.cache.Delete(key)
+	return nil
+}
+
+// Stats returns the cache counters
+func (s *MemoryStore) Stats() CacheStats {
+	return s.cache.Stats()
+}
+
+// Close stops the cache's janitor
+func (s *MemoryStore) Close() {
+	s.cache.Close()
+}
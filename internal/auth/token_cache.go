@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// TokenCacheConfig configures the caches in front of service key and API key token lookups
+type TokenCacheConfig struct {
+	Size        int           // Maximum number of cached lookups per repository
+	TTL         time.Duration // How long a found key is cached
+	NegativeTTL time.Duration // How long an unknown token is cached
+}
+
+// tokenCacheJanitorInterval is how often expired token lookups are removed
+const tokenCacheJanitorInterval = time.Minute
+
+// CachedServiceKeyRepository caches token lookups of a service key repository.
+// Found keys are cached for the TTL and unknown tokens for the negative TTL.
+// Update, Rotate and Delete drop every cached lookup of the key they change;
+// other instances see the change once their entries expire, or at once with ShareInvalidations.
+type CachedServiceKeyRepository struct {
+	storage.ServiceKeyRepository
+	cache       *Cache[*types.ServiceKey]
+	config      TokenCacheConfig
+	invalidator Invalidator
+}
+
+// NewCachedServiceKeyRepository wraps a service key repository with a token lookup cache
+func NewCachedServiceKeyRepository(repo storage.ServiceKeyRepository, config TokenCacheConfig) *CachedServiceKeyRepository {
+	return &CachedServiceKeyRepository{
+		ServiceKeyRepository: repo,
+		cache:                NewCache[*types.ServiceKey](WithMaxEntries(config.Size), WithJanitor(tokenCacheJanitorInterval)),
+		config:               config,
+	}
+}
+
+// ShareInvalidations sends the IDs of changed keys to the other replicas and drops the keys they change.
+// Call it before the repository is used.
+func (r *CachedServiceKeyRepository) ShareInvalidations(invalidator Invalidator) {
+	r.invalidator = invalidator
+	invalidator.Subscribe(InvalidationCacheServiceKeys, func(invalidation Invalidation) {
+		r.drop(invalidation.Key)
+	})
+}
+
+// FindByTokenValue finds a service key by token value, from the cache when possible
+func (r *CachedServiceKeyRepository) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ServiceKey, error) {
+	key := tokenCacheKey("", tokenValue)
+	return cachedLookup(r.cache, r.config, key, func() (*types.ServiceKey, error) {
+		return r.ServiceKeyRepository.FindByTokenValue(ctx, tokenValue)
+	})
+}
+
+// FindByTokenValueInOrg finds a service key by token value within an organization, from the cache when possible
+func (r *CachedServiceKeyRepository) FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error) {
+	key := tokenCacheKey(organizationID, tokenValue)
+	return cachedLookup(r.cache, r.config, key, func() (*types.ServiceKey, error) {
+		return r.ServiceKeyRepository.FindByTokenValueInOrg(ctx, organizationID, tokenValue)
+	})
+}
+
+// Update updates a service key and drops its cached lookups
+func (r *CachedServiceKeyRepository) Update(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
+	defer r.invalidate(serviceKey.ID)
+	return r.ServiceKeyRepository.Update(ctx, serviceKey, userID, githubID, username)
+}
+
+// Rotate rotates a service key and drops its cached lookups
+func (r *CachedServiceKeyRepository) Rotate(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
+	defer r.invalidate(serviceKey.ID)
+	return r.ServiceKeyRepository.Rotate(ctx, serviceKey, userID, githubID, username)
+}
+
+// Delete deletes a service key and drops its cached lookups
+func (r *CachedServiceKeyRepository) Delete(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
+	defer r.invalidate(serviceKey.ID)
+	return r.ServiceKeyRepository.Delete(ctx, serviceKey, userID, githubID, username)
+}
+
+// Stats returns the cache counters
+func (r *CachedServiceKeyRepository) Stats() CacheStats {
+	return r.cache.Stats()
+}
+
+// Close stops the cache's janitor
+func (r *CachedServiceKeyRepository) Close() {
+	r.cache.Close()
+}
+
+func (r *CachedServiceKeyRepository) invalidate(id string) {
+	r.drop(id)
+	publishInvalidation(r.invalidator, Invalidation{Cache: InvalidationCacheServiceKeys, Key: id})
+}
+
+func (r *CachedServiceKeyRepository) drop(id string) {
+	r.cache.DeleteFunc(func(_ string, serviceKey *types.ServiceKey) bool {
+		return serviceKey != nil && serviceKey.ID == id
+	})
+}
+
+// CachedApiKeyRepository caches token lookups of an API key repository.
+// Update, Rotate, Revoke and Delete drop every cached lookup of the key they change.
+type CachedApiKeyRepository struct {
+	storage.ApiKeyRepository
+	cache       *Cache[*types.ApiKey]
+	config      TokenCacheConfig
+	invalidator Invalidator
+}
+
+// NewCachedApiKeyRepository wraps an API key repository with a token lookup cache
+func NewCachedApiKeyRepository(repo storage.ApiKeyRepository, config TokenCacheConfig) *CachedApiKeyRepository {
+	return &CachedApiKeyRepository{
+		ApiKeyRepository: repo,
+		cache:            NewCache[*types.ApiKey](WithMaxEntries(config.Size), WithJanitor(tokenCacheJanitorInterval)),
+		config:           config,
+	}
+}
+
+// ShareInvalidations sends the IDs of changed keys to the other replicas and drops the keys they change.
+// Call it before the repository is used.
+func (r *CachedApiKeyRepository) ShareInvalidations(invalidator Invalidator) {
+	r.invalidator = invalidator
+	invalidator.Subscribe(InvalidationCacheApiKeys, func(invalidation Invalidation) {
+		r.drop(invalidation.Key)
+	})
+}
+
+// FindByTokenValue finds an API key by token value, from the cache when possible
+func (r *CachedApiKeyRepository) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ApiKey, error) {
+	key := tokenCacheKey("", tokenValue)
+	return cachedLookup(r.cache, r.config, key, func() (*types.ApiKey, error) {
+		return r.ApiKeyRepository.FindByTokenValue(ctx, tokenValue)
+	})
+}
+
+// Update updates an API key and drops its cached lookups
+func (r *CachedApiKeyRepository) Update(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	defer r.invalidate(apiKey.ID)
+	return r.ApiKeyRepository.Update(ctx, apiKey, userID, githubID, username)
+}
+
+// Rotate rotates an API key and drops its cached lookups
+func (r *CachedApiKeyRepository) Rotate(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	defer r.invalidate(apiKey.ID)
+	return r.ApiKeyRepository.Rotate(ctx, apiKey, userID, githubID, username)
+}
+
+// Revoke revokes an API key and drops its cached lookups
+func (r *CachedApiKeyRepository) Revoke(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	defer r.invalidate(apiKey.ID)
+	return r.ApiKeyRepository.Revoke(ctx, apiKey, userID, githubID, username)
+}
+
+// Delete deletes an API key and drops its cached lookups
+func (r *CachedApiKeyRepository) Delete(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	defer r.invalidate(apiKey.ID)
+	return r.ApiKeyRepository.Delete(ctx, apiKey, userID, githubID, username)
+}
+
+// Stats returns the cache counters
+func (r *CachedApiKeyRepository) Stats() CacheStats {
+	return r.cache.Stats()
+}
+
+// Close stops the cache's janitor
+func (r *CachedApiKeyRepository) Close() {
+	r.cache.Close()
+}
+
+func (r *CachedApiKeyRepository) invalidate(id string) {
+	r.drop(id)
+	publishInvalidation(r.invalidator, Invalidation{Cache: InvalidationCacheApiKeys, Key: id})
+}
+
+func (r *CachedApiKeyRepository) drop(id string) {
+	r.cache.DeleteFunc(func(_ string, apiKey *types.ApiKey) bool {
+		return apiKey != nil && apiKey.ID == id
+	})
+}
+
+// tokenCacheKey keys a lookup by the token hash, so plain tokens are never kept in memory
+func tokenCacheKey(organizationID, tokenValue string) string {
+	return organizationID + ":" + storage.HashToken(tokenValue)
+}
+
+// cachedLookup serves a token lookup from the cache, or runs it and caches the result.
+// Only found keys and storage.ErrNotFound are cached, other errors are returned as is.
+// Callers get their own copy so they cannot change the cached key.
+func cachedLookup[T any](cache *Cache[*T], config TokenCacheConfig, key string, lookup func() (*T, error)) (*T, error) {
+	if cached, ok := cache.Get(key); ok {
+		if cached == nil {
+			return nil, errCachedNotFound
+		}
+		found := *cached
+		return &found, nil
+	}
+
+	version := cache.Version()
+	value, err := lookup()
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			cache.SetWithTTLIfVersion(key, nil, config.NegativeTTL, version)
+		}
+		return nil, err
+	}
+
+	cached := *value
+	cache.SetWithTTLIfVersion(key, &cached, config.TTL, version)
+	return value, nil
+}
+
+// errCachedNotFound is returned for a token recently looked up and not found
+var errCachedNotFound = fmt.Errorf("token %w", storage.ErrNotFound)
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/storage/memory"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type countingServiceKeyRepo struct {
+	storage.ServiceKeyRepository
+	lookups int
+}
+
+func (r *countingServiceKeyRepo) FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error) {
+	r.lookups++
+	return r.ServiceKeyRepository.FindByTokenValueInOrg(ctx, organizationID, tokenValue)
+}
+
+func TestCachedServiceKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingServiceKeyRepo{ServiceKeyRepository: memory.NewRepositories(memory.NewStore()).ServiceKeys}
+	repo := NewCachedServiceKeyRepository(backend, TokenCacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+	defer repo.Close()
+
+	serviceKey := &types.ServiceKey{ID: "sk-1", OrganizationID: "org-1", Name: "before", TokenValue: "token-1"}
+	if err := repo.Create(ctx, serviceKey, "user-1", "github-1", "user"); err != nil {
+		t.Fatalf("failed to create service key: %v", err)
+	}
+
+	for range 3 {
+		found, err := repo.FindByTokenValueInOrg(ctx, "org-1", "token-1")
+		if err != nil {
+			t.Fatalf("failed to find service key: %v", err)
+		}
+		// Callers get a copy, so clearing the token must not reach the cache
+		found.TokenValue = ""
+	}
+	if backend.lookups != 1 {
+		t.Errorf("expected 1 backend lookup, got %d", backend.lookups)
+	}
+
+	// Unknown tokens are cached too
+	for range 2 {
+		if _, err := repo.FindByTokenValueInOrg(ctx, "org-1", "unknown"); !errors.Is(err, storage.ErrNotFound) {
+			t.Fatalf("expected storage.ErrNotFound, got %v", err)
+		}
+	}
+	if backend.lookups != 2 {
+		t.Errorf("expected 2 backend lookups, got %d", backend.lookups)
+	}
+
+	stats := repo.Stats()
+	if stats.Hits != 3 || stats.Misses != 2 {
+		t.Errorf("expected 3 hits and 2 misses, got %+v", stats)
+	}
+
+	// An update drops the cached lookup
+	serviceKey.Name = "after"
+	if err := repo.Update(ctx, serviceKey, "user-1", "github-1", "user"); err != nil {
+		t.Fatalf("failed to update service key: %v", err)
+	}
+	found, err := repo.FindByTokenValueInOrg(ctx, "org-1", "token-1")
+	if err != nil {
+		t.Fatalf("failed to find service key: %v", err)
+	}
+	if found.Name != "after" {
+		t.Errorf("expected the updated name, got %s", found.Name)
+	}
+	if found.TokenValue != storage.HashToken("token-1") {
+		t.Errorf("expected the cached key to keep its token hash")
+	}
+
+	// A deleted key is no longer found
+	if err := repo.Delete(ctx, found, "user-1", "github-1", "user"); err != nil {
+		t.Fatalf("failed to delete service key: %v", err)
+	}
+	if _, err := repo.FindByTokenValueInOrg(ctx, "org-1", "token-1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected storage.ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestCachedApiKeyRepositoryRevoke(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCachedApiKeyRepository(memory.NewRepositories(memory.NewStore()).ApiKeys, TokenCacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+	defer repo.Close()
+	validator := NewApiKeyValidator(repo)
+
+	apiKey := &types.ApiKey{ID: "ak-1", OrganizationID: "org-1", TokenValue: "api-token"}
+	if err := repo.Create(ctx, apiKey, "user-1", "github-1", "user"); err != nil {
+		t.Fatalf("failed to create API key: %v", err)
+	}
+
+	if _, ok, _ := validator.Validate(ctx, "api-token"); !ok {
+		t.Fatalf("expected the API key to be valid")
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	apiKey.RevokedReason = "leaked"
+	if err := repo.Revoke(ctx, apiKey, "user-1", "github-1", "user"); err != nil {
+		t.Fatalf("failed to revoke API key: %v", err)
+	}
+
+	// Revocation takes effect immediately, not when the cached entry expires
+	if _, ok, _ := validator.Validate(ctx, "api-token"); ok {
+		t.Errorf("expected the revoked API key to be rejected")
+	}
+}
@@ -0,0 +1,90 @@
+// Package checkpoint signs audit checkpoints, copies of the heads of an organization's audit chains,
+// so a checkpoint kept outside Baluster can later prove the history it covers was not rewritten.
+package checkpoint
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// messageHeader starts every signed message and names its format
+const messageHeader = "baluster-audit-checkpoint-v1"
+
+// ErrInvalidSignature is returned by Verify when a checkpoint was not signed with the key or was changed since
+var ErrInvalidSignature = errors.New("invalid audit checkpoint signature")
+
+// Signer signs checkpoints with an Ed25519 key
+type Signer struct {
+	privateKey ed25519.PrivateKey
+	publicKey  string
+}
+
+// NewSigner creates a signer from a 32 byte Ed25519 seed
+func NewSigner(seed []byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("audit signing key must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	return &Signer{
+		privateKey: privateKey,
+		publicKey:  base64.StdEncoding.EncodeToString(privateKey.Public().(ed25519.PublicKey)),
+	}, nil
+}
+
+// PublicKey returns the base64 public key the signer's checkpoints are verified with
+func (s *Signer) PublicKey() string {
+	return s.publicKey
+}
+
+// Sign sets the checkpoint's PublicKey and Signature
+func (s *Signer) Sign(checkpoint *types.AuditCheckpoint) {
+	checkpoint.PublicKey = s.publicKey
+	checkpoint.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, Message(checkpoint)))
+}
+
+// Verify checks that a checkpoint was signed by this signer and not changed since
+func (s *Signer) Verify(checkpoint *types.AuditCheckpoint) error {
+	if checkpoint.PublicKey != s.publicKey {
+		return ErrInvalidSignature
+	}
+	return Verify(s.publicKey, checkpoint)
+}
+
+// Verify checks a checkpoint's signature against a base64 Ed25519 public key.
+// Verifiers should pin the key instead of trusting the checkpoint's PublicKey.
+func Verify(publicKey string, checkpoint *types.AuditCheckpoint) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return ErrInvalidSignature
+	}
+	signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), Message(checkpoint), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Message returns the bytes a checkpoint's signature covers: a header line, the organization ID,
+// the creation time in RFC 3339 UTC and a "<resource_type> <sequence> <hash>" line per chain,
+// each line ending in a newline
+func Message(checkpoint *types.AuditCheckpoint) []byte {
+	var b strings.Builder
+	b.WriteString(messageHeader + "\n")
+	b.WriteString(checkpoint.OrganizationID + "\n")
+	b.WriteString(checkpoint.CreatedAt.UTC().Format(time.RFC3339Nano) + "\n")
+	for _, chain := range checkpoint.Chains {
+		b.WriteString(string(chain.ResourceType) + " " + strconv.FormatInt(chain.Sequence, 10) + " " + chain.Hash + "\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,75 @@
+package checkpoint
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+func newTestSigner(t *testing.T, fill byte) *Signer {
+	signer, err := NewSigner(bytes.Repeat([]byte{fill}, 32))
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func newTestCheckpoint() *types.AuditCheckpoint {
+	return &types.AuditCheckpoint{
+		OrganizationID: "org-1",
+		CreatedAt:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Chains: []types.AuditCheckpointHead{
+			{ResourceType: types.AuditResourceServiceKey, Sequence: 3, Hash: "abc"},
+			{ResourceType: types.AuditResourceApiKey, Sequence: 0, Hash: ""},
+		},
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer := newTestSigner(t, 1)
+
+	checkpoint := newTestCheckpoint()
+	signer.Sign(checkpoint)
+	if checkpoint.PublicKey != signer.PublicKey() || checkpoint.Signature == "" {
+		t.Fatalf("expected the checkpoint to carry the key and a signature, got %+v", checkpoint)
+	}
+	if err := signer.Verify(checkpoint); err != nil {
+		t.Errorf("expected a valid checkpoint: %v", err)
+	}
+	if err := Verify(signer.PublicKey(), checkpoint); err != nil {
+		t.Errorf("expected a valid checkpoint: %v", err)
+	}
+
+	changed := *checkpoint
+	changed.Chains = []types.AuditCheckpointHead{{ResourceType: types.AuditResourceServiceKey, Sequence: 2, Hash: "abc"}}
+	if err := signer.Verify(&changed); err == nil {
+		t.Errorf("expected a changed checkpoint to be rejected")
+	}
+
+	other := newTestSigner(t, 2)
+	if err := other.Verify(checkpoint); err == nil {
+		t.Errorf("expected a checkpoint signed with another key to be rejected")
+	}
+
+	// A forged checkpoint carrying its own key still fails against the pinned key
+	forged := newTestCheckpoint()
+	other.Sign(forged)
+	if err := Verify(signer.PublicKey(), forged); err == nil {
+		t.Errorf("expected a forged checkpoint to be rejected")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	want := "baluster-audit-checkpoint-v1\norg-1\n2026-01-02T03:04:05Z\nservice_key 3 abc\napi_key 0 \n"
+	if got := string(Message(newTestCheckpoint())); got != want {
+		t.Errorf("expected message %q, got %q", want, got)
+	}
+}
+
+func TestNewSignerRejectsShortSeed(t *testing.T) {
+	if _, err := NewSigner([]byte("short")); err == nil {
+		t.Errorf("expected a short seed to be rejected")
+	}
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// AccessRecorder records access validation decisions, Record must not block the request
+type AccessRecorder interface {
+	Record(log *types.AccessLog)
+}
+
+// NewAccessLog returns the access log record of a ValidateAccess decision.
+// The calling API key and client IP are taken from ctx.
+func NewAccessLog(ctx context.Context, organizationID string, output *ValidateAccessOutput) *types.AccessLog {
+	log := &types.AccessLog{
+		ID:              storage.GenerateID(),
+		EntityType:      "access_log",
+		OrganizationID:  organizationID,
+		ServiceKeyID:    output.ServiceKeyID,
+		ApplicationID:   output.ApplicationID,
+		ApplicationName: output.ApplicationName,
+		Allowed:         output.Valid,
+		DenialReason:    string(output.DenialReason),
+		ClientIP:        storage.GetRequestMetadata(ctx).ClientIP,
+		CreatedAt:       time.Now(),
+	}
+	log.PartitionKey = log.GetPartitionKey()
+	if apiKey, ok := auth.GetApiKey(ctx); ok {
+		log.ApiKeyID = apiKey.ID
+	}
+	return log
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -16,9 +17,11 @@ type ApiKeyCreator interface {
 }
 
 type CreateApiKeyInput struct {
-	ApplicationID string
-	Name         string
-	ExpiresAt    *time.Time
+	ApplicationID  string
+	Name           string
+	ExpiresAt      *time.Time
+	Scopes         []types.ApiKeyScope // Defaults to access:validate
+	ApplicationIDs []string            // Applications the key may query besides ApplicationID
 }
 
 type CreateApiKeyOutput struct {
@@ -26,8 +29,8 @@ type CreateApiKeyOutput struct {
 	TokenValue string
 }
 
-// CreateApiKey creates a new API key
-func CreateApiKey(ctx context.Context, repo ApiKeyCreator, input *CreateApiKeyInput) (*CreateApiKeyOutput, error) {
+// CreateApiKey creates a new API key, its applications must belong to the caller's organization
+func CreateApiKey(ctx context.Context, repo ApiKeyCreator, appRepo ApplicationFinder, input *CreateApiKeyInput) (*CreateApiKeyOutput, error) {
 	userID, githubID, username, ok := auth.GetUserInfo(ctx)
 	if !ok {
 		return nil, ErrUserInfoNotFound
@@ -38,6 +41,14 @@ func CreateApiKey(ctx context.Context, repo ApiKeyCreator, input *CreateApiKeyIn
 		return nil, fmt.Errorf("organization ID not found in context")
 	}
 
+	if err := validateApiKeyScopes(input.Scopes); err != nil {
+		return nil, err
+	}
+	applicationIDs := append([]string{input.ApplicationID}, input.ApplicationIDs...)
+	if err := validateApiKeyApplications(ctx, appRepo, orgID, applicationIDs); err != nil {
+		return nil, err
+	}
+
 	// Check API key limit (max 50)
 	count, err := repo.CountByOrganization(ctx, orgID)
 	if err != nil {
@@ -56,6 +67,8 @@ func CreateApiKey(ctx context.Context, repo ApiKeyCreator, input *CreateApiKeyIn
 		Name:              input.Name,
 		TokenValue:        tokenValue,
 		ExpiresAt:         input.ExpiresAt,
+		Scopes:            input.Scopes,
+		ApplicationIDs:    input.ApplicationIDs,
 		CreatedByUserID:   userID,
 		CreatedByGitHubID: githubID,
 		CreatedByUsername: username,
@@ -72,3 +85,26 @@ func CreateApiKey(ctx context.Context, repo ApiKeyCreator, input *CreateApiKeyIn
 		TokenValue: tokenValue,
 	}, nil
 }
+
+// validateApiKeyScopes checks that every requested scope is known
+func validateApiKeyScopes(scopes []types.ApiKeyScope) error {
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return fmt.Errorf("%w: %q", ErrInvalidApiKeyScope, scope)
+		}
+	}
+	return nil
+}
+
+// validateApiKeyApplications checks that every application exists in the organization
+func validateApiKeyApplications(ctx context.Context, appRepo ApplicationFinder, orgID string, applicationIDs []string) error {
+	for _, id := range applicationIDs {
+		if _, err := appRepo.Get(ctx, orgID, id); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return fmt.Errorf("%w: %q", ErrInvalidApiKeyApplication, id)
+			}
+			return fmt.Errorf("failed to get application %q: %w", id, err)
+		}
+	}
+	return nil
+}
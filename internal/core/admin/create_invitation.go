@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// InvitationExpiration is how long an invitation can be accepted or declined
+const InvitationExpiration = 7 * 24 * time.Hour
+
+type InvitationCreator interface {
+	Create(ctx context.Context, invitation *types.Invitation) error
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.Invitation, error)
+}
+
+// CreateInvitationInput represents the input for inviting a GitHub user to an organization
+type CreateInvitationInput struct {
+	GitHubUsername string
+	Role           types.Role
+}
+
+// CreateInvitationOutput represents the output from inviting a GitHub user
+type CreateInvitationOutput struct {
+	Invitation *types.Invitation
+}
+
+// CreateInvitation invites a GitHub user to the organization in context with a role
+func CreateInvitation(ctx context.Context, repo InvitationCreator, input *CreateInvitationInput) (*CreateInvitationOutput, error) {
+	userID, githubID, username, ok := auth.GetUserInfo(ctx)
+	if !ok {
+		return nil, ErrUserInfoNotFound
+	}
+
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	if !input.Role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+
+	invitations, err := repo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range invitations {
+		if existing.IsFor(input.GitHubUsername) && existing.Status == types.InvitationStatusPending && !existing.IsExpired() {
+			return nil, ErrInvitationExists
+		}
+	}
+
+	now := time.Now()
+	invitation := &types.Invitation{
+		ID:                storage.GenerateID(),
+		OrganizationID:    orgID,
+		GitHubUsername:    input.GitHubUsername,
+		Role:              input.Role,
+		Status:            types.InvitationStatusPending,
+		InvitedByUserID:   userID,
+		InvitedByGitHubID: githubID,
+		InvitedByUsername: username,
+		ExpiresAt:         now.Add(InvitationExpiration),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if err := repo.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	return &CreateInvitationOutput{
+		Invitation: invitation,
+	}, nil
+}
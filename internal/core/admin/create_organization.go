@@ -15,7 +15,7 @@ type OrganizationCreator interface {
 }
 
 type OrganizationMemberAdder interface {
-	AddMember(ctx context.Context, orgID, userID string) error
+	AddMember(ctx context.Context, orgID, userID string, role types.Role) error
 }
 
 type OrganizationWithMemberCreator interface {
@@ -59,12 +59,12 @@ func CreateOrganization(ctx context.Context, repo OrganizationCreator, memberRep
 		return nil, err
 	}
 
-	// Add creator as member (organization_member record in organizations container)
+	// Add creator as owner (organization_member record in organizations container)
 	// This is required - if it fails, the organization creation should fail
 	if memberRepo == nil {
 		return nil, fmt.Errorf("member repository is required")
 	}
-	if err := memberRepo.AddMember(ctx, org.ID, userID); err != nil {
+	if err := memberRepo.AddMember(ctx, org.ID, userID, types.RoleOwner); err != nil {
 		return nil, fmt.Errorf("failed to add creator as organization member: %w", err)
 	}
 
@@ -29,7 +29,7 @@ type CreateServiceKeyOutput struct {
 }
 
 // CreateServiceKey creates a new service key
-func CreateServiceKey(ctx context.Context, repo ServiceKeyCreator, input *CreateServiceKeyInput) (*CreateServiceKeyOutput, error) {
+func CreateServiceKey(ctx context.Context, repo ServiceKeyCreator, appRepo ApplicationFinder, input *CreateServiceKeyInput) (*CreateServiceKeyOutput, error) {
 	// Extract user information from context
 	userID, githubID, username, ok := auth.GetUserInfo(ctx)
 	if !ok {
@@ -50,6 +50,10 @@ func CreateServiceKey(ctx context.Context, repo ServiceKeyCreator, input *Create
 		return nil, ErrServiceKeyLimitExceeded
 	}
 
+	if err := validatePermissionGrants(ctx, appRepo, orgID, input.Applications); err != nil {
+		return nil, err
+	}
+
 	tokenValue := GenerateTokenValue()
 	serviceKey := &types.ServiceKey{
 		ID:                storage.GenerateID(),
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+	"github.com/brianfromlife/baluster/internal/webhook"
+)
+
+type WebhookCreator interface {
+	Create(ctx context.Context, webhook *types.Webhook) error
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.Webhook, error)
+}
+
+type CreateWebhookInput struct {
+	URL         string
+	Description string
+	Events      []types.WebhookEvent // Empty subscribes to every event
+}
+
+type CreateWebhookOutput struct {
+	Webhook *types.Webhook
+	Secret  string
+}
+
+// CreateWebhook registers a webhook endpoint for the organization, its host must resolve to public addresses.
+// The secret that signs its deliveries is returned only here.
+func CreateWebhook(ctx context.Context, repo WebhookCreator, resolver webhook.Resolver, input *CreateWebhookInput) (*CreateWebhookOutput, error) {
+	userID, githubID, username, ok := auth.GetUserInfo(ctx)
+	if !ok {
+		return nil, ErrUserInfoNotFound
+	}
+
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	if err := validateWebhook(ctx, resolver, input.URL, input.Events); err != nil {
+		return nil, err
+	}
+
+	// Check webhook limit (max 10)
+	webhooks, err := repo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing webhooks: %w", err)
+	}
+	if len(webhooks) >= 10 {
+		return nil, ErrWebhookLimitExceeded
+	}
+
+	secret := "whsec_" + GenerateTokenValue()
+	webhook := &types.Webhook{
+		ID:                storage.GenerateID(),
+		EntityType:        "webhook",
+		OrganizationID:    orgID,
+		URL:               input.URL,
+		Description:       input.Description,
+		Events:            input.Events,
+		Active:            true,
+		Secret:            secret,
+		CreatedByUserID:   userID,
+		CreatedByGitHubID: githubID,
+		CreatedByUsername: username,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if err := repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return &CreateWebhookOutput{
+		Webhook: webhook,
+		Secret:  secret,
+	}, nil
+}
+
+// validateWebhook checks that a webhook points to an https URL on a public address and subscribes to known events
+func validateWebhook(ctx context.Context, resolver webhook.Resolver, rawURL string, events []types.WebhookEvent) error {
+	if err := webhook.ValidateURL(ctx, resolver, rawURL); err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidWebhookURL, rawURL, err)
+	}
+	for _, event := range events {
+		if !event.IsValid() {
+			return fmt.Errorf("%w: %q", ErrInvalidWebhookEvent, event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type ApplicationDeleter interface {
+	Get(ctx context.Context, organizationID, id string) (*types.Application, error)
+	Delete(ctx context.Context, app *types.Application, userID, githubID, username string) error
+}
+
+// DeleteApplicationInput represents the input for deleting an application
+type DeleteApplicationInput struct {
+	ID     string
+	DryRun bool // When set nothing is changed, the output lists what would be
+}
+
+// DeleteApplicationOutput represents the output from deleting an application
+type DeleteApplicationOutput struct {
+	Application        *types.Application
+	DryRun             bool
+	UpdatedServiceKeys []UpdatedServiceKey
+}
+
+// UpdatedServiceKey reports a service key whose grant for a deleted application was removed
+type UpdatedServiceKey struct {
+	ServiceKeyID       string   `json:"service_key_id"`
+	ServiceKeyName     string   `json:"service_key_name"`
+	RemovedPermissions []string `json:"removed_permissions"`
+}
+
+// DeleteApplication deletes an application and removes its grants from every service key in the organization
+func DeleteApplication(ctx context.Context, repo ApplicationDeleter, serviceKeyRepo ServiceKeyGrantUpdater, input *DeleteApplicationInput) (*DeleteApplicationOutput, error) {
+	// Extract user information from context
+	userID, githubID, username, ok := auth.GetUserInfo(ctx)
+	if !ok {
+		return nil, ErrUserInfoNotFound
+	}
+
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	app, err := repo.Get(ctx, orgID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceKeys, err := serviceKeyRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service keys: %w", err)
+	}
+
+	output := &DeleteApplicationOutput{
+		Application: app,
+		DryRun:      input.DryRun,
+	}
+
+	// Grants are removed before the application so a failure never leaves a grant
+	// that a new application with the same name would pick up
+	for _, serviceKey := range serviceKeys {
+		var kept []types.ApplicationAccess
+		var removed []string
+		found := false
+		for _, access := range serviceKey.Applications {
+			if access.ApplicationID == app.ID {
+				found = true
+				removed = append(removed, access.Permissions...)
+				continue
+			}
+			kept = append(kept, access)
+		}
+		if !found {
+			continue
+		}
+
+		output.UpdatedServiceKeys = append(output.UpdatedServiceKeys, UpdatedServiceKey{
+			ServiceKeyID:       serviceKey.ID,
+			ServiceKeyName:     serviceKey.Name,
+			RemovedPermissions: removed,
+		})
+		if input.DryRun {
+			continue
+		}
+
+		serviceKey.Applications = kept
+		serviceKey.UpdatedAt = time.Now()
+		if err := serviceKeyRepo.Update(ctx, serviceKey, userID, githubID, username); err != nil {
+			return nil, fmt.Errorf("failed to remove grant from service key %s: %w", serviceKey.ID, err)
+		}
+	}
+
+	if input.DryRun {
+		return output, nil
+	}
+
+	if err := repo.Delete(ctx, app, userID, githubID, username); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+)
+
+type WebhookDeleter interface {
+	Delete(ctx context.Context, organizationID, id string) error
+}
+
+// DeleteWebhookInput represents the input for deleting a webhook
+type DeleteWebhookInput struct {
+	ID string
+}
+
+// DeleteWebhook deletes a webhook, the log of its deliveries is kept
+func DeleteWebhook(ctx context.Context, repo WebhookDeleter, input *DeleteWebhookInput) error {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return fmt.Errorf("organization ID not found in context")
+	}
+
+	return repo.Delete(ctx, orgID, input.ID)
+}
@@ -11,4 +11,41 @@ var (
 	ErrServiceKeyLimitExceeded = errors.New("maximum number of service keys (50) reached")
 	// ErrApiKeyLimitExceeded is returned when the maximum number of API keys (50) is reached
 	ErrApiKeyLimitExceeded = errors.New("maximum number of API keys (50) reached")
+	// ErrInvalidApiKeyScope is returned when an API key scope is not one of access:validate, service_keys:watch or admin
+	ErrInvalidApiKeyScope = errors.New("invalid API key scope")
+	// ErrInvalidApiKeyApplication is returned when an API key names an application that does not exist in its organization
+	ErrInvalidApiKeyApplication = errors.New("invalid API key application")
+	// ErrApiKeyRevoked is returned when rotating or revoking an API key that was already revoked
+	ErrApiKeyRevoked = errors.New("API key has been revoked")
+	// ErrInvalidPermissionGrant is returned, wrapped in an InvalidGrantsError listing every invalid grant, when a service key
+	// grants access to an application missing from the organization, names it wrongly, or grants a permission it does not declare
+	ErrInvalidPermissionGrant = errors.New("invalid permission grant")
+	// ErrInvalidGracePeriod is returned when a service key rotation asks for a negative grace period
+	ErrInvalidGracePeriod = errors.New("grace period must not be negative")
+	// ErrInvalidRole is returned when a role is not one of owner, admin, developer or viewer
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrInvitationExists is returned when the user already has a pending invitation to the organization
+	ErrInvitationExists = errors.New("user already has a pending invitation")
+	// ErrInvitationNotFound is returned when an invitation does not exist or was sent to another user
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationNotPending is returned when an invitation was already accepted or declined
+	ErrInvitationNotPending = errors.New("invitation is no longer pending")
+	// ErrInvitationExpired is returned when an invitation is answered after it expired
+	ErrInvitationExpired = errors.New("invitation has expired")
+	// ErrAlreadyMember is returned when the user is already a member of the organization
+	ErrAlreadyMember = errors.New("user is already a member of this organization")
+	// ErrMemberNotFound is returned when the user is not a member of the organization
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrLastOwner is returned when removing the only owner of an organization
+	ErrLastOwner = errors.New("cannot remove the last owner of an organization")
+	// ErrWebhookLimitExceeded is returned when the maximum number of webhooks (10) is reached
+	ErrWebhookLimitExceeded = errors.New("maximum number of webhooks (10) reached")
+	// ErrInvalidWebhookURL is returned when a webhook URL is not an absolute https URL on a public address
+	ErrInvalidWebhookURL = errors.New("invalid webhook URL")
+	// ErrInvalidWebhookEvent is returned when a webhook subscribes to an unknown event
+	ErrInvalidWebhookEvent = errors.New("invalid webhook event")
+	// ErrInvalidAuditFilter is returned when an audit log search names an unknown resource type or action, or an empty time range
+	ErrInvalidAuditFilter = errors.New("invalid audit log filter")
+	// ErrInvalidCheckpoint is returned when an audit checkpoint was not signed by this server, was changed or belongs to another organization
+	ErrInvalidCheckpoint = errors.New("invalid audit checkpoint")
 )
@@ -3,6 +3,7 @@ package admin
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/brianfromlife/baluster/internal/auth"
 	"github.com/brianfromlife/baluster/internal/types"
@@ -13,6 +14,20 @@ type ServiceKeyGetter interface {
 	GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error)
 }
 
+type ServiceKeyUsageGetter interface {
+	GetUsage(ctx context.Context, organizationID, serviceKeyID string) (*types.ServiceKeyUsage, error)
+}
+
+type ServiceKeyAccessLogLister interface {
+	ListByServiceKey(ctx context.Context, organizationID, serviceKeyID string, before time.Time, limit int) ([]*types.AccessLog, error)
+}
+
+// Access log records returned when no limit is given, and the most that can be asked for
+const (
+	defaultAccessLogLimit = 100
+	maxAccessLogLimit     = 1000
+)
+
 // GetServiceKeyInput represents the input for getting a service key
 type GetServiceKeyInput struct {
 	ID string
@@ -21,10 +36,11 @@ type GetServiceKeyInput struct {
 // GetServiceKeyOutput represents the output from getting a service key
 type GetServiceKeyOutput struct {
 	ServiceKey *types.ServiceKey
+	Usage      *types.ServiceKeyUsage
 }
 
-// GetServiceKey retrieves a service key by ID (without token value)
-func GetServiceKey(ctx context.Context, repo ServiceKeyGetter, input *GetServiceKeyInput) (*GetServiceKeyOutput, error) {
+// GetServiceKey retrieves a service key by ID (without token value) along with how often it was validated
+func GetServiceKey(ctx context.Context, repo ServiceKeyGetter, usageRepo ServiceKeyUsageGetter, input *GetServiceKeyInput) (*GetServiceKeyOutput, error) {
 	orgID, ok := auth.GetOrganizationID(ctx)
 	if !ok {
 		return nil, fmt.Errorf("organization ID not found in context")
@@ -35,8 +51,14 @@ func GetServiceKey(ctx context.Context, repo ServiceKeyGetter, input *GetService
 		return nil, err
 	}
 
+	usage, err := usageRepo.GetUsage(ctx, orgID, serviceKey.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &GetServiceKeyOutput{
 		ServiceKey: serviceKey,
+		Usage:      usage,
 	}, nil
 }
 
@@ -66,3 +88,39 @@ func GetServiceKeyHistory(ctx context.Context, repo ServiceKeyGetter, input *Get
 		History: history,
 	}, nil
 }
+
+// ListServiceKeyAccessLogInput represents the input for listing the access validation decisions about a service key
+type ListServiceKeyAccessLogInput struct {
+	ID     string
+	Before time.Time // Only decisions made before this time, zero for the latest
+	Limit  int       // Defaults to 100, at most 1000
+}
+
+// ListServiceKeyAccessLogOutput represents the output from listing a service key's access log
+type ListServiceKeyAccessLogOutput struct {
+	AccessLog []*types.AccessLog
+}
+
+// ListServiceKeyAccessLog lists the access validation decisions made about a service key, newest first.
+// Decisions are kept after the key is deleted.
+func ListServiceKeyAccessLog(ctx context.Context, repo ServiceKeyAccessLogLister, input *ListServiceKeyAccessLogInput) (*ListServiceKeyAccessLogOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultAccessLogLimit
+	}
+	limit = min(limit, maxAccessLogLimit)
+
+	accessLog, err := repo.ListByServiceKey(ctx, orgID, input.ID, input.Before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListServiceKeyAccessLogOutput{
+		AccessLog: accessLog,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// Deliveries listed when no limit is given, and the most that can be asked for
+const (
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 100
+)
+
+type WebhookGetter interface {
+	Get(ctx context.Context, organizationID, id string) (*types.Webhook, error)
+}
+
+type WebhookDeliveryLister interface {
+	Get(ctx context.Context, organizationID, id string) (*types.Webhook, error)
+	ListDeliveries(ctx context.Context, organizationID, webhookID string, limit int) ([]*types.WebhookDelivery, error)
+}
+
+// GetWebhookInput represents the input for getting a webhook
+type GetWebhookInput struct {
+	ID string
+}
+
+// GetWebhookOutput represents the output from getting a webhook
+type GetWebhookOutput struct {
+	Webhook *types.Webhook
+}
+
+// GetWebhook retrieves a webhook by ID
+func GetWebhook(ctx context.Context, repo WebhookGetter, input *GetWebhookInput) (*GetWebhookOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	webhook, err := repo.Get(ctx, orgID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetWebhookOutput{
+		Webhook: webhook,
+	}, nil
+}
+
+// ListWebhookDeliveriesInput represents the input for listing a webhook's deliveries
+type ListWebhookDeliveriesInput struct {
+	ID    string
+	Limit int // Defaults to 50, at most 100
+}
+
+// ListWebhookDeliveriesOutput represents the output from listing a webhook's deliveries
+type ListWebhookDeliveriesOutput struct {
+	Deliveries []*types.WebhookDelivery
+}
+
+// ListWebhookDeliveries lists the latest deliveries made to a webhook, newest first
+func ListWebhookDeliveries(ctx context.Context, repo WebhookDeliveryLister, input *ListWebhookDeliveriesInput) (*ListWebhookDeliveriesOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	// The webhook must belong to the organization
+	if _, err := repo.Get(ctx, orgID, input.ID); err != nil {
+		return nil, err
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryLimit
+	}
+	limit = min(limit, maxWebhookDeliveryLimit)
+
+	deliveries, err := repo.ListDeliveries(ctx, orgID, input.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListWebhookDeliveriesOutput{
+		Deliveries: deliveries,
+	}, nil
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/brianfromlife/baluster/internal/auth"
@@ -32,7 +33,9 @@ func GitHubOAuth(ctx context.Context, githubOAuth *oauth2.Config, stateCache *au
 	}
 
 	// Store state with 10 minute expiration
-	stateCache.Set(state, time.Now().Add(10*time.Minute))
+	if err := stateCache.Set(ctx, state, time.Now().Add(10*time.Minute)); err != nil {
+		return nil, fmt.Errorf("failed to store state: %w", err)
+	}
 
 	authURL := githubOAuth.AuthCodeURL(state, oauth2.AccessTypeOnline)
 	return &GitHubOAuthOutput{
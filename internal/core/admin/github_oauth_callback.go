@@ -27,7 +27,10 @@ type GitHubOAuthCallbackOutput struct {
 }
 
 func GitHubOAuthCallback(ctx context.Context, githubOAuth *oauth2.Config, stateCache *auth.StateCache, jwtConfig auth.JWTConfig, userRepo UserStore, input *GitHubOAuthCallbackInput) (*GitHubOAuthCallbackOutput, error) {
-	valid := stateCache.GetAndDelete(input.State)
+	valid, err := stateCache.GetAndDelete(ctx, input.State)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check state: %w", err)
+	}
 	if !valid {
 		return nil, fmt.Errorf("invalid state")
 	}
@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type InvitationLister interface {
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.Invitation, error)
+}
+
+type UserInvitationLister interface {
+	ListByUsername(ctx context.Context, username string) ([]*types.Invitation, error)
+}
+
+// ListInvitationsInput represents the input for listing an organization's invitations
+type ListInvitationsInput struct {
+}
+
+// ListInvitationsOutput represents the output from listing invitations
+type ListInvitationsOutput struct {
+	Invitations []*types.Invitation
+}
+
+// ListInvitations lists every invitation sent by the organization in context
+func ListInvitations(ctx context.Context, repo InvitationLister, input *ListInvitationsInput) (*ListInvitationsOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	invitations, err := repo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListInvitationsOutput{
+		Invitations: invitations,
+	}, nil
+}
+
+// ListMyInvitationsInput represents the input for listing the current user's invitations
+type ListMyInvitationsInput struct {
+}
+
+// ListMyInvitations lists the pending, unexpired invitations sent to the current user
+func ListMyInvitations(ctx context.Context, repo UserInvitationLister, input *ListMyInvitationsInput) (*ListInvitationsOutput, error) {
+	username, ok := auth.GetUsername(ctx)
+	if !ok {
+		return nil, ErrUserInfoNotFound
+	}
+
+	invitations, err := repo.ListByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []*types.Invitation{}
+	for _, invitation := range invitations {
+		if invitation.Status == types.InvitationStatusPending && !invitation.IsExpired() {
+			pending = append(pending, invitation)
+		}
+	}
+
+	return &ListInvitationsOutput{
+		Invitations: pending,
+	}, nil
+}
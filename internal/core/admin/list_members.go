@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type MemberLister interface {
+	ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error)
+}
+
+// ListMembersInput represents the input for listing organization members
+type ListMembersInput struct {
+}
+
+// ListMembersOutput represents the output from listing organization members
+type ListMembersOutput struct {
+	Members []*types.OrganizationMember
+}
+
+// ListMembers lists the members of the organization in context
+func ListMembers(ctx context.Context, repo MemberLister, input *ListMembersInput) (*ListMembersOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	members, err := repo.ListMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Members recorded before roles existed are reported with their effective role
+	for _, member := range members {
+		member.Role = member.GetRole()
+	}
+
+	return &ListMembersOutput{
+		Members: members,
+	}, nil
+}
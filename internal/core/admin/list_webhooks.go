@@ -0,0 +1,39 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type WebhookLister interface {
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.Webhook, error)
+}
+
+// ListWebhooksInput represents the input for listing webhooks
+type ListWebhooksInput struct {
+}
+
+// ListWebhooksOutput represents the output from listing webhooks
+type ListWebhooksOutput struct {
+	Webhooks []*types.Webhook
+}
+
+// ListWebhooks lists webhooks for an organization
+func ListWebhooks(ctx context.Context, repo WebhookLister, input *ListWebhooksInput) (*ListWebhooksOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	webhooks, err := repo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListWebhooksOutput{
+		Webhooks: webhooks,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// ApplicationFinder looks up the applications a service key is granted access to
+type ApplicationFinder interface {
+	Get(ctx context.Context, organizationID, id string) (*types.Application, error)
+}
+
+// ServiceKeyGrantUpdater finds and updates the service keys granted access to an application
+type ServiceKeyGrantUpdater interface {
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.ServiceKey, error)
+	Update(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error
+}
+
+// InvalidGrantReason explains why a service key grant does not match its application
+type InvalidGrantReason string
+
+const (
+	InvalidGrantApplicationNotFound     InvalidGrantReason = "application_not_found"
+	InvalidGrantApplicationNameMismatch InvalidGrantReason = "application_name_mismatch"
+	InvalidGrantPermissionNotDeclared   InvalidGrantReason = "permission_not_declared"
+)
+
+// InvalidGrant describes a single grant of a service key that does not match its application
+type InvalidGrant struct {
+	ApplicationID   string             `json:"application_id"`
+	ApplicationName string             `json:"application_name"`
+	Permission      string             `json:"permission,omitempty"` // Empty when the whole grant is invalid
+	Reason          InvalidGrantReason `json:"reason"`
+}
+
+// InvalidGrantsError lists every invalid grant of a service key.
+// It matches ErrInvalidPermissionGrant with errors.Is.
+type InvalidGrantsError struct {
+	Grants []InvalidGrant
+}
+
+func (e *InvalidGrantsError) Error() string {
+	details := make([]string, 0, len(e.Grants))
+	for _, grant := range e.Grants {
+		detail := fmt.Sprintf("%s on application %q", grant.Reason, grant.ApplicationID)
+		if grant.Permission != "" {
+			detail = fmt.Sprintf("%s: %q", detail, grant.Permission)
+		}
+		details = append(details, detail)
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidPermissionGrant, strings.Join(details, "; "))
+}
+
+func (e *InvalidGrantsError) Unwrap() error {
+	return ErrInvalidPermissionGrant
+}
+
+// validatePermissionGrants checks that every application a service key is granted exists in the
+// organization under the given name, and that every permission is declared by the application.
+// A wildcard grant such as orders:* must cover at least one declared permission.
+// Grants that only name the application ID get the application's current name.
+func validatePermissionGrants(ctx context.Context, appRepo ApplicationFinder, orgID string, applications []types.ApplicationAccess) error {
+	var invalid []InvalidGrant
+	for i := range applications {
+		access := &applications[i]
+		app, err := appRepo.Get(ctx, orgID, access.ApplicationID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				invalid = append(invalid, InvalidGrant{
+					ApplicationID:   access.ApplicationID,
+					ApplicationName: access.ApplicationName,
+					Reason:          InvalidGrantApplicationNotFound,
+				})
+				continue
+			}
+			return fmt.Errorf("failed to get application: %w", err)
+		}
+
+		if access.ApplicationName == "" {
+			access.ApplicationName = app.Name
+		}
+		invalid = append(invalid, checkGrant(app, *access)...)
+	}
+
+	if len(invalid) > 0 {
+		return &InvalidGrantsError{Grants: invalid}
+	}
+	return nil
+}
+
+// checkGrant compares a single grant with the application it refers to
+func checkGrant(app *types.Application, access types.ApplicationAccess) []InvalidGrant {
+	var invalid []InvalidGrant
+	if access.ApplicationName != app.Name {
+		invalid = append(invalid, InvalidGrant{
+			ApplicationID:   access.ApplicationID,
+			ApplicationName: access.ApplicationName,
+			Reason:          InvalidGrantApplicationNameMismatch,
+		})
+	}
+
+	for _, grant := range access.Permissions {
+		declared := slices.ContainsFunc(app.Permissions, func(permission string) bool {
+			return types.MatchPermission(grant, permission)
+		})
+		if !declared {
+			invalid = append(invalid, InvalidGrant{
+				ApplicationID:   access.ApplicationID,
+				ApplicationName: access.ApplicationName,
+				Permission:      grant,
+				Reason:          InvalidGrantPermissionNotDeclared,
+			})
+		}
+	}
+	return invalid
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type OrganizationMemberRemover interface {
+	ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error)
+	RemoveMember(ctx context.Context, orgID, userID string) error
+}
+
+// MembershipCacheInvalidator drops cached membership so role changes take effect immediately
+type MembershipCacheInvalidator interface {
+	Invalidate(orgID, userID string)
+	InvalidateOrganization(orgID string)
+}
+
+// RemoveMemberInput represents the input for removing an organization member
+type RemoveMemberInput struct {
+	UserID string
+}
+
+// RemoveMember removes a member from the organization in context and revokes their cached access
+func RemoveMember(ctx context.Context, repo OrganizationMemberRemover, cache MembershipCacheInvalidator, input *RemoveMemberInput) error {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return fmt.Errorf("organization ID not found in context")
+	}
+
+	members, err := repo.ListMembers(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	var target *types.OrganizationMember
+	owners := 0
+	for _, member := range members {
+		if member.GetRole() == types.RoleOwner {
+			owners++
+		}
+		if member.UserID == input.UserID {
+			target = member
+		}
+	}
+
+	if target == nil {
+		return ErrMemberNotFound
+	}
+	if target.GetRole() == types.RoleOwner && owners == 1 {
+		return ErrLastOwner
+	}
+
+	if err := repo.RemoveMember(ctx, orgID, input.UserID); err != nil {
+		return err
+	}
+
+	cache.InvalidateOrganization(orgID)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type ApiKeyRevoker interface {
+	Get(ctx context.Context, organizationID, id string) (*types.ApiKey, error)
+	Revoke(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error
+}
+
+// RevokeApiKeyInput represents the input for revoking an API key
+type RevokeApiKeyInput struct {
+	ID     string
+	Reason string
+}
+
+// RevokeApiKeyOutput represents the output from revoking an API key
+type RevokeApiKeyOutput struct {
+	Token *types.ApiKey
+}
+
+// RevokeApiKey revokes an API key immediately, including a previous token still in its grace period.
+// Unlike deleting, the key is kept so its record and history remain available.
+func RevokeApiKey(ctx context.Context, repo ApiKeyRevoker, input *RevokeApiKeyInput) (*RevokeApiKeyOutput, error) {
+	// Extract user information from context
+	userID, githubID, username, ok := auth.GetUserInfo(ctx)
+	if !ok {
+		return nil, ErrUserInfoNotFound
+	}
+
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	token, err := repo.Get(ctx, orgID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.IsRevoked() {
+		return nil, ErrApiKeyRevoked
+	}
+
+	now := time.Now()
+	token.RevokedAt = &now
+	token.RevokedReason = input.Reason
+	token.UpdatedAt = now
+
+	if err := repo.Revoke(ctx, token, userID, githubID, username); err != nil {
+		return nil, err
+	}
+
+	return &RevokeApiKeyOutput{
+		Token: token,
+	}, nil
+}
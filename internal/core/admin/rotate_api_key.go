@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type ApiKeyRotator interface {
+	Get(ctx context.Context, organizationID, id string) (*types.ApiKey, error)
+	Rotate(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error
+}
+
+// RotateApiKeyInput represents the input for rotating an API key
+type RotateApiKeyInput struct {
+	ID          string
+	GracePeriod time.Duration // How long the replaced token stays valid
+}
+
+// RotateApiKeyOutput represents the output from rotating an API key
+type RotateApiKeyOutput struct {
+	Token      *types.ApiKey
+	TokenValue string // The new unhashed token value (only returned on rotation)
+}
+
+// RotateApiKey issues a new token for an API key.
+// The replaced token keeps working until the grace period ends.
+func RotateApiKey(ctx context.Context, repo ApiKeyRotator, input *RotateApiKeyInput) (*RotateApiKeyOutput, error) {
+	// Extract user information from context
+	userID, githubID, username, ok := auth.GetUserInfo(ctx)
+	if !ok {
+		return nil, ErrUserInfoNotFound
+	}
+
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	if input.GracePeriod < 0 {
+		return nil, ErrInvalidGracePeriod
+	}
+
+	token, err := repo.Get(ctx, orgID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A revoked key stays revoked, rotating it would hand out a working token
+	if token.IsRevoked() {
+		return nil, ErrApiKeyRevoked
+	}
+
+	now := time.Now()
+	graceEndsAt := now.Add(input.GracePeriod)
+
+	// Only the token being replaced survives, an earlier one still in its grace period is dropped
+	tokenValue := GenerateTokenValue()
+	token.PreviousTokenValue = token.TokenValue
+	token.PreviousTokenExpiresAt = &graceEndsAt
+	token.TokenValue = tokenValue
+	token.UpdatedAt = now
+
+	if err := repo.Rotate(ctx, token, userID, githubID, username); err != nil {
+		return nil, err
+	}
+
+	return &RotateApiKeyOutput{
+		Token:      token,
+		TokenValue: tokenValue,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type ServiceKeyRotator interface {
+	Get(ctx context.Context, organizationID, id string) (*types.ServiceKey, error)
+	Rotate(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error
+}
+
+// RotateServiceKeyInput represents the input for rotating a service key
+type RotateServiceKeyInput struct {
+	ID          string
+	GracePeriod time.Duration // How long the replaced token stays valid
+}
+
+// RotateServiceKeyOutput represents the output from rotating a service key
+type RotateServiceKeyOutput struct {
+	ServiceKey *types.ServiceKey
+	TokenValue string // The new unhashed token value (only returned on rotation)
+}
+
+// RotateServiceKey issues a new token for a service key.
+// The replaced token keeps working until the grace period ends.
+func RotateServiceKey(ctx context.Context, repo ServiceKeyRotator, input *RotateServiceKeyInput) (*RotateServiceKeyOutput, error) {
+	// Extract user information from context
+	userID, githubID, username, ok := auth.GetUserInfo(ctx)
+	if !ok {
+		return nil, ErrUserInfoNotFound
+	}
+
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	if input.GracePeriod < 0 {
+		return nil, ErrInvalidGracePeriod
+	}
+
+	serviceKey, err := repo.Get(ctx, orgID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	graceEndsAt := now.Add(input.GracePeriod)
+
+	// Only the token being replaced survives, an earlier one still in its grace period is dropped
+	tokenValue := GenerateTokenValue()
+	serviceKey.PreviousTokenValue = serviceKey.TokenValue
+	serviceKey.PreviousTokenExpiresAt = &graceEndsAt
+	serviceKey.TokenValue = tokenValue
+	serviceKey.UpdatedAt = now
+
+	if err := repo.Rotate(ctx, serviceKey, userID, githubID, username); err != nil {
+		return nil, err
+	}
+
+	return &RotateServiceKeyOutput{
+		ServiceKey: serviceKey,
+		TokenValue: tokenValue,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/storage"
+)
+
+// Audit records returned per page when no limit is given, the most a page can hold,
+// and how many records an export reads at a time
+const (
+	defaultAuditLogLimit   = 100
+	maxAuditLogLimit       = 1000
+	auditLogExportPageSize = 500
+)
+
+type AuditSearcher interface {
+	Search(ctx context.Context, organizationID string, filter storage.AuditFilter, cursor string, limit int) ([]*storage.AuditEvent, error)
+}
+
+// SearchAuditLogInput represents the input for searching the organization's audit log
+type SearchAuditLogInput struct {
+	Filter storage.AuditFilter
+	Cursor string // The NextCursor of the previous page, empty for the first page
+	Limit  int    // Defaults to 100, at most 1000
+}
+
+// SearchAuditLogOutput represents the output from searching the audit log
+type SearchAuditLogOutput struct {
+	Events     []*storage.AuditEvent
+	NextCursor string // Empty on the last page
+}
+
+// SearchAuditLog returns a page of the organization's audit log, newest first
+func SearchAuditLog(ctx context.Context, repo AuditSearcher, input *SearchAuditLogInput) (*SearchAuditLogOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	if err := validateAuditFilter(input.Filter); err != nil {
+		return nil, err
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultAuditLogLimit
+	}
+	limit = min(limit, maxAuditLogLimit)
+
+	// Ask for one more record to learn whether there is a next page
+	events, err := repo.Search(ctx, orgID, input.Filter, input.Cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &SearchAuditLogOutput{Events: events}
+	if len(events) > limit {
+		output.Events = events[:limit]
+		output.NextCursor = events[limit-1].Cursor
+	}
+	return output, nil
+}
+
+// ExportAuditLogInput represents the input for exporting the organization's audit log
+type ExportAuditLogInput struct {
+	Filter storage.AuditFilter
+}
+
+// ExportAuditLog passes every record of the organization's audit log matching the filter to write, newest first.
+// It stops at the first error from write.
+func ExportAuditLog(ctx context.Context, repo AuditSearcher, input *ExportAuditLogInput, write func(*storage.AuditEvent) error) error {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return fmt.Errorf("organization ID not found in context")
+	}
+
+	if err := validateAuditFilter(input.Filter); err != nil {
+		return err
+	}
+
+	cursor := ""
+	for {
+		events, err := repo.Search(ctx, orgID, input.Filter, cursor, auditLogExportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := write(event); err != nil {
+				return err
+			}
+		}
+		if len(events) < auditLogExportPageSize {
+			return nil
+		}
+		cursor = events[len(events)-1].Cursor
+	}
+}
+
+// validateAuditFilter checks that a filter names known resource types and actions and a valid time range
+func validateAuditFilter(filter storage.AuditFilter) error {
+	for _, resourceType := range filter.ResourceTypes {
+		if !resourceType.IsValid() {
+			return fmt.Errorf("%w: unknown resource type %q", ErrInvalidAuditFilter, resourceType)
+		}
+	}
+	for _, action := range filter.Actions {
+		if !action.IsValid() {
+			return fmt.Errorf("%w: unknown action %q", ErrInvalidAuditFilter, action)
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidAuditFilter)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+type WebhookTestSender interface {
+	SendTest(ctx context.Context, webhook *types.Webhook) (*types.WebhookDelivery, error)
+}
+
+// SendWebhookTestEventInput represents the input for sending a test event
+type SendWebhookTestEventInput struct {
+	ID string
+}
+
+// SendWebhookTestEventOutput represents the output from sending a test event
+type SendWebhookTestEventOutput struct {
+	Delivery *types.WebhookDelivery
+}
+
+// SendWebhookTestEvent sends a webhook.test event to a webhook right away and reports how it went.
+// The event is sent even to a disabled webhook and is not retried.
+func SendWebhookTestEvent(ctx context.Context, repo WebhookGetter, sender WebhookTestSender, input *SendWebhookTestEventInput) (*SendWebhookTestEventOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	webhook, err := repo.Get(ctx, orgID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := sender.SendTest(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SendWebhookTestEventOutput{
+		Delivery: delivery,
+	}, nil
+}
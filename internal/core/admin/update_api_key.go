@@ -16,9 +16,11 @@ type ApiKeyUpdater interface {
 
 // UpdateApiKeyInput represents the input for updating an API key
 type UpdateApiKeyInput struct {
-	ID        string
-	Name      string
-	ExpiresAt *time.Time
+	ID             string
+	Name           string
+	ExpiresAt      *time.Time
+	Scopes         []types.ApiKeyScope // Left unchanged when nil
+	ApplicationIDs []string            // Left unchanged when nil
 }
 
 // UpdateApiKeyOutput represents the output from updating an API key
@@ -26,8 +28,8 @@ type UpdateApiKeyOutput struct {
 	Token *types.ApiKey
 }
 
-// UpdateApiKey updates an API key
-func UpdateApiKey(ctx context.Context, repo ApiKeyUpdater, input *UpdateApiKeyInput) (*UpdateApiKeyOutput, error) {
+// UpdateApiKey updates an API key, new applications must belong to the caller's organization
+func UpdateApiKey(ctx context.Context, repo ApiKeyUpdater, appRepo ApplicationFinder, input *UpdateApiKeyInput) (*UpdateApiKeyOutput, error) {
 	// Extract user information from context
 	userID, githubID, username, ok := auth.GetUserInfo(ctx)
 	if !ok {
@@ -39,6 +41,13 @@ func UpdateApiKey(ctx context.Context, repo ApiKeyUpdater, input *UpdateApiKeyIn
 		return nil, fmt.Errorf("organization ID not found in context")
 	}
 
+	if err := validateApiKeyScopes(input.Scopes); err != nil {
+		return nil, err
+	}
+	if err := validateApiKeyApplications(ctx, appRepo, orgID, input.ApplicationIDs); err != nil {
+		return nil, err
+	}
+
 	token, err := repo.Get(ctx, orgID, input.ID)
 	if err != nil {
 		return nil, err
@@ -48,6 +57,12 @@ func UpdateApiKey(ctx context.Context, repo ApiKeyUpdater, input *UpdateApiKeyIn
 	if input.ExpiresAt != nil {
 		token.ExpiresAt = input.ExpiresAt
 	}
+	if input.Scopes != nil {
+		token.Scopes = input.Scopes
+	}
+	if input.ApplicationIDs != nil {
+		token.ApplicationIDs = input.ApplicationIDs
+	}
 	token.UpdatedAt = time.Now()
 
 	if err := repo.Update(ctx, token, userID, githubID, username); err != nil {
@@ -24,11 +24,19 @@ type UpdateApplicationInput struct {
 
 // UpdateApplicationOutput represents the output from updating an application
 type UpdateApplicationOutput struct {
-	Application *types.Application
+	Application         *types.Application
+	AffectedServiceKeys []AffectedServiceKey // Service keys whose grants no longer match the application
+}
+
+// AffectedServiceKey reports a service key whose grants became invalid after an application update
+type AffectedServiceKey struct {
+	ServiceKeyID   string         `json:"service_key_id"`
+	ServiceKeyName string         `json:"service_key_name"`
+	InvalidGrants  []InvalidGrant `json:"invalid_grants"`
 }
 
 // UpdateApplication updates an application
-func UpdateApplication(ctx context.Context, repo ApplicationUpdater, input *UpdateApplicationInput) (*UpdateApplicationOutput, error) {
+func UpdateApplication(ctx context.Context, repo ApplicationUpdater, serviceKeyRepo ServiceKeyGrantUpdater, input *UpdateApplicationInput) (*UpdateApplicationOutput, error) {
 	// Extract user information from context
 	userID, githubID, username, ok := auth.GetUserInfo(ctx)
 	if !ok {
@@ -54,7 +62,48 @@ func UpdateApplication(ctx context.Context, repo ApplicationUpdater, input *Upda
 		return nil, err
 	}
 
+	serviceKeys, err := serviceKeyRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service keys: %w", err)
+	}
+
+	// A rename is copied into every grant for the application, so callers that validate
+	// access by name keep working. Dropped permissions are reported rather than removed,
+	// so an admin decides how to update each key.
+	var affected []AffectedServiceKey
+	for _, serviceKey := range serviceKeys {
+		renamed := false
+		var invalid []InvalidGrant
+		for i := range serviceKey.Applications {
+			access := &serviceKey.Applications[i]
+			if access.ApplicationID != app.ID {
+				continue
+			}
+			if access.ApplicationName != app.Name {
+				access.ApplicationName = app.Name
+				renamed = true
+			}
+			invalid = append(invalid, checkGrant(app, *access)...)
+		}
+
+		if renamed {
+			serviceKey.UpdatedAt = time.Now()
+			if err := serviceKeyRepo.Update(ctx, serviceKey, userID, githubID, username); err != nil {
+				return nil, fmt.Errorf("failed to rename grant of service key %s: %w", serviceKey.ID, err)
+			}
+		}
+
+		if len(invalid) > 0 {
+			affected = append(affected, AffectedServiceKey{
+				ServiceKeyID:   serviceKey.ID,
+				ServiceKeyName: serviceKey.Name,
+				InvalidGrants:  invalid,
+			})
+		}
+	}
+
 	return &UpdateApplicationOutput{
-		Application: app,
+		Application:         app,
+		AffectedServiceKeys: affected,
 	}, nil
 }
@@ -28,7 +28,7 @@ type UpdateServiceKeyOutput struct {
 }
 
 // UpdateServiceKey updates a service key
-func UpdateServiceKey(ctx context.Context, repo ServiceKeyUpdater, input *UpdateServiceKeyInput) (*UpdateServiceKeyOutput, error) {
+func UpdateServiceKey(ctx context.Context, repo ServiceKeyUpdater, appRepo ApplicationFinder, input *UpdateServiceKeyInput) (*UpdateServiceKeyOutput, error) {
 	// Extract user information from context
 	userID, githubID, username, ok := auth.GetUserInfo(ctx)
 	if !ok {
@@ -45,6 +45,10 @@ func UpdateServiceKey(ctx context.Context, repo ServiceKeyUpdater, input *Update
 		return nil, err
 	}
 
+	if err := validatePermissionGrants(ctx, appRepo, orgID, input.Applications); err != nil {
+		return nil, err
+	}
+
 	serviceKey.Name = input.Name
 	serviceKey.Applications = input.Applications
 	if input.ExpiresAt != nil {
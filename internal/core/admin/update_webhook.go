@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/types"
+	"github.com/brianfromlife/baluster/internal/webhook"
+)
+
+type WebhookUpdater interface {
+	Get(ctx context.Context, organizationID, id string) (*types.Webhook, error)
+	Update(ctx context.Context, webhook *types.Webhook) error
+}
+
+// UpdateWebhookInput represents the input for updating a webhook
+type UpdateWebhookInput struct {
+	ID          string
+	URL         string
+	Description string
+	Events      []types.WebhookEvent
+	Active      *bool // Optional, nil keeps the webhook enabled or disabled
+}
+
+// UpdateWebhookOutput represents the output from updating a webhook
+type UpdateWebhookOutput struct {
+	Webhook *types.Webhook
+}
+
+// UpdateWebhook updates a webhook, a disabled webhook receives no events
+func UpdateWebhook(ctx context.Context, repo WebhookUpdater, resolver webhook.Resolver, input *UpdateWebhookInput) (*UpdateWebhookOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	if err := validateWebhook(ctx, resolver, input.URL, input.Events); err != nil {
+		return nil, err
+	}
+
+	webhook, err := repo.Get(ctx, orgID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = input.URL
+	webhook.Description = input.Description
+	webhook.Events = input.Events
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+	webhook.UpdatedAt = time.Now()
+
+	if err := repo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return &UpdateWebhookOutput{
+		Webhook: webhook,
+	}, nil
+}
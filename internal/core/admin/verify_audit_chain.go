@@ -0,0 +1,238 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// Audit chain records read at a time during verification, and the most problems reported per chain
+const (
+	auditChainPageSize    = 500
+	maxAuditChainProblems = 100
+)
+
+type AuditChainReader interface {
+	GetChainHead(ctx context.Context, organizationID string, resourceType types.AuditResourceType) (*types.AuditChainHead, error)
+	ListChain(ctx context.Context, organizationID string, resourceType types.AuditResourceType, afterSequence int64, limit int) ([]*types.AuditHistory, error)
+}
+
+type CheckpointSigner interface {
+	Sign(checkpoint *types.AuditCheckpoint)
+	Verify(checkpoint *types.AuditCheckpoint) error
+}
+
+// AuditChainProblemKind is what verification found wrong with an audit chain
+type AuditChainProblemKind string
+
+const (
+	AuditChainMissing            AuditChainProblemKind = "missing"             // Records from Sequence to ToSequence are gone
+	AuditChainModified           AuditChainProblemKind = "modified"            // The record no longer matches its hash
+	AuditChainBrokenLink         AuditChainProblemKind = "broken_link"         // The record's previous_hash is not the hash of the record before it
+	AuditChainDuplicate          AuditChainProblemKind = "duplicate"           // Another record already has the sequence
+	AuditChainHeadMismatch       AuditChainProblemKind = "head_mismatch"       // The chain's head does not point at its last record
+	AuditChainCheckpointMismatch AuditChainProblemKind = "checkpoint_mismatch" // The record at the checkpoint's sequence is gone or has another hash
+)
+
+// AuditChainProblem is a place where an audit chain was altered
+type AuditChainProblem struct {
+	Kind       AuditChainProblemKind `json:"kind"`
+	Sequence   int64                 `json:"sequence"`
+	ToSequence int64                 `json:"to_sequence,omitempty"` // Set for missing records
+	RecordID   string                `json:"record_id,omitempty"`
+}
+
+// AuditChainReport is the result of verifying one of an organization's audit chains
+type AuditChainReport struct {
+	ResourceType      types.AuditResourceType `json:"resource_type"`
+	Valid             bool                    `json:"valid"`
+	Records           int64                   `json:"records"` // Chained records read, records written before the chain are not covered
+	HeadSequence      int64                   `json:"head_sequence"`
+	HeadHash          string                  `json:"head_hash"`
+	Problems          []AuditChainProblem     `json:"problems"`
+	ProblemsTruncated bool                    `json:"problems_truncated,omitempty"` // More than 100 problems were found
+}
+
+// VerifyAuditChainInput represents the input for verifying the organization's audit chains
+type VerifyAuditChainInput struct {
+	Checkpoint *types.AuditCheckpoint // An earlier checkpoint the chains must still contain, optional
+}
+
+// VerifyAuditChainOutput represents the output from verifying the audit chains
+type VerifyAuditChainOutput struct {
+	Valid  bool
+	Chains []*AuditChainReport
+}
+
+// VerifyAuditChain walks each of the organization's audit chains and reports records that were
+// changed, removed or reordered, and, given a checkpoint, whether the chains were rewritten since
+func VerifyAuditChain(ctx context.Context, repo AuditChainReader, signer CheckpointSigner, input *VerifyAuditChainInput) (*VerifyAuditChainOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	checkpointHeads := make(map[types.AuditResourceType]types.AuditCheckpointHead)
+	if input.Checkpoint != nil {
+		if input.Checkpoint.OrganizationID != orgID {
+			return nil, fmt.Errorf("%w: checkpoint belongs to another organization", ErrInvalidCheckpoint)
+		}
+		if err := signer.Verify(input.Checkpoint); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCheckpoint, err)
+		}
+		for _, head := range input.Checkpoint.Chains {
+			checkpointHeads[head.ResourceType] = head
+		}
+	}
+
+	output := &VerifyAuditChainOutput{Valid: true}
+	for _, resourceType := range types.AuditResourceTypes {
+		report, err := verifyChain(ctx, repo, orgID, resourceType, checkpointHeads[resourceType])
+		if err != nil {
+			return nil, err
+		}
+		output.Valid = output.Valid && report.Valid
+		output.Chains = append(output.Chains, report)
+	}
+	return output, nil
+}
+
+// verifyChain walks one audit chain in sequence order, checking every record's hash and link
+// and the chain's head, and that the record at the checkpoint's sequence still has its hash
+func verifyChain(ctx context.Context, repo AuditChainReader, orgID string, resourceType types.AuditResourceType, checkpoint types.AuditCheckpointHead) (*AuditChainReport, error) {
+	head, err := repo.GetChainHead(ctx, orgID, resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AuditChainReport{
+		ResourceType: resourceType,
+		HeadSequence: head.Sequence,
+		HeadHash:     head.Hash,
+		Problems:     []AuditChainProblem{},
+	}
+	addProblem := func(problem AuditChainProblem) {
+		if len(report.Problems) == maxAuditChainProblems {
+			report.ProblemsTruncated = true
+			return
+		}
+		report.Problems = append(report.Problems, problem)
+	}
+
+	var after, last int64
+	var previousHash string
+	checkpointFound := checkpoint.Sequence == 0
+	// IDs of the records read at the last sequence, pages overlap on it
+	readAtLast := make(map[string]bool)
+	for {
+		records, err := repo.ListChain(ctx, orgID, resourceType, after, auditChainPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		read := 0
+		for _, record := range records {
+			if record.Sequence == last && readAtLast[record.ID] {
+				continue
+			}
+			read++
+			report.Records++
+			if record.Sequence <= last {
+				readAtLast[record.ID] = true
+				addProblem(AuditChainProblem{Kind: AuditChainDuplicate, Sequence: record.Sequence, RecordID: record.ID})
+				continue
+			}
+
+			if record.Sequence > last+1 {
+				addProblem(AuditChainProblem{Kind: AuditChainMissing, Sequence: last + 1, ToSequence: record.Sequence - 1})
+			} else if record.PreviousHash != previousHash {
+				addProblem(AuditChainProblem{Kind: AuditChainBrokenLink, Sequence: record.Sequence, RecordID: record.ID})
+			}
+
+			hash, err := storage.HashAudit(record)
+			if err != nil {
+				return nil, err
+			}
+			if hash != record.Hash {
+				addProblem(AuditChainProblem{Kind: AuditChainModified, Sequence: record.Sequence, RecordID: record.ID})
+			}
+
+			if record.Sequence == checkpoint.Sequence {
+				checkpointFound = true
+				if record.Hash != checkpoint.Hash {
+					addProblem(AuditChainProblem{Kind: AuditChainCheckpointMismatch, Sequence: record.Sequence, RecordID: record.ID})
+				}
+			}
+
+			last = record.Sequence
+			previousHash = record.Hash
+			readAtLast = map[string]bool{record.ID: true}
+		}
+
+		// A page of nothing new means more than a page of records share the last sequence,
+		// which were already reported well past the problem limit
+		if len(records) < auditChainPageSize || read == 0 {
+			break
+		}
+		// The next page starts at the last sequence again, so a duplicate of it on the far side
+		// of the page boundary is still read
+		after = last - 1
+	}
+
+	switch {
+	case head.Sequence > last:
+		// Records were deleted from the end of the chain
+		addProblem(AuditChainProblem{Kind: AuditChainMissing, Sequence: last + 1, ToSequence: head.Sequence})
+	case head.Sequence < last || head.Hash != previousHash:
+		addProblem(AuditChainProblem{Kind: AuditChainHeadMismatch, Sequence: head.Sequence})
+	}
+	if !checkpointFound {
+		addProblem(AuditChainProblem{Kind: AuditChainCheckpointMismatch, Sequence: checkpoint.Sequence})
+	}
+
+	report.Valid = len(report.Problems) == 0
+	return report, nil
+}
+
+// CreateAuditCheckpointInput represents the input for creating an audit checkpoint
+type CreateAuditCheckpointInput struct {
+}
+
+// CreateAuditCheckpointOutput represents the output from creating an audit checkpoint
+type CreateAuditCheckpointOutput struct {
+	Checkpoint *types.AuditCheckpoint
+}
+
+// CreateAuditCheckpoint signs the current heads of the organization's audit chains.
+// Kept outside Baluster, the checkpoint can later be passed to VerifyAuditChain.
+func CreateAuditCheckpoint(ctx context.Context, repo AuditChainReader, signer CheckpointSigner, input *CreateAuditCheckpointInput) (*CreateAuditCheckpointOutput, error) {
+	orgID, ok := auth.GetOrganizationID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("organization ID not found in context")
+	}
+
+	checkpoint := &types.AuditCheckpoint{
+		OrganizationID: orgID,
+		CreatedAt:      time.Now().UTC(),
+	}
+	for _, resourceType := range types.AuditResourceTypes {
+		head, err := repo.GetChainHead(ctx, orgID, resourceType)
+		if err != nil {
+			return nil, err
+		}
+		checkpoint.Chains = append(checkpoint.Chains, types.AuditCheckpointHead{
+			ResourceType: resourceType,
+			Sequence:     head.Sequence,
+			Hash:         head.Hash,
+		})
+	}
+	signer.Sign(checkpoint)
+
+	return &CreateAuditCheckpointOutput{
+		Checkpoint: checkpoint,
+	}, nil
+}
@@ -0,0 +1,257 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/checkpoint"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// chainRepo is an AuditChainReader holding an API key chain, paged like the storage backends
+type chainRepo struct {
+	head    *types.AuditChainHead
+	records []*types.AuditHistory
+}
+
+func (r *chainRepo) GetChainHead(ctx context.Context, organizationID string, resourceType types.AuditResourceType) (*types.AuditChainHead, error) {
+	if resourceType != types.AuditResourceApiKey {
+		return storage.NewAuditChainHead(organizationID), nil
+	}
+	head := *r.head
+	return &head, nil
+}
+
+func (r *chainRepo) ListChain(ctx context.Context, organizationID string, resourceType types.AuditResourceType, afterSequence int64, limit int) ([]*types.AuditHistory, error) {
+	if resourceType != types.AuditResourceApiKey {
+		return nil, nil
+	}
+
+	records := slices.Clone(r.records)
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Sequence != records[j].Sequence {
+			return records[i].Sequence < records[j].Sequence
+		}
+		return records[i].ID < records[j].ID
+	})
+
+	var page []*types.AuditHistory
+	for _, record := range records {
+		if record.Sequence > afterSequence && len(page) < limit {
+			page = append(page, record)
+		}
+	}
+	return page, nil
+}
+
+// append chains another record to the end of the chain
+func (r *chainRepo) append(t *testing.T, id string) *types.AuditHistory {
+	t.Helper()
+	audit := &types.AuditHistory{
+		ID:             id,
+		OrganizationID: "org-1",
+		EntityID:       "ak-1",
+		Action:         types.AuditActionUpdated,
+		CreatedAt:      time.Date(2026, 1, 1, 0, 0, len(r.records), 0, time.UTC),
+	}
+	if err := storage.ChainAudit(r.head, audit); err != nil {
+		t.Fatalf("failed to chain audit history: %v", err)
+	}
+	r.records = append(r.records, audit)
+	return audit
+}
+
+// rehash recomputes the hashes from a record to the end of the chain, as someone rewriting it would
+func (r *chainRepo) rehash(t *testing.T, from int) {
+	t.Helper()
+	r.head.Sequence = r.records[from].Sequence - 1
+	r.head.Hash = r.records[from].PreviousHash
+	for _, record := range r.records[from:] {
+		if err := storage.ChainAudit(r.head, record); err != nil {
+			t.Fatalf("failed to chain audit history: %v", err)
+		}
+	}
+}
+
+func newChainRepo(t *testing.T, n int) *chainRepo {
+	repo := &chainRepo{head: storage.NewAuditChainHead("org-1")}
+	for i := range n {
+		repo.append(t, fmt.Sprintf("ah-%04d", i+1))
+	}
+	return repo
+}
+
+func newTestSigner(t *testing.T) *checkpoint.Signer {
+	signer, err := checkpoint.NewSigner(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func orgContext(orgID string) context.Context {
+	return context.WithValue(context.Background(), auth.OrganizationIDKey, orgID)
+}
+
+// apiKeyReport verifies the chains and returns the API key chain's report
+func apiKeyReport(t *testing.T, repo *chainRepo, cp *types.AuditCheckpoint) *AuditChainReport {
+	t.Helper()
+	output, err := VerifyAuditChain(orgContext("org-1"), repo, newTestSigner(t), &VerifyAuditChainInput{Checkpoint: cp})
+	if err != nil {
+		t.Fatalf("failed to verify audit chain: %v", err)
+	}
+	for _, report := range output.Chains {
+		if report.ResourceType == types.AuditResourceApiKey {
+			if output.Valid != report.Valid {
+				t.Errorf("expected the output to be as valid as the API key chain")
+			}
+			return report
+		}
+	}
+	t.Fatalf("expected an API key chain report")
+	return nil
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, repo *chainRepo)
+		want   []AuditChainProblem
+	}{
+		{
+			name:   "intact chain",
+			tamper: func(t *testing.T, repo *chainRepo) {},
+		},
+		{
+			name: "modified record",
+			tamper: func(t *testing.T, repo *chainRepo) {
+				repo.records[2].CreatedByUsername = "someone-else"
+			},
+			want: []AuditChainProblem{{Kind: AuditChainModified, Sequence: 3, RecordID: "ah-0003"}},
+		},
+		{
+			name: "missing record",
+			tamper: func(t *testing.T, repo *chainRepo) {
+				repo.records = slices.Delete(repo.records, 1, 3)
+			},
+			want: []AuditChainProblem{{Kind: AuditChainMissing, Sequence: 2, ToSequence: 3}},
+		},
+		{
+			name: "missing records at the end",
+			tamper: func(t *testing.T, repo *chainRepo) {
+				repo.records = repo.records[:3]
+			},
+			want: []AuditChainProblem{{Kind: AuditChainMissing, Sequence: 4, ToSequence: 5}},
+		},
+		{
+			name: "broken previous hash link",
+			tamper: func(t *testing.T, repo *chainRepo) {
+				// The record is rehashed so only its link to the record before it is wrong
+				repo.records[3].PreviousHash = repo.records[1].Hash
+				hash, err := storage.HashAudit(repo.records[3])
+				if err != nil {
+					t.Fatalf("failed to hash audit history: %v", err)
+				}
+				repo.records[3].Hash = hash
+				repo.records[4].PreviousHash = hash
+				repo.rehash(t, 4)
+			},
+			want: []AuditChainProblem{{Kind: AuditChainBrokenLink, Sequence: 4, RecordID: "ah-0004"}},
+		},
+		{
+			name: "head moved",
+			tamper: func(t *testing.T, repo *chainRepo) {
+				repo.head.Hash = repo.records[3].Hash
+			},
+			want: []AuditChainProblem{{Kind: AuditChainHeadMismatch, Sequence: 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newChainRepo(t, 5)
+			tt.tamper(t, repo)
+
+			report := apiKeyReport(t, repo, nil)
+			if report.Valid != (len(tt.want) == 0) || !slices.Equal(report.Problems, tt.want) {
+				t.Errorf("expected problems %+v, got valid %v with %+v", tt.want, report.Valid, report.Problems)
+			}
+		})
+	}
+}
+
+func TestVerifyAuditChainDuplicateAcrossPages(t *testing.T) {
+	repo := newChainRepo(t, auditChainPageSize+1)
+
+	// A second record at the last sequence of the first page only comes back on the next page
+	duplicate := *repo.records[auditChainPageSize-1]
+	duplicate.ID += "-copy"
+	repo.records = append(repo.records, &duplicate)
+
+	report := apiKeyReport(t, repo, nil)
+	want := []AuditChainProblem{{Kind: AuditChainDuplicate, Sequence: auditChainPageSize, RecordID: duplicate.ID}}
+	if !slices.Equal(report.Problems, want) {
+		t.Errorf("expected problems %+v, got %+v", want, report.Problems)
+	}
+	if report.Records != auditChainPageSize+2 {
+		t.Errorf("expected every record to be read once, got %d", report.Records)
+	}
+}
+
+func TestVerifyAuditChainCheckpoint(t *testing.T) {
+	ctx := orgContext("org-1")
+	repo := newChainRepo(t, 3)
+
+	output, err := CreateAuditCheckpoint(ctx, repo, newTestSigner(t), &CreateAuditCheckpointInput{})
+	if err != nil {
+		t.Fatalf("failed to create checkpoint: %v", err)
+	}
+	cp := output.Checkpoint
+
+	// The chain keeps growing past the checkpoint
+	repo.append(t, "ah-0004")
+	if report := apiKeyReport(t, repo, cp); !report.Valid {
+		t.Errorf("expected a grown chain to match its checkpoint, got %+v", report.Problems)
+	}
+
+	// Rewriting the chain consistently passes every other check, but not the checkpoint
+	repo.records[1].CreatedByUsername = "someone-else"
+	repo.rehash(t, 1)
+	if report := apiKeyReport(t, repo, nil); !report.Valid {
+		t.Fatalf("expected a consistent rewrite to verify without a checkpoint, got %+v", report.Problems)
+	}
+	report := apiKeyReport(t, repo, cp)
+	want := []AuditChainProblem{{Kind: AuditChainCheckpointMismatch, Sequence: 3, RecordID: "ah-0003"}}
+	if !slices.Equal(report.Problems, want) {
+		t.Errorf("expected problems %+v, got %+v", want, report.Problems)
+	}
+
+	// Records at or before the checkpoint can no longer be removed either
+	repo.records = repo.records[:2]
+	repo.head.Sequence, repo.head.Hash = 2, repo.records[1].Hash
+	report = apiKeyReport(t, repo, cp)
+	want = []AuditChainProblem{{Kind: AuditChainCheckpointMismatch, Sequence: 3}}
+	if !slices.Equal(report.Problems, want) {
+		t.Errorf("expected problems %+v, got %+v", want, report.Problems)
+	}
+
+	tampered := *cp
+	tampered.Chains = slices.Clone(cp.Chains)
+	tampered.Chains[1].Sequence = 1
+	other := *cp
+	other.OrganizationID = "org-2"
+	for _, invalid := range []*types.AuditCheckpoint{&tampered, &other} {
+		_, err := VerifyAuditChain(ctx, repo, newTestSigner(t), &VerifyAuditChainInput{Checkpoint: invalid})
+		if !errors.Is(err, ErrInvalidCheckpoint) {
+			t.Errorf("expected ErrInvalidCheckpoint, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// MaxBatchValidateAccessChecks is the maximum number of checks in one batch
+const MaxBatchValidateAccessChecks = 100
+
+// ErrTooManyChecks is returned when a batch holds more than MaxBatchValidateAccessChecks checks
+var ErrTooManyChecks = fmt.Errorf("a batch may hold at most %d checks", MaxBatchValidateAccessChecks)
+
+// ErrNoChecks is returned when a batch holds no checks
+var ErrNoChecks = errors.New("a batch must hold at least one check")
+
+type AccessCheck struct {
+	Token           string
+	ApplicationID   string // Identifies the application, takes precedence over ApplicationName
+	ApplicationName string
+	Permission      string // Optional, when set the key must also have this permission
+}
+
+type BatchValidateAccessInput struct {
+	OrganizationID string
+	Checks         []AccessCheck
+}
+
+type BatchValidateAccessOutput struct {
+	Results []*ValidateAccessOutput // In the same order as the checks
+}
+
+// BatchValidateAccess runs ValidateAccess for every check,
+// looking up each distinct token only once
+func BatchValidateAccess(ctx context.Context, serviceKeyRepo ServiceKeyTokenFinder, input *BatchValidateAccessInput) (*BatchValidateAccessOutput, error) {
+	if len(input.Checks) == 0 {
+		return nil, ErrNoChecks
+	}
+	if len(input.Checks) > MaxBatchValidateAccessChecks {
+		return nil, ErrTooManyChecks
+	}
+
+	finder := newCachingTokenFinder(serviceKeyRepo)
+
+	results := make([]*ValidateAccessOutput, len(input.Checks))
+	for i, check := range input.Checks {
+		output, err := ValidateAccess(ctx, finder, &ValidateAccessInput{
+			Token:           check.Token,
+			ApplicationID:   check.ApplicationID,
+			ApplicationName: check.ApplicationName,
+			OrganizationID:  input.OrganizationID,
+			Permission:      check.Permission,
+		})
+		if err != nil {
+			return nil, err
+		}
+		results[i] = output
+	}
+
+	return &BatchValidateAccessOutput{
+		Results: results,
+	}, nil
+}
+
+type tokenLookup struct {
+	serviceKey *types.ServiceKey
+	err        error
+}
+
+// cachingTokenFinder remembers lookups, including failed ones, for the duration of a batch
+type cachingTokenFinder struct {
+	ServiceKeyTokenFinder
+	lookups map[string]tokenLookup
+}
+
+func newCachingTokenFinder(finder ServiceKeyTokenFinder) *cachingTokenFinder {
+	return &cachingTokenFinder{
+		ServiceKeyTokenFinder: finder,
+		lookups:               make(map[string]tokenLookup),
+	}
+}
+
+func (f *cachingTokenFinder) FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error) {
+	key := organizationID + "\x00" + tokenValue
+	if lookup, ok := f.lookups[key]; ok {
+		return lookup.serviceKey, lookup.err
+	}
+
+	serviceKey, err := f.ServiceKeyTokenFinder.FindByTokenValueInOrg(ctx, organizationID, tokenValue)
+	f.lookups[key] = tokenLookup{serviceKey: serviceKey, err: err}
+	return serviceKey, err
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// PermissionMatch decides how many of the required permissions a service key must have
+type PermissionMatch string
+
+const (
+	// PermissionMatchAllOf requires every permission, it is the default
+	PermissionMatchAllOf PermissionMatch = "all_of"
+	// PermissionMatchAnyOf requires at least one of the permissions
+	PermissionMatchAnyOf PermissionMatch = "any_of"
+)
+
+// ErrNoPermissions is returned when a permission check names no permissions
+var ErrNoPermissions = errors.New("at least one permission is required")
+
+// ErrInvalidPermissionMatch is returned when a permission check uses an unknown match
+var ErrInvalidPermissionMatch = errors.New("match must be all_of or any_of")
+
+type CheckPermissionInput struct {
+	Token           string
+	ApplicationID   string // Identifies the application, takes precedence over ApplicationName
+	ApplicationName string // The application's current name, used when ApplicationID is empty
+	OrganizationID  string
+	Permissions     []string
+	Match           PermissionMatch // Defaults to all_of
+}
+
+type CheckPermissionOutput struct {
+	Allowed            bool
+	DenialReason       DenialReason // Empty when allowed
+	MissingPermissions []string     // Set when denied for missing permissions
+}
+
+// CheckPermission decides whether a service key has the required permissions for an application
+// and explains any denial
+func CheckPermission(ctx context.Context, serviceKeyRepo ServiceKeyTokenFinder, input *CheckPermissionInput) (*CheckPermissionOutput, error) {
+	if len(input.Permissions) == 0 {
+		return nil, ErrNoPermissions
+	}
+
+	match := input.Match
+	if match == "" {
+		match = PermissionMatchAllOf
+	}
+	if match != PermissionMatchAllOf && match != PermissionMatchAnyOf {
+		return nil, ErrInvalidPermissionMatch
+	}
+
+	_, access, denial := authorizeServiceKey(ctx, serviceKeyRepo, input.OrganizationID, input.Token, input.ApplicationID, input.ApplicationName)
+	if denial != "" {
+		return &CheckPermissionOutput{
+			Allowed:      false,
+			DenialReason: denial,
+		}, nil
+	}
+
+	var missing []string
+	for _, permission := range input.Permissions {
+		if !access.HasPermission(permission) {
+			missing = append(missing, permission)
+		}
+	}
+
+	allowed := len(missing) == 0
+	if match == PermissionMatchAnyOf {
+		allowed = len(missing) < len(input.Permissions)
+	}
+	if !allowed {
+		return &CheckPermissionOutput{
+			Allowed:            false,
+			DenialReason:       DenialReasonMissingPermission,
+			MissingPermissions: missing,
+		}, nil
+	}
+
+	return &CheckPermissionOutput{
+		Allowed: true,
+	}, nil
+}
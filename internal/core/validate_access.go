@@ -3,6 +3,8 @@ package core
 import (
 	"context"
 
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/storage"
 	"github.com/brianfromlife/baluster/internal/types"
 )
 
@@ -11,48 +13,113 @@ type ServiceKeyTokenFinder interface {
 	FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error)
 }
 
+// DenialReason explains why a service key was denied access
+type DenialReason string
+
+const (
+	DenialReasonMissingOrganization   DenialReason = "missing_organization"
+	DenialReasonWrongOrganization     DenialReason = "wrong_organization"
+	DenialReasonInvalidToken          DenialReason = "invalid_token"
+	DenialReasonExpired               DenialReason = "expired"
+	DenialReasonNoApplicationAccess   DenialReason = "no_application_access"
+	DenialReasonApplicationNotInScope DenialReason = "application_not_in_api_key_scope"
+	DenialReasonMissingPermission     DenialReason = "missing_permission"
+)
+
 type ValidateAccessInput struct {
 	Token           string
-	ApplicationName string
+	ApplicationID   string // Identifies the application, takes precedence over ApplicationName
+	ApplicationName string // The application's current name, used when ApplicationID is empty
 	OrganizationID  string
+	Permission      string // Optional, when set the key must also have this permission
 }
 
 type ValidateAccessOutput struct {
 	Valid       bool
 	Permissions []string
+
+	// What the decision was about, recorded in the access log and not returned to callers
+	ServiceKeyID    string       `json:"-"` // Empty when the token matched no service key
+	ApplicationID   string       `json:"-"`
+	ApplicationName string       `json:"-"`
+	DenialReason    DenialReason `json:"-"`
 }
 
 // ValidateAccess validates a service key for a specific application
 // and returns the permissions it has for that application
 func ValidateAccess(ctx context.Context, serviceKeyRepo ServiceKeyTokenFinder, input *ValidateAccessInput) (*ValidateAccessOutput, error) {
-	if input.OrganizationID == "" {
-		return &ValidateAccessOutput{
-			Valid: false,
-		}, nil
+	serviceKey, access, denial := authorizeServiceKey(ctx, serviceKeyRepo, input.OrganizationID, input.Token, input.ApplicationID, input.ApplicationName)
+
+	output := &ValidateAccessOutput{
+		ApplicationID:   input.ApplicationID,
+		ApplicationName: input.ApplicationName,
+		DenialReason:    denial,
+	}
+	if serviceKey != nil {
+		output.ServiceKeyID = serviceKey.ID
+	}
+	if access != nil {
+		output.ApplicationID = access.ApplicationID
+		output.ApplicationName = access.ApplicationName
+	}
+	if denial != "" {
+		return output, nil
+	}
+
+	output.Permissions = access.Permissions
+
+	if input.Permission != "" && !access.HasPermission(input.Permission) {
+		output.DenialReason = DenialReasonMissingPermission
+		return output, nil
 	}
 
-	serviceKey, err := serviceKeyRepo.FindByTokenValueInOrg(ctx, input.OrganizationID, input.Token)
+	output.Valid = true
+	return output, nil
+}
+
+// authorizeServiceKey looks up a service key and checks that it may access an application
+// on behalf of the API key that made the request. The application is resolved by ID when
+// one is given and by its current name otherwise. It returns a reason when access is denied,
+// along with the service key and grant found before the denial so it can be recorded.
+func authorizeServiceKey(ctx context.Context, serviceKeyRepo ServiceKeyTokenFinder, organizationID, token, applicationID, applicationName string) (*types.ServiceKey, *types.ApplicationAccess, DenialReason) {
+	if organizationID == "" {
+		return nil, nil, DenialReasonMissingOrganization
+	}
+
+	// The API key that made the request may only validate within its own organization
+	apiKey, hasApiKey := auth.GetApiKey(ctx)
+	if hasApiKey && apiKey.OrganizationID != organizationID {
+		return nil, nil, DenialReasonWrongOrganization
+	}
+
+	serviceKey, err := serviceKeyRepo.FindByTokenValueInOrg(ctx, organizationID, token)
 	if err != nil {
-		return &ValidateAccessOutput{
-			Valid: false,
-		}, nil
+		return nil, nil, DenialReasonInvalidToken
+	}
+
+	// After a rotation the previous token is only accepted until its grace period ends
+	if !serviceKey.AcceptsToken(storage.HashToken(token)) {
+		return nil, nil, DenialReasonInvalidToken
 	}
 
 	if serviceKey.IsExpired() {
-		return &ValidateAccessOutput{
-			Valid: false,
-		}, nil
+		return serviceKey, nil, DenialReasonExpired
 	}
 
-	access := serviceKey.HasAccessToApplication(input.ApplicationName)
+	var access *types.ApplicationAccess
+	if applicationID != "" {
+		access = serviceKey.ApplicationAccessByID(applicationID)
+	} else {
+		access = serviceKey.HasAccessToApplication(applicationName)
+	}
 	if access == nil {
-		return &ValidateAccessOutput{
-			Valid: false,
-		}, nil
+		return serviceKey, nil, DenialReasonNoApplicationAccess
+	}
+
+	// The API key that made the request may only query the applications it is scoped to
+	if hasApiKey && !apiKey.AllowsApplication(access.ApplicationID) {
+		return serviceKey, access, DenialReasonApplicationNotInScope
 	}
 
-	return &ValidateAccessOutput{
-		Valid:       true,
-		Permissions: access.Permissions,
-	}, nil
+	return serviceKey, access, ""
 }
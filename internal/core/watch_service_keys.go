@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// ServiceKeyEventLister lists the audit history of an organization's service keys
+type ServiceKeyEventLister interface {
+	ListEvents(ctx context.Context, organizationID, cursor string, limit int) ([]*storage.AuditEvent, error)
+}
+
+const (
+	// serviceKeyEventPageSize is how many audit records are read at once
+	serviceKeyEventPageSize = 100
+	// serviceKeyEventPollInterval is how often the audit history is checked for new records
+	serviceKeyEventPollInterval = time.Second
+	// serviceKeyEventSettleDelay holds back records this recent, a write ordered before them
+	// may still be committing or may come from a server whose clock is slightly behind
+	serviceKeyEventSettleDelay = 2 * time.Second
+)
+
+// ServiceKeyEvent is a change to a service key
+type ServiceKeyEvent struct {
+	Cursor            string // Resumes the watch right after this event
+	ServiceKeyID      string
+	Action            types.AuditAction // created, updated, rotated or deleted
+	ChangedAt         time.Time
+	ChangedByUsername string
+}
+
+type WatchServiceKeysInput struct {
+	OrganizationID string
+	Cursor         string // Optional, resumes after the event with this cursor instead of at the beginning
+}
+
+// WatchServiceKeys sends the changes to an organization's service keys, oldest first, until ctx is done
+// or send fails. The changes are read from the audit history written with every service key write,
+// so a watch resumed with the cursor of the last event it received misses nothing.
+func WatchServiceKeys(ctx context.Context, serviceKeyRepo ServiceKeyEventLister, input *WatchServiceKeysInput, send func(*ServiceKeyEvent) error) error {
+	cursor := input.Cursor
+	for {
+		events, err := serviceKeyRepo.ListEvents(ctx, input.OrganizationID, cursor, serviceKeyEventPageSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		settled := time.Now().Add(-serviceKeyEventSettleDelay)
+		held := false
+		for _, event := range events {
+			if event.CreatedAt.After(settled) {
+				held = true
+				break
+			}
+
+			err := send(&ServiceKeyEvent{
+				Cursor:            event.Cursor,
+				ServiceKeyID:      event.EntityID,
+				Action:            event.Action,
+				ChangedAt:         event.CreatedAt,
+				ChangedByUsername: event.CreatedByUsername,
+			})
+			if err != nil {
+				return err
+			}
+			cursor = event.Cursor
+		}
+
+		// A full page may be followed by more records, read them right away
+		if len(events) == serviceKeyEventPageSize && !held {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(serviceKeyEventPollInterval):
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestMetadata puts each request's ID, client IP and user agent in its context
+// so the audit history of the writes it makes records them.
+// It must run after chi's RequestID and RealIP middleware.
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := storage.WithRequestMetadata(r.Context(), storage.RequestMetadata{
+			RequestID: middleware.GetReqID(r.Context()),
+			ClientIP:  clientIP(r.RemoteAddr),
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientIP strips the port from a remote address, RealIP leaves addresses without one
+func clientIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"github.com/brianfromlife/baluster/internal/accesslog"
+	"github.com/brianfromlife/baluster/internal/storage"
+)
+
+// NewAccessLogRecorder starts the background writer of access validation decisions.
+// Close it after the server has shut down so the queued decisions are written.
+func NewAccessLogRecorder(repos *storage.Repositories, cfg *Config) *accesslog.Recorder {
+	return accesslog.NewRecorder(repos.AccessLogs, accesslog.Config{
+		QueueSize:     cfg.AccessLogQueueSize,
+		BatchSize:     cfg.AccessLogBatchSize,
+		FlushInterval: cfg.AccessLogFlushInterval,
+	})
+}
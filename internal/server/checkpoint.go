@@ -0,0 +1,23 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/checkpoint"
+)
+
+// NewCheckpointSigner creates the signer of audit checkpoints from AUDIT_SIGNING_KEY.
+// The key is kept apart from every other secret, so checkpoints outlive rotating them
+// and cannot be forged by whoever holds them.
+func NewCheckpointSigner(cfg *Config) (*checkpoint.Signer, error) {
+	if cfg.AuditSigningKey == "" {
+		return nil, fmt.Errorf("AUDIT_SIGNING_KEY environment variable is required")
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(cfg.AuditSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("AUDIT_SIGNING_KEY must be base64: %w", err)
+	}
+	return checkpoint.NewSigner(seed)
+}
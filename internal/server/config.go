@@ -1,52 +1,179 @@
 package server
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage/sqlstore"
+)
+
+// Supported values for STORAGE_BACKEND
+const (
+	StorageBackendCosmos   = "cosmos"
+	StorageBackendMemory   = "memory"
+	StorageBackendPostgres = "postgres"
+	StorageBackendSQLite   = "sqlite"
 )
 
 // Config holds server configuration
 type Config struct {
 	Port               string
+	StorageBackend     string
 	CosmosEndpoint     string
 	CosmosKey          string
 	CosmosDatabase     string
+	DatabaseURL        string
+	SQLitePath         string
 	JWTSecret          string
 	JWTExpiration      time.Duration
 	GitHubClientID     string
 	GitHubClientSecret string
 	GitHubRedirectURL  string
+
+	// ServiceKeyRotationGracePeriod is how long a rotated service key's previous token stays valid
+	ServiceKeyRotationGracePeriod time.Duration
+	// ApiKeyRotationGracePeriod is how long a rotated API key's previous token stays valid
+	ApiKeyRotationGracePeriod time.Duration
+
+	// TokenCacheSize is how many token lookups are cached per key type, 0 disables the cache.
+	// It needs RedisURL, without it a key changed on one server stays cached on the others.
+	TokenCacheSize int
+	// TokenCacheTTL is how long a found service key or API key is cached
+	TokenCacheTTL time.Duration
+	// TokenCacheNegativeTTL is how long an unknown token is cached
+	TokenCacheNegativeTTL time.Duration
+
+	// RedisURL points to a Redis-compatible server shared by the replicas, such as redis://localhost:6379/0.
+	// When set, OAuth states are stored there and cache invalidations are shared through it.
+	RedisURL string
+
+	// WebhookMaxAttempts is how many times a webhook delivery is tried before it is marked failed
+	WebhookMaxAttempts int
+	// WebhookRetryBackoff is the wait before the first retry, it doubles for each retry after that
+	WebhookRetryBackoff time.Duration
+	// WebhookTimeout bounds a single delivery request
+	WebhookTimeout time.Duration
+	// WebhookExpiryWindow is how long before a key expires that its expiring event is sent
+	WebhookExpiryWindow time.Duration
+	// WebhookExpiryScanInterval is how often keys are checked for expiry, 0 disables the check
+	WebhookExpiryScanInterval time.Duration
+
+	// AccessLogQueueSize is how many access decisions can wait to be written before new ones are dropped
+	AccessLogQueueSize int
+	// AccessLogBatchSize is the most access decisions written at once
+	AccessLogBatchSize int
+	// AccessLogFlushInterval is the longest an access decision waits before it is written
+	AccessLogFlushInterval time.Duration
+
+	// AuditSigningKey is the base64 Ed25519 seed audit checkpoints are signed with, required by the REST server
+	AuditSigningKey string
+
+	// MetricsPort serves the cache and access log counters, they are not served when it is empty.
+	// It must not be reachable from outside, the counters describe every organization on the server.
+	MetricsPort string
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
+	var env envParser
+	redisURL := getEnv("REDIS_URL", "")
+
+	// Token caches are only safe when their invalidations reach the other servers
+	tokenCacheSize := "0"
+	if redisURL != "" {
+		tokenCacheSize = "10000"
+	}
+
 	cfg := &Config{
 		Port:               getEnv("PORT", "8080"),
+		StorageBackend:     getEnv("STORAGE_BACKEND", StorageBackendCosmos),
 		CosmosEndpoint:     getEnv("COSMOS_ENDPOINT", ""),
 		CosmosKey:          getEnv("COSMOS_KEY", ""),
 		CosmosDatabase:     getEnv("COSMOS_DATABASE", ""),
+		DatabaseURL:        getEnv("DATABASE_URL", ""),
+		SQLitePath:         getEnv("SQLITE_PATH", "baluster.db"),
 		JWTSecret:          getEnv("JWT_SECRET", ""),
-		JWTExpiration:      parseDuration(getEnv("JWT_EXPIRATION", "24h")),
+		JWTExpiration:      env.duration("JWT_EXPIRATION", "24h"),
 		GitHubClientID:     getEnv("GITHUB_CLIENT_ID", ""),
 		GitHubClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
 		GitHubRedirectURL:  getEnv("GITHUB_REDIRECT_URL", "http://localhost:5173/auth/callback"),
+
+		ServiceKeyRotationGracePeriod: env.duration("SERVICE_KEY_ROTATION_GRACE_PERIOD", "24h"),
+		ApiKeyRotationGracePeriod:     env.duration("API_KEY_ROTATION_GRACE_PERIOD", "24h"),
+
+		TokenCacheSize:        env.int("TOKEN_CACHE_SIZE", tokenCacheSize),
+		TokenCacheTTL:         env.duration("TOKEN_CACHE_TTL", "30s"),
+		TokenCacheNegativeTTL: env.duration("TOKEN_CACHE_NEGATIVE_TTL", "5s"),
+
+		RedisURL: redisURL,
+
+		WebhookMaxAttempts:        env.int("WEBHOOK_MAX_ATTEMPTS", "5"),
+		WebhookRetryBackoff:       env.duration("WEBHOOK_RETRY_BACKOFF", "30s"),
+		WebhookTimeout:            env.duration("WEBHOOK_TIMEOUT", "10s"),
+		WebhookExpiryWindow:       env.duration("WEBHOOK_EXPIRY_WINDOW", "168h"),
+		WebhookExpiryScanInterval: env.duration("WEBHOOK_EXPIRY_SCAN_INTERVAL", "1h"),
+
+		AccessLogQueueSize:     env.int("ACCESS_LOG_QUEUE_SIZE", "10000"),
+		AccessLogBatchSize:     env.int("ACCESS_LOG_BATCH_SIZE", "100"),
+		AccessLogFlushInterval: env.duration("ACCESS_LOG_FLUSH_INTERVAL", "1s"),
+
+		AuditSigningKey: getEnv("AUDIT_SIGNING_KEY", ""),
+
+		MetricsPort: getEnv("METRICS_PORT", ""),
 	}
 
-	// Validate required Cosmos DB environment variables
-	if cfg.CosmosEndpoint == "" {
-		return nil, fmt.Errorf("COSMOS_ENDPOINT environment variable is required")
+	if env.err != nil {
+		return nil, env.err
 	}
-	if cfg.CosmosKey == "" {
-		return nil, fmt.Errorf("COSMOS_KEY environment variable is required")
+	if cfg.TokenCacheSize > 0 && cfg.RedisURL == "" {
+		return nil, fmt.Errorf("TOKEN_CACHE_SIZE needs REDIS_URL, without it keys changed on one server stay valid on the others until their cached lookups expire")
 	}
-	if cfg.CosmosDatabase == "" {
-		return nil, fmt.Errorf("COSMOS_DATABASE environment variable is required")
+
+	switch cfg.StorageBackend {
+	case StorageBackendCosmos:
+		// Validate required Cosmos DB environment variables
+		if cfg.CosmosEndpoint == "" {
+			return nil, fmt.Errorf("COSMOS_ENDPOINT environment variable is required")
+		}
+		if cfg.CosmosKey == "" {
+			return nil, fmt.Errorf("COSMOS_KEY environment variable is required")
+		}
+		if cfg.CosmosDatabase == "" {
+			return nil, fmt.Errorf("COSMOS_DATABASE environment variable is required")
+		}
+	case StorageBackendPostgres:
+		if cfg.DatabaseURL == "" {
+			return nil, fmt.Errorf("DATABASE_URL environment variable is required")
+		}
+		if err := requireDriver(sqlstore.Postgres); err != nil {
+			return nil, err
+		}
+	case StorageBackendSQLite:
+		if err := requireDriver(sqlstore.SQLite); err != nil {
+			return nil, err
+		}
+	case StorageBackendMemory:
+	default:
+		return nil, fmt.Errorf("unsupported STORAGE_BACKEND %q", cfg.StorageBackend)
 	}
 
 	return cfg, nil
 }
 
+// requireDriver checks that the dialect's database/sql driver was compiled in, since the
+// drivers are only registered in builds with the matching tag
+func requireDriver(dialect sqlstore.Dialect) error {
+	if !slices.Contains(sql.Drivers(), dialect.DriverName) {
+		return fmt.Errorf("STORAGE_BACKEND %q needs the %s driver, build with -tags %s", dialect.Name, dialect.DriverName, dialect.Name)
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -54,10 +181,23 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-func parseDuration(s string) time.Duration {
-	d, err := time.ParseDuration(s)
+// envParser reads typed environment variables, collecting an error for every malformed value
+type envParser struct {
+	err error
+}
+
+func (p *envParser) duration(key, defaultValue string) time.Duration {
+	d, err := time.ParseDuration(getEnv(key, defaultValue))
 	if err != nil {
-		return 24 * time.Hour
+		p.err = errors.Join(p.err, fmt.Errorf("invalid %s: %w", key, err))
 	}
 	return d
 }
+
+func (p *envParser) int(key, defaultValue string) int {
+	n, err := strconv.Atoi(getEnv(key, defaultValue))
+	if err != nil {
+		p.err = errors.Join(p.err, fmt.Errorf("invalid %s: %w", key, err))
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianfromlife/baluster/internal/storage/sqlstore"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		wantErr       string
+		wantCacheSize int
+	}{
+		{
+			name:          "token cache is off without Redis",
+			env:           map[string]string{},
+			wantCacheSize: 0,
+		},
+		{
+			name:          "token cache is on with Redis",
+			env:           map[string]string{"REDIS_URL": "redis://localhost:6379/0"},
+			wantCacheSize: 10000,
+		},
+		{
+			name:    "token cache cannot be turned on without Redis",
+			env:     map[string]string{"TOKEN_CACHE_SIZE": "100"},
+			wantErr: "TOKEN_CACHE_SIZE needs REDIS_URL",
+		},
+		{
+			name:    "malformed duration",
+			env:     map[string]string{"REDIS_URL": "redis://localhost:6379/0", "TOKEN_CACHE_TTL": "30 seconds"},
+			wantErr: "invalid TOKEN_CACHE_TTL",
+		},
+		{
+			name:    "malformed number",
+			env:     map[string]string{"WEBHOOK_MAX_ATTEMPTS": "five"},
+			wantErr: "invalid WEBHOOK_MAX_ATTEMPTS",
+		},
+		{
+			name:    "postgres without its driver",
+			env:     map[string]string{"STORAGE_BACKEND": StorageBackendPostgres, "DATABASE_URL": "postgres://localhost/baluster"},
+			wantErr: "build with -tags postgres",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env["STORAGE_BACKEND"] == StorageBackendPostgres && requireDriver(sqlstore.Postgres) == nil {
+				t.Skip("the postgres driver is compiled in")
+			}
+			for _, key := range []string{"REDIS_URL", "TOKEN_CACHE_SIZE", "TOKEN_CACHE_TTL", "WEBHOOK_MAX_ATTEMPTS", "DATABASE_URL"} {
+				t.Setenv(key, "")
+			}
+			t.Setenv("STORAGE_BACKEND", StorageBackendMemory)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := LoadConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if cfg.TokenCacheSize != tt.wantCacheSize {
+				t.Errorf("expected a token cache of %d entries, got %d", tt.wantCacheSize, cfg.TokenCacheSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+//go:build postgres
+
+package server
+
+// Registers the pgx database/sql driver used by the postgres storage backend.
+// Build with -tags postgres to enable STORAGE_BACKEND=postgres.
+import _ "github.com/jackc/pgx/v5/stdlib"
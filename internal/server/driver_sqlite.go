@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package server
+
+// Registers the pure Go SQLite database/sql driver used by the sqlite storage backend.
+// Build with -tags sqlite to enable STORAGE_BACKEND=sqlite.
+import _ "modernc.org/sqlite"
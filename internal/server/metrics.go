@@ -0,0 +1,20 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// NewMetricsServer serves handler on METRICS_PORT, it returns nil when the port is not set.
+// The counters it serves cover every organization on the server, so they are kept off the public port.
+func NewMetricsServer(cfg *Config, handler http.Handler) *http.Server {
+	if cfg.MetricsPort == "" {
+		return nil
+	}
+	return &http.Server{
+		Addr:         ":" + cfg.MetricsPort,
+		Handler:      handler,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+)
+
+const (
+	// redisStatePrefix namespaces the OAuth states in Redis
+	redisStatePrefix = "baluster:oauth_state:"
+	// redisInvalidationChannel is the pub/sub channel cache invalidations are shared on
+	redisInvalidationChannel = "baluster:cache_invalidations"
+)
+
+// Redis is the Redis-compatible server shared by the replicas
+type Redis struct {
+	Client      *redis.Client
+	Invalidator *auth.RedisInvalidator
+}
+
+// NewRedis connects to the server at REDIS_URL and subscribes to cache invalidations.
+// Returns nil when REDIS_URL is not set, every replica then keeps its state to itself.
+func NewRedis(ctx context.Context, cfg *Config) (*Redis, error) {
+	if cfg.RedisURL == "" {
+		return nil, nil
+	}
+
+	options, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	invalidator, err := auth.NewRedisInvalidator(ctx, client, redisInvalidationChannel)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	return &Redis{
+		Client:      client,
+		Invalidator: invalidator,
+	}, nil
+}
+
+// NewStateCache creates an OAuth state cache shared by the replicas, or one in memory without Redis
+func (r *Redis) NewStateCache() *auth.StateCache {
+	if r == nil {
+		return auth.NewStateCache()
+	}
+	return auth.NewSharedStateCache(auth.NewRedisStore(r.Client, redisStatePrefix))
+}
+
+// Close unsubscribes from cache invalidations and closes the connection
+func (r *Redis) Close() {
+	if r == nil {
+		return
+	}
+	_ = r.Invalidator.Close()
+	_ = r.Client.Close()
+}
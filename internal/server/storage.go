@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/auth"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/storage/cosmos"
+	"github.com/brianfromlife/baluster/internal/storage/memory"
+	"github.com/brianfromlife/baluster/internal/storage/sqlstore"
+)
+
+// NewRepositories creates the repositories for the storage backend selected in the configuration
+func NewRepositories(ctx context.Context, cfg *Config) (*storage.Repositories, error) {
+	switch cfg.StorageBackend {
+	case StorageBackendCosmos:
+		client, err := cosmos.NewClient(ctx, cosmos.Config{
+			Endpoint: cfg.CosmosEndpoint,
+			Key:      cfg.CosmosKey,
+			Database: cfg.CosmosDatabase,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Cosmos DB client: %w", err)
+		}
+		return cosmos.NewRepositories(client)
+	case StorageBackendPostgres:
+		db, err := sqlstore.Open(ctx, sqlstore.Config{
+			Dialect: sqlstore.Postgres,
+			DSN:     cfg.DatabaseURL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return sqlstore.NewRepositories(db), nil
+	case StorageBackendSQLite:
+		db, err := sqlstore.Open(ctx, sqlstore.Config{
+			Dialect: sqlstore.SQLite,
+			DSN:     "file:" + cfg.SQLitePath + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+		})
+		if err != nil {
+			return nil, err
+		}
+		return sqlstore.NewRepositories(db), nil
+	case StorageBackendMemory:
+		return memory.NewRepositories(memory.NewStore()), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", cfg.StorageBackend)
+	}
+}
+
+// TokenCaches are the caches in front of service key and API key token lookups
+type TokenCaches struct {
+	ServiceKeys *auth.CachedServiceKeyRepository
+	ApiKeys     *auth.CachedApiKeyRepository
+}
+
+// WithTokenCaches puts token lookup caches in front of the service key and API key repositories.
+// Writes through repos invalidate the keys they change. Returns nil when TOKEN_CACHE_SIZE is 0.
+func WithTokenCaches(repos *storage.Repositories, cfg *Config) *TokenCaches {
+	if cfg.TokenCacheSize <= 0 {
+		return nil
+	}
+
+	config := auth.TokenCacheConfig{
+		Size:        cfg.TokenCacheSize,
+		TTL:         cfg.TokenCacheTTL,
+		NegativeTTL: cfg.TokenCacheNegativeTTL,
+	}
+	caches := &TokenCaches{
+		ServiceKeys: auth.NewCachedServiceKeyRepository(repos.ServiceKeys, config),
+		ApiKeys:     auth.NewCachedApiKeyRepository(repos.ApiKeys, config),
+	}
+	repos.ServiceKeys = caches.ServiceKeys
+	repos.ApiKeys = caches.ApiKeys
+	return caches
+}
+
+// ShareInvalidations shares the caches' invalidations with the other replicas
+func (c *TokenCaches) ShareInvalidations(invalidator auth.Invalidator) {
+	if c == nil {
+		return
+	}
+	c.ServiceKeys.ShareInvalidations(invalidator)
+	c.ApiKeys.ShareInvalidations(invalidator)
+}
+
+// Close stops the caches' janitors
+func (c *TokenCaches) Close() {
+	if c == nil {
+		return
+	}
+	c.ServiceKeys.Close()
+	c.ApiKeys.Close()
+}
+
+// Stats returns the hit and miss counters of each cache, it is empty when caching is off
+func (c *TokenCaches) Stats() map[string]auth.CacheStats {
+	if c == nil {
+		return map[string]auth.CacheStats{}
+	}
+	return map[string]auth.CacheStats{
+		"service_keys": c.ServiceKeys.Stats(),
+		"api_keys":     c.ApiKeys.Stats(),
+	}
+}
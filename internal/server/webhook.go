@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/webhook"
+)
+
+// WithWebhooks starts a webhook dispatcher and sends it every key and application change written through repos.
+// Call it after WithTokenCaches so cache invalidation happens before the events go out.
+func WithWebhooks(repos *storage.Repositories, cfg *Config) *webhook.Dispatcher {
+	dispatcher := webhook.NewDispatcher(repos, webhook.Config{
+		MaxAttempts:        cfg.WebhookMaxAttempts,
+		InitialBackoff:     cfg.WebhookRetryBackoff,
+		Timeout:            cfg.WebhookTimeout,
+		ExpiryWindow:       cfg.WebhookExpiryWindow,
+		ExpiryScanInterval: cfg.WebhookExpiryScanInterval,
+	})
+	storage.WithChangeListener(repos, dispatcher)
+	return dispatcher
+}
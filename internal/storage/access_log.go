@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// SummarizeUsage adds up a batch of access log records per service key,
+// so backends can update each key's usage once per batch.
+// Records that matched no service key are left out.
+func SummarizeUsage(logs []*types.AccessLog) []*types.ServiceKeyUsage {
+	var usages []*types.ServiceKeyUsage
+	byKey := make(map[string]*types.ServiceKeyUsage)
+	for _, log := range logs {
+		if log.ServiceKeyID == "" {
+			continue
+		}
+		key := log.OrganizationID + "\x00" + log.ServiceKeyID
+		usage, ok := byKey[key]
+		if !ok {
+			usage = NewServiceKeyUsage(log.OrganizationID, log.ServiceKeyID)
+			byKey[key] = usage
+			usages = append(usages, usage)
+		}
+		usage.Add(log)
+	}
+	return usages
+}
+
+// NewServiceKeyUsage returns the usage of a service key that was never validated
+func NewServiceKeyUsage(organizationID, serviceKeyID string) *types.ServiceKeyUsage {
+	usage := &types.ServiceKeyUsage{
+		ID:             types.ServiceKeyUsageID(serviceKeyID),
+		EntityType:     "service_key_usage",
+		OrganizationID: organizationID,
+		ServiceKeyID:   serviceKeyID,
+	}
+	usage.PartitionKey = usage.GetPartitionKey()
+	return usage
+}
+
+// MergeUsage adds the counts and latest times of delta to usage
+func MergeUsage(usage, delta *types.ServiceKeyUsage) {
+	usage.AllowedCount += delta.AllowedCount
+	usage.DeniedCount += delta.DeniedCount
+	usage.LastUsedAt = latest(usage.LastUsedAt, delta.LastUsedAt)
+	usage.LastDeniedAt = latest(usage.LastDeniedAt, delta.LastDeniedAt)
+}
+
+// latest returns the later of two optional times
+func latest(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// maxUserAgentLength bounds the user agent kept in audit history
+const maxUserAgentLength = 512
+
+// RequestMetadata identifies the request behind a write, it is copied into the audit history
+type RequestMetadata struct {
+	RequestID string
+	ClientIP  string
+	UserAgent string
+}
+
+type requestMetadataKey struct{}
+
+// WithRequestMetadata returns a context whose writes are audited with metadata
+func WithRequestMetadata(ctx context.Context, metadata RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, metadata)
+}
+
+// GetRequestMetadata returns the request metadata from context, it is empty outside a request
+func GetRequestMetadata(ctx context.Context) RequestMetadata {
+	metadata, _ := ctx.Value(requestMetadataKey{}).(RequestMetadata)
+	return metadata
+}
+
+// NewAuditHistory builds the audit history record for a write, with the request metadata from ctx
+func NewAuditHistory(ctx context.Context, organizationID, entityID string, action types.AuditAction, userID, githubID, username string, changes []types.AuditChange) *types.AuditHistory {
+	metadata := GetRequestMetadata(ctx)
+	userAgent := metadata.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	auditHistory := &types.AuditHistory{
+		ID:                GenerateID(),
+		OrganizationID:    organizationID,
+		EntityType:        "audit_history",
+		EntityID:          entityID,
+		Action:            action,
+		CreatedByUserID:   userID,
+		CreatedByGitHubID: githubID,
+		CreatedByUsername: username,
+		CreatedAt:         time.Now().Truncate(time.Microsecond), // Kept as is by every backend, so its hash does not change
+		Changes:           changes,
+		RequestID:         metadata.RequestID,
+		ClientIP:          metadata.ClientIP,
+		UserAgent:         userAgent,
+	}
+	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+	return auditHistory
+}
+
+// AuditFilter narrows an organization's audit history, zero fields match every record
+type AuditFilter struct {
+	ActorUserID   string
+	ActorUsername string // Compared case-insensitively, like GitHub usernames
+	ResourceTypes []types.AuditResourceType
+	Actions       []types.AuditAction
+	From          time.Time // Inclusive
+	To            time.Time // Exclusive
+}
+
+// Matches reports whether a record about a resourceType entity passes the filter
+func (f AuditFilter) Matches(resourceType types.AuditResourceType, audit *types.AuditHistory) bool {
+	if f.ActorUserID != "" && audit.CreatedByUserID != f.ActorUserID {
+		return false
+	}
+	if f.ActorUsername != "" && !strings.EqualFold(audit.CreatedByUsername, f.ActorUsername) {
+		return false
+	}
+	if len(f.ResourceTypes) > 0 && !slices.Contains(f.ResourceTypes, resourceType) {
+		return false
+	}
+	if len(f.Actions) > 0 && !slices.Contains(f.Actions, audit.Action) {
+		return false
+	}
+	if !f.From.IsZero() && audit.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !audit.CreatedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Includes reports whether the filter lets through any record about a resourceType entity,
+// so backends can skip the others
+func (f AuditFilter) Includes(resourceType types.AuditResourceType) bool {
+	return len(f.ResourceTypes) == 0 || slices.Contains(f.ResourceTypes, resourceType)
+}
+
+// SearchHistory orders audit history newest first by creation time and ID, for backends without a sequence,
+// and returns up to limit records matching filter after the cursor.
+// Each event must have its ResourceType set.
+func SearchHistory(events []*AuditEvent, filter AuditFilter, cursor string, limit int) ([]*AuditEvent, error) {
+	var before time.Time
+	var beforeID string
+	if cursor != "" {
+		var err error
+		if before, beforeID, err = decodeHistoryCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return historyBefore(events[j].CreatedAt, events[j].ID, events[i].CreatedAt, events[i].ID)
+	})
+
+	var found []*AuditEvent
+	for _, event := range events {
+		if cursor != "" && !historyBefore(event.CreatedAt, event.ID, before, beforeID) {
+			continue
+		}
+		if !filter.Matches(event.ResourceType, event.AuditHistory) {
+			continue
+		}
+		if len(found) == limit {
+			break
+		}
+		event.Cursor = strconv.FormatInt(event.CreatedAt.UnixNano(), 10) + "." + event.ID
+		found = append(found, event)
+	}
+
+	return found, nil
+}
+
+// DiffServiceKeys returns the fields changed between two versions of a service key.
+// Token values are left out.
+func DiffServiceKeys(before, after *types.ServiceKey) []types.AuditChange {
+	var changes []types.AuditChange
+	changes = diffValue(changes, "name", before.Name, after.Name)
+	changes = diffTime(changes, "expires_at", before.ExpiresAt, after.ExpiresAt)
+	changes = diffTime(changes, "previous_token_expires_at", before.PreviousTokenExpiresAt, after.PreviousTokenExpiresAt)
+	return diffGrants(changes, before.Applications, after.Applications)
+}
+
+// DiffApiKeys returns the fields changed between two versions of an API key.
+// Token values are left out.
+func DiffApiKeys(before, after *types.ApiKey) []types.AuditChange {
+	var changes []types.AuditChange
+	changes = diffValue(changes, "name", before.Name, after.Name)
+	changes = diffValue(changes, "application_id", before.ApplicationID, after.ApplicationID)
+	changes = diffList(changes, "application_ids", before.ApplicationIDs, after.ApplicationIDs)
+	changes = diffList(changes, "scopes", scopeStrings(before.Scopes), scopeStrings(after.Scopes))
+	changes = diffTime(changes, "expires_at", before.ExpiresAt, after.ExpiresAt)
+	changes = diffTime(changes, "previous_token_expires_at", before.PreviousTokenExpiresAt, after.PreviousTokenExpiresAt)
+	changes = diffTime(changes, "revoked_at", before.RevokedAt, after.RevokedAt)
+	return diffValue(changes, "revoked_reason", before.RevokedReason, after.RevokedReason)
+}
+
+// DiffApplications returns the fields changed between two versions of an application
+func DiffApplications(before, after *types.Application) []types.AuditChange {
+	var changes []types.AuditChange
+	changes = diffValue(changes, "name", before.Name, after.Name)
+	changes = diffValue(changes, "description", before.Description, after.Description)
+	return diffList(changes, "permissions", before.Permissions, after.Permissions)
+}
+
+func diffValue(changes []types.AuditChange, field, before, after string) []types.AuditChange {
+	if before == after {
+		return changes
+	}
+	return append(changes, types.AuditChange{Field: field, Before: before, After: after})
+}
+
+func diffTime(changes []types.AuditChange, field string, before, after *time.Time) []types.AuditChange {
+	if before == nil && after == nil || before != nil && after != nil && before.Equal(*after) {
+		return changes
+	}
+	return append(changes, types.AuditChange{Field: field, Before: timeValue(before), After: timeValue(after)})
+}
+
+// timeValue keeps a missing time out of the JSON of a change
+func timeValue(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+func diffList(changes []types.AuditChange, field string, before, after []string) []types.AuditChange {
+	var added, removed []string
+	for _, value := range after {
+		if !slices.Contains(before, value) && !slices.Contains(added, value) {
+			added = append(added, value)
+		}
+	}
+	for _, value := range before {
+		if !slices.Contains(after, value) && !slices.Contains(removed, value) {
+			removed = append(removed, value)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return changes
+	}
+	return append(changes, types.AuditChange{Field: field, Added: added, Removed: removed})
+}
+
+// diffGrants reports the applications granted and taken away under "applications",
+// then each application's name and permission changes under "applications.<application_id>"
+func diffGrants(changes []types.AuditChange, before, after []types.ApplicationAccess) []types.AuditChange {
+	beforeByID := make(map[string]types.ApplicationAccess, len(before))
+	var beforeIDs []string
+	for _, grant := range before {
+		beforeByID[grant.ApplicationID] = grant
+		beforeIDs = append(beforeIDs, grant.ApplicationID)
+	}
+	afterByID := make(map[string]types.ApplicationAccess, len(after))
+	var afterIDs []string
+	for _, grant := range after {
+		afterByID[grant.ApplicationID] = grant
+		afterIDs = append(afterIDs, grant.ApplicationID)
+	}
+
+	changes = diffList(changes, "applications", beforeIDs, afterIDs)
+	for _, grant := range after {
+		field := "applications." + grant.ApplicationID
+		previous := beforeByID[grant.ApplicationID]
+		if _, ok := beforeByID[grant.ApplicationID]; ok {
+			changes = diffValue(changes, field+".application_name", previous.ApplicationName, grant.ApplicationName)
+		}
+		changes = diffList(changes, field+".permissions", previous.Permissions, grant.Permissions)
+	}
+	for _, grant := range before {
+		if _, ok := afterByID[grant.ApplicationID]; !ok {
+			changes = diffList(changes, "applications."+grant.ApplicationID+".permissions", grant.Permissions, nil)
+		}
+	}
+	return changes
+}
+
+func scopeStrings(scopes []types.ApiKeyScope) []string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return values
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// AuditChainHeadID is the ID of the document holding an audit chain's head,
+// in backends that keep it next to the chained audit history
+const AuditChainHeadID = "audit-chain-head"
+
+// auditHashContent is what an audit history record's hash covers, in a fixed field order
+type auditHashContent struct {
+	Sequence          int64               `json:"sequence"`
+	PreviousHash      string              `json:"previous_hash"`
+	ID                string              `json:"id"`
+	OrganizationID    string              `json:"organization_id"`
+	EntityID          string              `json:"entity_id"`
+	Action            types.AuditAction   `json:"action"`
+	CreatedByUserID   string              `json:"created_by_user_id"`
+	CreatedByGitHubID string              `json:"created_by_github_id"`
+	CreatedByUsername string              `json:"created_by_username"`
+	CreatedAt         string              `json:"created_at"`
+	Changes           []types.AuditChange `json:"changes"`
+	RequestID         string              `json:"request_id"`
+	ClientIP          string              `json:"client_ip"`
+	UserAgent         string              `json:"user_agent"`
+}
+
+// NewAuditChainHead returns the head of an organization's empty audit chain
+func NewAuditChainHead(organizationID string) *types.AuditChainHead {
+	head := &types.AuditChainHead{
+		ID:             AuditChainHeadID,
+		EntityType:     "audit_chain_head",
+		OrganizationID: organizationID,
+	}
+	head.PartitionKey = head.GetPartitionKey()
+	return head
+}
+
+// HashAudit returns the hex SHA-256 of an audit history record's content, sequence and previous hash.
+// The creation time is hashed in UTC to the microsecond, the precision every backend keeps.
+func HashAudit(audit *types.AuditHistory) (string, error) {
+	changes := audit.Changes
+	if len(changes) == 0 {
+		changes = nil
+	}
+
+	content, err := json.Marshal(auditHashContent{
+		Sequence:          audit.Sequence,
+		PreviousHash:      audit.PreviousHash,
+		ID:                audit.ID,
+		OrganizationID:    audit.OrganizationID,
+		EntityID:          audit.EntityID,
+		Action:            audit.Action,
+		CreatedByUserID:   audit.CreatedByUserID,
+		CreatedByGitHubID: audit.CreatedByGitHubID,
+		CreatedByUsername: audit.CreatedByUsername,
+		CreatedAt:         audit.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		Changes:           changes,
+		RequestID:         audit.RequestID,
+		ClientIP:          audit.ClientIP,
+		UserAgent:         audit.UserAgent,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit history: %w", err)
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ChainAudit links an audit history record to the end of the chain ending at head and moves head to it.
+// The caller writes both in one transaction.
+func ChainAudit(head *types.AuditChainHead, audit *types.AuditHistory) error {
+	audit.Sequence = head.Sequence + 1
+	audit.PreviousHash = head.Hash
+
+	hash, err := HashAudit(audit)
+	if err != nil {
+		return err
+	}
+	audit.Hash = hash
+
+	head.Sequence = audit.Sequence
+	head.Hash = hash
+	head.UpdatedAt = audit.CreatedAt
+	return nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// Change describes a write to a service key, API key or application,
+// with the same action and user as the audit history record it wrote
+type Change struct {
+	OrganizationID string
+	EntityType     string // "service_key", "api_key" or "application"
+	EntityID       string
+	Action         types.AuditAction
+	Entity         any // *types.ServiceKey, *types.ApiKey or *types.Application as written
+	UserID         string
+	GitHubID       string
+	Username       string
+}
+
+// ChangeListener is told about every successful write that records audit history.
+// Changed runs on the writer's goroutine, so it must return quickly.
+type ChangeListener interface {
+	Changed(ctx context.Context, change Change)
+}
+
+// WithChangeListener wraps the service key, API key and application repositories
+// so that listener hears about their writes once they succeed
+func WithChangeListener(repos *Repositories, listener ChangeListener) {
+	repos.ServiceKeys = &notifyingServiceKeyRepository{ServiceKeyRepository: repos.ServiceKeys, listener: listener}
+	repos.ApiKeys = &notifyingApiKeyRepository{ApiKeyRepository: repos.ApiKeys, listener: listener}
+	repos.Applications = &notifyingApplicationRepository{ApplicationRepository: repos.Applications, listener: listener}
+}
+
+type notifyingServiceKeyRepository struct {
+	ServiceKeyRepository
+	listener ChangeListener
+}
+
+func (r *notifyingServiceKeyRepository) Create(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
+	return r.notify(ctx, serviceKey, types.AuditActionCreated, userID, githubID, username, r.ServiceKeyRepository.Create)
+}
+
+func (r *notifyingServiceKeyRepository) Update(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
+	return r.notify(ctx, serviceKey, types.AuditActionUpdated, userID, githubID, username, r.ServiceKeyRepository.Update)
+}
+
+func (r *notifyingServiceKeyRepository) Rotate(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
+	return r.notify(ctx, serviceKey, types.AuditActionRotated, userID, githubID, username, r.ServiceKeyRepository.Rotate)
+}
+
+func (r *notifyingServiceKeyRepository) Delete(ctx context.Context, serviceKey *types.ServiceKey, userID, githubID, username string) error {
+	return r.notify(ctx, serviceKey, types.AuditActionDeleted, userID, githubID, username, r.ServiceKeyRepository.Delete)
+}
+
+func (r *notifyingServiceKeyRepository) notify(ctx context.Context, serviceKey *types.ServiceKey, action types.AuditAction, userID, githubID, username string, write func(context.Context, *types.ServiceKey, string, string, string) error) error {
+	if err := write(ctx, serviceKey, userID, githubID, username); err != nil {
+		return err
+	}
+	r.listener.Changed(ctx, Change{
+		OrganizationID: serviceKey.OrganizationID,
+		EntityType:     "service_key",
+		EntityID:       serviceKey.ID,
+		Action:         action,
+		Entity:         serviceKey,
+		UserID:         userID,
+		GitHubID:       githubID,
+		Username:       username,
+	})
+	return nil
+}
+
+type notifyingApiKeyRepository struct {
+	ApiKeyRepository
+	listener ChangeListener
+}
+
+func (r *notifyingApiKeyRepository) Create(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	return r.notify(ctx, apiKey, types.AuditActionCreated, userID, githubID, username, r.ApiKeyRepository.Create)
+}
+
+func (r *notifyingApiKeyRepository) Update(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	return r.notify(ctx, apiKey, types.AuditActionUpdated, userID, githubID, username, r.ApiKeyRepository.Update)
+}
+
+func (r *notifyingApiKeyRepository) Rotate(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	return r.notify(ctx, apiKey, types.AuditActionRotated, userID, githubID, username, r.ApiKeyRepository.Rotate)
+}
+
+func (r *notifyingApiKeyRepository) Revoke(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	return r.notify(ctx, apiKey, types.AuditActionRevoked, userID, githubID, username, r.ApiKeyRepository.Revoke)
+}
+
+func (r *notifyingApiKeyRepository) Delete(ctx context.Context, apiKey *types.ApiKey, userID, githubID, username string) error {
+	return r.notify(ctx, apiKey, types.AuditActionDeleted, userID, githubID, username, r.ApiKeyRepository.Delete)
+}
+
+func (r *notifyingApiKeyRepository) notify(ctx context.Context, apiKey *types.ApiKey, action types.AuditAction, userID, githubID, username string, write func(context.Context, *types.ApiKey, string, string, string) error) error {
+	if err := write(ctx, apiKey, userID, githubID, username); err != nil {
+		return err
+	}
+	r.listener.Changed(ctx, Change{
+		OrganizationID: apiKey.OrganizationID,
+		EntityType:     "api_key",
+		EntityID:       apiKey.ID,
+		Action:         action,
+		Entity:         apiKey,
+		UserID:         userID,
+		GitHubID:       githubID,
+		Username:       username,
+	})
+	return nil
+}
+
+type notifyingApplicationRepository struct {
+	ApplicationRepository
+	listener ChangeListener
+}
+
+func (r *notifyingApplicationRepository) Create(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	return r.notify(ctx, app, types.AuditActionCreated, userID, githubID, username, r.ApplicationRepository.Create)
+}
+
+func (r *notifyingApplicationRepository) Update(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	return r.notify(ctx, app, types.AuditActionUpdated, userID, githubID, username, r.ApplicationRepository.Update)
+}
+
+func (r *notifyingApplicationRepository) Delete(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	return r.notify(ctx, app, types.AuditActionDeleted, userID, githubID, username, r.ApplicationRepository.Delete)
+}
+
+func (r *notifyingApplicationRepository) notify(ctx context.Context, app *types.Application, action types.AuditAction, userID, githubID, username string, write func(context.Context, *types.Application, string, string, string) error) error {
+	if err := write(ctx, app, userID, githubID, username); err != nil {
+		return err
+	}
+	r.listener.Changed(ctx, Change{
+		OrganizationID: app.OrganizationID,
+		EntityType:     "application",
+		EntityID:       app.ID,
+		Action:         action,
+		Entity:         app,
+		UserID:         userID,
+		GitHubID:       githubID,
+		Username:       username,
+	})
+	return nil
+}
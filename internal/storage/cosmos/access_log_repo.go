@@ -0,0 +1,172 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// maxBatchOperations is the most operations a Cosmos DB transactional batch may hold
+const maxBatchOperations = 100
+
+// AccessLogRepository handles access log and service key usage storage operations.
+// Usage documents live in the access_logs container next to the records they count.
+type AccessLogRepository struct {
+	client    *Client
+	container *azcosmos.ContainerClient
+}
+
+// NewAccessLogRepository creates a new access log repository
+func NewAccessLogRepository(client *Client) (*AccessLogRepository, error) {
+	container, err := client.GetContainer("access_logs")
+	if err != nil {
+		return nil, err
+	}
+	return &AccessLogRepository{
+		client:    client,
+		container: container,
+	}, nil
+}
+
+// Record stores a batch of decisions and adds them to their service keys' usage.
+// Records are written in transactional batches per organization, usage documents are
+// incremented with patches so replicas recording at the same time do not lose counts.
+func (r *AccessLogRepository) Record(ctx context.Context, logs []*types.AccessLog) error {
+	byOrganization := make(map[string][]*types.AccessLog)
+	for _, log := range logs {
+		log.EntityType = "access_log"
+		log.PartitionKey = log.GetPartitionKey()
+		byOrganization[log.PartitionKey] = append(byOrganization[log.PartitionKey], log)
+	}
+
+	for organizationID, logs := range byOrganization {
+		for start := 0; start < len(logs); start += maxBatchOperations {
+			end := min(start+maxBatchOperations, len(logs))
+
+			batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(organizationID))
+			for _, log := range logs[start:end] {
+				item, err := json.Marshal(log)
+				if err != nil {
+					return fmt.Errorf("failed to marshal access log: %w", err)
+				}
+				batch.CreateItem(item, nil)
+			}
+
+			resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
+			if err != nil {
+				return handleCosmosError(err)
+			}
+			if !resp.Success {
+				return handleCosmosError(fmt.Errorf("batch operation failed"))
+			}
+		}
+	}
+
+	for _, usage := range storage.SummarizeUsage(logs) {
+		if err := r.addUsage(ctx, usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addUsage increments a service key's usage document, creating it on first use
+func (r *AccessLogRepository) addUsage(ctx context.Context, delta *types.ServiceKeyUsage) error {
+	partitionKey := azcosmos.NewPartitionKeyString(delta.PartitionKey)
+
+	patch := azcosmos.PatchOperations{}
+	patch.AppendIncrement("/allowed_count", delta.AllowedCount)
+	patch.AppendIncrement("/denied_count", delta.DeniedCount)
+	// Batches are recorded in order, so a replica lagging behind may briefly set an older time
+	if delta.LastUsedAt != nil {
+		patch.AppendSet("/last_used_at", delta.LastUsedAt)
+	}
+	if delta.LastDeniedAt != nil {
+		patch.AppendSet("/last_denied_at", delta.LastDeniedAt)
+	}
+
+	// Try the patch again when another replica created the document first
+	for range 2 {
+		_, err := r.container.PatchItem(ctx, partitionKey, delta.ID, patch, nil)
+		if !isStatus(err, 404) {
+			return handleCosmosError(err)
+		}
+
+		item, err := json.Marshal(delta)
+		if err != nil {
+			return fmt.Errorf("failed to marshal service key usage: %w", err)
+		}
+		_, err = r.container.CreateItem(ctx, partitionKey, item, nil)
+		if !isStatus(err, 409) {
+			return handleCosmosError(err)
+		}
+	}
+	return fmt.Errorf("failed to update service key usage %s", delta.ID)
+}
+
+// ListByServiceKey returns up to limit decisions about a service key made before a time, newest first
+func (r *AccessLogRepository) ListByServiceKey(ctx context.Context, organizationID, serviceKeyID string, before time.Time, limit int) ([]*types.AccessLog, error) {
+	q := selectAll().
+		whereEquals("entity_type", "access_log").
+		whereEquals("service_key_id", serviceKeyID)
+	if !before.IsZero() {
+		q = q.whereBelow("created_at", before.UTC())
+	}
+	query, options := q.orderByDesc("created_at").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
+
+	var logs []*types.AccessLog
+	for queryPager.More() && len(logs) < limit {
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, handleCosmosError(err)
+		}
+
+		for _, item := range queryResponse.Items {
+			if len(logs) == limit {
+				break
+			}
+			var log types.AccessLog
+			if err := json.Unmarshal(item, &log); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal access log: %w", err)
+			}
+			logs = append(logs, &log)
+		}
+	}
+
+	return logs, nil
+}
+
+// GetUsage returns a service key's usage, with zero counts when it was never validated
+func (r *AccessLogRepository) GetUsage(ctx context.Context, organizationID, serviceKeyID string) (*types.ServiceKeyUsage, error) {
+	itemResponse, err := r.container.ReadItem(ctx, azcosmos.NewPartitionKeyString(organizationID), types.ServiceKeyUsageID(serviceKeyID), nil)
+	if isStatus(err, 404) {
+		return storage.NewServiceKeyUsage(organizationID, serviceKeyID), nil
+	}
+	if err != nil {
+		return nil, handleCosmosError(err)
+	}
+
+	var usage types.ServiceKeyUsage
+	if err := json.Unmarshal(itemResponse.Value, &usage); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal service key usage: %w", err)
+	}
+	if usage.EntityType != "service_key_usage" {
+		return storage.NewServiceKeyUsage(organizationID, serviceKeyID), nil
+	}
+	usage.PartitionKey = usage.GetPartitionKey()
+	return &usage, nil
+}
+
+// isStatus reports whether err is a Cosmos DB response with the given status code
+func isStatus(err error, statusCode int) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == statusCode
+}
@@ -1,12 +1,12 @@
-package storage
+package cosmos
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/brianfromlife/baluster/internal/storage"
 	"github.com/brianfromlife/baluster/internal/types"
 )
 
@@ -28,21 +28,10 @@ func NewApiKeyRepository(client *Client) (*ApiKeyRepository, error) {
 
 // Create creates a new API key with audit history
 func (r *ApiKeyRepository) Create(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
-	token.TokenValue = HashToken(token.TokenValue)
+	token.TokenValue = storage.HashToken(token.TokenValue)
 	token.PartitionKey = token.GetPartitionKey()
 
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    token.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          token.ID,
-		Action:            types.AuditActionCreated,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
-	}
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionCreated, userID, githubID, username, nil)
 
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(token.PartitionKey))
 
@@ -53,24 +42,7 @@ func (r *ApiKeyRepository) Create(ctx context.Context, token *types.ApiKey, user
 	}
 	batch.CreateItem(tokenItem, nil)
 
-	// Add audit history create
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-	batch.CreateItem(auditItem, nil)
-
-	// Execute batch
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
-
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
-	}
-
-	return nil
+	return executeAudited(ctx, r.container, batch, auditHistory)
 }
 
 // Get retrieves an API key by ID using the organization ID as the partition key
@@ -90,9 +62,10 @@ func (r *ApiKeyRepository) Get(ctx context.Context, organizationID, id string) (
 
 // FindByTokenValue finds an API key by its hashed value (queries across all partitions)
 func (r *ApiKeyRepository) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ApiKey, error) {
-	hashed := HashToken(tokenValue)
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.token_value = '%s' AND (c.entity_type = 'api_key' OR NOT IS_DEFINED(c.entity_type))", hashed)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), nil)
+	hashed := storage.HashToken(tokenValue)
+	// A rotated key is also found by its previous token, which the caller checks against the grace period
+	query, options := selectAll().whereEqualsAny(hashed, "token_value", "previous_token_value").whereEntityType("api_key").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), options)
 
 	for queryPager.More() {
 		queryResponse, err := queryPager.NextPage(ctx)
@@ -109,13 +82,13 @@ func (r *ApiKeyRepository) FindByTokenValue(ctx context.Context, tokenValue stri
 		}
 	}
 
-	return nil, fmt.Errorf("token not found")
+	return nil, fmt.Errorf("token %w", storage.ErrNotFound)
 }
 
 // CountByOrganization counts API keys for an organization
 func (r *ApiKeyRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
-	query := fmt.Sprintf("SELECT VALUE COUNT(1) FROM c WHERE c.organization_id = '%s' AND (c.entity_type = 'api_key' OR NOT IS_DEFINED(c.entity_type))", organizationID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	query, options := selectCount().whereEquals("organization_id", organizationID).whereEntityType("api_key").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	if !queryPager.More() {
 		return 0, nil
@@ -140,8 +113,8 @@ func (r *ApiKeyRepository) CountByOrganization(ctx context.Context, organization
 
 // ListByOrganization lists API keys for an organization
 func (r *ApiKeyRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.ApiKey, error) {
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.organization_id = '%s' AND (c.entity_type = 'api_key' OR NOT IS_DEFINED(c.entity_type))", organizationID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	query, options := selectAll().whereEquals("organization_id", organizationID).whereEntityType("api_key").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	var tokens []*types.ApiKey
 	for queryPager.More() {
@@ -164,21 +137,32 @@ func (r *ApiKeyRepository) ListByOrganization(ctx context.Context, organizationI
 
 // Update updates an API key with audit history
 func (r *ApiKeyRepository) Update(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	return r.replace(ctx, token, types.AuditActionUpdated, userID, githubID, username)
+}
+
+// Rotate stores an API key with a new token value with audit history.
+// The new token value is hashed, the previous hash must already be set by the caller.
+func (r *ApiKeyRepository) Rotate(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	return r.replace(ctx, token, types.AuditActionRotated, userID, githubID, username)
+}
+
+// Revoke stores a revoked API key with audit history, the revocation must already be set by the caller
+func (r *ApiKeyRepository) Revoke(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	return r.replace(ctx, token, types.AuditActionRevoked, userID, githubID, username)
+}
+
+func (r *ApiKeyRepository) replace(ctx context.Context, token *types.ApiKey, action types.AuditAction, userID, githubID, username string) error {
 	token.PartitionKey = token.GetPartitionKey()
 
-	// Create audit history record
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    token.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          token.ID,
-		Action:            types.AuditActionUpdated,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
+	// Read the stored version to record what changed
+	before, err := r.Get(ctx, token.OrganizationID, token.ID)
+	if err != nil {
+		return err
 	}
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+
+	// Create audit history record
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, action, userID, githubID, username, storage.DiffApiKeys(before, token))
 
 	// Create transactional batch
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(token.PartitionKey))
@@ -190,24 +174,7 @@ func (r *ApiKeyRepository) Update(ctx context.Context, token *types.ApiKey, user
 	}
 	batch.ReplaceItem(token.ID, tokenItem, nil)
 
-	// Add audit history create
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-	batch.CreateItem(auditItem, nil)
-
-	// Execute batch
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
-
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
-	}
-
-	return nil
+	return executeAudited(ctx, r.container, batch, auditHistory)
 }
 
 // Delete deletes an API key with audit history
@@ -215,18 +182,7 @@ func (r *ApiKeyRepository) Delete(ctx context.Context, token *types.ApiKey, user
 	token.PartitionKey = token.GetPartitionKey()
 
 	// Create audit history record
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    token.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          token.ID,
-		Action:            types.AuditActionDeleted,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
-	}
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionDeleted, userID, githubID, username, nil)
 
 	// Create transactional batch
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(token.PartitionKey))
@@ -234,30 +190,13 @@ func (r *ApiKeyRepository) Delete(ctx context.Context, token *types.ApiKey, user
 	// Add API key delete
 	batch.DeleteItem(token.ID, nil)
 
-	// Add audit history create
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-	batch.CreateItem(auditItem, nil)
-
-	// Execute batch
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
-
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
-	}
-
-	return nil
+	return executeAudited(ctx, r.container, batch, auditHistory)
 }
 
 // GetHistory retrieves audit history for an API key
 func (r *ApiKeyRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.organization_id = '%s' AND c.entity_type = 'audit_history' AND c.entity_id = '%s' ORDER BY c.created_at DESC", organizationID, entityID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	query, options := selectAll().whereEquals("organization_id", organizationID).whereEquals("entity_type", "audit_history").whereEquals("entity_id", entityID).orderByDesc("created_at").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	var history []*types.AuditHistory
 	for queryPager.More() {
@@ -1,12 +1,12 @@
-package storage
+package cosmos
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/brianfromlife/baluster/internal/storage"
 	"github.com/brianfromlife/baluster/internal/types"
 )
 
@@ -30,19 +30,7 @@ func NewApplicationRepository(client *Client) (*ApplicationRepository, error) {
 func (r *ApplicationRepository) Create(ctx context.Context, app *types.Application, userID, githubID, username string) error {
 	app.PartitionKey = app.GetPartitionKey()
 
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    app.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          app.ID,
-		Action:            types.AuditActionCreated,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
-	}
-
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+	auditHistory := storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionCreated, userID, githubID, username, nil)
 
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(app.PartitionKey))
 
@@ -53,28 +41,13 @@ func (r *ApplicationRepository) Create(ctx context.Context, app *types.Applicati
 
 	batch.CreateItem(appItem, nil)
 
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-	batch.CreateItem(auditItem, nil)
-
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
-
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
-	}
-
-	return nil
+	return executeAudited(ctx, r.container, batch, auditHistory)
 }
 
 // CountByOrganization counts applications for an organization
 func (r *ApplicationRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
-	query := fmt.Sprintf("SELECT VALUE COUNT(1) FROM c WHERE c.organization_id = '%s' AND (c.entity_type = 'application' OR NOT IS_DEFINED(c.entity_type))", organizationID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	query, options := selectCount().whereEquals("organization_id", organizationID).whereEntityType("application").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	if !queryPager.More() {
 		return 0, nil
@@ -99,8 +72,8 @@ func (r *ApplicationRepository) CountByOrganization(ctx context.Context, organiz
 
 // ListByOrganization lists applications for an organization
 func (r *ApplicationRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Application, error) {
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.organization_id = '%s' AND (c.entity_type = 'application' OR NOT IS_DEFINED(c.entity_type))", organizationID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	query, options := selectAll().whereEquals("organization_id", organizationID).whereEntityType("application").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	var apps []*types.Application
 	for queryPager.More() {
@@ -140,19 +113,14 @@ func (r *ApplicationRepository) Get(ctx context.Context, organizationID, id stri
 func (r *ApplicationRepository) Update(ctx context.Context, app *types.Application, userID, githubID, username string) error {
 	app.PartitionKey = app.GetPartitionKey()
 
-	// Create audit history record
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    app.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          app.ID,
-		Action:            types.AuditActionUpdated,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
+	// Read the stored version to record what changed
+	before, err := r.Get(ctx, app.OrganizationID, app.ID)
+	if err != nil {
+		return err
 	}
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+
+	// Create audit history record
+	auditHistory := storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionUpdated, userID, githubID, username, storage.DiffApplications(before, app))
 
 	// Create transactional batch
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(app.PartitionKey))
@@ -164,42 +132,14 @@ func (r *ApplicationRepository) Update(ctx context.Context, app *types.Applicati
 	}
 	batch.ReplaceItem(app.ID, appItem, nil)
 
-	// Add audit history create
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-	batch.CreateItem(auditItem, nil)
-
-	// Execute batch
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
-
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
-	}
-
-	return nil
+	return executeAudited(ctx, r.container, batch, auditHistory)
 }
 
 // Delete deletes an application with audit history
 func (r *ApplicationRepository) Delete(ctx context.Context, app *types.Application, userID, githubID, username string) error {
 	app.PartitionKey = app.GetPartitionKey()
 
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    app.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          app.ID,
-		Action:            types.AuditActionDeleted,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
-	}
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+	auditHistory := storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionDeleted, userID, githubID, username, nil)
 
 	// Create transactional batch
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(app.PartitionKey))
@@ -207,30 +147,13 @@ func (r *ApplicationRepository) Delete(ctx context.Context, app *types.Applicati
 	// Add application delete
 	batch.DeleteItem(app.ID, nil)
 
-	// Add audit history create
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-	batch.CreateItem(auditItem, nil)
-
-	// Execute batch
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
-
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
-	}
-
-	return nil
+	return executeAudited(ctx, r.container, batch, auditHistory)
 }
 
 // GetHistory retrieves audit history for an application
 func (r *ApplicationRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.organization_id = '%s' AND c.entity_type = 'audit_history' AND c.entity_id = '%s' ORDER BY c.created_at DESC", organizationID, entityID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	query, options := selectAll().whereEquals("organization_id", organizationID).whereEquals("entity_type", "audit_history").whereEquals("entity_id", entityID).orderByDesc("created_at").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	var history []*types.AuditHistory
 	for queryPager.More() {
@@ -0,0 +1,241 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// auditContainers maps each audited kind of entity to the container holding its audit history
+var auditContainers = map[types.AuditResourceType]string{
+	types.AuditResourceServiceKey:  "service_keys",
+	types.AuditResourceApiKey:      "api_keys",
+	types.AuditResourceApplication: "applications",
+}
+
+// AuditRepository searches the audit history kept next to service keys, API keys and applications
+type AuditRepository struct {
+	containers map[types.AuditResourceType]*azcosmos.ContainerClient
+}
+
+func NewAuditRepository(client *Client) (*AuditRepository, error) {
+	containers := make(map[types.AuditResourceType]*azcosmos.ContainerClient, len(auditContainers))
+	for resourceType, name := range auditContainers {
+		container, err := client.GetContainer(name)
+		if err != nil {
+			return nil, err
+		}
+		containers[resourceType] = container
+	}
+	return &AuditRepository{
+		containers: containers,
+	}, nil
+}
+
+// Search returns up to limit records of an organization matching filter, newest first, after the cursor
+func (r *AuditRepository) Search(ctx context.Context, organizationID string, filter storage.AuditFilter, cursor string, limit int) ([]*storage.AuditEvent, error) {
+	var before time.Time
+	if cursor != "" {
+		var err error
+		if before, err = storage.HistoryCursorTime(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	var events []*storage.AuditEvent
+	for _, resourceType := range types.AuditResourceTypes {
+		if !filter.Includes(resourceType) {
+			continue
+		}
+		found, err := r.search(ctx, resourceType, organizationID, filter, before, limit)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, found...)
+	}
+
+	return storage.SearchHistory(events, filter, cursor, limit)
+}
+
+// search reads a container's audit history newest first by _ts. Once it holds limit matching records
+// it stops at the first record too old, given the clock skew, to have been created after any of them.
+func (r *AuditRepository) search(ctx context.Context, resourceType types.AuditResourceType, organizationID string, filter storage.AuditFilter, before time.Time, limit int) ([]*storage.AuditEvent, error) {
+	builder := selectAll().whereEquals("organization_id", organizationID).whereEquals("entity_type", "audit_history")
+	if filter.ActorUserID != "" {
+		builder = builder.whereEquals("created_by_user_id", filter.ActorUserID)
+	}
+	if filter.ActorUsername != "" {
+		builder = builder.whereEqualsIgnoreCase("created_by_username", filter.ActorUsername)
+	}
+	if len(filter.Actions) > 0 {
+		actions := make([]string, len(filter.Actions))
+		for i, action := range filter.Actions {
+			actions[i] = string(action)
+		}
+		builder = builder.whereIn("action", actions)
+	}
+	if !filter.From.IsZero() {
+		builder = builder.whereAtLeast("_ts", filter.From.Add(-historyClockSkew).Unix())
+	}
+	if !filter.To.IsZero() {
+		builder = builder.whereBelow("_ts", filter.To.Add(historyClockSkew).Unix())
+	}
+	if !before.IsZero() {
+		builder = builder.whereAtMost("_ts", before.Add(historyClockSkew).Unix())
+	}
+	query, options := builder.orderByDesc("_ts").build()
+	queryPager := r.containers[resourceType].NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
+
+	var events []*storage.AuditEvent
+	var counted int
+	var oldest time.Time
+	for queryPager.More() {
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, handleCosmosError(err)
+		}
+
+		for _, item := range queryResponse.Items {
+			var record struct {
+				types.AuditHistory
+				Timestamp int64 `json:"_ts"`
+			}
+			if err := json.Unmarshal(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal audit history: %w", err)
+			}
+			if counted >= limit && record.Timestamp < oldest.Add(-historyClockSkew).Unix() {
+				return events, nil
+			}
+			if !filter.Matches(resourceType, &record.AuditHistory) {
+				continue
+			}
+			// Records at the cursor's time are left to SearchHistory and not counted
+			audit := record.AuditHistory
+			events = append(events, &storage.AuditEvent{AuditHistory: &audit, ResourceType: resourceType})
+			if !before.IsZero() && !audit.CreatedAt.Before(before) {
+				continue
+			}
+			counted++
+			if oldest.IsZero() || audit.CreatedAt.Before(oldest) {
+				oldest = audit.CreatedAt
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// maxChainAttempts bounds how often a write is retried when another write extended the audit chain first
+const maxChainAttempts = 5
+
+// GetChainHead returns the head of an organization's audit chain for a kind of entity
+func (r *AuditRepository) GetChainHead(ctx context.Context, organizationID string, resourceType types.AuditResourceType) (*types.AuditChainHead, error) {
+	head, _, err := readChainHead(ctx, r.containers[resourceType], organizationID)
+	return head, err
+}
+
+// ListChain returns up to limit records of an organization's audit chain for a kind of entity after afterSequence
+func (r *AuditRepository) ListChain(ctx context.Context, organizationID string, resourceType types.AuditResourceType, afterSequence int64, limit int) ([]*types.AuditHistory, error) {
+	query, options := selectAll().
+		whereEquals("organization_id", organizationID).
+		whereEquals("entity_type", "audit_history").
+		whereAbove("sequence", afterSequence).
+		orderByAsc("sequence").
+		build()
+	queryPager := r.containers[resourceType].NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
+
+	var history []*types.AuditHistory
+	for queryPager.More() && len(history) < limit {
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, handleCosmosError(err)
+		}
+
+		for _, item := range queryResponse.Items {
+			if len(history) == limit {
+				break
+			}
+			var audit types.AuditHistory
+			if err := json.Unmarshal(item, &audit); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal audit history: %w", err)
+			}
+			history = append(history, &audit)
+		}
+	}
+
+	return history, nil
+}
+
+// readChainHead reads the head of the audit chain kept in a container's partition together with its ETag,
+// which is empty while the chain has no head document
+func readChainHead(ctx context.Context, container *azcosmos.ContainerClient, organizationID string) (*types.AuditChainHead, azcore.ETag, error) {
+	itemResponse, err := container.ReadItem(ctx, azcosmos.NewPartitionKeyString(organizationID), storage.AuditChainHeadID, nil)
+	if isStatus(err, 404) {
+		return storage.NewAuditChainHead(organizationID), "", nil
+	}
+	if err != nil {
+		return nil, "", handleCosmosError(err)
+	}
+
+	var head types.AuditChainHead
+	if err := json.Unmarshal(itemResponse.Value, &head); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal audit chain head: %w", err)
+	}
+	head.PartitionKey = head.GetPartitionKey()
+	return &head, itemResponse.ETag, nil
+}
+
+// executeAudited runs a transactional batch together with an audit history record added to the end of its
+// organization's audit chain in the container, and the chain's new head. The head is only replaced if it has
+// not changed since it was read, when another write extended the chain first the batch is retried.
+func executeAudited(ctx context.Context, container *azcosmos.ContainerClient, batch azcosmos.TransactionalBatch, auditHistory *types.AuditHistory) error {
+	for attempt := 1; ; attempt++ {
+		head, etag, err := readChainHead(ctx, container, auditHistory.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if err := storage.ChainAudit(head, auditHistory); err != nil {
+			return err
+		}
+
+		auditItem, err := json.Marshal(auditHistory)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit history: %w", err)
+		}
+		headItem, err := json.Marshal(head)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit chain head: %w", err)
+		}
+
+		// Each attempt adds its operations to a copy of the caller's batch
+		chained := batch
+		chained.CreateItem(auditItem, nil)
+		if etag == "" {
+			chained.CreateItem(headItem, nil)
+		} else {
+			chained.ReplaceItem(head.ID, headItem, &azcosmos.TransactionalBatchItemOptions{IfMatchETag: &etag})
+		}
+
+		resp, err := container.ExecuteTransactionalBatch(ctx, chained, nil)
+		if err != nil {
+			return handleCosmosError(err)
+		}
+		if resp.Success {
+			return nil
+		}
+
+		// The head is the last operation, it fails with 409 or 412 when another write moved it
+		results := resp.OperationResults
+		moved := len(results) > 0 && (results[len(results)-1].StatusCode == http.StatusConflict || results[len(results)-1].StatusCode == http.StatusPreconditionFailed)
+		if !moved || attempt == maxChainAttempts {
+			return handleCosmosError(fmt.Errorf("batch operation failed"))
+		}
+	}
+}
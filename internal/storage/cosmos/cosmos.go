@@ -0,0 +1,164 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/brianfromlife/baluster/internal/storage"
+)
+
+type Client struct {
+	client     *azcosmos.Client
+	database   *azcosmos.DatabaseClient
+	containers map[string]*azcosmos.ContainerClient
+}
+
+type Config struct {
+	Endpoint string
+	Key      string
+	Database string
+
+	// clientOptions lets tests send requests to a fake endpoint
+	clientOptions *azcosmos.ClientOptions
+}
+
+func NewClient(ctx context.Context, cfg Config) (*Client, error) {
+	cred, err := azcosmos.NewKeyCredential(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	client, err := azcosmos.NewClientWithKey(cfg.Endpoint, cred, cfg.clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	database, err := client.NewDatabase(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database: %w", err)
+	}
+
+	c := &Client{
+		client:     client,
+		database:   database,
+		containers: make(map[string]*azcosmos.ContainerClient),
+	}
+
+	containers := []string{"organizations", "applications", "service_keys", "api_keys", "users", "webhooks", "access_logs"}
+	for _, containerName := range containers {
+		container, err := database.NewContainer(containerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get container %s: %w", containerName, err)
+		}
+		c.containers[containerName] = container
+	}
+
+	return c, nil
+}
+
+// GetContainer returns a container by name
+func (c *Client) GetContainer(name string) (*azcosmos.ContainerClient, error) {
+	container, ok := c.containers[name]
+	if !ok {
+		return nil, fmt.Errorf("container %s not found", name)
+	}
+	return container, nil
+}
+
+// NewRepositories creates every Cosmos DB backed repository
+func NewRepositories(client *Client) (*storage.Repositories, error) {
+	orgRepo, err := NewOrganizationRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize organization repository: %w", err)
+	}
+
+	orgMemberRepo, err := NewOrganizationMemberRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize organization member repository: %w", err)
+	}
+
+	invitationRepo, err := NewInvitationRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize invitation repository: %w", err)
+	}
+
+	appRepo, err := NewApplicationRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize application repository: %w", err)
+	}
+
+	serviceKeyRepo, err := NewServiceKeyRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize service key repository: %w", err)
+	}
+
+	apiKeyRepo, err := NewApiKeyRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize API key repository: %w", err)
+	}
+
+	userRepo, err := NewUserRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize user repository: %w", err)
+	}
+
+	webhookRepo, err := NewWebhookRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize webhook repository: %w", err)
+	}
+
+	auditRepo, err := NewAuditRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize audit repository: %w", err)
+	}
+
+	accessLogRepo, err := NewAccessLogRepository(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize access log repository: %w", err)
+	}
+
+	return &storage.Repositories{
+		Organizations:       orgRepo,
+		OrganizationMembers: orgMemberRepo,
+		Invitations:         invitationRepo,
+		Applications:        appRepo,
+		ServiceKeys:         serviceKeyRepo,
+		ApiKeys:             apiKeyRepo,
+		Users:               userRepo,
+		Webhooks:            webhookRepo,
+		Audit:               auditRepo,
+		AccessLogs:          accessLogRepo,
+	}, nil
+}
+
+// handleCosmosError converts Cosmos DB errors to more readable errors
+func handleCosmosError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// log error
+	fmt.Printf("Cosmos error: %v\n", err)
+
+	var respErr *azcore.ResponseError
+	if err, ok := err.(*azcore.ResponseError); ok {
+		respErr = err
+	}
+
+	if respErr != nil {
+		switch respErr.StatusCode {
+		case 404:
+			return storage.ErrNotFound
+		case 409:
+			return fmt.Errorf("conflict: resource already exists")
+		case 400:
+			return fmt.Errorf("bad request: %s", respErr.Error())
+		default:
+			return fmt.Errorf("cosmos db error: %s", respErr.Error())
+		}
+	}
+
+	return fmt.Errorf("cosmos db error: %w", err)
+}
@@ -0,0 +1,192 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+)
+
+// fakeQuery is a query the fake endpoint received
+type fakeQuery struct {
+	Container    string
+	Text         string
+	Parameters   map[string]any
+	PartitionKey string
+}
+
+// fakeCosmos answers Cosmos DB queries from documents held in memory. It evaluates the
+// conditions queryBuilder writes, looking values up in the bound parameters only,
+// and records every query so tests can check what was sent.
+type fakeCosmos struct {
+	mu        sync.Mutex
+	documents map[string][]map[string]any // By container
+	queries   []fakeQuery
+}
+
+var (
+	containerPathPattern  = regexp.MustCompile(`/colls/([^/]+)/docs$`)
+	fieldEqualsPattern    = regexp.MustCompile(`^c\.(\w+) = (@p\d+)$`)
+	fieldUndefinedPattern = regexp.MustCompile(`^NOT IS_DEFINED\(c\.(\w+)\)$`)
+)
+
+// newTestClient starts a fake Cosmos DB endpoint and returns a client connected to it
+func newTestClient(t *testing.T) (*Client, *fakeCosmos) {
+	t.Helper()
+	fake := &fakeCosmos{documents: map[string][]map[string]any{}}
+	server := httptest.NewTLSServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(context.Background(), Config{
+		Endpoint: server.URL,
+		Key:      base64.StdEncoding.EncodeToString([]byte("test-key")),
+		Database: "baluster",
+		clientOptions: &azcosmos.ClientOptions{ClientOptions: azcore.ClientOptions{
+			Transport: server.Client(),
+			Retry:     policy.RetryOptions{MaxRetries: -1},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client, fake
+}
+
+// add stores a document in a container
+func (f *fakeCosmos) add(t *testing.T, container string, document any) {
+	t.Helper()
+	data, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("failed to marshal document: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("failed to unmarshal document: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.documents[container] = append(f.documents[container], fields)
+}
+
+// received returns the queries received so far
+func (f *fakeCosmos) received() []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeQuery(nil), f.queries...)
+}
+
+func (f *fakeCosmos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Account properties, read once by the client to find its regions
+	if r.Method == http.MethodGet && r.URL.Path == "/" {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"readableLocations":[],"writableLocations":[],"userConsistencyPolicy":{"defaultConsistencyLevel":"Session"}}`))
+		return
+	}
+
+	match := containerPathPattern.FindStringSubmatch(r.URL.Path)
+	if r.Method != http.MethodPost || !strings.EqualFold(r.Header.Get("x-ms-documentdb-query"), "true") || match == nil {
+		http.Error(w, fmt.Sprintf(`{"code":"BadRequest","message":"unsupported request %s %s"}`, r.Method, r.URL.Path), http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Query      string `json:"query"`
+		Parameters []struct {
+			Name  string `json:"name"`
+			Value any    `json:"value"`
+		} `json:"parameters"`
+	}
+	data, _ := io.ReadAll(r.Body)
+	if err := json.Unmarshal(data, &body); err != nil {
+		http.Error(w, `{"code":"BadRequest"}`, http.StatusBadRequest)
+		return
+	}
+
+	query := fakeQuery{
+		Container:    match[1],
+		Text:         body.Query,
+		Parameters:   map[string]any{},
+		PartitionKey: r.Header.Get("x-ms-documentdb-partitionkey"),
+	}
+	for _, param := range body.Parameters {
+		query.Parameters[param.Name] = param.Value
+	}
+
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	documents := f.documents[query.Container]
+	f.mu.Unlock()
+
+	results := []map[string]any{}
+	for _, document := range documents {
+		ok, err := query.matches(document)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"code":"BadRequest","message":%q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+		if ok {
+			results = append(results, document)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"Documents": results, "_count": len(results)})
+}
+
+// matches evaluates the query's conditions against a document, every condition must hold
+func (q fakeQuery) matches(document map[string]any) (bool, error) {
+	where, ok := strings.CutPrefix(q.Text, "SELECT * FROM c WHERE ")
+	if !ok {
+		return false, fmt.Errorf("unsupported query %q", q.Text)
+	}
+	where, _, _ = strings.Cut(where, " ORDER BY ")
+
+	for _, condition := range strings.Split(where, " AND ") {
+		// A parenthesized condition holds when any of its terms does
+		terms := []string{condition}
+		if inner, ok := strings.CutPrefix(condition, "("); ok {
+			terms = strings.Split(strings.TrimSuffix(inner, ")"), " OR ")
+		}
+
+		held := false
+		for _, term := range terms {
+			ok, err := q.evaluate(term, document)
+			if err != nil {
+				return false, err
+			}
+			held = held || ok
+		}
+		if !held {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evaluate evaluates a single term, a comparison with a bound parameter or an undefined check
+func (q fakeQuery) evaluate(term string, document map[string]any) (bool, error) {
+	if match := fieldEqualsPattern.FindStringSubmatch(term); match != nil {
+		value, ok := q.Parameters[match[2]]
+		if !ok {
+			return false, fmt.Errorf("unbound parameter %s", match[2])
+		}
+		return document[match[1]] == value, nil
+	}
+	if match := fieldUndefinedPattern.FindStringSubmatch(term); match != nil {
+		_, defined := document[match[1]]
+		return !defined, nil
+	}
+	return false, fmt.Errorf("unsupported condition %q", term)
+}
@@ -0,0 +1,147 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// InvitationRepository handles organization invitation storage operations
+type InvitationRepository struct {
+	client    *Client
+	container *azcosmos.ContainerClient
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(client *Client) (*InvitationRepository, error) {
+	// Use the same container as organizations so accepting an invitation and
+	// adding the member can share a transactional batch
+	container, err := client.GetContainer("organizations")
+	if err != nil {
+		return nil, err
+	}
+	return &InvitationRepository{
+		client:    client,
+		container: container,
+	}, nil
+}
+
+// Create creates a new invitation
+func (r *InvitationRepository) Create(ctx context.Context, invitation *types.Invitation) error {
+	invitation.EntityType = "invitation"
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	item, err := json.Marshal(invitation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invitation: %w", err)
+	}
+
+	_, err = r.container.CreateItem(ctx, azcosmos.NewPartitionKeyString(invitation.PartitionKey), item, nil)
+	return handleCosmosError(err)
+}
+
+// Get retrieves an invitation by ID (queries across all partitions)
+func (r *InvitationRepository) Get(ctx context.Context, id string) (*types.Invitation, error) {
+	query, options := selectAll().whereEquals("id", id).whereEquals("entity_type", "invitation").build()
+	invitations, err := r.list(ctx, query, azcosmos.NewPartitionKey(), options)
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return invitations[0], nil
+}
+
+// ListByOrganization lists invitations for an organization
+func (r *InvitationRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Invitation, error) {
+	query, options := selectAll().whereEquals("entity_type", "invitation").build()
+	return r.list(ctx, query, azcosmos.NewPartitionKeyString(organizationID), options)
+}
+
+// ListByUsername lists invitations sent to a GitHub user (queries across all partitions)
+func (r *InvitationRepository) ListByUsername(ctx context.Context, username string) ([]*types.Invitation, error) {
+	query, options := selectAll().whereEquals("entity_type", "invitation").whereEqualsIgnoreCase("github_username", username).build()
+	return r.list(ctx, query, azcosmos.NewPartitionKey(), options)
+}
+
+// Update updates an invitation
+func (r *InvitationRepository) Update(ctx context.Context, invitation *types.Invitation) error {
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	item, err := json.Marshal(invitation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invitation: %w", err)
+	}
+
+	_, err = r.container.ReplaceItem(ctx, azcosmos.NewPartitionKeyString(invitation.PartitionKey), invitation.ID, item, nil)
+	return handleCosmosError(err)
+}
+
+// Accept marks an invitation accepted and adds the user as a member with the invited role.
+// Uses a transactional batch since both are in the same container and partition.
+func (r *InvitationRepository) Accept(ctx context.Context, invitation *types.Invitation, userID string) error {
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	member := &types.OrganizationMember{
+		ID:             invitation.OrganizationID + "_" + userID,
+		PartitionKey:   invitation.OrganizationID,
+		EntityType:     "organization_member",
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           invitation.Role,
+		CreatedAt:      time.Now(),
+	}
+
+	invitationItem, err := json.Marshal(invitation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invitation: %w", err)
+	}
+
+	memberItem, err := json.Marshal(member)
+	if err != nil {
+		return fmt.Errorf("failed to marshal organization member: %w", err)
+	}
+
+	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(invitation.PartitionKey))
+	batch.ReplaceItem(invitation.ID, invitationItem, nil)
+	batch.CreateItem(memberItem, nil)
+
+	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
+	if err != nil {
+		return handleCosmosError(err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("transactional batch failed")
+	}
+
+	return nil
+}
+
+func (r *InvitationRepository) list(ctx context.Context, query string, partitionKey azcosmos.PartitionKey, options *azcosmos.QueryOptions) ([]*types.Invitation, error) {
+	queryPager := r.container.NewQueryItemsPager(query, partitionKey, options)
+
+	var invitations []*types.Invitation
+	for queryPager.More() {
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, handleCosmosError(err)
+		}
+
+		for _, item := range queryResponse.Items {
+			var invitation types.Invitation
+			if err := json.Unmarshal(item, &invitation); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal invitation: %w", err)
+			}
+			invitations = append(invitations, &invitation)
+		}
+	}
+
+	return invitations, nil
+}
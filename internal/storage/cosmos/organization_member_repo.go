@@ -1,4 +1,4 @@
-package storage
+package cosmos
 
 import (
 	"context"
@@ -37,13 +37,19 @@ func NewOrganizationMemberRepository(client *Client) (*OrganizationMemberReposit
 }
 
 // IsMember checks if a user is a member of an organization
-// First checks organization_member records, then falls back to the old MemberIDs array for backward compatibility
 func (r *OrganizationMemberRepository) IsMember(ctx context.Context, orgID, userID string) (bool, error) {
+	_, isMember, err := r.GetMemberRole(ctx, orgID, userID)
+	return isMember, err
+}
+
+// GetMemberRole returns the role of a user in an organization and whether the user is a member
+// First checks organization_member records, then falls back to the old MemberIDs array for backward compatibility
+func (r *OrganizationMemberRepository) GetMemberRole(ctx context.Context, orgID, userID string) (types.Role, bool, error) {
 	memberID := orgID + "_" + userID
 	partitionKey := orgID
 
 	// Check for organization_member record in the organizations container
-	_, err := r.container.ReadItem(ctx, azcosmos.NewPartitionKeyString(partitionKey), memberID, nil)
+	resp, err := r.container.ReadItem(ctx, azcosmos.NewPartitionKeyString(partitionKey), memberID, nil)
 	if err != nil {
 		// Check if it's a 404 error (member not found)
 		var respErr *azcore.ResponseError
@@ -55,30 +61,36 @@ func (r *OrganizationMemberRepository) IsMember(ctx context.Context, orgID, user
 			org, getErr := r.orgRepo.Get(ctx, orgID)
 			if getErr != nil {
 				// If we can't get the org, return false (not a member)
-				return false, nil
+				return "", false, nil
 			}
-			// Check if userID is in the MemberIDs array
+			// Check if userID is in the MemberIDs array, legacy members were organization creators
 			for _, memberID := range org.MemberIDs {
 				if memberID == userID {
-					return true, nil
+					return types.RoleOwner, true, nil
 				}
 			}
-			return false, nil
+			return "", false, nil
 		}
-		return false, handleCosmosError(err)
+		return "", false, handleCosmosError(err)
+	}
+
+	var member types.OrganizationMember
+	if err := json.Unmarshal(resp.Value, &member); err != nil {
+		return "", false, fmt.Errorf("failed to unmarshal organization member: %w", err)
 	}
 
-	return true, nil
+	return member.GetRole(), true, nil
 }
 
 // AddMember adds a user as a member of an organization
-func (r *OrganizationMemberRepository) AddMember(ctx context.Context, orgID, userID string) error {
+func (r *OrganizationMemberRepository) AddMember(ctx context.Context, orgID, userID string, role types.Role) error {
 	member := &types.OrganizationMember{
 		ID:             orgID + "_" + userID,
 		PartitionKey:   orgID,
 		EntityType:     "organization_member",
 		OrganizationID: orgID,
 		UserID:         userID,
+		Role:           role,
 		CreatedAt:      time.Now(),
 	}
 
@@ -102,13 +114,14 @@ func (r *OrganizationMemberRepository) CreateOrganizationWithMember(ctx context.
 	}
 	org.PartitionKey = org.GetPartitionKey()
 
-	// Create member record
+	// Create member record, the creator owns the organization
 	member := &types.OrganizationMember{
 		ID:             org.ID + "_" + userID,
 		PartitionKey:   org.OrganizationID,
 		EntityType:     "organization_member",
 		OrganizationID: org.ID,
 		UserID:         userID,
+		Role:           types.RoleOwner,
 		CreatedAt:      time.Now(),
 	}
 
@@ -153,8 +166,8 @@ func (r *OrganizationMemberRepository) RemoveMember(ctx context.Context, orgID,
 // ListMembers lists all members of an organization
 func (r *OrganizationMemberRepository) ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error) {
 	// Query only organization_member records in this partition
-	query := "SELECT * FROM c WHERE c.entity_type = 'organization_member'"
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(orgID), nil)
+	query, options := selectAll().whereEquals("entity_type", "organization_member").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(orgID), options)
 
 	var members []*types.OrganizationMember
 	for queryPager.More() {
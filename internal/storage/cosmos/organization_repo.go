@@ -1,4 +1,4 @@
-package storage
+package cosmos
 
 import (
 	"context"
@@ -75,9 +75,9 @@ func (r *OrganizationRepository) Get(ctx context.Context, id string) (*types.Org
 // List lists all organizations
 func (r *OrganizationRepository) List(ctx context.Context) ([]*types.Organization, error) {
 	// Filter by entity_type to only get organizations, not members
-	query := "SELECT * FROM c WHERE (c.entity_type = 'organization' OR NOT IS_DEFINED(c.entity_type))"
+	query, options := selectAll().whereEntityType("organization").build()
 	// Use NewPartitionKey() for cross-partition query (empty partition key list)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), nil)
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), options)
 
 	var orgs []*types.Organization
 	for queryPager.More() {
@@ -108,8 +108,8 @@ func (r *OrganizationRepository) ListByMemberID(ctx context.Context, userID stri
 	orgMap := make(map[string]*types.Organization) // Use map to deduplicate
 
 	// Step 1: Query for organization_member records where user_id matches (new system)
-	memberQuery := fmt.Sprintf("SELECT * FROM c WHERE c.entity_type = 'organization_member' AND c.user_id = '%s'", userID)
-	memberPager := r.container.NewQueryItemsPager(memberQuery, azcosmos.NewPartitionKey(), nil)
+	memberQuery, memberOptions := selectAll().whereEquals("entity_type", "organization_member").whereEquals("user_id", userID).build()
+	memberPager := r.container.NewQueryItemsPager(memberQuery, azcosmos.NewPartitionKey(), memberOptions)
 
 	for memberPager.More() {
 		memberResponse, err := memberPager.NextPage(ctx)
@@ -139,8 +139,8 @@ func (r *OrganizationRepository) ListByMemberID(ctx context.Context, userID stri
 	}
 
 	// Step 2: Query organizations where member_ids array contains the user ID (backward compatibility)
-	legacyQuery := fmt.Sprintf("SELECT * FROM c WHERE ((c.entity_type = 'organization' OR NOT IS_DEFINED(c.entity_type)) AND ARRAY_CONTAINS(c.member_ids, '%s'))", userID)
-	legacyPager := r.container.NewQueryItemsPager(legacyQuery, azcosmos.NewPartitionKey(), nil)
+	legacyQuery, legacyOptions := selectAll().whereEntityType("organization").whereArrayContains("member_ids", userID).build()
+	legacyPager := r.container.NewQueryItemsPager(legacyQuery, azcosmos.NewPartitionKey(), legacyOptions)
 
 	for legacyPager.More() {
 		legacyResponse, err := legacyPager.NextPage(ctx)
@@ -0,0 +1,147 @@
+package cosmos
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+)
+
+// fieldPattern restricts field names to plain document properties and system
+// properties such as _ts, so that only values, never identifiers, can come from callers
+var fieldPattern = regexp.MustCompile(`^_?[a-z][a-z0-9_]*$`)
+
+// queryBuilder builds parameterized Cosmos DB SQL queries.
+// Every value is bound through QueryOptions.QueryParameters and never written into
+// the query text, so user-controlled IDs cannot change the meaning of a query.
+type queryBuilder struct {
+	projection string
+	conditions []string
+	orderBy    string
+	params     []azcosmos.QueryParameter
+}
+
+// selectAll starts a "SELECT * FROM c" query
+func selectAll() *queryBuilder {
+	return &queryBuilder{projection: "*"}
+}
+
+// selectCount starts a "SELECT VALUE COUNT(1) FROM c" query
+func selectCount() *queryBuilder {
+	return &queryBuilder{projection: "VALUE COUNT(1)"}
+}
+
+// field validates a document property name and returns its reference.
+// Field names are always constants in this package, so an invalid name is a programming error.
+func field(name string) string {
+	if !fieldPattern.MatchString(name) {
+		panic(fmt.Sprintf("cosmos: invalid query field %q", name))
+	}
+	return "c." + name
+}
+
+// bind adds a query parameter for value and returns its placeholder
+func (q *queryBuilder) bind(value any) string {
+	name := fmt.Sprintf("@p%d", len(q.params))
+	q.params = append(q.params, azcosmos.QueryParameter{Name: name, Value: value})
+	return name
+}
+
+// whereEquals adds "c.<name> = @pN"
+func (q *queryBuilder) whereEquals(name string, value any) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("%s = %s", field(name), q.bind(value)))
+	return q
+}
+
+// whereEqualsAny adds "(c.<a> = @pN OR c.<b> = @pN)", matching value against any of the fields
+func (q *queryBuilder) whereEqualsAny(value any, names ...string) *queryBuilder {
+	param := q.bind(value)
+	clauses := make([]string, len(names))
+	for i, name := range names {
+		clauses[i] = fmt.Sprintf("%s = %s", field(name), param)
+	}
+	q.conditions = append(q.conditions, "("+strings.Join(clauses, " OR ")+")")
+	return q
+}
+
+// whereEqualsIgnoreCase adds "STRINGEQUALS(c.<name>, @pN, true)"
+func (q *queryBuilder) whereEqualsIgnoreCase(name string, value string) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("STRINGEQUALS(%s, %s, true)", field(name), q.bind(value)))
+	return q
+}
+
+// whereEntityType matches documents with the given entity_type discriminator,
+// including legacy documents written before entity_type existed
+func (q *queryBuilder) whereEntityType(entityType string) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("(%s = %s OR NOT IS_DEFINED(%s))", field("entity_type"), q.bind(entityType), field("entity_type")))
+	return q
+}
+
+// whereArrayContains adds "ARRAY_CONTAINS(c.<name>, @pN)"
+func (q *queryBuilder) whereArrayContains(name string, value any) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("ARRAY_CONTAINS(%s, %s)", field(name), q.bind(value)))
+	return q
+}
+
+// whereAtLeast adds "c.<name> >= @pN"
+func (q *queryBuilder) whereAtLeast(name string, value any) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("%s >= %s", field(name), q.bind(value)))
+	return q
+}
+
+// whereAtMost adds "c.<name> <= @pN"
+func (q *queryBuilder) whereAtMost(name string, value any) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("%s <= %s", field(name), q.bind(value)))
+	return q
+}
+
+// whereAbove adds "c.<name> > @pN"
+func (q *queryBuilder) whereAbove(name string, value any) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("%s > %s", field(name), q.bind(value)))
+	return q
+}
+
+// whereBelow adds "c.<name> < @pN"
+func (q *queryBuilder) whereBelow(name string, value any) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("%s < %s", field(name), q.bind(value)))
+	return q
+}
+
+// whereIn adds "ARRAY_CONTAINS(@pN, c.<name>)", matching any of values
+func (q *queryBuilder) whereIn(name string, values []string) *queryBuilder {
+	q.conditions = append(q.conditions, fmt.Sprintf("ARRAY_CONTAINS(%s, %s)", q.bind(values), field(name)))
+	return q
+}
+
+// orderByAsc orders results by a field, oldest or smallest first
+func (q *queryBuilder) orderByAsc(name string) *queryBuilder {
+	q.orderBy = field(name) + " ASC"
+	return q
+}
+
+// orderByDesc orders results by a field, newest or largest first
+func (q *queryBuilder) orderByDesc(name string) *queryBuilder {
+	q.orderBy = field(name) + " DESC"
+	return q
+}
+
+// build returns the query text and the options carrying its parameters
+func (q *queryBuilder) build() (string, *azcosmos.QueryOptions) {
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	b.WriteString(q.projection)
+	b.WriteString(" FROM c")
+	if len(q.conditions) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(q.conditions, " AND "))
+	}
+	if q.orderBy != "" {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(q.orderBy)
+	}
+
+	return b.String(), &azcosmos.QueryOptions{
+		QueryParameters: q.params,
+	}
+}
@@ -0,0 +1,108 @@
+package cosmos
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	tests := []struct {
+		name       string
+		builder    *queryBuilder
+		wantQuery  string
+		wantParams []any
+	}{
+		{
+			name:      "history",
+			builder:   selectAll().whereEquals("organization_id", "org-1").whereEquals("entity_type", "audit_history").whereEquals("entity_id", "sk-1").orderByDesc("created_at"),
+			wantQuery: "SELECT * FROM c WHERE c.organization_id = @p0 AND c.entity_type = @p1 AND c.entity_id = @p2 ORDER BY c.created_at DESC",
+			wantParams: []any{
+				"org-1", "audit_history", "sk-1",
+			},
+		},
+		{
+			name:       "count with legacy entity type",
+			builder:    selectCount().whereEquals("organization_id", "org-1").whereEntityType("service_key"),
+			wantQuery:  "SELECT VALUE COUNT(1) FROM c WHERE c.organization_id = @p0 AND (c.entity_type = @p1 OR NOT IS_DEFINED(c.entity_type))",
+			wantParams: []any{"org-1", "service_key"},
+		},
+		{
+			name:       "case-insensitive equality",
+			builder:    selectAll().whereEquals("entity_type", "invitation").whereEqualsIgnoreCase("github_username", "Octocat"),
+			wantQuery:  "SELECT * FROM c WHERE c.entity_type = @p0 AND STRINGEQUALS(c.github_username, @p1, true)",
+			wantParams: []any{"invitation", "Octocat"},
+		},
+		{
+			name:       "any of several fields",
+			builder:    selectAll().whereEqualsAny("hash", "token_value", "previous_token_value"),
+			wantQuery:  "SELECT * FROM c WHERE (c.token_value = @p0 OR c.previous_token_value = @p0)",
+			wantParams: []any{"hash"},
+		},
+		{
+			name:       "array contains",
+			builder:    selectAll().whereArrayContains("member_ids", "user-1"),
+			wantQuery:  "SELECT * FROM c WHERE ARRAY_CONTAINS(c.member_ids, @p0)",
+			wantParams: []any{"user-1"},
+		},
+		{
+			name:       "system property lower bound",
+			builder:    selectAll().whereEquals("entity_type", "audit_history").whereAtLeast("_ts", int64(1700000000)),
+			wantQuery:  "SELECT * FROM c WHERE c.entity_type = @p0 AND c._ts >= @p1",
+			wantParams: []any{"audit_history", int64(1700000000)},
+		},
+		{
+			name:       "audit search",
+			builder:    selectAll().whereIn("action", []string{"updated", "deleted"}).whereAtMost("_ts", int64(1700000600)).whereBelow("_ts", int64(1700000900)).orderByDesc("_ts"),
+			wantQuery:  "SELECT * FROM c WHERE ARRAY_CONTAINS(@p0, c.action) AND c._ts <= @p1 AND c._ts < @p2 ORDER BY c._ts DESC",
+			wantParams: []any{[]string{"updated", "deleted"}, int64(1700000600), int64(1700000900)},
+		},
+		{
+			name:       "audit chain",
+			builder:    selectAll().whereEquals("entity_type", "audit_history").whereAbove("sequence", int64(42)).orderByAsc("sequence"),
+			wantQuery:  "SELECT * FROM c WHERE c.entity_type = @p0 AND c.sequence > @p1 ORDER BY c.sequence ASC",
+			wantParams: []any{"audit_history", int64(42)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, options := tt.builder.build()
+			if query != tt.wantQuery {
+				t.Errorf("expected query %q, got %q", tt.wantQuery, query)
+			}
+			if len(options.QueryParameters) != len(tt.wantParams) {
+				t.Fatalf("expected %d parameters, got %d", len(tt.wantParams), len(options.QueryParameters))
+			}
+			for i, param := range options.QueryParameters {
+				if !reflect.DeepEqual(param.Value, tt.wantParams[i]) {
+					t.Errorf("expected parameter %s to be %v, got %v", param.Name, tt.wantParams[i], param.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryBuilderBindsCraftedValues(t *testing.T) {
+	crafted := "x' OR 1=1 --"
+
+	query, options := selectAll().whereEquals("organization_id", crafted).whereEquals("entity_id", crafted).build()
+	if strings.Contains(query, crafted) || strings.Contains(query, "'") {
+		t.Errorf("expected crafted value to stay out of the query text, got %q", query)
+	}
+	for _, param := range options.QueryParameters {
+		if param.Value != crafted {
+			t.Errorf("expected crafted value to be bound verbatim, got %v", param.Value)
+		}
+	}
+}
+
+func TestQueryBuilderRejectsInvalidField(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected invalid field name to panic")
+		}
+	}()
+
+	selectAll().whereEquals("id = 'x' OR 1=1 --", "value")
+}
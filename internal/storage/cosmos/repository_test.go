@@ -0,0 +1,177 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// craftedIDs try to break out of a quoted value if it were written into the query text
+var craftedIDs = []string{
+	"x' OR 1=1 --",
+	`x" OR 1=1 --`,
+	"org-1' OR c.organization_id != '",
+	"org-1 OR 1=1",
+}
+
+func TestRepositoriesIgnoreCraftedIDs(t *testing.T) {
+	ctx := context.Background()
+	client, fake := newTestClient(t)
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake.add(t, "service_keys", &types.ServiceKey{ID: "sk-1", EntityType: "service_key", OrganizationID: "org-1", Name: "orders", TokenValue: storage.HashToken("sk-token"), CreatedAt: created})
+	fake.add(t, "service_keys", &types.AuditHistory{ID: "ah-1", EntityType: "audit_history", OrganizationID: "org-1", EntityID: "sk-1", Action: types.AuditActionCreated, CreatedAt: created})
+	fake.add(t, "api_keys", &types.ApiKey{ID: "ak-1", EntityType: "api_key", OrganizationID: "org-1", Name: "ci", CreatedAt: created})
+	fake.add(t, "api_keys", &types.AuditHistory{ID: "ah-2", EntityType: "audit_history", OrganizationID: "org-1", EntityID: "ak-1", Action: types.AuditActionCreated, CreatedAt: created})
+	fake.add(t, "applications", &types.Application{ID: "app-1", EntityType: "application", OrganizationID: "org-1", Name: "orders", CreatedAt: created})
+	fake.add(t, "applications", &types.AuditHistory{ID: "ah-3", EntityType: "audit_history", OrganizationID: "org-1", EntityID: "app-1", Action: types.AuditActionCreated, CreatedAt: created})
+
+	serviceKeys, err := NewServiceKeyRepository(client)
+	if err != nil {
+		t.Fatalf("failed to create service key repository: %v", err)
+	}
+	apiKeys, err := NewApiKeyRepository(client)
+	if err != nil {
+		t.Fatalf("failed to create API key repository: %v", err)
+	}
+	applications, err := NewApplicationRepository(client)
+	if err != nil {
+		t.Fatalf("failed to create application repository: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		// call queries with an organization ID and an entity ID, and returns how many records it found
+		call func(orgID, entityID string) (int, error)
+		// entityID is the real entity ID, replaced by a crafted one in the second half of the test
+		entityID string
+		// bound returns the value the crafted entity ID is sent as
+		bound func(entityID string) string
+	}{
+		{
+			name: "service keys by organization",
+			call: func(orgID, _ string) (int, error) {
+				keys, err := serviceKeys.ListByOrganization(ctx, orgID)
+				return len(keys), err
+			},
+		},
+		{
+			name: "service key history",
+			call: func(orgID, entityID string) (int, error) {
+				history, err := serviceKeys.GetHistory(ctx, orgID, entityID)
+				return len(history), err
+			},
+			entityID: "sk-1",
+		},
+		{
+			name: "service key by token in organization",
+			call: func(orgID, token string) (int, error) {
+				_, err := serviceKeys.FindByTokenValueInOrg(ctx, orgID, token)
+				if errors.Is(err, storage.ErrNotFound) {
+					return 0, nil
+				}
+				if err != nil {
+					return 0, err
+				}
+				return 1, nil
+			},
+			entityID: "sk-token",
+			bound:    storage.HashToken,
+		},
+		{
+			name: "API keys by organization",
+			call: func(orgID, _ string) (int, error) {
+				keys, err := apiKeys.ListByOrganization(ctx, orgID)
+				return len(keys), err
+			},
+		},
+		{
+			name: "API key history",
+			call: func(orgID, entityID string) (int, error) {
+				history, err := apiKeys.GetHistory(ctx, orgID, entityID)
+				return len(history), err
+			},
+			entityID: "ak-1",
+		},
+		{
+			name: "applications by organization",
+			call: func(orgID, _ string) (int, error) {
+				apps, err := applications.ListByOrganization(ctx, orgID)
+				return len(apps), err
+			},
+		},
+		{
+			name: "application history",
+			call: func(orgID, entityID string) (int, error) {
+				history, err := applications.GetHistory(ctx, orgID, entityID)
+				return len(history), err
+			},
+			entityID: "app-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The real IDs find the record, so an empty result below comes from the crafted ID
+			if found, err := tt.call("org-1", tt.entityID); err != nil || found != 1 {
+				t.Fatalf("expected the real IDs to find 1 record, got %d and %v", found, err)
+			}
+
+			for _, crafted := range craftedIDs {
+				orgQueries := len(fake.received())
+				found, err := tt.call(crafted, tt.entityID)
+				if err != nil || found != 0 {
+					t.Errorf("expected organization %q to find nothing, got %d and %v", crafted, found, err)
+				}
+				expectBound(t, fake.received()[orgQueries:], crafted, crafted, crafted)
+
+				if tt.entityID == "" {
+					continue
+				}
+				bound := crafted
+				if tt.bound != nil {
+					bound = tt.bound(crafted)
+				}
+				entityQueries := len(fake.received())
+				found, err = tt.call("org-1", crafted)
+				if err != nil || found != 0 {
+					t.Errorf("expected entity %q to find nothing, got %d and %v", crafted, found, err)
+				}
+				expectBound(t, fake.received()[entityQueries:], crafted, bound, "org-1")
+			}
+		})
+	}
+}
+
+// expectBound checks that queries kept a crafted value out of their text, sent it as the bound
+// parameter value, and were scoped to the organization's partition
+func expectBound(t *testing.T, queries []fakeQuery, crafted, bound, orgID string) {
+	t.Helper()
+	if len(queries) == 0 {
+		t.Fatalf("expected a query for %q", crafted)
+	}
+	partitionKey, _ := json.Marshal([]string{orgID})
+	for _, query := range queries {
+		if strings.Contains(query.Text, crafted) || strings.ContainsAny(query.Text, `'"`) {
+			t.Errorf("expected %q to stay out of the query text, got %q", crafted, query.Text)
+		}
+
+		values := make([]any, 0, len(query.Parameters))
+		for _, value := range query.Parameters {
+			values = append(values, value)
+		}
+		if !slices.Contains(values, any(bound)) {
+			t.Errorf("expected %q to be bound as a parameter, got %v", bound, query.Parameters)
+		}
+		if query.PartitionKey != string(partitionKey) {
+			t.Errorf("expected the query to be scoped to partition %s, got %s", partitionKey, query.PartitionKey)
+		}
+	}
+}
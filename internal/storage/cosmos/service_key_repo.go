@@ -1,14 +1,13 @@
-package storage
+package cosmos
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/brianfromlife/baluster/internal/storage"
 	"github.com/brianfromlife/baluster/internal/types"
 )
 
@@ -28,30 +27,13 @@ func NewServiceKeyRepository(client *Client) (*ServiceKeyRepository, error) {
 	}, nil
 }
 
-// HashToken hashes a token value for storage
-func HashToken(tokenValue string) string {
-	hash := sha256.Sum256([]byte(tokenValue))
-	return hex.EncodeToString(hash[:])
-}
-
 // Create creates a new service key with audit history
 func (r *ServiceKeyRepository) Create(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
-	token.TokenValue = HashToken(token.TokenValue)
+	token.TokenValue = storage.HashToken(token.TokenValue)
 	token.PartitionKey = token.GetPartitionKey()
 
 	// Create audit history record
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    token.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          token.ID,
-		Action:            types.AuditActionCreated,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
-	}
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionCreated, userID, githubID, username, nil)
 
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(token.PartitionKey))
 
@@ -61,23 +43,7 @@ func (r *ServiceKeyRepository) Create(ctx context.Context, token *types.ServiceK
 	}
 	batch.CreateItem(tokenItem, nil)
 
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-
-	batch.CreateItem(auditItem, nil)
-
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
-
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
-	}
-
-	return nil
+	return executeAudited(ctx, r.container, batch, auditHistory)
 }
 
 // Get retrieves a service key by ID using the organization ID as the partition key
@@ -94,7 +60,7 @@ func (r *ServiceKeyRepository) Get(ctx context.Context, organizationID, id strin
 
 	// Verify entity type to ensure we got a service key, not audit history
 	if token.EntityType != "" && token.EntityType != "service_key" {
-		return nil, fmt.Errorf("token not found")
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
 	}
 
 	return &token, nil
@@ -102,11 +68,12 @@ func (r *ServiceKeyRepository) Get(ctx context.Context, organizationID, id strin
 
 // FindByTokenValue finds a service key by its hashed value (queries across all partitions)
 func (r *ServiceKeyRepository) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ServiceKey, error) {
-	hashed := HashToken(tokenValue)
+	hashed := storage.HashToken(tokenValue)
 
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.token_value = '%s' AND (c.entity_type = 'service_key' OR NOT IS_DEFINED(c.entity_type))", hashed)
+	// A rotated key is also found by its previous token, which the caller checks against the grace period
+	query, options := selectAll().whereEqualsAny(hashed, "token_value", "previous_token_value").whereEntityType("service_key").build()
 
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), nil)
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), options)
 
 	for queryPager.More() {
 		queryResponse, err := queryPager.NextPage(ctx)
@@ -124,16 +91,16 @@ func (r *ServiceKeyRepository) FindByTokenValue(ctx context.Context, tokenValue
 		}
 	}
 
-	return nil, fmt.Errorf("token not found")
+	return nil, fmt.Errorf("token %w", storage.ErrNotFound)
 }
 
 // FindByTokenValueInOrg finds a service key by its hashed value within a specific organization (more efficient)
 func (r *ServiceKeyRepository) FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error) {
-	hashed := HashToken(tokenValue)
+	hashed := storage.HashToken(tokenValue)
 
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.organization_id = '%s' AND c.token_value = '%s' AND (c.entity_type = 'service_key' OR NOT IS_DEFINED(c.entity_type))", organizationID, hashed)
+	query, options := selectAll().whereEquals("organization_id", organizationID).whereEqualsAny(hashed, "token_value", "previous_token_value").whereEntityType("service_key").build()
 
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	for queryPager.More() {
 		queryResponse, err := queryPager.NextPage(ctx)
@@ -151,13 +118,13 @@ func (r *ServiceKeyRepository) FindByTokenValueInOrg(ctx context.Context, organi
 		}
 	}
 
-	return nil, fmt.Errorf("token not found")
+	return nil, fmt.Errorf("token %w", storage.ErrNotFound)
 }
 
 // CountByOrganization counts service keys for an organization
 func (r *ServiceKeyRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
-	query := fmt.Sprintf("SELECT VALUE COUNT(1) FROM c WHERE c.organization_id = '%s' AND (c.entity_type = 'service_key' OR NOT IS_DEFINED(c.entity_type))", organizationID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	query, options := selectCount().whereEquals("organization_id", organizationID).whereEntityType("service_key").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	if !queryPager.More() {
 		return 0, nil
@@ -182,8 +149,8 @@ func (r *ServiceKeyRepository) CountByOrganization(ctx context.Context, organiza
 
 // ListByOrganization lists service keys for an organization
 func (r *ServiceKeyRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.ServiceKey, error) {
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.organization_id = '%s' AND (c.entity_type = 'service_key' OR NOT IS_DEFINED(c.entity_type))", organizationID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+	query, options := selectAll().whereEquals("organization_id", organizationID).whereEntityType("service_key").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	var tokens []*types.ServiceKey
 	for queryPager.More() {
@@ -206,21 +173,27 @@ func (r *ServiceKeyRepository) ListByOrganization(ctx context.Context, organizat
 
 // Update updates a service key with audit history
 func (r *ServiceKeyRepository) Update(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	return r.replace(ctx, token, types.AuditActionUpdated, userID, githubID, username)
+}
+
+// Rotate stores a service key with a new token value with audit history.
+// The new token value is hashed, the previous hash must already be set by the caller.
+func (r *ServiceKeyRepository) Rotate(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	return r.replace(ctx, token, types.AuditActionRotated, userID, githubID, username)
+}
+
+func (r *ServiceKeyRepository) replace(ctx context.Context, token *types.ServiceKey, action types.AuditAction, userID, githubID, username string) error {
 	token.PartitionKey = token.GetPartitionKey()
 
-	// Create audit history record
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    token.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          token.ID,
-		Action:            types.AuditActionUpdated,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
+	// Read the stored version to record what changed
+	before, err := r.Get(ctx, token.OrganizationID, token.ID)
+	if err != nil {
+		return err
 	}
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+
+	// Create audit history record
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, action, userID, githubID, username, storage.DiffServiceKeys(before, token))
 
 	// Create transactional batch
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(token.PartitionKey))
@@ -232,24 +205,7 @@ func (r *ServiceKeyRepository) Update(ctx context.Context, token *types.ServiceK
 	}
 	batch.ReplaceItem(token.ID, tokenItem, nil)
 
-	// Add audit history create
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-	batch.CreateItem(auditItem, nil)
-
-	// Execute batch
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
-
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
-	}
-
-	return nil
+	return executeAudited(ctx, r.container, batch, auditHistory)
 }
 
 // Delete deletes a service key with audit history
@@ -257,18 +213,7 @@ func (r *ServiceKeyRepository) Delete(ctx context.Context, token *types.ServiceK
 	token.PartitionKey = token.GetPartitionKey()
 
 	// Create audit history record
-	auditHistory := &types.AuditHistory{
-		ID:                GenerateID(),
-		OrganizationID:    token.OrganizationID,
-		EntityType:        "audit_history",
-		EntityID:          token.ID,
-		Action:            types.AuditActionDeleted,
-		CreatedByUserID:   userID,
-		CreatedByGitHubID: githubID,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
-	}
-	auditHistory.PartitionKey = auditHistory.GetPartitionKey()
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionDeleted, userID, githubID, username, nil)
 
 	// Create transactional batch
 	batch := r.container.NewTransactionalBatch(azcosmos.NewPartitionKeyString(token.PartitionKey))
@@ -276,30 +221,49 @@ func (r *ServiceKeyRepository) Delete(ctx context.Context, token *types.ServiceK
 	// Add service key delete
 	batch.DeleteItem(token.ID, nil)
 
-	// Add audit history create
-	auditItem, err := json.Marshal(auditHistory)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit history: %w", err)
-	}
-	batch.CreateItem(auditItem, nil)
+	return executeAudited(ctx, r.container, batch, auditHistory)
+}
 
-	// Execute batch
-	resp, err := r.container.ExecuteTransactionalBatch(ctx, batch, nil)
-	if err != nil {
-		return handleCosmosError(err)
-	}
+// GetHistory retrieves audit history for a service key
+func (r *ServiceKeyRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	query, options := selectAll().whereEquals("organization_id", organizationID).whereEquals("entity_type", "audit_history").whereEquals("entity_id", entityID).orderByDesc("created_at").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
-	if !resp.Success {
-		return handleCosmosError(fmt.Errorf("batch operation failed"))
+	var history []*types.AuditHistory
+	for queryPager.More() {
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, handleCosmosError(err)
+		}
+
+		for _, item := range queryResponse.Items {
+			var audit types.AuditHistory
+			if err := json.Unmarshal(item, &audit); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal audit history: %w", err)
+			}
+			history = append(history, &audit)
+		}
 	}
 
-	return nil
+	return history, nil
 }
 
-// GetHistory retrieves audit history for a service key
-func (r *ServiceKeyRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.organization_id = '%s' AND c.entity_type = 'audit_history' AND c.entity_id = '%s' ORDER BY c.created_at DESC", organizationID, entityID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), nil)
+// historyClockSkew widens the _ts lower bound of ListEvents, created_at comes from the
+// writing server's clock while _ts is set by Cosmos DB when the write lands
+const historyClockSkew = time.Minute
+
+// ListEvents returns the audit history of every service key in an organization after the cursor
+func (r *ServiceKeyRepository) ListEvents(ctx context.Context, organizationID, cursor string, limit int) ([]*storage.AuditEvent, error) {
+	builder := selectAll().whereEquals("organization_id", organizationID).whereEquals("entity_type", "audit_history")
+	if cursor != "" {
+		after, err := storage.HistoryCursorTime(cursor)
+		if err != nil {
+			return nil, err
+		}
+		builder = builder.whereAtLeast("_ts", after.Add(-historyClockSkew).Unix())
+	}
+	query, options := builder.build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
 
 	var history []*types.AuditHistory
 	for queryPager.More() {
@@ -317,5 +281,5 @@ func (r *ServiceKeyRepository) GetHistory(ctx context.Context, organizationID, e
 		}
 	}
 
-	return history, nil
+	return storage.HistoryAfter(history, cursor, limit)
 }
@@ -1,4 +1,4 @@
-package storage
+package cosmos
 
 import (
 	"context"
@@ -53,8 +53,8 @@ func (r *UserRepository) CreateOrUpdate(ctx context.Context, user *types.User) e
 func (r *UserRepository) GetByGitHubID(ctx context.Context, githubID string) (*types.User, error) {
 	// Query by github_id field since that's the partition key
 	// The item ID is user.ID, not githubID, so we need to query instead of ReadItem
-	query := fmt.Sprintf("SELECT * FROM c WHERE c.github_id = '%s'", githubID)
-	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(githubID), nil)
+	query, options := selectAll().whereEquals("github_id", githubID).build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(githubID), options)
 
 	for queryPager.More() {
 		queryResponse, err := queryPager.NextPage(ctx)
@@ -0,0 +1,253 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// WebhookRepository handles webhook and webhook delivery storage operations.
+// Deliveries live in the webhooks container next to their webhook.
+type WebhookRepository struct {
+	client    *Client
+	container *azcosmos.ContainerClient
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(client *Client) (*WebhookRepository, error) {
+	container, err := client.GetContainer("webhooks")
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookRepository{
+		client:    client,
+		container: container,
+	}, nil
+}
+
+// Create creates a new webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *types.Webhook) error {
+	webhook.EntityType = "webhook"
+	webhook.PartitionKey = webhook.GetPartitionKey()
+
+	item, err := json.Marshal(webhook)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	_, err = r.container.CreateItem(ctx, azcosmos.NewPartitionKeyString(webhook.PartitionKey), item, nil)
+	return handleCosmosError(err)
+}
+
+// Get retrieves a webhook by ID using the organization ID as the partition key
+func (r *WebhookRepository) Get(ctx context.Context, organizationID, id string) (*types.Webhook, error) {
+	itemResponse, err := r.container.ReadItem(ctx, azcosmos.NewPartitionKeyString(organizationID), id, nil)
+	if err != nil {
+		return nil, handleCosmosError(err)
+	}
+
+	var webhook types.Webhook
+	if err := json.Unmarshal(itemResponse.Value, &webhook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+	if webhook.EntityType != "webhook" {
+		return nil, storage.ErrNotFound
+	}
+
+	return &webhook, nil
+}
+
+// ListByOrganization lists webhooks for an organization
+func (r *WebhookRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Webhook, error) {
+	query, options := selectAll().whereEquals("entity_type", "webhook").build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
+
+	var webhooks []*types.Webhook
+	for queryPager.More() {
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, handleCosmosError(err)
+		}
+
+		for _, item := range queryResponse.Items {
+			var webhook types.Webhook
+			if err := json.Unmarshal(item, &webhook); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+			}
+			webhooks = append(webhooks, &webhook)
+		}
+	}
+
+	return webhooks, nil
+}
+
+// Update updates a webhook
+func (r *WebhookRepository) Update(ctx context.Context, webhook *types.Webhook) error {
+	webhook.PartitionKey = webhook.GetPartitionKey()
+
+	item, err := json.Marshal(webhook)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	_, err = r.container.ReplaceItem(ctx, azcosmos.NewPartitionKeyString(webhook.PartitionKey), webhook.ID, item, nil)
+	return handleCosmosError(err)
+}
+
+// Delete deletes a webhook, its deliveries are kept
+func (r *WebhookRepository) Delete(ctx context.Context, organizationID, id string) error {
+	if _, err := r.Get(ctx, organizationID, id); err != nil {
+		return err
+	}
+
+	_, err := r.container.DeleteItem(ctx, azcosmos.NewPartitionKeyString(organizationID), id, nil)
+	return handleCosmosError(err)
+}
+
+// CreateDelivery records a new delivery
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	delivery.EntityType = "webhook_delivery"
+	delivery.PartitionKey = delivery.GetPartitionKey()
+
+	item, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook delivery: %w", err)
+	}
+
+	_, err = r.container.CreateItem(ctx, azcosmos.NewPartitionKeyString(delivery.PartitionKey), item, nil)
+	return handleCosmosError(err)
+}
+
+// GetDelivery retrieves a delivery by ID using the organization ID as the partition key
+func (r *WebhookRepository) GetDelivery(ctx context.Context, organizationID, id string) (*types.WebhookDelivery, error) {
+	itemResponse, err := r.container.ReadItem(ctx, azcosmos.NewPartitionKeyString(organizationID), id, nil)
+	if err != nil {
+		return nil, handleCosmosError(err)
+	}
+
+	var delivery types.WebhookDelivery
+	if err := json.Unmarshal(itemResponse.Value, &delivery); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+	}
+	if delivery.EntityType != "webhook_delivery" {
+		return nil, storage.ErrNotFound
+	}
+
+	return &delivery, nil
+}
+
+// UpdateDelivery records the outcome of another delivery attempt
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	delivery.PartitionKey = delivery.GetPartitionKey()
+
+	item, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook delivery: %w", err)
+	}
+
+	_, err = r.container.ReplaceItem(ctx, azcosmos.NewPartitionKeyString(delivery.PartitionKey), delivery.ID, item, nil)
+	return handleCosmosError(err)
+}
+
+// ClaimDelivery stores delivery unless the stored delivery finished or made another attempt since it was read.
+// The replace is conditioned on the ETag read, so only one of several dispatchers claiming at once succeeds.
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, delivery *types.WebhookDelivery, previousAttempts int) (bool, error) {
+	delivery.PartitionKey = delivery.GetPartitionKey()
+	partitionKey := azcosmos.NewPartitionKeyString(delivery.PartitionKey)
+
+	itemResponse, err := r.container.ReadItem(ctx, partitionKey, delivery.ID, nil)
+	if isStatus(err, http.StatusNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, handleCosmosError(err)
+	}
+	var stored types.WebhookDelivery
+	if err := json.Unmarshal(itemResponse.Value, &stored); err != nil {
+		return false, fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+	}
+	if stored.EntityType != "webhook_delivery" || stored.Status != types.WebhookDeliveryStatusPending || stored.Attempts != previousAttempts {
+		return false, nil
+	}
+
+	item, err := json.Marshal(delivery)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal webhook delivery: %w", err)
+	}
+
+	_, err = r.container.ReplaceItem(ctx, partitionKey, delivery.ID, item, &azcosmos.ItemOptions{IfMatchEtag: &itemResponse.ETag})
+	if isStatus(err, http.StatusPreconditionFailed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, handleCosmosError(err)
+	}
+	return true, nil
+}
+
+// ListDeliveries returns up to limit deliveries made to a webhook, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, organizationID, webhookID string, limit int) ([]*types.WebhookDelivery, error) {
+	query, options := selectAll().
+		whereEquals("entity_type", "webhook_delivery").
+		whereEquals("webhook_id", webhookID).
+		orderByDesc("created_at").
+		build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
+
+	var deliveries []*types.WebhookDelivery
+	for queryPager.More() && len(deliveries) < limit {
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, handleCosmosError(err)
+		}
+
+		for _, item := range queryResponse.Items {
+			if len(deliveries) == limit {
+				break
+			}
+			var delivery types.WebhookDelivery
+			if err := json.Unmarshal(item, &delivery); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+			}
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+
+	return deliveries, nil
+}
+
+// ListPendingDeliveries returns up to limit deliveries of the organization still waiting for an attempt, oldest first
+func (r *WebhookRepository) ListPendingDeliveries(ctx context.Context, organizationID string, limit int) ([]*types.WebhookDelivery, error) {
+	query, options := selectAll().
+		whereEquals("entity_type", "webhook_delivery").
+		whereEquals("status", string(types.WebhookDeliveryStatusPending)).
+		orderByAsc("created_at").
+		build()
+	queryPager := r.container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(organizationID), options)
+
+	var deliveries []*types.WebhookDelivery
+	for queryPager.More() && len(deliveries) < limit {
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, handleCosmosError(err)
+		}
+
+		for _, item := range queryResponse.Items {
+			if len(deliveries) == limit {
+				break
+			}
+			var delivery types.WebhookDelivery
+			if err := json.Unmarshal(item, &delivery); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+			}
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+
+	return deliveries, nil
+}
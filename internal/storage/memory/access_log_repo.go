@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const accessLogsContainer = "access_logs"
+
+// AccessLogRepository handles access log and service key usage storage operations.
+// Usage documents live in the access_logs container next to the records they count.
+type AccessLogRepository struct {
+	store *Store
+	// mu makes reading and writing back a usage document atomic
+	mu sync.Mutex
+}
+
+// NewAccessLogRepository creates a new access log repository
+func NewAccessLogRepository(store *Store) *AccessLogRepository {
+	return &AccessLogRepository{
+		store: store,
+	}
+}
+
+// Record stores a batch of decisions and adds them to their service keys' usage
+func (r *AccessLogRepository) Record(ctx context.Context, logs []*types.AccessLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batches := make(map[string]*batch)
+	partition := func(organizationID string) *batch {
+		b, ok := batches[organizationID]
+		if !ok {
+			b = newBatch(accessLogsContainer, organizationID)
+			batches[organizationID] = b
+		}
+		return b
+	}
+
+	for _, log := range logs {
+		log.EntityType = "access_log"
+		log.PartitionKey = log.GetPartitionKey()
+		if err := partition(log.PartitionKey).create(log.ID, log); err != nil {
+			return err
+		}
+	}
+
+	for _, delta := range storage.SummarizeUsage(logs) {
+		usage, err := r.GetUsage(ctx, delta.OrganizationID, delta.ServiceKeyID)
+		if err != nil {
+			return err
+		}
+		storage.MergeUsage(usage, delta)
+		if err := partition(usage.PartitionKey).upsert(usage.ID, usage); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range batches {
+		if err := r.store.execute(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListByServiceKey returns up to limit decisions about a service key made before a time, newest first
+func (r *AccessLogRepository) ListByServiceKey(ctx context.Context, organizationID, serviceKeyID string, before time.Time, limit int) ([]*types.AccessLog, error) {
+	if organizationID == "" {
+		return nil, nil
+	}
+	logs, err := query(r.store, accessLogsContainer, organizationID, "access_log", func(log *types.AccessLog) bool {
+		return log.ServiceKeyID == serviceKeyID && (before.IsZero() || log.CreatedAt.Before(before))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].CreatedAt.After(logs[j].CreatedAt)
+	})
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+// GetUsage returns a service key's usage, with zero counts when it was never validated
+func (r *AccessLogRepository) GetUsage(ctx context.Context, organizationID, serviceKeyID string) (*types.ServiceKeyUsage, error) {
+	var usage types.ServiceKeyUsage
+	err := r.store.read(accessLogsContainer, organizationID, types.ServiceKeyUsageID(serviceKeyID), &usage)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && usage.EntityType != "service_key_usage") {
+		return storage.NewServiceKeyUsage(organizationID, serviceKeyID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	usage.PartitionKey = usage.GetPartitionKey()
+	return &usage, nil
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const apiKeysContainer = "api_keys"
+
+type ApiKeyRepository struct {
+	store *Store
+}
+
+func NewApiKeyRepository(store *Store) *ApiKeyRepository {
+	return &ApiKeyRepository{
+		store: store,
+	}
+}
+
+// Create creates a new API key with audit history
+func (r *ApiKeyRepository) Create(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	token.PartitionKey = token.GetPartitionKey()
+
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionCreated, userID, githubID, username, nil)
+
+	b := newBatch(apiKeysContainer, token.PartitionKey)
+	if err := b.create(token.ID, token); err != nil {
+		return err
+	}
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// Get retrieves an API key by ID using the organization ID as the partition key
+func (r *ApiKeyRepository) Get(ctx context.Context, organizationID, id string) (*types.ApiKey, error) {
+	var token types.ApiKey
+	if err := r.store.read(apiKeysContainer, organizationID, id, &token); err != nil {
+		return nil, err
+	}
+
+	// Verify entity type to ensure we got an API key, not audit history
+	if token.EntityType != "" && token.EntityType != "api_key" {
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
+	}
+
+	return &token, nil
+}
+
+// FindByTokenValue finds an API key by its hashed value (queries across all partitions)
+func (r *ApiKeyRepository) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ApiKey, error) {
+	hashed := storage.HashToken(tokenValue)
+
+	// A rotated key is also found by its previous token, which the caller checks against the grace period
+	tokens, err := query(r.store, apiKeysContainer, "", "api_key", func(t *types.ApiKey) bool {
+		return t.TokenValue == hashed || t.PreviousTokenValue == hashed
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
+	}
+
+	return tokens[0], nil
+}
+
+// CountByOrganization counts API keys for an organization
+func (r *ApiKeyRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
+	tokens, err := r.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
+
+// ListByOrganization lists API keys for an organization
+func (r *ApiKeyRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.ApiKey, error) {
+	if organizationID == "" {
+		return nil, nil
+	}
+	return query(r.store, apiKeysContainer, organizationID, "api_key", func(t *types.ApiKey) bool {
+		return t.OrganizationID == organizationID
+	})
+}
+
+// Update updates an API key with audit history
+func (r *ApiKeyRepository) Update(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	return r.replace(ctx, token, types.AuditActionUpdated, userID, githubID, username)
+}
+
+// Rotate stores an API key with a new token value with audit history.
+// The new token value is hashed, the previous hash must already be set by the caller.
+func (r *ApiKeyRepository) Rotate(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	return r.replace(ctx, token, types.AuditActionRotated, userID, githubID, username)
+}
+
+// Revoke stores a revoked API key with audit history, the revocation must already be set by the caller
+func (r *ApiKeyRepository) Revoke(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	return r.replace(ctx, token, types.AuditActionRevoked, userID, githubID, username)
+}
+
+func (r *ApiKeyRepository) replace(ctx context.Context, token *types.ApiKey, action types.AuditAction, userID, githubID, username string) error {
+	token.PartitionKey = token.GetPartitionKey()
+
+	var before types.ApiKey
+	if err := r.store.read(apiKeysContainer, token.PartitionKey, token.ID, &before); err != nil {
+		return err
+	}
+
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, action, userID, githubID, username, storage.DiffApiKeys(&before, token))
+
+	b := newBatch(apiKeysContainer, token.PartitionKey)
+	if err := b.replace(token.ID, token); err != nil {
+		return err
+	}
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// Delete deletes an API key with audit history
+func (r *ApiKeyRepository) Delete(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	token.PartitionKey = token.GetPartitionKey()
+
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionDeleted, userID, githubID, username, nil)
+
+	b := newBatch(apiKeysContainer, token.PartitionKey)
+	b.delete(token.ID)
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// GetHistory retrieves audit history for an API key
+func (r *ApiKeyRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	return getHistory(r.store, apiKeysContainer, organizationID, entityID)
+}
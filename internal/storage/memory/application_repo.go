@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const applicationsContainer = "applications"
+
+type ApplicationRepository struct {
+	store *Store
+}
+
+func NewApplicationRepository(store *Store) *ApplicationRepository {
+	return &ApplicationRepository{
+		store: store,
+	}
+}
+
+// Create creates a new application with audit history
+func (r *ApplicationRepository) Create(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	app.PartitionKey = app.GetPartitionKey()
+
+	auditHistory := storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionCreated, userID, githubID, username, nil)
+
+	b := newBatch(applicationsContainer, app.PartitionKey)
+	if err := b.create(app.ID, app); err != nil {
+		return err
+	}
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// CountByOrganization counts applications for an organization
+func (r *ApplicationRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
+	apps, err := r.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return 0, err
+	}
+	return len(apps), nil
+}
+
+// ListByOrganization lists applications for an organization
+func (r *ApplicationRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Application, error) {
+	if organizationID == "" {
+		return nil, nil
+	}
+	return query(r.store, applicationsContainer, organizationID, "application", func(a *types.Application) bool {
+		return a.OrganizationID == organizationID
+	})
+}
+
+// Get retrieves an application by ID using the organization ID as the partition key
+func (r *ApplicationRepository) Get(ctx context.Context, organizationID, id string) (*types.Application, error) {
+	var app types.Application
+	if err := r.store.read(applicationsContainer, organizationID, id, &app); err != nil {
+		return nil, err
+	}
+
+	if app.EntityType != "" && app.EntityType != "application" {
+		return nil, fmt.Errorf("application not found")
+	}
+
+	return &app, nil
+}
+
+// Update updates an application with audit history
+func (r *ApplicationRepository) Update(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	app.PartitionKey = app.GetPartitionKey()
+
+	var before types.Application
+	if err := r.store.read(applicationsContainer, app.PartitionKey, app.ID, &before); err != nil {
+		return err
+	}
+
+	auditHistory := storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionUpdated, userID, githubID, username, storage.DiffApplications(&before, app))
+
+	b := newBatch(applicationsContainer, app.PartitionKey)
+	if err := b.replace(app.ID, app); err != nil {
+		return err
+	}
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// Delete deletes an application with audit history
+func (r *ApplicationRepository) Delete(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	app.PartitionKey = app.GetPartitionKey()
+
+	auditHistory := storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionDeleted, userID, githubID, username, nil)
+
+	b := newBatch(applicationsContainer, app.PartitionKey)
+	b.delete(app.ID)
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// GetHistory retrieves audit history for an application
+func (r *ApplicationRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	return getHistory(r.store, applicationsContainer, organizationID, entityID)
+}
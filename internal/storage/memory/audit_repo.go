@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// auditContainers maps each audited kind of entity to the container holding its audit history
+var auditContainers = map[types.AuditResourceType]string{
+	types.AuditResourceServiceKey:  serviceKeysContainer,
+	types.AuditResourceApiKey:      apiKeysContainer,
+	types.AuditResourceApplication: applicationsContainer,
+}
+
+// AuditRepository searches the audit history kept next to service keys, API keys and applications
+type AuditRepository struct {
+	store *Store
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(store *Store) *AuditRepository {
+	return &AuditRepository{
+		store: store,
+	}
+}
+
+// Search returns up to limit records of an organization matching filter, newest first, after the cursor
+func (r *AuditRepository) Search(ctx context.Context, organizationID string, filter storage.AuditFilter, cursor string, limit int) ([]*storage.AuditEvent, error) {
+	var events []*storage.AuditEvent
+	for _, resourceType := range types.AuditResourceTypes {
+		if !filter.Includes(resourceType) {
+			continue
+		}
+		history, err := query(r.store, auditContainers[resourceType], organizationID, "audit_history", func(a *types.AuditHistory) bool {
+			return a.OrganizationID == organizationID
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, audit := range history {
+			events = append(events, &storage.AuditEvent{AuditHistory: audit, ResourceType: resourceType})
+		}
+	}
+
+	return storage.SearchHistory(events, filter, cursor, limit)
+}
+
+// GetChainHead returns the head of an organization's audit chain for a kind of entity
+func (r *AuditRepository) GetChainHead(ctx context.Context, organizationID string, resourceType types.AuditResourceType) (*types.AuditChainHead, error) {
+	return r.store.chainHead(auditContainers[resourceType], organizationID)
+}
+
+// ListChain returns up to limit records of an organization's audit chain for a kind of entity after afterSequence
+func (r *AuditRepository) ListChain(ctx context.Context, organizationID string, resourceType types.AuditResourceType, afterSequence int64, limit int) ([]*types.AuditHistory, error) {
+	history, err := query(r.store, auditContainers[resourceType], organizationID, "audit_history", func(a *types.AuditHistory) bool {
+		return a.OrganizationID == organizationID && a.Sequence > afterSequence
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].Sequence != history[j].Sequence {
+			return history[i].Sequence < history[j].Sequence
+		}
+		return history[i].ID < history[j].ID
+	})
+	if len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+// chainHead reads the head of the audit chain kept in a container's partition
+func (s *Store) chainHead(containerName, organizationID string) (*types.AuditChainHead, error) {
+	var head types.AuditChainHead
+	err := s.read(containerName, organizationID, storage.AuditChainHeadID, &head)
+	if errors.Is(err, storage.ErrNotFound) {
+		return storage.NewAuditChainHead(organizationID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	head.PartitionKey = head.GetPartitionKey()
+	return &head, nil
+}
+
+// executeAudited adds an audit history record to the end of its organization's audit chain
+// in the batch's container and applies the batch with the record and the chain's new head
+func (s *Store) executeAudited(b *batch, auditHistory *types.AuditHistory) error {
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+
+	head, err := s.chainHead(b.containerName, b.partitionKey)
+	if err != nil {
+		return err
+	}
+	if err := storage.ChainAudit(head, auditHistory); err != nil {
+		return err
+	}
+
+	if err := b.create(auditHistory.ID, auditHistory); err != nil {
+		return err
+	}
+	if err := b.upsert(head.ID, head); err != nil {
+		return err
+	}
+	return s.execute(b)
+}
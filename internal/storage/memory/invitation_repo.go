@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// InvitationRepository handles organization invitation storage operations.
+// Invitations live in the organizations container next to the organization's members.
+type InvitationRepository struct {
+	store *Store
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(store *Store) *InvitationRepository {
+	return &InvitationRepository{
+		store: store,
+	}
+}
+
+// Create creates a new invitation
+func (r *InvitationRepository) Create(ctx context.Context, invitation *types.Invitation) error {
+	invitation.EntityType = "invitation"
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	b := newBatch(organizationsContainer, invitation.PartitionKey)
+	if err := b.create(invitation.ID, invitation); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// Get retrieves an invitation by ID (queries across all partitions)
+func (r *InvitationRepository) Get(ctx context.Context, id string) (*types.Invitation, error) {
+	invitations, err := r.list("", func(i *types.Invitation) bool {
+		return i.ID == id
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return invitations[0], nil
+}
+
+// ListByOrganization lists invitations for an organization
+func (r *InvitationRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Invitation, error) {
+	if organizationID == "" {
+		return nil, nil
+	}
+	return r.list(organizationID, nil)
+}
+
+// ListByUsername lists invitations sent to a GitHub user (queries across all partitions)
+func (r *InvitationRepository) ListByUsername(ctx context.Context, username string) ([]*types.Invitation, error) {
+	return r.list("", func(i *types.Invitation) bool {
+		return i.IsFor(username)
+	})
+}
+
+// Update updates an invitation
+func (r *InvitationRepository) Update(ctx context.Context, invitation *types.Invitation) error {
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	b := newBatch(organizationsContainer, invitation.PartitionKey)
+	if err := b.replace(invitation.ID, invitation); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// Accept marks an invitation accepted and adds the user as a member with the invited role atomically
+func (r *InvitationRepository) Accept(ctx context.Context, invitation *types.Invitation, userID string) error {
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	member := &types.OrganizationMember{
+		ID:             invitation.OrganizationID + "_" + userID,
+		PartitionKey:   invitation.OrganizationID,
+		EntityType:     "organization_member",
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           invitation.Role,
+		CreatedAt:      time.Now(),
+	}
+
+	b := newBatch(organizationsContainer, invitation.PartitionKey)
+	if err := b.replace(invitation.ID, invitation); err != nil {
+		return err
+	}
+	if err := b.create(member.ID, member); err != nil {
+		return err
+	}
+
+	if err := r.store.execute(b); err != nil {
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return nil
+}
+
+// list returns invitations in a partition, or in every partition when organizationID is empty.
+// Legacy organizations have no entity_type, so the discriminator is checked explicitly.
+func (r *InvitationRepository) list(organizationID string, match func(*types.Invitation) bool) ([]*types.Invitation, error) {
+	return query(r.store, organizationsContainer, organizationID, "invitation", func(i *types.Invitation) bool {
+		return i.EntityType == "invitation" && (match == nil || match(i))
+	})
+}
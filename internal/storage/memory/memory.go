@@ -0,0 +1,266 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// Store is an in-memory document store that mirrors the Cosmos DB layout:
+// named containers holding JSON documents grouped by partition key.
+// It is intended for local development and integration tests.
+type Store struct {
+	mu         sync.RWMutex
+	containers map[string]*container
+
+	// chainMu is held from reading an audit chain's head until the record extending it is written
+	chainMu sync.Mutex
+
+	// claimMu is held from reading a webhook delivery until the claim replacing it is written
+	claimMu sync.Mutex
+}
+
+// container holds documents keyed by partition key and then by document ID
+type container struct {
+	partitions map[string]map[string]json.RawMessage
+}
+
+// NewStore creates an empty in-memory store with the same containers as Cosmos DB
+func NewStore() *Store {
+	s := &Store{
+		containers: make(map[string]*container),
+	}
+
+	containers := []string{"organizations", "applications", "service_keys", "api_keys", "users", "webhooks", "access_logs"}
+	for _, containerName := range containers {
+		s.containers[containerName] = &container{
+			partitions: make(map[string]map[string]json.RawMessage),
+		}
+	}
+
+	return s
+}
+
+// NewRepositories creates every in-memory repository backed by a single store
+func NewRepositories(store *Store) *storage.Repositories {
+	return &storage.Repositories{
+		Organizations:       NewOrganizationRepository(store),
+		OrganizationMembers: NewOrganizationMemberRepository(store),
+		Invitations:         NewInvitationRepository(store),
+		Applications:        NewApplicationRepository(store),
+		ServiceKeys:         NewServiceKeyRepository(store),
+		ApiKeys:             NewApiKeyRepository(store),
+		Users:               NewUserRepository(store),
+		Webhooks:            NewWebhookRepository(store),
+		Audit:               NewAuditRepository(store),
+		AccessLogs:          NewAccessLogRepository(store),
+	}
+}
+
+// operation is a single write inside a batch
+type operation struct {
+	kind operationKind
+	id   string
+	doc  json.RawMessage
+}
+
+type operationKind int
+
+const (
+	operationCreate operationKind = iota
+	operationReplace
+	operationUpsert
+	operationDelete
+)
+
+// batch collects writes against one partition so they can be applied atomically,
+// matching the semantics of a Cosmos DB transactional batch
+type batch struct {
+	containerName string
+	partitionKey  string
+	operations    []operation
+}
+
+func newBatch(containerName, partitionKey string) *batch {
+	return &batch{
+		containerName: containerName,
+		partitionKey:  partitionKey,
+	}
+}
+
+func (b *batch) create(id string, v any) error {
+	return b.add(operationCreate, id, v)
+}
+
+func (b *batch) replace(id string, v any) error {
+	return b.add(operationReplace, id, v)
+}
+
+func (b *batch) upsert(id string, v any) error {
+	return b.add(operationUpsert, id, v)
+}
+
+func (b *batch) delete(id string) {
+	b.operations = append(b.operations, operation{kind: operationDelete, id: id})
+}
+
+func (b *batch) add(kind operationKind, id string, v any) error {
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+	b.operations = append(b.operations, operation{kind: kind, id: id, doc: doc})
+	return nil
+}
+
+// execute applies every operation in the batch or none of them
+func (s *Store) execute(b *batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.containers[b.containerName]
+	if !ok {
+		return fmt.Errorf("container %s not found", b.containerName)
+	}
+
+	partition := c.partitions[b.partitionKey]
+
+	// Validate the whole batch against a view of the partition before mutating it
+	exists := make(map[string]bool)
+	for id := range partition {
+		exists[id] = true
+	}
+	for _, op := range b.operations {
+		switch op.kind {
+		case operationCreate:
+			if exists[op.id] {
+				return fmt.Errorf("conflict: resource already exists")
+			}
+			exists[op.id] = true
+		case operationReplace:
+			if !exists[op.id] {
+				return storage.ErrNotFound
+			}
+		case operationUpsert:
+			exists[op.id] = true
+		case operationDelete:
+			if !exists[op.id] {
+				return storage.ErrNotFound
+			}
+			delete(exists, op.id)
+		}
+	}
+
+	if partition == nil {
+		partition = make(map[string]json.RawMessage)
+		c.partitions[b.partitionKey] = partition
+	}
+	for _, op := range b.operations {
+		if op.kind == operationDelete {
+			delete(partition, op.id)
+			continue
+		}
+		partition[op.id] = op.doc
+	}
+
+	return nil
+}
+
+// read unmarshals a single document from a partition into v
+func (s *Store) read(containerName, partitionKey, id string, v any) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.containers[containerName]
+	if !ok {
+		return fmt.Errorf("container %s not found", containerName)
+	}
+
+	doc, ok := c.partitions[partitionKey][id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	return json.Unmarshal(doc, v)
+}
+
+// documents returns a snapshot of the documents in a partition, or in every
+// partition when partitionKey is empty (a cross-partition query)
+func (s *Store) documents(containerName, partitionKey string) ([]json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.containers[containerName]
+	if !ok {
+		return nil, fmt.Errorf("container %s not found", containerName)
+	}
+
+	var docs []json.RawMessage
+	if partitionKey != "" {
+		for _, doc := range c.partitions[partitionKey] {
+			docs = append(docs, doc)
+		}
+		return docs, nil
+	}
+
+	for _, partition := range c.partitions {
+		for _, doc := range partition {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// query unmarshals every document of the given entity type in a partition.
+// Documents without an entity_type are treated as legacy entities and included
+// unless they are audit history.
+func query[T any](s *Store, containerName, partitionKey, entityType string, match func(*T) bool) ([]*T, error) {
+	docs, err := s.documents(containerName, partitionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*T
+	for _, doc := range docs {
+		var header struct {
+			EntityType string `json:"entity_type"`
+		}
+		if err := json.Unmarshal(doc, &header); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal document: %w", err)
+		}
+		if header.EntityType != entityType && (header.EntityType != "" || entityType == "audit_history") {
+			continue
+		}
+
+		var item T
+		if err := json.Unmarshal(doc, &item); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", entityType, err)
+		}
+		if match != nil && !match(&item) {
+			continue
+		}
+		results = append(results, &item)
+	}
+
+	return results, nil
+}
+
+// getHistory returns the audit history of an entity, newest first
+func getHistory(s *Store, containerName, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	history, err := query(s, containerName, organizationID, "audit_history", func(a *types.AuditHistory) bool {
+		return a.OrganizationID == organizationID && a.EntityID == entityID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt.After(history[j].CreatedAt)
+	})
+
+	return history, nil
+}
@@ -0,0 +1,530 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+func TestServiceKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewServiceKeyRepository(NewStore())
+
+	key := &types.ServiceKey{
+		ID:             "sk-1",
+		EntityType:     "service_key",
+		OrganizationID: "org-1",
+		Name:           "Test Service Key",
+		TokenValue:     "secret",
+	}
+	if err := repo.Create(ctx, key, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if key.TokenValue != storage.HashToken("secret") {
+		t.Errorf("expected token value to be hashed on create")
+	}
+
+	if _, err := repo.FindByTokenValueInOrg(ctx, "org-1", "secret"); err != nil {
+		t.Errorf("expected token to be found in org-1: %v", err)
+	}
+	if _, err := repo.FindByTokenValueInOrg(ctx, "org-2", "secret"); err == nil {
+		t.Errorf("expected token not to be found in org-2")
+	}
+	if _, err := repo.FindByTokenValue(ctx, "secret"); err != nil {
+		t.Errorf("expected cross-partition lookup to find token: %v", err)
+	}
+
+	// Audit history lives in the same partition but must not be listed as a key
+	count, err := repo.CountByOrganization(ctx, "org-1")
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 service key, got %d", count)
+	}
+
+	key.Name = "Renamed"
+	if err := repo.Update(ctx, key, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	graceEndsAt := time.Now().Add(time.Hour)
+	key.PreviousTokenValue = key.TokenValue
+	key.PreviousTokenExpiresAt = &graceEndsAt
+	key.TokenValue = "rotated"
+	if err := repo.Rotate(ctx, key, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	for _, token := range []string{"secret", "rotated"} {
+		found, err := repo.FindByTokenValueInOrg(ctx, "org-1", token)
+		if err != nil {
+			t.Fatalf("expected %q to be found after rotation: %v", token, err)
+		}
+		if !found.AcceptsToken(storage.HashToken(token)) {
+			t.Errorf("expected %q to be accepted during the grace period", token)
+		}
+	}
+	time.Sleep(time.Millisecond)
+	if err := repo.Delete(ctx, key, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if _, err := repo.Get(ctx, "org-1", "sk-1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+
+	history, err := repo.GetHistory(ctx, "org-1", "sk-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("expected 4 audit records, got %d", len(history))
+	}
+	if history[0].Action != types.AuditActionDeleted {
+		t.Errorf("expected newest audit record to be %q, got %q", types.AuditActionDeleted, history[0].Action)
+	}
+	if history[1].Action != types.AuditActionRotated {
+		t.Errorf("expected rotation to be recorded as %q, got %q", types.AuditActionRotated, history[1].Action)
+	}
+
+	// Events come oldest first and resume after a cursor
+	events, err := repo.ListEvents(ctx, "org-1", "", 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 4 || events[2].Action != types.AuditActionRotated {
+		t.Fatalf("expected 4 events with the rotation third, got %+v", events)
+	}
+	events, err = repo.ListEvents(ctx, "org-1", events[2].Cursor, 10)
+	if err != nil {
+		t.Fatalf("list events after cursor: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != types.AuditActionDeleted {
+		t.Errorf("expected only the deletion after the rotation, got %+v", events)
+	}
+	if _, err := repo.ListEvents(ctx, "org-1", "not-a-cursor", 10); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestAuditHistoryRecordsChanges(t *testing.T) {
+	ctx := storage.WithRequestMetadata(context.Background(), storage.RequestMetadata{
+		RequestID: "req-1",
+		ClientIP:  "203.0.113.7",
+		UserAgent: "baluster-cli/1.0",
+	})
+	repo := NewServiceKeyRepository(NewStore())
+
+	key := &types.ServiceKey{
+		ID:             "sk-1",
+		OrganizationID: "org-1",
+		Name:           "billing",
+		TokenValue:     "secret",
+		Applications: []types.ApplicationAccess{
+			{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"read", "write"}},
+			{ApplicationID: "app-2", ApplicationName: "users", Permissions: []string{"read"}},
+		},
+	}
+	if err := repo.Create(ctx, key, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := *key
+	updated.ExpiresAt = &expiresAt
+	updated.Applications = []types.ApplicationAccess{
+		{ApplicationID: "app-1", ApplicationName: "orders", Permissions: []string{"read", "admin"}},
+		{ApplicationID: "app-3", ApplicationName: "billing", Permissions: []string{"read"}},
+	}
+	if err := repo.Update(ctx, &updated, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	history, err := repo.GetHistory(ctx, "org-1", "sk-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	var audit *types.AuditHistory
+	for _, record := range history {
+		if record.Action == types.AuditActionUpdated {
+			audit = record
+		}
+	}
+	if audit == nil {
+		t.Fatalf("expected an updated audit record, got %+v", history)
+	}
+	if audit.RequestID != "req-1" || audit.ClientIP != "203.0.113.7" || audit.UserAgent != "baluster-cli/1.0" {
+		t.Errorf("expected the request metadata to be recorded, got %+v", audit)
+	}
+
+	changes := make(map[string]types.AuditChange)
+	for _, change := range audit.Changes {
+		changes[change.Field] = change
+	}
+	if len(changes) != 5 {
+		t.Errorf("expected 5 changes, got %+v", audit.Changes)
+	}
+	if change := changes["expires_at"]; change.Before != nil || change.After == nil {
+		t.Errorf("expected the expiry to be set, got %+v", change)
+	}
+	if change := changes["applications"]; !slices.Equal(change.Added, []string{"app-3"}) || !slices.Equal(change.Removed, []string{"app-2"}) {
+		t.Errorf("expected app-3 to be granted and app-2 taken away, got %+v", change)
+	}
+	if change := changes["applications.app-1.permissions"]; !slices.Equal(change.Added, []string{"admin"}) || !slices.Equal(change.Removed, []string{"write"}) {
+		t.Errorf("expected admin to be added and write removed, got %+v", change)
+	}
+	if change := changes["applications.app-2.permissions"]; !slices.Equal(change.Removed, []string{"read"}) {
+		t.Errorf("expected app-2's permissions to be removed, got %+v", change)
+	}
+	if change := changes["applications.app-3.permissions"]; !slices.Equal(change.Added, []string{"read"}) {
+		t.Errorf("expected app-3's permissions to be added, got %+v", change)
+	}
+
+	// A rotation changes the token, which must stay out of the record
+	updated.PreviousTokenValue = updated.TokenValue
+	updated.TokenValue = "rotated"
+	if err := repo.Rotate(ctx, &updated, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	history, err = repo.GetHistory(ctx, "org-1", "sk-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	b, err := json.Marshal(history)
+	if err != nil {
+		t.Fatalf("marshal history: %v", err)
+	}
+	for _, token := range []string{storage.HashToken("secret"), storage.HashToken("rotated")} {
+		if strings.Contains(string(b), token) {
+			t.Errorf("expected token hashes to be kept out of the audit history")
+		}
+	}
+}
+
+func TestAuditSearch(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepositories(NewStore())
+
+	for _, key := range []*types.ServiceKey{
+		{ID: "sk-1", OrganizationID: "org-1", Name: "billing", TokenValue: "token-1"},
+		{ID: "sk-2", OrganizationID: "org-2", Name: "billing", TokenValue: "token-2"},
+	} {
+		if err := repos.ServiceKeys.Create(ctx, key, "user-1", "github-1", "octocat"); err != nil {
+			t.Fatalf("create service key: %v", err)
+		}
+	}
+	if err := repos.ApiKeys.Create(ctx, &types.ApiKey{ID: "ak-1", OrganizationID: "org-1", TokenValue: "token-3"}, "user-2", "github-2", "hubot"); err != nil {
+		t.Fatalf("create API key: %v", err)
+	}
+	if err := repos.Applications.Create(ctx, &types.Application{ID: "app-1", OrganizationID: "org-1", Name: "orders"}, "user-1", "github-1", "octocat"); err != nil {
+		t.Fatalf("create application: %v", err)
+	}
+
+	events, err := repos.Audit.Search(ctx, "org-1", storage.AuditFilter{}, "", 10)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected org-1's 3 events, got %d", len(events))
+	}
+	if events[0].EntityID != "app-1" || events[0].ResourceType != types.AuditResourceApplication {
+		t.Errorf("expected the application to come first, got %+v", events[0])
+	}
+
+	events, err = repos.Audit.Search(ctx, "org-1", storage.AuditFilter{ActorUsername: "OctoCat", ResourceTypes: []types.AuditResourceType{types.AuditResourceServiceKey, types.AuditResourceApiKey}}, "", 10)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(events) != 1 || events[0].EntityID != "sk-1" {
+		t.Errorf("expected only sk-1, got %+v", events)
+	}
+
+	// Paging one at a time returns every event once, in the same order
+	var ids []string
+	cursor := ""
+	for {
+		page, err := repos.Audit.Search(ctx, "org-1", storage.AuditFilter{}, cursor, 1)
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		ids = append(ids, page[0].EntityID)
+		cursor = page[0].Cursor
+	}
+	if !slices.Equal(ids, []string{"app-1", "ak-1", "sk-1"}) {
+		t.Errorf("expected every event once, got %v", ids)
+	}
+
+	if _, err := repos.Audit.Search(ctx, "org-1", storage.AuditFilter{}, "not-a-cursor", 10); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestAuditChain(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepositories(NewStore())
+
+	// Concurrent writes still extend the chain one record at a time
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			key := &types.ServiceKey{ID: fmt.Sprintf("sk-%d", i), OrganizationID: "org-1", Name: "billing", TokenValue: fmt.Sprintf("token-%d", i)}
+			if err := repos.ServiceKeys.Create(ctx, key, "user-1", "github-1", "octocat"); err != nil {
+				t.Errorf("create service key: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	// A write that fails leaves the chain as it was
+	if err := repos.ServiceKeys.Create(ctx, &types.ServiceKey{ID: "sk-0", OrganizationID: "org-1", TokenValue: "token-x"}, "user-1", "github-1", "octocat"); err == nil {
+		t.Fatalf("expected conflict creating duplicate service key")
+	}
+
+	head, err := repos.Audit.GetChainHead(ctx, "org-1", types.AuditResourceServiceKey)
+	if err != nil {
+		t.Fatalf("get chain head: %v", err)
+	}
+	if head.Sequence != 20 {
+		t.Fatalf("expected the chain to end at 20, got %+v", head)
+	}
+
+	var previous *types.AuditHistory
+	for after := int64(0); ; {
+		history, err := repos.Audit.ListChain(ctx, "org-1", types.AuditResourceServiceKey, after, 7)
+		if err != nil {
+			t.Fatalf("list chain: %v", err)
+		}
+		for _, audit := range history {
+			hash, err := storage.HashAudit(audit)
+			if err != nil {
+				t.Fatalf("hash audit: %v", err)
+			}
+			if audit.Sequence != after+1 || hash != audit.Hash {
+				t.Fatalf("expected record %d with a matching hash, got %+v", after+1, audit)
+			}
+			if previous != nil && audit.PreviousHash != previous.Hash {
+				t.Fatalf("expected record %d to link to the one before it", audit.Sequence)
+			}
+			previous = audit
+			after = audit.Sequence
+		}
+		if len(history) < 7 {
+			break
+		}
+	}
+	if previous == nil || previous.Sequence != head.Sequence || previous.Hash != head.Hash {
+		t.Errorf("expected the head to point at the last record, got %+v", head)
+	}
+
+	// Each kind of entity and organization has its own chain
+	if head, err := repos.Audit.GetChainHead(ctx, "org-1", types.AuditResourceApiKey); err != nil || head.Sequence != 0 || head.Hash != "" {
+		t.Errorf("expected an empty API key chain, got %+v, %v", head, err)
+	}
+	if history, err := repos.Audit.ListChain(ctx, "org-2", types.AuditResourceServiceKey, 0, 10); err != nil || len(history) != 0 {
+		t.Errorf("expected org-2 to have an empty chain, got %d records, %v", len(history), err)
+	}
+}
+
+func TestApiKeyRevocationIsKept(t *testing.T) {
+	ctx := context.Background()
+	repo := NewApiKeyRepository(NewStore())
+
+	key := &types.ApiKey{ID: "key-1", EntityType: "api_key", OrganizationID: "org-1", Name: "Test Key", TokenValue: "secret"}
+	if err := repo.Create(ctx, key, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	revokedAt := time.Now()
+	key.RevokedAt = &revokedAt
+	key.RevokedReason = "leaked"
+	if err := repo.Revoke(ctx, key, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	found, err := repo.FindByTokenValue(ctx, "secret")
+	if err != nil {
+		t.Fatalf("expected revoked key to be kept: %v", err)
+	}
+	if !found.IsRevoked() || found.RevokedReason != "leaked" {
+		t.Errorf("expected revocation to round-trip, got %+v", found)
+	}
+
+	history, err := repo.GetHistory(ctx, "org-1", "key-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 2 || history[0].Action != types.AuditActionRevoked {
+		t.Errorf("expected revocation to be the newest audit record, got %+v", history)
+	}
+}
+
+func TestBatchIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	repo := NewApplicationRepository(NewStore())
+
+	app := &types.Application{ID: "app-1", EntityType: "application", OrganizationID: "org-1", Name: "test_app"}
+	if err := repo.Create(ctx, app, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Creating the same application again conflicts, so its audit record must not be written either
+	if err := repo.Create(ctx, app, "user-1", "github-123", "testuser"); err == nil {
+		t.Fatalf("expected conflict creating duplicate application")
+	}
+
+	history, err := repo.GetHistory(ctx, "org-1", "app-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("expected 1 audit record, got %d", len(history))
+	}
+}
+
+func TestOrganizationMembership(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	orgRepo := NewOrganizationRepository(store)
+	memberRepo := NewOrganizationMemberRepository(store)
+
+	org := &types.Organization{ID: "org-1", Name: "Test Org"}
+	if err := memberRepo.CreateOrganizationWithMember(ctx, org, "user-1"); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+
+	isMember, err := memberRepo.IsMember(ctx, "org-1", "user-1")
+	if err != nil || !isMember {
+		t.Errorf("expected user-1 to be a member, got %v (err %v)", isMember, err)
+	}
+	isMember, err = memberRepo.IsMember(ctx, "org-1", "user-2")
+	if err != nil || isMember {
+		t.Errorf("expected user-2 not to be a member, got %v (err %v)", isMember, err)
+	}
+
+	if role, _, err := memberRepo.GetMemberRole(ctx, "org-1", "user-1"); err != nil || role != types.RoleOwner {
+		t.Errorf("expected creator to be an owner, got %q (err %v)", role, err)
+	}
+	if err := memberRepo.AddMember(ctx, "org-1", "user-2", types.RoleViewer); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if role, isMember, err := memberRepo.GetMemberRole(ctx, "org-1", "user-2"); err != nil || !isMember || role != types.RoleViewer {
+		t.Errorf("expected user-2 to be a viewer, got %q (member %v, err %v)", role, isMember, err)
+	}
+	if err := memberRepo.RemoveMember(ctx, "org-1", "user-2"); err != nil {
+		t.Fatalf("remove member: %v", err)
+	}
+
+	orgs, err := orgRepo.ListByMemberID(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("list by member: %v", err)
+	}
+	if len(orgs) != 1 || orgs[0].ID != "org-1" {
+		t.Errorf("expected org-1 for user-1, got %v", orgs)
+	}
+
+	all, err := orgRepo.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(all) != 1 {
+		t.Errorf("expected member records to be excluded from organizations, got %d", len(all))
+	}
+
+	if err := memberRepo.RemoveMember(ctx, "org-1", "user-1"); err != nil {
+		t.Fatalf("remove member: %v", err)
+	}
+	members, err := memberRepo.ListMembers(ctx, "org-1")
+	if err != nil {
+		t.Fatalf("list members: %v", err)
+	}
+	if len(members) != 0 {
+		t.Errorf("expected no members after removal, got %d", len(members))
+	}
+}
+
+func TestCraftedIDsReturnNothing(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	repo := NewServiceKeyRepository(store)
+
+	key := &types.ServiceKey{ID: "sk-1", OrganizationID: "org-1", Name: "Test Service Key", TokenValue: "secret"}
+	if err := repo.Create(ctx, key, "user-1", "github-123", "testuser"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	crafted := "x' OR 1=1 --"
+	if keys, err := repo.ListByOrganization(ctx, crafted); err != nil || len(keys) != 0 {
+		t.Errorf("expected no service keys for crafted organization ID, got %d (err %v)", len(keys), err)
+	}
+	if history, err := repo.GetHistory(ctx, "org-1", crafted); err != nil || len(history) != 0 {
+		t.Errorf("expected no history for crafted entity ID, got %d (err %v)", len(history), err)
+	}
+	if _, err := repo.FindByTokenValue(ctx, crafted); err == nil {
+		t.Errorf("expected crafted token not to match")
+	}
+}
+
+func TestInvitationAccept(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	memberRepo := NewOrganizationMemberRepository(store)
+	invitationRepo := NewInvitationRepository(store)
+
+	org := &types.Organization{ID: "org-1", Name: "Test Org"}
+	if err := memberRepo.CreateOrganizationWithMember(ctx, org, "user-1"); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+
+	invitation := &types.Invitation{
+		ID:             "inv-1",
+		OrganizationID: "org-1",
+		GitHubUsername: "OctoCat",
+		Role:           types.RoleDeveloper,
+		Status:         types.InvitationStatusPending,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+	if err := invitationRepo.Create(ctx, invitation); err != nil {
+		t.Fatalf("create invitation: %v", err)
+	}
+
+	// Organizations written before entity_type existed must not be listed as invitations
+	invitations, err := invitationRepo.ListByUsername(ctx, "octocat")
+	if err != nil || len(invitations) != 1 {
+		t.Fatalf("expected one invitation for octocat, got %d (err %v)", len(invitations), err)
+	}
+	if _, err := invitationRepo.Get(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing invitation, got %v", err)
+	}
+
+	invitation.Status = types.InvitationStatusAccepted
+	if err := invitationRepo.Accept(ctx, invitation, "user-2"); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if role, isMember, err := memberRepo.GetMemberRole(ctx, "org-1", "user-2"); err != nil || !isMember || role != types.RoleDeveloper {
+		t.Errorf("expected user-2 to join as developer, got %q (member %v, err %v)", role, isMember, err)
+	}
+
+	// Accepting again conflicts on the member record and must not change the invitation
+	invitation.Status = types.InvitationStatusDeclined
+	if err := invitationRepo.Accept(ctx, invitation, "user-2"); err == nil {
+		t.Errorf("expected accepting twice to fail")
+	}
+	stored, err := invitationRepo.Get(ctx, "inv-1")
+	if err != nil || stored.Status != types.InvitationStatusAccepted {
+		t.Errorf("expected failed batch to leave the invitation accepted, got %+v (err %v)", stored, err)
+	}
+}
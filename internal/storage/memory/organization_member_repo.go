@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// OrganizationMemberRepository handles organization membership storage operations
+type OrganizationMemberRepository struct {
+	store   *Store
+	orgRepo *OrganizationRepository // For backward compatibility with MemberIDs array
+}
+
+// NewOrganizationMemberRepository creates a new organization member repository
+func NewOrganizationMemberRepository(store *Store) *OrganizationMemberRepository {
+	return &OrganizationMemberRepository{
+		store:   store,
+		orgRepo: NewOrganizationRepository(store),
+	}
+}
+
+// IsMember checks if a user is a member of an organization
+func (r *OrganizationMemberRepository) IsMember(ctx context.Context, orgID, userID string) (bool, error) {
+	_, isMember, err := r.GetMemberRole(ctx, orgID, userID)
+	return isMember, err
+}
+
+// GetMemberRole returns the role of a user in an organization and whether the user is a member
+// First checks organization_member records, then falls back to the old MemberIDs array for backward compatibility
+func (r *OrganizationMemberRepository) GetMemberRole(ctx context.Context, orgID, userID string) (types.Role, bool, error) {
+	var member types.OrganizationMember
+	err := r.store.read(organizationsContainer, orgID, orgID+"_"+userID, &member)
+	if err == nil {
+		return member.GetRole(), true, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return "", false, err
+	}
+
+	org, err := r.orgRepo.Get(ctx, orgID)
+	if err != nil {
+		// If we can't get the org, return false (not a member)
+		return "", false, nil
+	}
+	if !slices.Contains(org.MemberIDs, userID) {
+		return "", false, nil
+	}
+	// Legacy members were organization creators
+	return types.RoleOwner, true, nil
+}
+
+// AddMember adds a user as a member of an organization
+func (r *OrganizationMemberRepository) AddMember(ctx context.Context, orgID, userID string, role types.Role) error {
+	member := &types.OrganizationMember{
+		ID:             orgID + "_" + userID,
+		PartitionKey:   orgID,
+		EntityType:     "organization_member",
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           role,
+		CreatedAt:      time.Now(),
+	}
+
+	b := newBatch(organizationsContainer, member.PartitionKey)
+	if err := b.create(member.ID, member); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// CreateOrganizationWithMember creates an organization and adds the creator as its owner atomically
+func (r *OrganizationMemberRepository) CreateOrganizationWithMember(ctx context.Context, org *types.Organization, userID string) error {
+	// Set entity_type and ensure organization_id matches id
+	org.EntityType = "organization"
+	if org.OrganizationID == "" {
+		org.OrganizationID = org.ID
+	}
+	org.PartitionKey = org.GetPartitionKey()
+
+	member := &types.OrganizationMember{
+		ID:             org.ID + "_" + userID,
+		PartitionKey:   org.OrganizationID,
+		EntityType:     "organization_member",
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Role:           types.RoleOwner,
+		CreatedAt:      time.Now(),
+	}
+
+	// Both items are in the same partition, so they are written together or not at all
+	b := newBatch(organizationsContainer, org.OrganizationID)
+	if err := b.create(org.ID, org); err != nil {
+		return err
+	}
+	if err := b.create(member.ID, member); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// RemoveMember removes a user from an organization
+func (r *OrganizationMemberRepository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	b := newBatch(organizationsContainer, orgID)
+	b.delete(orgID + "_" + userID)
+	return r.store.execute(b)
+}
+
+// ListMembers lists all members of an organization
+func (r *OrganizationMemberRepository) ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error) {
+	if orgID == "" {
+		return nil, nil
+	}
+	return query[types.OrganizationMember](r.store, organizationsContainer, orgID, "organization_member", nil)
+}
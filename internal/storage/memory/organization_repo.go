@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const organizationsContainer = "organizations"
+
+// OrganizationRepository handles organization storage operations
+type OrganizationRepository struct {
+	store *Store
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(store *Store) *OrganizationRepository {
+	return &OrganizationRepository{
+		store: store,
+	}
+}
+
+// Create creates a new organization
+func (r *OrganizationRepository) Create(ctx context.Context, org *types.Organization) error {
+	// Set entity_type and ensure organization_id matches id
+	org.EntityType = "organization"
+	if org.OrganizationID == "" {
+		org.OrganizationID = org.ID
+	}
+	org.PartitionKey = org.GetPartitionKey()
+
+	b := newBatch(organizationsContainer, org.PartitionKey)
+	if err := b.create(org.ID, org); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// Delete deletes an organization by ID
+func (r *OrganizationRepository) Delete(ctx context.Context, id string) error {
+	b := newBatch(organizationsContainer, id)
+	b.delete(id)
+	return r.store.execute(b)
+}
+
+// Get retrieves an organization by ID
+func (r *OrganizationRepository) Get(ctx context.Context, id string) (*types.Organization, error) {
+	var org types.Organization
+	if err := r.store.read(organizationsContainer, id, id, &org); err != nil {
+		return nil, err
+	}
+
+	// Verify entity type to ensure we got an organization, not a member
+	if org.EntityType != "" && org.EntityType != "organization" {
+		return nil, fmt.Errorf("organization not found")
+	}
+
+	return &org, nil
+}
+
+// List lists all organizations
+func (r *OrganizationRepository) List(ctx context.Context) ([]*types.Organization, error) {
+	return query[types.Organization](r.store, organizationsContainer, "", "organization", nil)
+}
+
+// ListByMemberID lists organizations where the user is a member
+func (r *OrganizationRepository) ListByMemberID(ctx context.Context, userID string) ([]*types.Organization, error) {
+	orgMap := make(map[string]*types.Organization) // Use map to deduplicate
+
+	members, err := query(r.store, organizationsContainer, "", "organization_member", func(m *types.OrganizationMember) bool {
+		return m.UserID == userID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range members {
+		org, err := r.Get(ctx, member.OrganizationID)
+		if err != nil {
+			// If org not found, skip this member
+			continue
+		}
+		orgMap[org.ID] = org
+	}
+
+	// Organizations where member_ids array contains the user ID (backward compatibility)
+	legacy, err := query(r.store, organizationsContainer, "", "organization", func(o *types.Organization) bool {
+		return slices.Contains(o.MemberIDs, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range legacy {
+		orgMap[org.ID] = org
+	}
+
+	var orgs []*types.Organization
+	for _, org := range orgMap {
+		orgs = append(orgs, org)
+	}
+
+	return orgs, nil
+}
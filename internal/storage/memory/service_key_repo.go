@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const serviceKeysContainer = "service_keys"
+
+type ServiceKeyRepository struct {
+	store *Store
+}
+
+func NewServiceKeyRepository(store *Store) *ServiceKeyRepository {
+	return &ServiceKeyRepository{
+		store: store,
+	}
+}
+
+// Create creates a new service key with audit history
+func (r *ServiceKeyRepository) Create(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	token.PartitionKey = token.GetPartitionKey()
+
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionCreated, userID, githubID, username, nil)
+
+	b := newBatch(serviceKeysContainer, token.PartitionKey)
+	if err := b.create(token.ID, token); err != nil {
+		return err
+	}
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// Get retrieves a service key by ID using the organization ID as the partition key
+func (r *ServiceKeyRepository) Get(ctx context.Context, organizationID, id string) (*types.ServiceKey, error) {
+	var token types.ServiceKey
+	if err := r.store.read(serviceKeysContainer, organizationID, id, &token); err != nil {
+		return nil, err
+	}
+
+	// Verify entity type to ensure we got a service key, not audit history
+	if token.EntityType != "" && token.EntityType != "service_key" {
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
+	}
+
+	return &token, nil
+}
+
+// FindByTokenValue finds a service key by its hashed value (queries across all partitions)
+func (r *ServiceKeyRepository) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ServiceKey, error) {
+	return r.findByTokenValue("", tokenValue)
+}
+
+// FindByTokenValueInOrg finds a service key by its hashed value within a specific organization
+func (r *ServiceKeyRepository) FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error) {
+	if organizationID == "" {
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
+	}
+	return r.findByTokenValue(organizationID, tokenValue)
+}
+
+func (r *ServiceKeyRepository) findByTokenValue(partitionKey, tokenValue string) (*types.ServiceKey, error) {
+	hashed := storage.HashToken(tokenValue)
+
+	// A rotated key is also found by its previous token, which the caller checks against the grace period
+	tokens, err := query(r.store, serviceKeysContainer, partitionKey, "service_key", func(t *types.ServiceKey) bool {
+		return t.TokenValue == hashed || t.PreviousTokenValue == hashed
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
+	}
+
+	return tokens[0], nil
+}
+
+// CountByOrganization counts service keys for an organization
+func (r *ServiceKeyRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
+	tokens, err := r.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
+
+// ListByOrganization lists service keys for an organization
+func (r *ServiceKeyRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.ServiceKey, error) {
+	if organizationID == "" {
+		return nil, nil
+	}
+	return query(r.store, serviceKeysContainer, organizationID, "service_key", func(t *types.ServiceKey) bool {
+		return t.OrganizationID == organizationID
+	})
+}
+
+// Update updates a service key with audit history
+func (r *ServiceKeyRepository) Update(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	return r.replace(ctx, token, types.AuditActionUpdated, userID, githubID, username)
+}
+
+// Rotate stores a service key with a new token value with audit history.
+// The new token value is hashed, the previous hash must already be set by the caller.
+func (r *ServiceKeyRepository) Rotate(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	return r.replace(ctx, token, types.AuditActionRotated, userID, githubID, username)
+}
+
+func (r *ServiceKeyRepository) replace(ctx context.Context, token *types.ServiceKey, action types.AuditAction, userID, githubID, username string) error {
+	token.PartitionKey = token.GetPartitionKey()
+
+	var before types.ServiceKey
+	if err := r.store.read(serviceKeysContainer, token.PartitionKey, token.ID, &before); err != nil {
+		return err
+	}
+
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, action, userID, githubID, username, storage.DiffServiceKeys(&before, token))
+
+	b := newBatch(serviceKeysContainer, token.PartitionKey)
+	if err := b.replace(token.ID, token); err != nil {
+		return err
+	}
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// Delete deletes a service key with audit history
+func (r *ServiceKeyRepository) Delete(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	token.PartitionKey = token.GetPartitionKey()
+
+	auditHistory := storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionDeleted, userID, githubID, username, nil)
+
+	b := newBatch(serviceKeysContainer, token.PartitionKey)
+	b.delete(token.ID)
+	return r.store.executeAudited(b, auditHistory)
+}
+
+// GetHistory retrieves audit history for a service key
+func (r *ServiceKeyRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	return getHistory(r.store, serviceKeysContainer, organizationID, entityID)
+}
+
+// ListEvents returns the audit history of every service key in an organization after the cursor
+func (r *ServiceKeyRepository) ListEvents(ctx context.Context, organizationID, cursor string, limit int) ([]*storage.AuditEvent, error) {
+	history, err := query(r.store, serviceKeysContainer, organizationID, "audit_history", func(a *types.AuditHistory) bool {
+		return a.OrganizationID == organizationID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.HistoryAfter(history, cursor, limit)
+}
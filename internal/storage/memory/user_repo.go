@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const usersContainer = "users"
+
+// UserRepository handles user storage operations
+type UserRepository struct {
+	store *Store
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{
+		store: store,
+	}
+}
+
+// CreateOrUpdate creates or updates a user
+func (r *UserRepository) CreateOrUpdate(ctx context.Context, user *types.User) error {
+	if user.ID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+	if user.GitHubID == "" {
+		return fmt.Errorf("user GitHubID is required")
+	}
+
+	user.PartitionKey = user.GetPartitionKey()
+
+	b := newBatch(usersContainer, user.PartitionKey)
+	if err := b.upsert(user.ID, user); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// GetByGitHubID retrieves a user by GitHub ID
+func (r *UserRepository) GetByGitHubID(ctx context.Context, githubID string) (*types.User, error) {
+	if githubID == "" {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	users, err := query(r.store, usersContainer, githubID, "", func(u *types.User) bool {
+		return u.GitHubID == githubID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return users[0], nil
+}
+
+// GetByID retrieves a user by ID
+// id is the item ID, githubID is the partition key
+func (r *UserRepository) GetByID(ctx context.Context, id string, githubID string) (*types.User, error) {
+	var user types.User
+	if err := r.store.read(usersContainer, githubID, id, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const webhooksContainer = "webhooks"
+
+// WebhookRepository handles webhook and webhook delivery storage operations.
+// Deliveries live in the webhooks container next to their webhook.
+type WebhookRepository struct {
+	store *Store
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(store *Store) *WebhookRepository {
+	return &WebhookRepository{
+		store: store,
+	}
+}
+
+// Create creates a new webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *types.Webhook) error {
+	webhook.EntityType = "webhook"
+	webhook.PartitionKey = webhook.GetPartitionKey()
+
+	b := newBatch(webhooksContainer, webhook.PartitionKey)
+	if err := b.create(webhook.ID, webhook); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// Get retrieves a webhook by ID using the organization ID as the partition key
+func (r *WebhookRepository) Get(ctx context.Context, organizationID, id string) (*types.Webhook, error) {
+	var webhook types.Webhook
+	if err := r.store.read(webhooksContainer, organizationID, id, &webhook); err != nil {
+		return nil, err
+	}
+	if webhook.EntityType != "webhook" {
+		return nil, storage.ErrNotFound
+	}
+	return &webhook, nil
+}
+
+// ListByOrganization lists webhooks for an organization
+func (r *WebhookRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Webhook, error) {
+	if organizationID == "" {
+		return nil, nil
+	}
+	webhooks, err := query[types.Webhook](r.store, webhooksContainer, organizationID, "webhook", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+// Update updates a webhook
+func (r *WebhookRepository) Update(ctx context.Context, webhook *types.Webhook) error {
+	webhook.PartitionKey = webhook.GetPartitionKey()
+
+	b := newBatch(webhooksContainer, webhook.PartitionKey)
+	if err := b.replace(webhook.ID, webhook); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// Delete deletes a webhook, its deliveries are kept
+func (r *WebhookRepository) Delete(ctx context.Context, organizationID, id string) error {
+	if _, err := r.Get(ctx, organizationID, id); err != nil {
+		return err
+	}
+
+	b := newBatch(webhooksContainer, organizationID)
+	b.delete(id)
+
+	return r.store.execute(b)
+}
+
+// CreateDelivery records a new delivery
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	delivery.EntityType = "webhook_delivery"
+	delivery.PartitionKey = delivery.GetPartitionKey()
+
+	b := newBatch(webhooksContainer, delivery.PartitionKey)
+	if err := b.create(delivery.ID, delivery); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// GetDelivery retrieves a delivery by ID using the organization ID as the partition key
+func (r *WebhookRepository) GetDelivery(ctx context.Context, organizationID, id string) (*types.WebhookDelivery, error) {
+	var delivery types.WebhookDelivery
+	if err := r.store.read(webhooksContainer, organizationID, id, &delivery); err != nil {
+		return nil, err
+	}
+	if delivery.EntityType != "webhook_delivery" {
+		return nil, storage.ErrNotFound
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery records the outcome of another delivery attempt
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	delivery.PartitionKey = delivery.GetPartitionKey()
+
+	b := newBatch(webhooksContainer, delivery.PartitionKey)
+	if err := b.replace(delivery.ID, delivery); err != nil {
+		return err
+	}
+
+	return r.store.execute(b)
+}
+
+// ClaimDelivery stores delivery unless the stored delivery finished or made another attempt since it was read
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, delivery *types.WebhookDelivery, previousAttempts int) (bool, error) {
+	r.store.claimMu.Lock()
+	defer r.store.claimMu.Unlock()
+
+	stored, err := r.GetDelivery(ctx, delivery.OrganizationID, delivery.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if stored.Status != types.WebhookDeliveryStatusPending || stored.Attempts != previousAttempts {
+		return false, nil
+	}
+
+	if err := r.UpdateDelivery(ctx, delivery); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListDeliveries returns up to limit deliveries made to a webhook, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, organizationID, webhookID string, limit int) ([]*types.WebhookDelivery, error) {
+	if organizationID == "" {
+		return nil, nil
+	}
+	deliveries, err := query(r.store, webhooksContainer, organizationID, "webhook_delivery", func(d *types.WebhookDelivery) bool {
+		return d.WebhookID == webhookID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// ListPendingDeliveries returns up to limit deliveries of the organization still waiting for an attempt, oldest first
+func (r *WebhookRepository) ListPendingDeliveries(ctx context.Context, organizationID string, limit int) ([]*types.WebhookDelivery, error) {
+	if organizationID == "" {
+		return nil, nil
+	}
+	deliveries, err := query(r.store, webhooksContainer, organizationID, "webhook_delivery", func(d *types.WebhookDelivery) bool {
+		return d.Status == types.WebhookDeliveryStatusPending
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
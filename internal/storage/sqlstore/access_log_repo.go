@@ -0,0 +1,128 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const accessLogColumns = `id, organization_id, service_key_id, application_id, application_name,
+	allowed, denial_reason, api_key_id, client_ip, created_at`
+
+// AccessLogRepository handles access log and service key usage storage operations
+type AccessLogRepository struct {
+	db *DB
+}
+
+// NewAccessLogRepository creates a new access log repository
+func NewAccessLogRepository(db *DB) *AccessLogRepository {
+	return &AccessLogRepository{
+		db: db,
+	}
+}
+
+// Record stores a batch of decisions and adds them to their service keys' usage in one transaction.
+// Usage rows are incremented in place so replicas recording at the same time do not lose counts.
+func (r *AccessLogRepository) Record(ctx context.Context, logs []*types.AccessLog) error {
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, log := range logs {
+			log.EntityType = "access_log"
+			log.PartitionKey = log.GetPartitionKey()
+
+			_, err := r.db.exec(ctx, tx, `INSERT INTO access_logs (`+accessLogColumns+`)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				log.ID, log.OrganizationID, log.ServiceKeyID, log.ApplicationID, log.ApplicationName,
+				log.Allowed, log.DenialReason, log.ApiKeyID, log.ClientIP, log.CreatedAt.UTC(),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert access log: %w", err)
+			}
+		}
+
+		for _, usage := range storage.SummarizeUsage(logs) {
+			_, err := r.db.exec(ctx, tx, `INSERT INTO service_key_usage
+				(organization_id, service_key_id, allowed_count, denied_count, last_used_at, last_denied_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (organization_id, service_key_id) DO UPDATE SET
+					allowed_count = service_key_usage.allowed_count + excluded.allowed_count,
+					denied_count = service_key_usage.denied_count + excluded.denied_count,
+					last_used_at = CASE
+						WHEN excluded.last_used_at IS NULL THEN service_key_usage.last_used_at
+						WHEN service_key_usage.last_used_at IS NULL OR excluded.last_used_at > service_key_usage.last_used_at THEN excluded.last_used_at
+						ELSE service_key_usage.last_used_at
+					END,
+					last_denied_at = CASE
+						WHEN excluded.last_denied_at IS NULL THEN service_key_usage.last_denied_at
+						WHEN service_key_usage.last_denied_at IS NULL OR excluded.last_denied_at > service_key_usage.last_denied_at THEN excluded.last_denied_at
+						ELSE service_key_usage.last_denied_at
+					END`,
+				usage.OrganizationID, usage.ServiceKeyID, usage.AllowedCount, usage.DeniedCount,
+				nullTime(usage.LastUsedAt), nullTime(usage.LastDeniedAt),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to update service key usage: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListByServiceKey returns up to limit decisions about a service key made before a time, newest first
+func (r *AccessLogRepository) ListByServiceKey(ctx context.Context, organizationID, serviceKeyID string, before time.Time, limit int) ([]*types.AccessLog, error) {
+	query := `SELECT ` + accessLogColumns + ` FROM access_logs WHERE organization_id = ? AND service_key_id = ?`
+	args := []any{organizationID, serviceKeyID}
+	if !before.IsZero() {
+		query += ` AND created_at < ?`
+		args = append(args, before.UTC())
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.query(ctx, r.db.db, query, args...)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var logs []*types.AccessLog
+	for rows.Next() {
+		log := &types.AccessLog{EntityType: "access_log"}
+		err := rows.Scan(
+			&log.ID, &log.OrganizationID, &log.ServiceKeyID, &log.ApplicationID, &log.ApplicationName,
+			&log.Allowed, &log.DenialReason, &log.ApiKeyID, &log.ClientIP, &log.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access log: %w", err)
+		}
+		log.PartitionKey = log.GetPartitionKey()
+		logs = append(logs, log)
+	}
+
+	return logs, handleSQLError(rows.Err())
+}
+
+// GetUsage returns a service key's usage, with zero counts when it was never validated
+func (r *AccessLogRepository) GetUsage(ctx context.Context, organizationID, serviceKeyID string) (*types.ServiceKeyUsage, error) {
+	usage := storage.NewServiceKeyUsage(organizationID, serviceKeyID)
+
+	var lastUsedAt, lastDeniedAt sql.NullTime
+	err := r.db.queryRow(ctx, r.db.db, `SELECT allowed_count, denied_count, last_used_at, last_denied_at
+		FROM service_key_usage WHERE organization_id = ? AND service_key_id = ?`,
+		organizationID, serviceKeyID,
+	).Scan(&usage.AllowedCount, &usage.DeniedCount, &lastUsedAt, &lastDeniedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return usage, nil
+	}
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+
+	usage.LastUsedAt = timePtr(lastUsedAt)
+	usage.LastDeniedAt = timePtr(lastDeniedAt)
+	return usage, nil
+}
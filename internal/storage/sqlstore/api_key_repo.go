@@ -0,0 +1,206 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const apiKeyColumns = `id, organization_id, application_id, name, token_value, expires_at,
+	created_by_user_id, created_by_github_id, created_by_username, created_at, updated_at,
+	previous_token_value, previous_token_expires_at, revoked_at, revoked_reason, scopes, application_ids`
+
+type ApiKeyRepository struct {
+	db *DB
+}
+
+func NewApiKeyRepository(db *DB) *ApiKeyRepository {
+	return &ApiKeyRepository{
+		db: db,
+	}
+}
+
+// Create creates a new API key with audit history
+func (r *ApiKeyRepository) Create(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	token.PartitionKey = token.GetPartitionKey()
+
+	scopes, applicationIDs, err := marshalApiKeyScopes(token)
+	if err != nil {
+		return err
+	}
+
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := r.db.exec(ctx, tx, `INSERT INTO api_keys (`+apiKeyColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			token.ID, token.OrganizationID, token.ApplicationID, token.Name, token.TokenValue, nullTime(token.ExpiresAt),
+			token.CreatedByUserID, token.CreatedByGitHubID, token.CreatedByUsername, token.CreatedAt.UTC(), token.UpdatedAt.UTC(),
+			token.PreviousTokenValue, nullTime(token.PreviousTokenExpiresAt), nullTime(token.RevokedAt), token.RevokedReason,
+			scopes, applicationIDs,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert API key: %w", err)
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "api_keys", storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionCreated, userID, githubID, username, nil))
+	})
+}
+
+// Get retrieves an API key by ID within an organization
+func (r *ApiKeyRepository) Get(ctx context.Context, organizationID, id string) (*types.ApiKey, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+apiKeyColumns+` FROM api_keys WHERE organization_id = ? AND id = ?`, organizationID, id)
+	token, err := scanApiKey(row)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return token, nil
+}
+
+// FindByTokenValue finds an API key by its hashed value using the token_value indexes.
+// A rotated key is also found by its previous token, which the caller checks against the grace period.
+func (r *ApiKeyRepository) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ApiKey, error) {
+	hashed := storage.HashToken(tokenValue)
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+apiKeyColumns+` FROM api_keys WHERE token_value = ? OR previous_token_value = ?`, hashed, hashed)
+	token, err := scanApiKey(row)
+	if err != nil {
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
+	}
+	return token, nil
+}
+
+// CountByOrganization counts API keys for an organization
+func (r *ApiKeyRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
+	return r.db.count(ctx, "SELECT COUNT(1) FROM api_keys WHERE organization_id = ?", organizationID)
+}
+
+// ListByOrganization lists API keys for an organization
+func (r *ApiKeyRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.ApiKey, error) {
+	rows, err := r.db.query(ctx, r.db.db, `SELECT `+apiKeyColumns+` FROM api_keys WHERE organization_id = ? ORDER BY created_at`, organizationID)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var tokens []*types.ApiKey
+	for rows.Next() {
+		token, err := scanApiKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, handleSQLError(rows.Err())
+}
+
+// Update updates an API key with audit history
+func (r *ApiKeyRepository) Update(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	return r.update(ctx, token, types.AuditActionUpdated, userID, githubID, username)
+}
+
+// Rotate stores an API key with a new token value with audit history.
+// The new token value is hashed, the previous hash must already be set by the caller.
+func (r *ApiKeyRepository) Rotate(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	return r.update(ctx, token, types.AuditActionRotated, userID, githubID, username)
+}
+
+// Revoke stores a revoked API key with audit history, the revocation must already be set by the caller
+func (r *ApiKeyRepository) Revoke(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	return r.update(ctx, token, types.AuditActionRevoked, userID, githubID, username)
+}
+
+func (r *ApiKeyRepository) update(ctx context.Context, token *types.ApiKey, action types.AuditAction, userID, githubID, username string) error {
+	token.PartitionKey = token.GetPartitionKey()
+
+	scopes, applicationIDs, err := marshalApiKeyScopes(token)
+	if err != nil {
+		return err
+	}
+
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		// Read the stored version to record what changed
+		row := r.db.queryRow(ctx, tx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE organization_id = ? AND id = ?`, token.OrganizationID, token.ID)
+		before, err := scanApiKey(row)
+		if err != nil {
+			return handleSQLError(err)
+		}
+
+		err = r.db.execOne(ctx, tx, `UPDATE api_keys
+			SET application_id = ?, name = ?, token_value = ?, expires_at = ?, updated_at = ?,
+				previous_token_value = ?, previous_token_expires_at = ?, revoked_at = ?, revoked_reason = ?,
+				scopes = ?, application_ids = ?
+			WHERE organization_id = ? AND id = ?`,
+			token.ApplicationID, token.Name, token.TokenValue, nullTime(token.ExpiresAt), token.UpdatedAt.UTC(),
+			token.PreviousTokenValue, nullTime(token.PreviousTokenExpiresAt), nullTime(token.RevokedAt), token.RevokedReason,
+			scopes, applicationIDs,
+			token.OrganizationID, token.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "api_keys", storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, action, userID, githubID, username, storage.DiffApiKeys(before, token)))
+	})
+}
+
+// Delete deletes an API key with audit history
+func (r *ApiKeyRepository) Delete(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error {
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := r.db.execOne(ctx, tx, "DELETE FROM api_keys WHERE organization_id = ? AND id = ?", token.OrganizationID, token.ID); err != nil {
+			return err
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "api_keys", storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionDeleted, userID, githubID, username, nil))
+	})
+}
+
+// GetHistory retrieves audit history for an API key
+func (r *ApiKeyRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	return r.db.getHistory(ctx, "api_keys", organizationID, entityID)
+}
+
+func scanApiKey(row rowScanner) (*types.ApiKey, error) {
+	token := &types.ApiKey{EntityType: "api_key"}
+	var scopes, applicationIDs []byte
+	var expiresAt, previousTokenExpiresAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&token.ID, &token.OrganizationID, &token.ApplicationID, &token.Name, &token.TokenValue, &expiresAt,
+		&token.CreatedByUserID, &token.CreatedByGitHubID, &token.CreatedByUsername, &token.CreatedAt, &token.UpdatedAt,
+		&token.PreviousTokenValue, &previousTokenExpiresAt, &revokedAt, &token.RevokedReason,
+		&scopes, &applicationIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Scopes, err = unmarshalJSON[types.ApiKeyScope](scopes); err != nil {
+		return nil, err
+	}
+	if token.ApplicationIDs, err = unmarshalJSON[string](applicationIDs); err != nil {
+		return nil, err
+	}
+
+	token.ExpiresAt = timePtr(expiresAt)
+	token.PreviousTokenExpiresAt = timePtr(previousTokenExpiresAt)
+	token.RevokedAt = timePtr(revokedAt)
+	token.PartitionKey = token.GetPartitionKey()
+
+	return token, nil
+}
+
+// marshalApiKeyScopes encodes the JSON columns of an API key
+func marshalApiKeyScopes(token *types.ApiKey) (string, string, error) {
+	scopes, err := marshalJSON(token.Scopes)
+	if err != nil {
+		return "", "", err
+	}
+	applicationIDs, err := marshalJSON(token.ApplicationIDs)
+	if err != nil {
+		return "", "", err
+	}
+	return scopes, applicationIDs, nil
+}
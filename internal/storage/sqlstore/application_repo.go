@@ -0,0 +1,148 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const applicationColumns = `id, organization_id, name, description, permissions,
+	created_by_user_id, created_by_github_id, created_by_username, created_at, updated_at`
+
+type ApplicationRepository struct {
+	db *DB
+}
+
+func NewApplicationRepository(db *DB) *ApplicationRepository {
+	return &ApplicationRepository{
+		db: db,
+	}
+}
+
+// Create creates a new application with audit history
+func (r *ApplicationRepository) Create(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	app.PartitionKey = app.GetPartitionKey()
+
+	permissions, err := marshalJSON(app.Permissions)
+	if err != nil {
+		return err
+	}
+
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := r.db.exec(ctx, tx, `INSERT INTO applications (`+applicationColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			app.ID, app.OrganizationID, app.Name, app.Description, permissions,
+			app.CreatedByUserID, app.CreatedByGitHubID, app.CreatedByUsername, app.CreatedAt.UTC(), app.UpdatedAt.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert application: %w", err)
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "applications", storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionCreated, userID, githubID, username, nil))
+	})
+}
+
+// CountByOrganization counts applications for an organization
+func (r *ApplicationRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
+	return r.db.count(ctx, "SELECT COUNT(1) FROM applications WHERE organization_id = ?", organizationID)
+}
+
+// ListByOrganization lists applications for an organization
+func (r *ApplicationRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Application, error) {
+	rows, err := r.db.query(ctx, r.db.db, `SELECT `+applicationColumns+` FROM applications WHERE organization_id = ? ORDER BY created_at`, organizationID)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var apps []*types.Application
+	for rows.Next() {
+		app, err := scanApplication(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		apps = append(apps, app)
+	}
+
+	return apps, handleSQLError(rows.Err())
+}
+
+// Get retrieves an application by ID within an organization
+func (r *ApplicationRepository) Get(ctx context.Context, organizationID, id string) (*types.Application, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+applicationColumns+` FROM applications WHERE organization_id = ? AND id = ?`, organizationID, id)
+	app, err := scanApplication(row)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return app, nil
+}
+
+// Update updates an application with audit history
+func (r *ApplicationRepository) Update(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	app.PartitionKey = app.GetPartitionKey()
+
+	permissions, err := marshalJSON(app.Permissions)
+	if err != nil {
+		return err
+	}
+
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		// Read the stored version to record what changed
+		row := r.db.queryRow(ctx, tx, `SELECT `+applicationColumns+` FROM applications WHERE organization_id = ? AND id = ?`, app.OrganizationID, app.ID)
+		before, err := scanApplication(row)
+		if err != nil {
+			return handleSQLError(err)
+		}
+
+		err = r.db.execOne(ctx, tx, `UPDATE applications
+			SET name = ?, description = ?, permissions = ?, updated_at = ?
+			WHERE organization_id = ? AND id = ?`,
+			app.Name, app.Description, permissions, app.UpdatedAt.UTC(),
+			app.OrganizationID, app.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "applications", storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionUpdated, userID, githubID, username, storage.DiffApplications(before, app)))
+	})
+}
+
+// Delete deletes an application with audit history
+func (r *ApplicationRepository) Delete(ctx context.Context, app *types.Application, userID, githubID, username string) error {
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := r.db.execOne(ctx, tx, "DELETE FROM applications WHERE organization_id = ? AND id = ?", app.OrganizationID, app.ID); err != nil {
+			return err
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "applications", storage.NewAuditHistory(ctx, app.OrganizationID, app.ID, types.AuditActionDeleted, userID, githubID, username, nil))
+	})
+}
+
+// GetHistory retrieves audit history for an application
+func (r *ApplicationRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	return r.db.getHistory(ctx, "applications", organizationID, entityID)
+}
+
+func scanApplication(row rowScanner) (*types.Application, error) {
+	app := &types.Application{EntityType: "application"}
+	var permissions []byte
+	err := row.Scan(
+		&app.ID, &app.OrganizationID, &app.Name, &app.Description, &permissions,
+		&app.CreatedByUserID, &app.CreatedByGitHubID, &app.CreatedByUsername, &app.CreatedAt, &app.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	app.Permissions, err = unmarshalJSON[string](permissions)
+	if err != nil {
+		return nil, err
+	}
+	app.PartitionKey = app.GetPartitionKey()
+
+	return app, nil
+}
@@ -0,0 +1,151 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// auditTables maps each audited kind of entity to the entity_table of its audit history
+var auditTables = map[types.AuditResourceType]string{
+	types.AuditResourceServiceKey:  "service_keys",
+	types.AuditResourceApiKey:      "api_keys",
+	types.AuditResourceApplication: "applications",
+}
+
+// AuditRepository searches the audit_history table
+type AuditRepository struct {
+	db *DB
+}
+
+func NewAuditRepository(db *DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// Search returns up to limit records of an organization matching filter, newest first.
+// The cursor is the seq of the last record already seen.
+func (r *AuditRepository) Search(ctx context.Context, organizationID string, filter storage.AuditFilter, cursor string, limit int) ([]*storage.AuditEvent, error) {
+	conditions := []string{"organization_id = ?"}
+	args := []any{organizationID}
+
+	if cursor != "" {
+		before, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrInvalidCursor, err)
+		}
+		conditions = append(conditions, "seq < ?")
+		args = append(args, before)
+	}
+	if filter.ActorUserID != "" {
+		conditions = append(conditions, "created_by_user_id = ?")
+		args = append(args, filter.ActorUserID)
+	}
+	if filter.ActorUsername != "" {
+		conditions = append(conditions, "lower(created_by_username) = lower(?)")
+		args = append(args, filter.ActorUsername)
+	}
+	if len(filter.ResourceTypes) > 0 {
+		tables := make([]any, len(filter.ResourceTypes))
+		for i, resourceType := range filter.ResourceTypes {
+			tables[i] = auditTables[resourceType]
+		}
+		conditions = append(conditions, "entity_table IN ("+placeholders(len(tables))+")")
+		args = append(args, tables...)
+	}
+	if len(filter.Actions) > 0 {
+		conditions = append(conditions, "action IN ("+placeholders(len(filter.Actions))+")")
+		for _, action := range filter.Actions {
+			args = append(args, string(action))
+		}
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+	args = append(args, limit)
+
+	rows, err := r.db.query(ctx, r.db.db, `SELECT `+auditHistoryColumns+`, entity_table, seq
+		FROM audit_history
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY seq DESC
+		LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var events []*storage.AuditEvent
+	for rows.Next() {
+		var entityTable string
+		var seq int64
+		audit, err := scanAuditHistory(rows, &entityTable, &seq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit history: %w", err)
+		}
+		events = append(events, &storage.AuditEvent{
+			AuditHistory: audit,
+			ResourceType: auditResourceType(entityTable),
+			Cursor:       strconv.FormatInt(seq, 10),
+		})
+	}
+
+	return events, handleSQLError(rows.Err())
+}
+
+// GetChainHead returns the head of an organization's audit chain for a kind of entity
+func (r *AuditRepository) GetChainHead(ctx context.Context, organizationID string, resourceType types.AuditResourceType) (*types.AuditChainHead, error) {
+	return r.db.getChainHead(ctx, r.db.db, auditTables[resourceType], organizationID)
+}
+
+// ListChain returns up to limit records of an organization's audit chain for a kind of entity after afterSequence
+func (r *AuditRepository) ListChain(ctx context.Context, organizationID string, resourceType types.AuditResourceType, afterSequence int64, limit int) ([]*types.AuditHistory, error) {
+	rows, err := r.db.query(ctx, r.db.db, `SELECT `+auditHistoryColumns+`
+		FROM audit_history
+		WHERE organization_id = ? AND entity_table = ? AND chain_sequence > 0 AND chain_sequence > ?
+		ORDER BY chain_sequence, id
+		LIMIT ?`,
+		organizationID, auditTables[resourceType], afterSequence, limit,
+	)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var history []*types.AuditHistory
+	for rows.Next() {
+		audit, err := scanAuditHistory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit history: %w", err)
+		}
+		history = append(history, audit)
+	}
+
+	return history, handleSQLError(rows.Err())
+}
+
+// auditResourceType returns the kind of entity stored in entityTable
+func auditResourceType(entityTable string) types.AuditResourceType {
+	for resourceType, table := range auditTables {
+		if table == entityTable {
+			return resourceType
+		}
+	}
+	return types.AuditResourceType(entityTable)
+}
+
+// placeholders returns n comma-separated ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,129 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const invitationColumns = `id, organization_id, github_username, role, status,
+	invited_by_user_id, invited_by_github_id, invited_by_username, expires_at, responded_at, created_at, updated_at`
+
+// InvitationRepository handles organization invitation storage operations
+type InvitationRepository struct {
+	db         *DB
+	memberRepo *OrganizationMemberRepository
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *DB) *InvitationRepository {
+	return &InvitationRepository{
+		db:         db,
+		memberRepo: NewOrganizationMemberRepository(db),
+	}
+}
+
+// Create creates a new invitation
+func (r *InvitationRepository) Create(ctx context.Context, invitation *types.Invitation) error {
+	invitation.EntityType = "invitation"
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	_, err := r.db.exec(ctx, r.db.db, `INSERT INTO invitations (`+invitationColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		invitation.ID, invitation.OrganizationID, invitation.GitHubUsername, string(invitation.Role), string(invitation.Status),
+		invitation.InvitedByUserID, invitation.InvitedByGitHubID, invitation.InvitedByUsername,
+		invitation.ExpiresAt.UTC(), nullTime(invitation.RespondedAt), invitation.CreatedAt.UTC(), invitation.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert invitation: %w", handleSQLError(err))
+	}
+	return nil
+}
+
+// Get retrieves an invitation by ID
+func (r *InvitationRepository) Get(ctx context.Context, id string) (*types.Invitation, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+invitationColumns+` FROM invitations WHERE id = ?`, id)
+	invitation, err := scanInvitation(row)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return invitation, nil
+}
+
+// ListByOrganization lists invitations for an organization
+func (r *InvitationRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Invitation, error) {
+	return r.list(ctx, `SELECT `+invitationColumns+` FROM invitations WHERE organization_id = ? ORDER BY created_at`, organizationID)
+}
+
+// ListByUsername lists invitations sent to a GitHub user, ignoring case
+func (r *InvitationRepository) ListByUsername(ctx context.Context, username string) ([]*types.Invitation, error) {
+	return r.list(ctx, `SELECT `+invitationColumns+` FROM invitations WHERE lower(github_username) = lower(?) ORDER BY created_at`, username)
+}
+
+// Update updates an invitation
+func (r *InvitationRepository) Update(ctx context.Context, invitation *types.Invitation) error {
+	return handleSQLError(r.update(ctx, r.db.db, invitation))
+}
+
+// Accept marks an invitation accepted and adds the user as a member with the invited role atomically
+func (r *InvitationRepository) Accept(ctx context.Context, invitation *types.Invitation, userID string) error {
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := r.update(ctx, tx, invitation); err != nil {
+			return err
+		}
+		return r.memberRepo.insert(ctx, tx, invitation.OrganizationID, userID, invitation.Role)
+	})
+}
+
+func (r *InvitationRepository) update(ctx context.Context, q querier, invitation *types.Invitation) error {
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	return r.db.execOne(ctx, q, `UPDATE invitations
+		SET role = ?, status = ?, expires_at = ?, responded_at = ?, updated_at = ?
+		WHERE organization_id = ? AND id = ?`,
+		string(invitation.Role), string(invitation.Status), invitation.ExpiresAt.UTC(), nullTime(invitation.RespondedAt), invitation.UpdatedAt.UTC(),
+		invitation.OrganizationID, invitation.ID,
+	)
+}
+
+func (r *InvitationRepository) list(ctx context.Context, query string, args ...any) ([]*types.Invitation, error) {
+	rows, err := r.db.query(ctx, r.db.db, query, args...)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var invitations []*types.Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, handleSQLError(rows.Err())
+}
+
+func scanInvitation(row rowScanner) (*types.Invitation, error) {
+	invitation := &types.Invitation{EntityType: "invitation"}
+	var role, status string
+	var respondedAt sql.NullTime
+	err := row.Scan(
+		&invitation.ID, &invitation.OrganizationID, &invitation.GitHubUsername, &role, &status,
+		&invitation.InvitedByUserID, &invitation.InvitedByGitHubID, &invitation.InvitedByUsername,
+		&invitation.ExpiresAt, &respondedAt, &invitation.CreatedAt, &invitation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.Role = types.Role(role)
+	invitation.Status = types.InvitationStatus(status)
+	invitation.RespondedAt = timePtr(respondedAt)
+	invitation.PartitionKey = invitation.GetPartitionKey()
+
+	return invitation, nil
+}
@@ -0,0 +1,18 @@
+CREATE TABLE invitations (
+    id                   TEXT PRIMARY KEY,
+    organization_id      TEXT NOT NULL,
+    github_username      TEXT NOT NULL,
+    role                 TEXT NOT NULL,
+    status               TEXT NOT NULL,
+    invited_by_user_id   TEXT NOT NULL,
+    invited_by_github_id TEXT NOT NULL,
+    invited_by_username  TEXT NOT NULL,
+    expires_at           TIMESTAMPTZ NOT NULL,
+    responded_at         TIMESTAMPTZ,
+    created_at           TIMESTAMPTZ NOT NULL,
+    updated_at           TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX invitations_organization_id_idx ON invitations (organization_id);
+-- GitHub usernames are case-insensitive
+CREATE INDEX invitations_github_username_idx ON invitations (lower(github_username));
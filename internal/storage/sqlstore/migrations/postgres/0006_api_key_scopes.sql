@@ -0,0 +1,3 @@
+-- Scopes limit which operations an API key may call and which applications it may query
+ALTER TABLE api_keys ADD COLUMN scopes JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE api_keys ADD COLUMN application_ids JSONB NOT NULL DEFAULT '[]';
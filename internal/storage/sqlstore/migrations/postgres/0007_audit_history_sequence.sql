@@ -0,0 +1,4 @@
+-- The sequence orders audit history so event streams can resume from a cursor
+ALTER TABLE audit_history ADD COLUMN seq BIGSERIAL;
+
+CREATE INDEX audit_history_seq_idx ON audit_history (organization_id, entity_table, seq);
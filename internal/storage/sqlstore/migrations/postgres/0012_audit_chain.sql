@@ -0,0 +1,17 @@
+-- Audit records are hash chained per organization and kind of entity, records written before have a zero chain_sequence
+ALTER TABLE audit_history ADD COLUMN chain_sequence BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE audit_history ADD COLUMN previous_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_history ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+-- A sequence is used once per chain, records written before the chain all share zero
+CREATE UNIQUE INDEX audit_history_chain_idx ON audit_history (organization_id, entity_table, chain_sequence) WHERE chain_sequence > 0;
+
+-- The last record of each chain, updated in the same transaction as the record
+CREATE TABLE audit_chain_heads (
+    organization_id TEXT NOT NULL,
+    entity_table    TEXT NOT NULL,
+    last_sequence   BIGINT NOT NULL,
+    last_hash       TEXT NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (organization_id, entity_table)
+);
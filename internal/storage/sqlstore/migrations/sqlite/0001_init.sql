@@ -0,0 +1,91 @@
+CREATE TABLE organizations (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    member_ids TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE organization_members (
+    id              TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    user_id         TEXT NOT NULL,
+    created_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX organization_members_organization_id_idx ON organization_members (organization_id);
+CREATE INDEX organization_members_user_id_idx ON organization_members (user_id);
+
+CREATE TABLE users (
+    id         TEXT PRIMARY KEY,
+    github_id  TEXT NOT NULL UNIQUE,
+    username   TEXT NOT NULL,
+    avatar_url TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE applications (
+    id                   TEXT PRIMARY KEY,
+    organization_id      TEXT NOT NULL,
+    name                 TEXT NOT NULL,
+    description          TEXT NOT NULL,
+    permissions          TEXT NOT NULL DEFAULT '[]',
+    created_by_user_id   TEXT NOT NULL,
+    created_by_github_id TEXT NOT NULL,
+    created_by_username  TEXT NOT NULL,
+    created_at           TIMESTAMP NOT NULL,
+    updated_at           TIMESTAMP NOT NULL
+);
+
+CREATE INDEX applications_organization_id_idx ON applications (organization_id);
+
+CREATE TABLE service_keys (
+    id                   TEXT PRIMARY KEY,
+    organization_id      TEXT NOT NULL,
+    name                 TEXT NOT NULL,
+    token_value          TEXT NOT NULL,
+    applications         TEXT NOT NULL DEFAULT '[]',
+    expires_at           TIMESTAMP,
+    created_by_user_id   TEXT NOT NULL,
+    created_by_github_id TEXT NOT NULL,
+    created_by_username  TEXT NOT NULL,
+    created_at           TIMESTAMP NOT NULL,
+    updated_at           TIMESTAMP NOT NULL
+);
+
+CREATE INDEX service_keys_organization_id_idx ON service_keys (organization_id);
+CREATE UNIQUE INDEX service_keys_token_value_idx ON service_keys (token_value);
+
+CREATE TABLE api_keys (
+    id                   TEXT PRIMARY KEY,
+    organization_id      TEXT NOT NULL,
+    application_id       TEXT NOT NULL,
+    name                 TEXT NOT NULL,
+    token_value          TEXT NOT NULL,
+    expires_at           TIMESTAMP,
+    created_by_user_id   TEXT NOT NULL,
+    created_by_github_id TEXT NOT NULL,
+    created_by_username  TEXT NOT NULL,
+    created_at           TIMESTAMP NOT NULL,
+    updated_at           TIMESTAMP NOT NULL
+);
+
+CREATE INDEX api_keys_organization_id_idx ON api_keys (organization_id);
+CREATE UNIQUE INDEX api_keys_token_value_idx ON api_keys (token_value);
+
+-- Audit history for every audited table. entity_table records which table the
+-- entity lives in, mirroring the per-container audit records in Cosmos DB.
+CREATE TABLE audit_history (
+    id                   TEXT PRIMARY KEY,
+    organization_id      TEXT NOT NULL,
+    entity_table         TEXT NOT NULL,
+    entity_id            TEXT NOT NULL,
+    action               TEXT NOT NULL,
+    created_by_user_id   TEXT NOT NULL,
+    created_by_github_id TEXT NOT NULL,
+    created_by_username  TEXT NOT NULL,
+    created_at           TIMESTAMP NOT NULL
+);
+
+CREATE INDEX audit_history_entity_idx ON audit_history (organization_id, entity_table, entity_id, created_at);
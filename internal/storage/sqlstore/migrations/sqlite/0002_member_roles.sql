@@ -0,0 +1,2 @@
+-- Members created before roles existed could only be organization creators
+ALTER TABLE organization_members ADD COLUMN role TEXT NOT NULL DEFAULT 'owner';
@@ -0,0 +1,5 @@
+-- The previous token stays valid during the grace period after a rotation
+ALTER TABLE service_keys ADD COLUMN previous_token_value TEXT NOT NULL DEFAULT '';
+ALTER TABLE service_keys ADD COLUMN previous_token_expires_at TIMESTAMP;
+
+CREATE INDEX service_keys_previous_token_value_idx ON service_keys (previous_token_value);
@@ -0,0 +1,9 @@
+-- The previous token stays valid during the grace period after a rotation
+ALTER TABLE api_keys ADD COLUMN previous_token_value TEXT NOT NULL DEFAULT '';
+ALTER TABLE api_keys ADD COLUMN previous_token_expires_at TIMESTAMP;
+
+-- Revoked keys are kept for forensics
+ALTER TABLE api_keys ADD COLUMN revoked_at TIMESTAMP;
+ALTER TABLE api_keys ADD COLUMN revoked_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX api_keys_previous_token_value_idx ON api_keys (previous_token_value);
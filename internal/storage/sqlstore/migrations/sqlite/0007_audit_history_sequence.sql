@@ -0,0 +1,13 @@
+-- The sequence orders audit history so event streams can resume from a cursor.
+-- SQLite cannot add an autoincrement column, so a trigger numbers new rows.
+ALTER TABLE audit_history ADD COLUMN seq INTEGER;
+UPDATE audit_history SET seq = rowid;
+
+CREATE INDEX audit_history_seq_idx ON audit_history (organization_id, entity_table, seq);
+CREATE INDEX audit_history_max_seq_idx ON audit_history (seq);
+
+CREATE TRIGGER audit_history_seq AFTER INSERT ON audit_history
+WHEN NEW.seq IS NULL
+BEGIN
+    UPDATE audit_history SET seq = (SELECT COALESCE(MAX(seq), 0) + 1 FROM audit_history) WHERE id = NEW.id;
+END;
@@ -0,0 +1,34 @@
+CREATE TABLE webhooks (
+    id                   TEXT PRIMARY KEY,
+    organization_id      TEXT NOT NULL,
+    url                  TEXT NOT NULL,
+    description          TEXT NOT NULL,
+    events               TEXT NOT NULL,
+    active               BOOLEAN NOT NULL,
+    secret               TEXT NOT NULL,
+    created_by_user_id   TEXT NOT NULL,
+    created_by_github_id TEXT NOT NULL,
+    created_by_username  TEXT NOT NULL,
+    created_at           TIMESTAMP NOT NULL,
+    updated_at           TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhooks_organization_id_idx ON webhooks (organization_id);
+
+-- Deliveries are kept after their webhook is deleted
+CREATE TABLE webhook_deliveries (
+    id              TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    webhook_id      TEXT NOT NULL,
+    event           TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    attempts        INTEGER NOT NULL,
+    response_status INTEGER NOT NULL,
+    error           TEXT NOT NULL,
+    next_attempt_at TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (organization_id, webhook_id, created_at);
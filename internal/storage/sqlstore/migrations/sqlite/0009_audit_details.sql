@@ -0,0 +1,5 @@
+-- Audit records carry the fields a write changed and the request that made it
+ALTER TABLE audit_history ADD COLUMN changes TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE audit_history ADD COLUMN request_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_history ADD COLUMN client_ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_history ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Organization-wide audit searches walk the history newest first
+CREATE INDEX audit_history_org_seq_idx ON audit_history (organization_id, seq DESC);
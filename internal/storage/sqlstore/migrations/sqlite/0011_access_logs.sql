@@ -0,0 +1,26 @@
+-- Access validation decisions, written in batches off the request path
+CREATE TABLE access_logs (
+    id               TEXT PRIMARY KEY,
+    organization_id  TEXT NOT NULL,
+    service_key_id   TEXT NOT NULL,
+    application_id   TEXT NOT NULL,
+    application_name TEXT NOT NULL,
+    allowed          BOOLEAN NOT NULL,
+    denial_reason    TEXT NOT NULL,
+    api_key_id       TEXT NOT NULL,
+    client_ip        TEXT NOT NULL,
+    created_at       TIMESTAMP NOT NULL
+);
+
+CREATE INDEX access_logs_service_key_id_idx ON access_logs (organization_id, service_key_id, created_at);
+
+-- Running totals per service key, updated with every batch of decisions
+CREATE TABLE service_key_usage (
+    organization_id TEXT NOT NULL,
+    service_key_id  TEXT NOT NULL,
+    allowed_count   INTEGER NOT NULL,
+    denied_count    INTEGER NOT NULL,
+    last_used_at    TIMESTAMP,
+    last_denied_at  TIMESTAMP,
+    PRIMARY KEY (organization_id, service_key_id)
+);
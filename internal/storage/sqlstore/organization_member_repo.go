@@ -0,0 +1,109 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// OrganizationMemberRepository handles organization membership storage operations
+type OrganizationMemberRepository struct {
+	db      *DB
+	orgRepo *OrganizationRepository // For backward compatibility with MemberIDs array
+}
+
+// NewOrganizationMemberRepository creates a new organization member repository
+func NewOrganizationMemberRepository(db *DB) *OrganizationMemberRepository {
+	return &OrganizationMemberRepository{
+		db:      db,
+		orgRepo: NewOrganizationRepository(db),
+	}
+}
+
+// IsMember checks if a user is a member of an organization
+func (r *OrganizationMemberRepository) IsMember(ctx context.Context, orgID, userID string) (bool, error) {
+	_, isMember, err := r.GetMemberRole(ctx, orgID, userID)
+	return isMember, err
+}
+
+// GetMemberRole returns the role of a user in an organization and whether the user is a member
+// First checks organization_members records, then falls back to the old MemberIDs array for backward compatibility
+func (r *OrganizationMemberRepository) GetMemberRole(ctx context.Context, orgID, userID string) (types.Role, bool, error) {
+	var role string
+	err := r.db.queryRow(ctx, r.db.db, "SELECT role FROM organization_members WHERE id = ? AND organization_id = ?", orgID+"_"+userID, orgID).Scan(&role)
+	if err == nil {
+		return types.Role(role), true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", false, handleSQLError(err)
+	}
+
+	org, err := r.orgRepo.Get(ctx, orgID)
+	if err != nil {
+		// If we can't get the org, return false (not a member)
+		return "", false, nil
+	}
+	if !slices.Contains(org.MemberIDs, userID) {
+		return "", false, nil
+	}
+	// Legacy members were organization creators
+	return types.RoleOwner, true, nil
+}
+
+// AddMember adds a user as a member of an organization
+func (r *OrganizationMemberRepository) AddMember(ctx context.Context, orgID, userID string, role types.Role) error {
+	return handleSQLError(r.insert(ctx, r.db.db, orgID, userID, role))
+}
+
+func (r *OrganizationMemberRepository) insert(ctx context.Context, q querier, orgID, userID string, role types.Role) error {
+	_, err := r.db.exec(ctx, q, "INSERT INTO organization_members (id, organization_id, user_id, role, created_at) VALUES (?, ?, ?, ?, ?)",
+		orgID+"_"+userID, orgID, userID, string(role), time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert organization member: %w", err)
+	}
+	return nil
+}
+
+// CreateOrganizationWithMember creates an organization and adds the creator as its owner atomically
+func (r *OrganizationMemberRepository) CreateOrganizationWithMember(ctx context.Context, org *types.Organization, userID string) error {
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := r.orgRepo.insert(ctx, tx, org); err != nil {
+			return err
+		}
+		return r.insert(ctx, tx, org.ID, userID, types.RoleOwner)
+	})
+}
+
+// RemoveMember removes a user from an organization
+func (r *OrganizationMemberRepository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	return handleSQLError(r.db.execOne(ctx, r.db.db, "DELETE FROM organization_members WHERE id = ? AND organization_id = ?", orgID+"_"+userID, orgID))
+}
+
+// ListMembers lists all members of an organization
+func (r *OrganizationMemberRepository) ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error) {
+	rows, err := r.db.query(ctx, r.db.db, "SELECT id, organization_id, user_id, role, created_at FROM organization_members WHERE organization_id = ? ORDER BY created_at", orgID)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var members []*types.OrganizationMember
+	for rows.Next() {
+		member := &types.OrganizationMember{EntityType: "organization_member"}
+		var role string
+		if err := rows.Scan(&member.ID, &member.OrganizationID, &member.UserID, &role, &member.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization member: %w", err)
+		}
+		member.Role = types.Role(role)
+		member.PartitionKey = member.GetPartitionKey()
+		members = append(members, member)
+	}
+
+	return members, handleSQLError(rows.Err())
+}
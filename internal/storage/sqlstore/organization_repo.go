@@ -0,0 +1,117 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const organizationColumns = "id, name, member_ids, created_at, updated_at"
+
+// OrganizationRepository handles organization storage operations
+type OrganizationRepository struct {
+	db *DB
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *DB) *OrganizationRepository {
+	return &OrganizationRepository{
+		db: db,
+	}
+}
+
+// Create creates a new organization
+func (r *OrganizationRepository) Create(ctx context.Context, org *types.Organization) error {
+	return handleSQLError(r.insert(ctx, r.db.db, org))
+}
+
+func (r *OrganizationRepository) insert(ctx context.Context, q querier, org *types.Organization) error {
+	// Set entity_type and ensure organization_id matches id
+	org.EntityType = "organization"
+	if org.OrganizationID == "" {
+		org.OrganizationID = org.ID
+	}
+	org.PartitionKey = org.GetPartitionKey()
+
+	memberIDs, err := marshalJSON(org.MemberIDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.exec(ctx, q, `INSERT INTO organizations (`+organizationColumns+`) VALUES (?, ?, ?, ?, ?)`,
+		org.ID, org.Name, memberIDs, org.CreatedAt.UTC(), org.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert organization: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes an organization by ID
+func (r *OrganizationRepository) Delete(ctx context.Context, id string) error {
+	return handleSQLError(r.db.execOne(ctx, r.db.db, "DELETE FROM organizations WHERE id = ?", id))
+}
+
+// Get retrieves an organization by ID
+func (r *OrganizationRepository) Get(ctx context.Context, id string) (*types.Organization, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+organizationColumns+` FROM organizations WHERE id = ?`, id)
+	org, err := scanOrganization(row)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return org, nil
+}
+
+// List lists all organizations
+func (r *OrganizationRepository) List(ctx context.Context) ([]*types.Organization, error) {
+	return r.list(ctx, `SELECT `+organizationColumns+` FROM organizations ORDER BY created_at`)
+}
+
+// ListByMemberID lists organizations where the user is a member, either through an
+// organization_members record or the legacy member_ids array
+func (r *OrganizationRepository) ListByMemberID(ctx context.Context, userID string) ([]*types.Organization, error) {
+	return r.list(ctx, `SELECT `+organizationColumns+` FROM organizations
+		WHERE id IN (SELECT organization_id FROM organization_members WHERE user_id = ?)
+		OR `+r.db.dialect.memberIDsContains+`
+		ORDER BY created_at`,
+		userID, userID,
+	)
+}
+
+func (r *OrganizationRepository) list(ctx context.Context, query string, args ...any) ([]*types.Organization, error) {
+	rows, err := r.db.query(ctx, r.db.db, query, args...)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var orgs []*types.Organization
+	for rows.Next() {
+		org, err := scanOrganization(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+
+	return orgs, handleSQLError(rows.Err())
+}
+
+func scanOrganization(row rowScanner) (*types.Organization, error) {
+	org := &types.Organization{EntityType: "organization"}
+	var memberIDs []byte
+	if err := row.Scan(&org.ID, &org.Name, &memberIDs, &org.CreatedAt, &org.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	org.MemberIDs, err = unmarshalJSON[string](memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	org.OrganizationID = org.ID
+	org.PartitionKey = org.GetPartitionKey()
+
+	return org, nil
+}
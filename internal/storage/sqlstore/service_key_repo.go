@@ -0,0 +1,201 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const serviceKeyColumns = `id, organization_id, name, token_value, applications, expires_at,
+	created_by_user_id, created_by_github_id, created_by_username, created_at, updated_at,
+	previous_token_value, previous_token_expires_at`
+
+type ServiceKeyRepository struct {
+	db *DB
+}
+
+func NewServiceKeyRepository(db *DB) *ServiceKeyRepository {
+	return &ServiceKeyRepository{
+		db: db,
+	}
+}
+
+// Create creates a new service key with audit history
+func (r *ServiceKeyRepository) Create(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	token.PartitionKey = token.GetPartitionKey()
+
+	applications, err := marshalJSON(token.Applications)
+	if err != nil {
+		return err
+	}
+
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := r.db.exec(ctx, tx, `INSERT INTO service_keys (`+serviceKeyColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			token.ID, token.OrganizationID, token.Name, token.TokenValue, applications, nullTime(token.ExpiresAt),
+			token.CreatedByUserID, token.CreatedByGitHubID, token.CreatedByUsername, token.CreatedAt.UTC(), token.UpdatedAt.UTC(),
+			token.PreviousTokenValue, nullTime(token.PreviousTokenExpiresAt),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert service key: %w", err)
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "service_keys", storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionCreated, userID, githubID, username, nil))
+	})
+}
+
+// Get retrieves a service key by ID within an organization
+func (r *ServiceKeyRepository) Get(ctx context.Context, organizationID, id string) (*types.ServiceKey, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+serviceKeyColumns+` FROM service_keys WHERE organization_id = ? AND id = ?`, organizationID, id)
+	token, err := scanServiceKey(row)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return token, nil
+}
+
+// FindByTokenValue finds a service key by its hashed value using the token_value indexes.
+// A rotated key is also found by its previous token, which the caller checks against the grace period.
+func (r *ServiceKeyRepository) FindByTokenValue(ctx context.Context, tokenValue string) (*types.ServiceKey, error) {
+	hashed := storage.HashToken(tokenValue)
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+serviceKeyColumns+` FROM service_keys WHERE token_value = ? OR previous_token_value = ?`, hashed, hashed)
+	token, err := scanServiceKey(row)
+	if err != nil {
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
+	}
+	return token, nil
+}
+
+// FindByTokenValueInOrg finds a service key by its hashed value within a specific organization
+func (r *ServiceKeyRepository) FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error) {
+	hashed := storage.HashToken(tokenValue)
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+serviceKeyColumns+` FROM service_keys WHERE (token_value = ? OR previous_token_value = ?) AND organization_id = ?`, hashed, hashed, organizationID)
+	token, err := scanServiceKey(row)
+	if err != nil {
+		return nil, fmt.Errorf("token %w", storage.ErrNotFound)
+	}
+	return token, nil
+}
+
+// CountByOrganization counts service keys for an organization
+func (r *ServiceKeyRepository) CountByOrganization(ctx context.Context, organizationID string) (int, error) {
+	return r.db.count(ctx, "SELECT COUNT(1) FROM service_keys WHERE organization_id = ?", organizationID)
+}
+
+// ListByOrganization lists service keys for an organization
+func (r *ServiceKeyRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.ServiceKey, error) {
+	rows, err := r.db.query(ctx, r.db.db, `SELECT `+serviceKeyColumns+` FROM service_keys WHERE organization_id = ? ORDER BY created_at`, organizationID)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var tokens []*types.ServiceKey
+	for rows.Next() {
+		token, err := scanServiceKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service key: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, handleSQLError(rows.Err())
+}
+
+// Update updates a service key with audit history
+func (r *ServiceKeyRepository) Update(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	return r.update(ctx, token, types.AuditActionUpdated, userID, githubID, username)
+}
+
+// Rotate stores a service key with a new token value with audit history.
+// The new token value is hashed, the previous hash must already be set by the caller.
+func (r *ServiceKeyRepository) Rotate(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	token.TokenValue = storage.HashToken(token.TokenValue)
+	return r.update(ctx, token, types.AuditActionRotated, userID, githubID, username)
+}
+
+func (r *ServiceKeyRepository) update(ctx context.Context, token *types.ServiceKey, action types.AuditAction, userID, githubID, username string) error {
+	token.PartitionKey = token.GetPartitionKey()
+
+	applications, err := marshalJSON(token.Applications)
+	if err != nil {
+		return err
+	}
+
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		// Read the stored version to record what changed
+		row := r.db.queryRow(ctx, tx, `SELECT `+serviceKeyColumns+` FROM service_keys WHERE organization_id = ? AND id = ?`, token.OrganizationID, token.ID)
+		before, err := scanServiceKey(row)
+		if err != nil {
+			return handleSQLError(err)
+		}
+
+		err = r.db.execOne(ctx, tx, `UPDATE service_keys
+			SET name = ?, token_value = ?, applications = ?, expires_at = ?, updated_at = ?,
+				previous_token_value = ?, previous_token_expires_at = ?
+			WHERE organization_id = ? AND id = ?`,
+			token.Name, token.TokenValue, applications, nullTime(token.ExpiresAt), token.UpdatedAt.UTC(),
+			token.PreviousTokenValue, nullTime(token.PreviousTokenExpiresAt),
+			token.OrganizationID, token.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "service_keys", storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, action, userID, githubID, username, storage.DiffServiceKeys(before, token)))
+	})
+}
+
+// Delete deletes a service key with audit history
+func (r *ServiceKeyRepository) Delete(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error {
+	return r.db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := r.db.execOne(ctx, tx, "DELETE FROM service_keys WHERE organization_id = ? AND id = ?", token.OrganizationID, token.ID); err != nil {
+			return err
+		}
+
+		return r.db.insertAuditHistory(ctx, tx, "service_keys", storage.NewAuditHistory(ctx, token.OrganizationID, token.ID, types.AuditActionDeleted, userID, githubID, username, nil))
+	})
+}
+
+// GetHistory retrieves audit history for a service key
+func (r *ServiceKeyRepository) GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	return r.db.getHistory(ctx, "service_keys", organizationID, entityID)
+}
+
+// ListEvents returns the audit history of every service key in an organization after the cursor
+func (r *ServiceKeyRepository) ListEvents(ctx context.Context, organizationID, cursor string, limit int) ([]*storage.AuditEvent, error) {
+	return r.db.listEvents(ctx, "service_keys", organizationID, cursor, limit)
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanServiceKey(row rowScanner) (*types.ServiceKey, error) {
+	token := &types.ServiceKey{EntityType: "service_key"}
+	var applications []byte
+	var expiresAt, previousTokenExpiresAt sql.NullTime
+	err := row.Scan(
+		&token.ID, &token.OrganizationID, &token.Name, &token.TokenValue, &applications, &expiresAt,
+		&token.CreatedByUserID, &token.CreatedByGitHubID, &token.CreatedByUsername, &token.CreatedAt, &token.UpdatedAt,
+		&token.PreviousTokenValue, &previousTokenExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Applications, err = unmarshalJSON[types.ApplicationAccess](applications)
+	if err != nil {
+		return nil, err
+	}
+	token.ExpiresAt = timePtr(expiresAt)
+	token.PreviousTokenExpiresAt = timePtr(previousTokenExpiresAt)
+	token.PartitionKey = token.GetPartitionKey()
+
+	return token, nil
+}
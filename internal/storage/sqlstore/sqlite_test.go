@@ -0,0 +1,28 @@
+package sqlstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// TestSQLite runs the repository suite against a temporary SQLite file.
+// The driver is pure Go, so it needs no build tag and runs with every go test.
+func TestSQLite(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "baluster.db")
+
+	db, err := Open(context.Background(), Config{Dialect: SQLite, DSN: dsn})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	testRepositories(t, db)
+
+	// Reopening applies no migrations twice
+	if err := db.migrate(context.Background()); err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+}
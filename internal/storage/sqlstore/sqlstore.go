@@ -0,0 +1,468 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+// Dialect describes the differences between the SQL databases supported by this package
+type Dialect struct {
+	// Name is the dialect name, also used as the migrations directory
+	Name string
+	// DriverName is the database/sql driver the dialect expects to be registered
+	DriverName string
+	// positional renders the nth (1-based) query parameter placeholder
+	positional func(n int) string
+	// memberIDsContains is a predicate matching organizations whose legacy
+	// member_ids JSON array contains the single query parameter
+	memberIDsContains string
+	// maxOpenConns limits the connection pool, zero means unlimited
+	maxOpenConns int
+}
+
+// Postgres is the dialect for PostgreSQL, using the pgx database/sql driver
+var Postgres = Dialect{
+	Name:       "postgres",
+	DriverName: "pgx",
+	positional: func(n int) string {
+		return "$" + strconv.Itoa(n)
+	},
+	memberIDsContains: "member_ids @> jsonb_build_array(?::text)",
+}
+
+// SQLite is the dialect for an embedded SQLite file, using the pure Go modernc.org/sqlite driver.
+// SQLite allows a single writer, so the pool is limited to one connection to avoid busy errors.
+var SQLite = Dialect{
+	Name:              "sqlite",
+	DriverName:        "sqlite",
+	memberIDsContains: "EXISTS (SELECT 1 FROM json_each(organizations.member_ids) WHERE json_each.value = ?)",
+	maxOpenConns:      1,
+}
+
+// Config holds the SQL database configuration
+type Config struct {
+	Dialect Dialect
+	DSN     string
+}
+
+// DB wraps a database/sql connection pool with its dialect
+type DB struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// Open connects to the database and applies any pending migrations
+func Open(ctx context.Context, cfg Config) (*DB, error) {
+	sqlDB, err := sql.Open(cfg.Dialect.DriverName, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", cfg.Dialect.Name, err)
+	}
+
+	if cfg.Dialect.maxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.Dialect.maxOpenConns)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("failed to connect to %s database: %w", cfg.Dialect.Name, err)
+	}
+
+	db := &DB{
+		db:      sqlDB,
+		dialect: cfg.Dialect,
+	}
+
+	if err := db.migrate(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Close closes the underlying connection pool
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// NewRepositories creates every SQL backed repository
+func NewRepositories(db *DB) *storage.Repositories {
+	return &storage.Repositories{
+		Organizations:       NewOrganizationRepository(db),
+		OrganizationMembers: NewOrganizationMemberRepository(db),
+		Invitations:         NewInvitationRepository(db),
+		Applications:        NewApplicationRepository(db),
+		ServiceKeys:         NewServiceKeyRepository(db),
+		ApiKeys:             NewApiKeyRepository(db),
+		Users:               NewUserRepository(db),
+		Webhooks:            NewWebhookRepository(db),
+		Audit:               NewAuditRepository(db),
+		AccessLogs:          NewAccessLogRepository(db),
+	}
+}
+
+// migrate applies every migration for the dialect that has not been recorded in schema_migrations.
+// Each migration runs in its own transaction together with its bookkeeping row.
+func (d *DB) migrate(ctx context.Context) error {
+	if _, err := d.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY)"); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	dir := path.Join("migrations", d.dialect.Name)
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return fmt.Errorf("no migrations for dialect %s: %w", d.dialect.Name, err)
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".sql") {
+			versions = append(versions, entry.Name())
+		}
+	}
+	sort.Strings(versions)
+
+	for _, version := range versions {
+		var applied int
+		if err := d.queryRow(ctx, d.db, "SELECT COUNT(1) FROM schema_migrations WHERE version = ?", version).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := fs.ReadFile(migrations, path.Join(dir, version))
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", version, err)
+		}
+
+		err = d.withTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err := d.exec(ctx, tx, "INSERT INTO schema_migrations (version) VALUES (?)", version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// rebind rewrites ? placeholders into the dialect's positional form
+func (d *DB) rebind(query string) string {
+	if d.dialect.positional == nil {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.dialect.positional(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (d *DB) exec(ctx context.Context, q querier, query string, args ...any) (sql.Result, error) {
+	return q.ExecContext(ctx, d.rebind(query), args...)
+}
+
+func (d *DB) query(ctx context.Context, q querier, query string, args ...any) (*sql.Rows, error) {
+	return q.QueryContext(ctx, d.rebind(query), args...)
+}
+
+func (d *DB) queryRow(ctx context.Context, q querier, query string, args ...any) *sql.Row {
+	return q.QueryRowContext(ctx, d.rebind(query), args...)
+}
+
+// withTx runs fn inside a transaction, committing on success and rolling back on error.
+// This replaces the Cosmos DB transactional batch used for entity + audit history writes.
+func (d *DB) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return handleSQLError(err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return handleSQLError(err)
+	}
+
+	return handleSQLError(tx.Commit())
+}
+
+// execOne executes a write that must affect exactly one row
+func (d *DB) execOne(ctx context.Context, q querier, query string, args ...any) error {
+	result, err := d.exec(ctx, q, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// handleSQLError converts database errors to the errors returned by every storage backend
+func handleSQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, storage.ErrNotFound) {
+		return storage.ErrNotFound
+	}
+	return fmt.Errorf("database error: %w", err)
+}
+
+// auditHistoryColumns are the audit_history columns scanned by scanAuditHistory
+const auditHistoryColumns = `id, organization_id, entity_id, action, created_by_user_id, created_by_github_id, created_by_username, created_at,
+	changes, request_id, client_ip, user_agent, chain_sequence, previous_hash, hash`
+
+// insertAuditHistory writes an audit history record for an entity stored in entityTable,
+// at the end of its organization's audit chain for entityTable
+func (d *DB) insertAuditHistory(ctx context.Context, q querier, entityTable string, audit *types.AuditHistory) error {
+	head, err := d.lockChainHead(ctx, q, entityTable, audit)
+	if err != nil {
+		return err
+	}
+	if err := storage.ChainAudit(head, audit); err != nil {
+		return err
+	}
+
+	changes, err := marshalJSON(audit.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.exec(ctx, q, `INSERT INTO audit_history
+		(id, organization_id, entity_table, entity_id, action, created_by_user_id, created_by_github_id, created_by_username, created_at,
+			changes, request_id, client_ip, user_agent, chain_sequence, previous_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		audit.ID, audit.OrganizationID, entityTable, audit.EntityID, string(audit.Action),
+		audit.CreatedByUserID, audit.CreatedByGitHubID, audit.CreatedByUsername, audit.CreatedAt.UTC(),
+		changes, audit.RequestID, audit.ClientIP, audit.UserAgent, audit.Sequence, audit.PreviousHash, audit.Hash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit history: %w", err)
+	}
+
+	_, err = d.exec(ctx, q, `UPDATE audit_chain_heads SET last_sequence = ?, last_hash = ?, updated_at = ?
+		WHERE organization_id = ? AND entity_table = ?`,
+		head.Sequence, head.Hash, head.UpdatedAt.UTC(), audit.OrganizationID, entityTable,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update audit chain head: %w", err)
+	}
+	return nil
+}
+
+// lockChainHead reads the head of an organization's audit chain for entityTable, creating it when the chain is empty.
+// Writing the head row first locks it until the transaction ends, so concurrent writers extend the chain one at a time.
+func (d *DB) lockChainHead(ctx context.Context, q querier, entityTable string, audit *types.AuditHistory) (*types.AuditChainHead, error) {
+	_, err := d.exec(ctx, q, `INSERT INTO audit_chain_heads (organization_id, entity_table, last_sequence, last_hash, updated_at)
+		VALUES (?, ?, 0, '', ?)
+		ON CONFLICT (organization_id, entity_table) DO NOTHING`,
+		audit.OrganizationID, entityTable, audit.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit chain head: %w", err)
+	}
+
+	_, err = d.exec(ctx, q, `UPDATE audit_chain_heads SET updated_at = ? WHERE organization_id = ? AND entity_table = ?`,
+		audit.CreatedAt.UTC(), audit.OrganizationID, entityTable,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock audit chain head: %w", err)
+	}
+
+	return d.getChainHead(ctx, q, entityTable, audit.OrganizationID)
+}
+
+// getChainHead returns the head of an organization's audit chain for entityTable
+func (d *DB) getChainHead(ctx context.Context, q querier, entityTable, organizationID string) (*types.AuditChainHead, error) {
+	head := storage.NewAuditChainHead(organizationID)
+	err := d.queryRow(ctx, q, `SELECT last_sequence, last_hash, updated_at FROM audit_chain_heads WHERE organization_id = ? AND entity_table = ?`,
+		organizationID, entityTable,
+	).Scan(&head.Sequence, &head.Hash, &head.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return head, nil
+	}
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return head, nil
+}
+
+// getHistory returns the audit history of an entity, newest first
+func (d *DB) getHistory(ctx context.Context, entityTable, organizationID, entityID string) ([]*types.AuditHistory, error) {
+	rows, err := d.query(ctx, d.db, `SELECT `+auditHistoryColumns+`
+		FROM audit_history
+		WHERE organization_id = ? AND entity_table = ? AND entity_id = ?
+		ORDER BY created_at DESC`,
+		organizationID, entityTable, entityID,
+	)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var history []*types.AuditHistory
+	for rows.Next() {
+		audit, err := scanAuditHistory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit history: %w", err)
+		}
+		history = append(history, audit)
+	}
+
+	return history, handleSQLError(rows.Err())
+}
+
+// listEvents returns the audit history of every entity in entityTable for an organization, oldest first.
+// The cursor is the seq of the last record already seen.
+func (d *DB) listEvents(ctx context.Context, entityTable, organizationID, cursor string, limit int) ([]*storage.AuditEvent, error) {
+	var after int64
+	if cursor != "" {
+		var err error
+		if after, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrInvalidCursor, err)
+		}
+	}
+
+	rows, err := d.query(ctx, d.db, `SELECT `+auditHistoryColumns+`, seq
+		FROM audit_history
+		WHERE organization_id = ? AND entity_table = ? AND seq > ?
+		ORDER BY seq
+		LIMIT ?`,
+		organizationID, entityTable, after, limit,
+	)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var events []*storage.AuditEvent
+	for rows.Next() {
+		var seq int64
+		audit, err := scanAuditHistory(rows, &seq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit history: %w", err)
+		}
+		events = append(events, &storage.AuditEvent{
+			AuditHistory: audit,
+			Cursor:       strconv.FormatInt(seq, 10),
+		})
+	}
+
+	return events, handleSQLError(rows.Err())
+}
+
+// scanAuditHistory scans the auditHistoryColumns of a row, followed by any extra columns
+func scanAuditHistory(row rowScanner, extra ...any) (*types.AuditHistory, error) {
+	audit := &types.AuditHistory{EntityType: "audit_history"}
+	var action string
+	var changes []byte
+	dest := []any{
+		&audit.ID, &audit.OrganizationID, &audit.EntityID, &action, &audit.CreatedByUserID, &audit.CreatedByGitHubID, &audit.CreatedByUsername, &audit.CreatedAt,
+		&changes, &audit.RequestID, &audit.ClientIP, &audit.UserAgent, &audit.Sequence, &audit.PreviousHash, &audit.Hash,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	var err error
+	audit.Changes, err = unmarshalJSON[types.AuditChange](changes)
+	if err != nil {
+		return nil, err
+	}
+	audit.Action = types.AuditAction(action)
+	audit.PartitionKey = audit.GetPartitionKey()
+
+	return audit, nil
+}
+
+// count runs a COUNT query and returns its result
+func (d *DB) count(ctx context.Context, query string, args ...any) (int, error) {
+	var n int
+	if err := d.queryRow(ctx, d.db, query, args...).Scan(&n); err != nil {
+		return 0, handleSQLError(err)
+	}
+	return n, nil
+}
+
+// marshalJSON encodes a value for a JSON column, storing nil slices as empty arrays
+func marshalJSON[T any](v []T) (string, error) {
+	if v == nil {
+		v = []T{}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON column: %w", err)
+	}
+	return string(b), nil
+}
+
+// unmarshalJSON decodes a JSON column
+func unmarshalJSON[T any](data []byte) ([]T, error) {
+	var v []T
+	if len(data) == 0 {
+		return v, nil
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON column: %w", err)
+	}
+	return v, nil
+}
+
+// nullTime converts an optional time for a nullable timestamp column
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// timePtr converts a nullable timestamp column back to an optional time
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
@@ -0,0 +1,403 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+func TestRebind(t *testing.T) {
+	db := &DB{dialect: Postgres}
+
+	got := db.rebind("SELECT * FROM service_keys WHERE organization_id = ? AND token_value = ?")
+	want := "SELECT * FROM service_keys WHERE organization_id = $1 AND token_value = $2"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRebindSQLite(t *testing.T) {
+	db := &DB{dialect: SQLite}
+
+	query := "SELECT * FROM service_keys WHERE organization_id = ? AND token_value = ?"
+	if got := db.rebind(query); got != query {
+		t.Errorf("expected SQLite placeholders to be left unchanged, got %q", got)
+	}
+}
+
+func TestMigrationsEmbedded(t *testing.T) {
+	postgresMigrations, err := fs.ReadDir(migrations, "migrations/"+Postgres.Name)
+	if err != nil {
+		t.Fatalf("read migrations: %v", err)
+	}
+
+	// Every dialect must carry the same set of schema versions
+	for _, dialect := range []Dialect{SQLite} {
+		entries, err := fs.ReadDir(migrations, "migrations/"+dialect.Name)
+		if err != nil {
+			t.Fatalf("read migrations: %v", err)
+		}
+		if len(entries) != len(postgresMigrations) {
+			t.Errorf("expected %d migrations for %s, got %d", len(postgresMigrations), dialect.Name, len(entries))
+		}
+	}
+}
+
+// testRepositories exercises every repository against a migrated database.
+// TestSQLite runs it on every go test, TestPostgres needs the postgres tag and a database.
+func testRepositories(t *testing.T, db *DB) {
+	t.Helper()
+	suffix := storage.GenerateID()
+	ctx := storage.WithRequestMetadata(context.Background(), storage.RequestMetadata{RequestID: "req-" + suffix, ClientIP: "203.0.113.7"})
+	repos := NewRepositories(db)
+	orgID := "org-" + suffix
+	userID := "user-" + suffix
+
+	org := &types.Organization{ID: orgID, Name: "Test Org", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := repos.OrganizationMembers.CreateOrganizationWithMember(ctx, org, userID); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	isMember, err := repos.OrganizationMembers.IsMember(ctx, orgID, userID)
+	if err != nil || !isMember {
+		t.Fatalf("expected creator to be a member, got %v (err %v)", isMember, err)
+	}
+	if role, _, err := repos.OrganizationMembers.GetMemberRole(ctx, orgID, userID); err != nil || role != types.RoleOwner {
+		t.Errorf("expected creator to be an owner, got %q (err %v)", role, err)
+	}
+	if err := repos.OrganizationMembers.AddMember(ctx, orgID, "viewer-"+suffix, types.RoleViewer); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if role, _, err := repos.OrganizationMembers.GetMemberRole(ctx, orgID, "viewer-"+suffix); err != nil || role != types.RoleViewer {
+		t.Errorf("expected viewer role to round-trip, got %q (err %v)", role, err)
+	}
+	orgs, err := repos.Organizations.ListByMemberID(ctx, userID)
+	if err != nil || len(orgs) != 1 {
+		t.Fatalf("expected one organization for member, got %d (err %v)", len(orgs), err)
+	}
+
+	// A duplicate organization must roll back the member insert as well
+	if err := repos.OrganizationMembers.CreateOrganizationWithMember(ctx, org, "other-"+suffix); err == nil {
+		t.Fatalf("expected duplicate organization to fail")
+	}
+	if isMember, _ := repos.OrganizationMembers.IsMember(ctx, orgID, "other-"+suffix); isMember {
+		t.Errorf("expected failed transaction not to add a member")
+	}
+
+	invitation := &types.Invitation{
+		ID:             "inv-" + suffix,
+		OrganizationID: orgID,
+		GitHubUsername: "Invitee-" + suffix,
+		Role:           types.RoleDeveloper,
+		Status:         types.InvitationStatusPending,
+		ExpiresAt:      time.Now().Add(time.Hour),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := repos.Invitations.Create(ctx, invitation); err != nil {
+		t.Fatalf("create invitation: %v", err)
+	}
+	invitations, err := repos.Invitations.ListByUsername(ctx, "invitee-"+suffix)
+	if err != nil || len(invitations) != 1 {
+		t.Fatalf("expected case-insensitive username lookup, got %d (err %v)", len(invitations), err)
+	}
+	invitation.Status = types.InvitationStatusAccepted
+	if err := repos.Invitations.Accept(ctx, invitation, "invitee-"+suffix); err != nil {
+		t.Fatalf("accept invitation: %v", err)
+	}
+	if role, _, err := repos.OrganizationMembers.GetMemberRole(ctx, orgID, "invitee-"+suffix); err != nil || role != types.RoleDeveloper {
+		t.Errorf("expected invitee to join as developer, got %q (err %v)", role, err)
+	}
+
+	key := &types.ServiceKey{
+		ID:             "sk-" + suffix,
+		OrganizationID: orgID,
+		Name:           "Test Service Key",
+		TokenValue:     "secret-" + suffix,
+		Applications: []types.ApplicationAccess{
+			{ApplicationID: "app-1", ApplicationName: "test_app", Permissions: []string{"read"}},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := repos.ServiceKeys.Create(ctx, key, userID, "github-123", "testuser"); err != nil {
+		t.Fatalf("create service key: %v", err)
+	}
+
+	found, err := repos.ServiceKeys.FindByTokenValueInOrg(ctx, orgID, "secret-"+suffix)
+	if err != nil {
+		t.Fatalf("find service key: %v", err)
+	}
+	if !found.HasPermission("test_app", "read") {
+		t.Errorf("expected permissions to round-trip, got %+v", found.Applications)
+	}
+	if _, err := repos.ServiceKeys.FindByTokenValueInOrg(ctx, "other-org", "secret-"+suffix); err == nil {
+		t.Errorf("expected token not to be found in another organization")
+	}
+
+	key.Name = "Renamed"
+	key.UpdatedAt = time.Now()
+	if err := repos.ServiceKeys.Update(ctx, key, userID, "github-123", "testuser"); err != nil {
+		t.Fatalf("update service key: %v", err)
+	}
+
+	graceEndsAt := time.Now().Add(time.Hour)
+	key.PreviousTokenValue = key.TokenValue
+	key.PreviousTokenExpiresAt = &graceEndsAt
+	key.TokenValue = "rotated-" + suffix
+	if err := repos.ServiceKeys.Rotate(ctx, key, userID, "github-123", "testuser"); err != nil {
+		t.Fatalf("rotate service key: %v", err)
+	}
+	found, err = repos.ServiceKeys.FindByTokenValueInOrg(ctx, orgID, "secret-"+suffix)
+	if err != nil {
+		t.Fatalf("find service key by previous token: %v", err)
+	}
+	if found.PreviousTokenExpiresAt == nil || !found.AcceptsToken(storage.HashToken("secret-"+suffix)) {
+		t.Errorf("expected previous token to round-trip with its grace period, got %+v", found)
+	}
+	if _, err := repos.ServiceKeys.FindByTokenValue(ctx, "rotated-"+suffix); err != nil {
+		t.Errorf("expected new token to be found: %v", err)
+	}
+	if err := repos.ServiceKeys.Delete(ctx, key, userID, "github-123", "testuser"); err != nil {
+		t.Fatalf("delete service key: %v", err)
+	}
+	if _, err := repos.ServiceKeys.Get(ctx, orgID, key.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+
+	history, err := repos.ServiceKeys.GetHistory(ctx, orgID, key.ID)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 4 || history[0].Action != types.AuditActionDeleted || history[1].Action != types.AuditActionRotated {
+		t.Fatalf("expected created, updated, rotated and deleted audit records newest first, got %+v", history)
+	}
+	if changes := history[2].Changes; len(changes) != 1 || changes[0].Field != "name" || changes[0].After != "Renamed" {
+		t.Errorf("expected the update to record the name change, got %+v", changes)
+	}
+	if history[2].RequestID != "req-"+suffix || history[2].ClientIP != "203.0.113.7" {
+		t.Errorf("expected the request metadata to round-trip, got %+v", history[2])
+	}
+
+	// Events come oldest first and resume after a cursor
+	events, err := repos.ServiceKeys.ListEvents(ctx, orgID, "", 2)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 || events[0].Action != types.AuditActionCreated || events[1].Action != types.AuditActionUpdated {
+		t.Fatalf("expected the created and updated events first, got %+v", events)
+	}
+	events, err = repos.ServiceKeys.ListEvents(ctx, orgID, events[1].Cursor, 10)
+	if err != nil {
+		t.Fatalf("list events after cursor: %v", err)
+	}
+	if len(events) != 2 || events[0].Action != types.AuditActionRotated || events[1].Action != types.AuditActionDeleted {
+		t.Errorf("expected the rotated and deleted events after the cursor, got %+v", events)
+	}
+	if _, err := repos.ServiceKeys.ListEvents(ctx, orgID, "not-a-cursor", 10); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+
+	// The organization's audit log pages newest first and filters in the query
+	auditEvents, err := repos.Audit.Search(ctx, orgID, storage.AuditFilter{}, "", 3)
+	if err != nil {
+		t.Fatalf("search audit log: %v", err)
+	}
+	if len(auditEvents) != 3 || auditEvents[0].Action != types.AuditActionDeleted || auditEvents[0].ResourceType != types.AuditResourceServiceKey {
+		t.Fatalf("expected the newest 3 audit events, got %+v", auditEvents)
+	}
+	auditEvents, err = repos.Audit.Search(ctx, orgID, storage.AuditFilter{}, auditEvents[2].Cursor, 10)
+	if err != nil || len(auditEvents) != 1 || auditEvents[0].Action != types.AuditActionCreated {
+		t.Errorf("expected the created event after the cursor, got %+v (err %v)", auditEvents, err)
+	}
+	auditEvents, err = repos.Audit.Search(ctx, orgID, storage.AuditFilter{
+		ActorUsername: "TestUser",
+		ResourceTypes: []types.AuditResourceType{types.AuditResourceServiceKey},
+		Actions:       []types.AuditAction{types.AuditActionUpdated, types.AuditActionRotated},
+		From:          time.Now().Add(-time.Hour),
+	}, "", 10)
+	if err != nil || len(auditEvents) != 2 || auditEvents[0].Action != types.AuditActionRotated {
+		t.Errorf("expected the rotated and updated events, got %+v (err %v)", auditEvents, err)
+	}
+	if _, err := repos.Audit.Search(ctx, orgID, storage.AuditFilter{}, "not-a-cursor", 10); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+
+	// The service key's records form a hash chain that still verifies once read back
+	chain, err := repos.Audit.ListChain(ctx, orgID, types.AuditResourceServiceKey, 0, 10)
+	if err != nil {
+		t.Fatalf("list audit chain: %v", err)
+	}
+	if len(chain) != 4 {
+		t.Fatalf("expected 4 chained records, got %d", len(chain))
+	}
+	for i, audit := range chain {
+		hash, err := storage.HashAudit(audit)
+		if err != nil {
+			t.Fatalf("hash audit: %v", err)
+		}
+		if audit.Sequence != int64(i+1) || hash != audit.Hash || i > 0 && audit.PreviousHash != chain[i-1].Hash {
+			t.Errorf("expected record %d to verify and link to the one before it, got %+v", i+1, audit)
+		}
+	}
+	head, err := repos.Audit.GetChainHead(ctx, orgID, types.AuditResourceServiceKey)
+	if err != nil || head.Sequence != 4 || head.Hash != chain[3].Hash {
+		t.Errorf("expected the head to point at the last record, got %+v (err %v)", head, err)
+	}
+	if head, err := repos.Audit.GetChainHead(ctx, orgID, types.AuditResourceApiKey); err != nil || head.Sequence != 0 {
+		t.Errorf("expected an empty API key chain, got %+v (err %v)", head, err)
+	}
+
+	// Usage adds up across batches, records that matched no key are kept out of it
+	usedAt := time.Now().Add(-time.Minute)
+	for _, batch := range [][]*types.AccessLog{
+		{
+			{ID: "al-1-" + suffix, OrganizationID: orgID, ServiceKeyID: key.ID, ApplicationID: "app-1", Allowed: true, ApiKeyID: "ak-1", ClientIP: "203.0.113.7", CreatedAt: usedAt},
+			{ID: "al-2-" + suffix, OrganizationID: orgID, ServiceKeyID: key.ID, DenialReason: "expired", CreatedAt: usedAt.Add(time.Second)},
+		},
+		{
+			{ID: "al-3-" + suffix, OrganizationID: orgID, ServiceKeyID: key.ID, Allowed: true, CreatedAt: usedAt.Add(2 * time.Second)},
+			{ID: "al-4-" + suffix, OrganizationID: orgID, DenialReason: "invalid_token", CreatedAt: usedAt},
+		},
+	} {
+		if err := repos.AccessLogs.Record(ctx, batch); err != nil {
+			t.Fatalf("record access log: %v", err)
+		}
+	}
+	usage, err := repos.AccessLogs.GetUsage(ctx, orgID, key.ID)
+	if err != nil || usage.AllowedCount != 2 || usage.DeniedCount != 1 || usage.LastUsedAt == nil || !usage.LastUsedAt.After(usedAt) {
+		t.Errorf("expected the usage to add up, got %+v (err %v)", usage, err)
+	}
+	if usage, err := repos.AccessLogs.GetUsage(ctx, orgID, "unused"); err != nil || usage.AllowedCount != 0 || usage.LastUsedAt != nil {
+		t.Errorf("expected an empty usage for an unused key, got %+v (err %v)", usage, err)
+	}
+	accessLog, err := repos.AccessLogs.ListByServiceKey(ctx, orgID, key.ID, usedAt.Add(2*time.Second), 10)
+	if err != nil || len(accessLog) != 2 || accessLog[0].ID != "al-2-"+suffix || accessLog[1].ClientIP != "203.0.113.7" {
+		t.Errorf("expected the two earlier decisions newest first, got %+v (err %v)", accessLog, err)
+	}
+
+	webhook := &types.Webhook{
+		ID:             "wh-" + suffix,
+		OrganizationID: orgID,
+		URL:            "https://example.com/hooks",
+		Events:         []types.WebhookEvent{types.WebhookEventServiceKeyCreated},
+		Active:         true,
+		Secret:         "whsec-" + suffix,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := repos.Webhooks.Create(ctx, webhook); err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	webhook.Active = false
+	if err := repos.Webhooks.Update(ctx, webhook); err != nil {
+		t.Fatalf("update webhook: %v", err)
+	}
+	gotWebhook, err := repos.Webhooks.Get(ctx, orgID, webhook.ID)
+	if err != nil || gotWebhook.Active || gotWebhook.Secret != webhook.Secret || len(gotWebhook.Events) != 1 {
+		t.Errorf("expected the webhook to round-trip, got %+v (err %v)", gotWebhook, err)
+	}
+	for i, status := range []types.WebhookDeliveryStatus{types.WebhookDeliveryStatusFailed, types.WebhookDeliveryStatusPending} {
+		delivery := &types.WebhookDelivery{
+			ID:             fmt.Sprintf("whd-%d-%s", i, suffix),
+			OrganizationID: orgID,
+			WebhookID:      webhook.ID,
+			Event:          types.WebhookEventServiceKeyCreated,
+			Payload:        `{}`,
+			Status:         status,
+			CreatedAt:      time.Now().Add(time.Duration(i) * time.Second),
+			UpdatedAt:      time.Now(),
+		}
+		if err := repos.Webhooks.CreateDelivery(ctx, delivery); err != nil {
+			t.Fatalf("create webhook delivery: %v", err)
+		}
+	}
+	deliveries, err := repos.Webhooks.ListDeliveries(ctx, orgID, webhook.ID, 1)
+	if err != nil || len(deliveries) != 1 || deliveries[0].Status != types.WebhookDeliveryStatusPending {
+		t.Errorf("expected the newest delivery, got %+v (err %v)", deliveries, err)
+	}
+	pending, err := repos.Webhooks.ListPendingDeliveries(ctx, orgID, 10)
+	if err != nil || len(pending) != 1 || pending[0].ID != "whd-1-"+suffix {
+		t.Errorf("expected only the pending delivery, got %+v (err %v)", pending, err)
+	}
+	// Of two dispatchers claiming the same attempt only the first one gets it
+	claim, err := repos.Webhooks.GetDelivery(ctx, orgID, "whd-1-"+suffix)
+	if err != nil {
+		t.Fatalf("get webhook delivery: %v", err)
+	}
+	claim.Attempts++
+	claim.UpdatedAt = time.Now()
+	if claimed, err := repos.Webhooks.ClaimDelivery(ctx, claim, 0); err != nil || !claimed {
+		t.Errorf("expected the first claim to succeed, got %v (err %v)", claimed, err)
+	}
+	if claimed, err := repos.Webhooks.ClaimDelivery(ctx, claim, 0); err != nil || claimed {
+		t.Errorf("expected the second claim to fail, got %v (err %v)", claimed, err)
+	}
+	if claimed, err := repos.Webhooks.ClaimDelivery(ctx, &types.WebhookDelivery{ID: "whd-0-" + suffix, OrganizationID: orgID}, 0); err != nil || claimed {
+		t.Errorf("expected a finished delivery not to be claimed, got %v (err %v)", claimed, err)
+	}
+	if err := repos.Webhooks.Delete(ctx, orgID, webhook.ID); err != nil {
+		t.Fatalf("delete webhook: %v", err)
+	}
+	if _, err := repos.Webhooks.GetDelivery(ctx, orgID, "whd-0-"+suffix); err != nil {
+		t.Errorf("expected deliveries to be kept after the webhook is deleted: %v", err)
+	}
+
+	// Concurrent writers each lock the chain head, so the chain stays a single line
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			apiKey := &types.ApiKey{
+				ID:             fmt.Sprintf("ak-%d-%s", i, suffix),
+				OrganizationID: orgID,
+				ApplicationID:  "app-1",
+				Name:           fmt.Sprintf("Concurrent %d", i),
+				TokenValue:     fmt.Sprintf("ak-secret-%d-%s", i, suffix),
+				CreatedAt:      time.Now(),
+				UpdatedAt:      time.Now(),
+			}
+			errs <- repos.ApiKeys.Create(ctx, apiKey, userID, "github-123", "testuser")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("create API key: %v", err)
+		}
+	}
+	chain, err = repos.Audit.ListChain(ctx, orgID, types.AuditResourceApiKey, 0, 10)
+	if err != nil {
+		t.Fatalf("list audit chain: %v", err)
+	}
+	if len(chain) != 5 {
+		t.Fatalf("expected 5 chained records, got %d", len(chain))
+	}
+	for i, audit := range chain {
+		if audit.Sequence != int64(i+1) || i > 0 && audit.PreviousHash != chain[i-1].Hash {
+			t.Errorf("expected concurrent record %d to link to the one before it, got %+v", i+1, audit)
+		}
+	}
+
+	user := &types.User{ID: userID, GitHubID: "gh-" + suffix, Username: "testuser", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := repos.Users.CreateOrUpdate(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	user.Username = "renamed"
+	if err := repos.Users.CreateOrUpdate(ctx, user); err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+	got, err := repos.Users.GetByGitHubID(ctx, user.GitHubID)
+	if err != nil || got.Username != "renamed" {
+		t.Errorf("expected upserted user, got %+v (err %v)", got, err)
+	}
+}
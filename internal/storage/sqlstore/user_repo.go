@@ -0,0 +1,76 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const userColumns = "id, github_id, username, avatar_url, created_at, updated_at"
+
+// UserRepository handles user storage operations
+type UserRepository struct {
+	db *DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+// CreateOrUpdate creates or updates a user
+func (r *UserRepository) CreateOrUpdate(ctx context.Context, user *types.User) error {
+	if user.ID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+	if user.GitHubID == "" {
+		return fmt.Errorf("user GitHubID is required")
+	}
+
+	user.PartitionKey = user.GetPartitionKey()
+
+	_, err := r.db.exec(ctx, r.db.db, `INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			github_id = excluded.github_id,
+			username = excluded.username,
+			avatar_url = excluded.avatar_url,
+			updated_at = excluded.updated_at`,
+		user.ID, user.GitHubID, user.Username, user.AvatarURL, user.CreatedAt.UTC(), user.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert user (id: %s, github_id: %s): %w", user.ID, user.GitHubID, handleSQLError(err))
+	}
+	return nil
+}
+
+// GetByGitHubID retrieves a user by GitHub ID
+func (r *UserRepository) GetByGitHubID(ctx context.Context, githubID string) (*types.User, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+userColumns+` FROM users WHERE github_id = ?`, githubID)
+	user, err := scanUser(row)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user, nil
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id string, githubID string) (*types.User, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+userColumns+` FROM users WHERE id = ? AND github_id = ?`, id, githubID)
+	user, err := scanUser(row)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return user, nil
+}
+
+func scanUser(row rowScanner) (*types.User, error) {
+	user := &types.User{}
+	if err := row.Scan(&user.ID, &user.GitHubID, &user.Username, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		return nil, err
+	}
+	user.PartitionKey = user.GetPartitionKey()
+	return user, nil
+}
@@ -0,0 +1,249 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+const webhookColumns = `id, organization_id, url, description, events, active, secret,
+	created_by_user_id, created_by_github_id, created_by_username, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, organization_id, webhook_id, event, payload, status,
+	attempts, response_status, error, next_attempt_at, created_at, updated_at`
+
+// WebhookRepository handles webhook and webhook delivery storage operations
+type WebhookRepository struct {
+	db *DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// Create creates a new webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *types.Webhook) error {
+	webhook.EntityType = "webhook"
+	webhook.PartitionKey = webhook.GetPartitionKey()
+
+	events, err := marshalJSON(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.exec(ctx, r.db.db, `INSERT INTO webhooks (`+webhookColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		webhook.ID, webhook.OrganizationID, webhook.URL, webhook.Description, events, webhook.Active, webhook.Secret,
+		webhook.CreatedByUserID, webhook.CreatedByGitHubID, webhook.CreatedByUsername, webhook.CreatedAt.UTC(), webhook.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook: %w", handleSQLError(err))
+	}
+	return nil
+}
+
+// Get retrieves a webhook by ID within an organization
+func (r *WebhookRepository) Get(ctx context.Context, organizationID, id string) (*types.Webhook, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+webhookColumns+` FROM webhooks WHERE organization_id = ? AND id = ?`, organizationID, id)
+	webhook, err := scanWebhook(row)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return webhook, nil
+}
+
+// ListByOrganization lists webhooks for an organization
+func (r *WebhookRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*types.Webhook, error) {
+	rows, err := r.db.query(ctx, r.db.db, `SELECT `+webhookColumns+` FROM webhooks WHERE organization_id = ? ORDER BY created_at`, organizationID)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var webhooks []*types.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, handleSQLError(rows.Err())
+}
+
+// Update updates a webhook
+func (r *WebhookRepository) Update(ctx context.Context, webhook *types.Webhook) error {
+	webhook.PartitionKey = webhook.GetPartitionKey()
+
+	events, err := marshalJSON(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	return handleSQLError(r.db.execOne(ctx, r.db.db, `UPDATE webhooks
+		SET url = ?, description = ?, events = ?, active = ?, secret = ?, updated_at = ?
+		WHERE organization_id = ? AND id = ?`,
+		webhook.URL, webhook.Description, events, webhook.Active, webhook.Secret, webhook.UpdatedAt.UTC(),
+		webhook.OrganizationID, webhook.ID,
+	))
+}
+
+// Delete deletes a webhook, its deliveries are kept
+func (r *WebhookRepository) Delete(ctx context.Context, organizationID, id string) error {
+	return handleSQLError(r.db.execOne(ctx, r.db.db, `DELETE FROM webhooks WHERE organization_id = ? AND id = ?`, organizationID, id))
+}
+
+// CreateDelivery records a new delivery
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	delivery.EntityType = "webhook_delivery"
+	delivery.PartitionKey = delivery.GetPartitionKey()
+
+	_, err := r.db.exec(ctx, r.db.db, `INSERT INTO webhook_deliveries (`+webhookDeliveryColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		delivery.ID, delivery.OrganizationID, delivery.WebhookID, string(delivery.Event), delivery.Payload, string(delivery.Status),
+		delivery.Attempts, delivery.ResponseStatus, delivery.Error, nullTime(delivery.NextAttemptAt), delivery.CreatedAt.UTC(), delivery.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook delivery: %w", handleSQLError(err))
+	}
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID within an organization
+func (r *WebhookRepository) GetDelivery(ctx context.Context, organizationID, id string) (*types.WebhookDelivery, error) {
+	row := r.db.queryRow(ctx, r.db.db, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE organization_id = ? AND id = ?`, organizationID, id)
+	delivery, err := scanWebhookDelivery(row)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	return delivery, nil
+}
+
+// UpdateDelivery records the outcome of another delivery attempt
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	delivery.PartitionKey = delivery.GetPartitionKey()
+
+	return handleSQLError(r.db.execOne(ctx, r.db.db, `UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_status = ?, error = ?, next_attempt_at = ?, updated_at = ?
+		WHERE organization_id = ? AND id = ?`,
+		string(delivery.Status), delivery.Attempts, delivery.ResponseStatus, delivery.Error, nullTime(delivery.NextAttemptAt), delivery.UpdatedAt.UTC(),
+		delivery.OrganizationID, delivery.ID,
+	))
+}
+
+// ClaimDelivery stores delivery unless the stored delivery finished or made another attempt since it was read.
+// The condition is part of the update, so only one of several dispatchers claiming at once succeeds.
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, delivery *types.WebhookDelivery, previousAttempts int) (bool, error) {
+	delivery.PartitionKey = delivery.GetPartitionKey()
+
+	result, err := r.db.exec(ctx, r.db.db, `UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_status = ?, error = ?, next_attempt_at = ?, updated_at = ?
+		WHERE organization_id = ? AND id = ? AND status = ? AND attempts = ?`,
+		string(delivery.Status), delivery.Attempts, delivery.ResponseStatus, delivery.Error, nullTime(delivery.NextAttemptAt), delivery.UpdatedAt.UTC(),
+		delivery.OrganizationID, delivery.ID, string(types.WebhookDeliveryStatusPending), previousAttempts,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery: %w", handleSQLError(err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, handleSQLError(err)
+	}
+	return affected == 1, nil
+}
+
+// ListDeliveries returns up to limit deliveries made to a webhook, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, organizationID, webhookID string, limit int) ([]*types.WebhookDelivery, error) {
+	rows, err := r.db.query(ctx, r.db.db, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE organization_id = ? AND webhook_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?`,
+		organizationID, webhookID, limit,
+	)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var deliveries []*types.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, handleSQLError(rows.Err())
+}
+
+// ListPendingDeliveries returns up to limit deliveries of the organization still waiting for an attempt, oldest first
+func (r *WebhookRepository) ListPendingDeliveries(ctx context.Context, organizationID string, limit int) ([]*types.WebhookDelivery, error) {
+	rows, err := r.db.query(ctx, r.db.db, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE organization_id = ? AND status = ?
+		ORDER BY created_at
+		LIMIT ?`,
+		organizationID, string(types.WebhookDeliveryStatusPending), limit,
+	)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	var deliveries []*types.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, handleSQLError(rows.Err())
+}
+
+func scanWebhook(row rowScanner) (*types.Webhook, error) {
+	webhook := &types.Webhook{EntityType: "webhook"}
+	var events []byte
+	err := row.Scan(
+		&webhook.ID, &webhook.OrganizationID, &webhook.URL, &webhook.Description, &events, &webhook.Active, &webhook.Secret,
+		&webhook.CreatedByUserID, &webhook.CreatedByGitHubID, &webhook.CreatedByUsername, &webhook.CreatedAt, &webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.Events, err = unmarshalJSON[types.WebhookEvent](events); err != nil {
+		return nil, err
+	}
+	webhook.PartitionKey = webhook.GetPartitionKey()
+
+	return webhook, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (*types.WebhookDelivery, error) {
+	delivery := &types.WebhookDelivery{EntityType: "webhook_delivery"}
+	var event, status string
+	var nextAttemptAt sql.NullTime
+	err := row.Scan(
+		&delivery.ID, &delivery.OrganizationID, &delivery.WebhookID, &event, &delivery.Payload, &status,
+		&delivery.Attempts, &delivery.ResponseStatus, &delivery.Error, &nextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Event = types.WebhookEvent(event)
+	delivery.Status = types.WebhookDeliveryStatus(status)
+	delivery.NextAttemptAt = timePtr(nextAttemptAt)
+	delivery.PartitionKey = delivery.GetPartitionKey()
+
+	return delivery, nil
+}
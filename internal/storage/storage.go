@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// ErrNotFound is returned by every backend when the requested item does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalidCursor is returned for a history cursor the backend did not hand out
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// AuditEvent is an audit history record together with the cursor that resumes right after it
+type AuditEvent struct {
+	*types.AuditHistory
+	ResourceType types.AuditResourceType `json:"resource_type,omitempty"` // Set by AuditRepository.Search
+	Cursor       string                  `json:"cursor"`
+}
+
+// ServiceKeyRepository stores service keys and their audit history
+type ServiceKeyRepository interface {
+	Create(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error
+	Get(ctx context.Context, organizationID, id string) (*types.ServiceKey, error)
+	FindByTokenValue(ctx context.Context, tokenValue string) (*types.ServiceKey, error)
+	FindByTokenValueInOrg(ctx context.Context, organizationID, tokenValue string) (*types.ServiceKey, error)
+	CountByOrganization(ctx context.Context, organizationID string) (int, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.ServiceKey, error)
+	Update(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error
+	Rotate(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error
+	Delete(ctx context.Context, token *types.ServiceKey, userID, githubID, username string) error
+	GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error)
+	// ListEvents returns the audit history of every service key in an organization, oldest first,
+	// starting after cursor, or at the beginning when cursor is empty
+	ListEvents(ctx context.Context, organizationID, cursor string, limit int) ([]*AuditEvent, error)
+}
+
+// AuditRepository searches the audit history of every service key, API key and application in an organization
+type AuditRepository interface {
+	// Search returns up to limit records matching filter, newest first,
+	// starting after cursor, or with the newest record when cursor is empty
+	Search(ctx context.Context, organizationID string, filter AuditFilter, cursor string, limit int) ([]*AuditEvent, error)
+	// GetChainHead returns the head of an organization's audit chain for a kind of entity,
+	// with a zero Sequence when the chain is empty
+	GetChainHead(ctx context.Context, organizationID string, resourceType types.AuditResourceType) (*types.AuditChainHead, error)
+	// ListChain returns up to limit records of an organization's audit chain for a kind of entity
+	// with a Sequence above afterSequence, in chain order
+	ListChain(ctx context.Context, organizationID string, resourceType types.AuditResourceType, afterSequence int64, limit int) ([]*types.AuditHistory, error)
+}
+
+// ApiKeyRepository stores API keys and their audit history
+type ApiKeyRepository interface {
+	Create(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error
+	Get(ctx context.Context, organizationID, id string) (*types.ApiKey, error)
+	FindByTokenValue(ctx context.Context, tokenValue string) (*types.ApiKey, error)
+	CountByOrganization(ctx context.Context, organizationID string) (int, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.ApiKey, error)
+	Update(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error
+	Rotate(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error
+	Revoke(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error
+	Delete(ctx context.Context, token *types.ApiKey, userID, githubID, username string) error
+	GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error)
+}
+
+// ApplicationRepository stores applications and their audit history
+type ApplicationRepository interface {
+	Create(ctx context.Context, app *types.Application, userID, githubID, username string) error
+	Get(ctx context.Context, organizationID, id string) (*types.Application, error)
+	CountByOrganization(ctx context.Context, organizationID string) (int, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.Application, error)
+	Update(ctx context.Context, app *types.Application, userID, githubID, username string) error
+	Delete(ctx context.Context, app *types.Application, userID, githubID, username string) error
+	GetHistory(ctx context.Context, organizationID, entityID string) ([]*types.AuditHistory, error)
+}
+
+// OrganizationRepository stores organizations
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *types.Organization) error
+	Delete(ctx context.Context, id string) error
+	Get(ctx context.Context, id string) (*types.Organization, error)
+	List(ctx context.Context) ([]*types.Organization, error)
+	ListByMemberID(ctx context.Context, userID string) ([]*types.Organization, error)
+}
+
+// OrganizationMemberRepository stores organization membership records
+type OrganizationMemberRepository interface {
+	IsMember(ctx context.Context, orgID, userID string) (bool, error)
+	GetMemberRole(ctx context.Context, orgID, userID string) (types.Role, bool, error)
+	AddMember(ctx context.Context, orgID, userID string, role types.Role) error
+	CreateOrganizationWithMember(ctx context.Context, org *types.Organization, userID string) error
+	RemoveMember(ctx context.Context, orgID, userID string) error
+	ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error)
+}
+
+// InvitationRepository stores invitations to join an organization
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *types.Invitation) error
+	Get(ctx context.Context, id string) (*types.Invitation, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.Invitation, error)
+	ListByUsername(ctx context.Context, username string) ([]*types.Invitation, error)
+	Update(ctx context.Context, invitation *types.Invitation) error
+	Accept(ctx context.Context, invitation *types.Invitation, userID string) error
+}
+
+// UserRepository stores users authenticated through GitHub
+type UserRepository interface {
+	CreateOrUpdate(ctx context.Context, user *types.User) error
+	GetByGitHubID(ctx context.Context, githubID string) (*types.User, error)
+	GetByID(ctx context.Context, id string, githubID string) (*types.User, error)
+}
+
+// WebhookRepository stores webhook endpoints and the log of deliveries made to them
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *types.Webhook) error
+	Get(ctx context.Context, organizationID, id string) (*types.Webhook, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*types.Webhook, error)
+	Update(ctx context.Context, webhook *types.Webhook) error
+	Delete(ctx context.Context, organizationID, id string) error
+	CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error
+	GetDelivery(ctx context.Context, organizationID, id string) (*types.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error
+	// ClaimDelivery stores delivery in place of the pending delivery with its ID that has made previousAttempts
+	// attempts. It returns false and stores nothing when the stored delivery no longer matches, because another
+	// dispatcher claimed or finished it first.
+	ClaimDelivery(ctx context.Context, delivery *types.WebhookDelivery, previousAttempts int) (bool, error)
+	// ListDeliveries returns up to limit deliveries made to a webhook, newest first
+	ListDeliveries(ctx context.Context, organizationID, webhookID string, limit int) ([]*types.WebhookDelivery, error)
+	// ListPendingDeliveries returns up to limit deliveries of the organization still waiting for an attempt, oldest first
+	ListPendingDeliveries(ctx context.Context, organizationID string, limit int) ([]*types.WebhookDelivery, error)
+}
+
+// AccessLogRepository stores access validation decisions and how often each service key was used
+type AccessLogRepository interface {
+	// Record stores a batch of decisions, which may span organizations, and adds them to their service keys' usage
+	Record(ctx context.Context, logs []*types.AccessLog) error
+	// ListByServiceKey returns up to limit decisions about a service key made before a time, newest first.
+	// A zero before starts from the latest decision.
+	ListByServiceKey(ctx context.Context, organizationID, serviceKeyID string, before time.Time, limit int) ([]*types.AccessLog, error)
+	// GetUsage returns a service key's usage, with zero counts when it was never validated
+	GetUsage(ctx context.Context, organizationID, serviceKeyID string) (*types.ServiceKeyUsage, error)
+}
+
+// Repositories groups every repository of a single storage backend
+type Repositories struct {
+	Organizations       OrganizationRepository
+	OrganizationMembers OrganizationMemberRepository
+	Invitations         InvitationRepository
+	Applications        ApplicationRepository
+	ServiceKeys         ServiceKeyRepository
+	ApiKeys             ApiKeyRepository
+	Users               UserRepository
+	Webhooks            WebhookRepository
+	Audit               AuditRepository
+	AccessLogs          AccessLogRepository
+}
+
+// HashToken hashes a token value for storage
+func HashToken(tokenValue string) string {
+	hash := sha256.Sum256([]byte(tokenValue))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateID generates a random ID
+func GenerateID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.URLEncoding.EncodeToString(b)
+}
+
+// HistoryAfter orders audit history by creation time and ID, for backends without a sequence,
+// and returns up to limit records after the cursor
+func HistoryAfter(history []*types.AuditHistory, cursor string, limit int) ([]*AuditEvent, error) {
+	var after time.Time
+	var afterID string
+	if cursor != "" {
+		var err error
+		if after, afterID, err = decodeHistoryCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return historyBefore(history[i].CreatedAt, history[i].ID, history[j].CreatedAt, history[j].ID)
+	})
+
+	var events []*AuditEvent
+	for _, audit := range history {
+		if cursor != "" && !historyBefore(after, afterID, audit.CreatedAt, audit.ID) {
+			continue
+		}
+		if len(events) == limit {
+			break
+		}
+		events = append(events, &AuditEvent{
+			AuditHistory: audit,
+			Cursor:       strconv.FormatInt(audit.CreatedAt.UnixNano(), 10) + "." + audit.ID,
+		})
+	}
+
+	return events, nil
+}
+
+// HistoryCursorTime returns the creation time a cursor from HistoryAfter points at
+func HistoryCursorTime(cursor string) (time.Time, error) {
+	createdAt, _, err := decodeHistoryCursor(cursor)
+	return createdAt, err
+}
+
+func decodeHistoryCursor(cursor string) (time.Time, string, error) {
+	nanos, id, ok := strings.Cut(cursor, ".")
+	if !ok || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return time.Unix(0, n), id, nil
+}
+
+// historyBefore orders audit history by creation time and then ID
+func historyBefore(aTime time.Time, aID string, bTime time.Time, bID string) bool {
+	if !aTime.Equal(bTime) {
+		return aTime.Before(bTime)
+	}
+	return aID < bID
+}
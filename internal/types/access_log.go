@@ -0,0 +1,63 @@
+package types
+
+import "time"
+
+// AccessLog records a single access validation decision
+type AccessLog struct {
+	ID              string    `json:"id" cosmosdb:"id"`
+	PartitionKey    string    `json:"-" cosmosdb:"_partitionKey"`
+	EntityType      string    `json:"entity_type"` // "access_log" discriminator
+	OrganizationID  string    `json:"organization_id"`
+	ServiceKeyID    string    `json:"service_key_id"` // Empty when the token matched no service key
+	ApplicationID   string    `json:"application_id"`
+	ApplicationName string    `json:"application_name"`
+	Allowed         bool      `json:"allowed"`
+	DenialReason    string    `json:"denial_reason,omitempty"`
+	ApiKeyID        string    `json:"api_key_id"` // The API key that asked
+	ClientIP        string    `json:"client_ip"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// GetPartitionKey returns the partition key for Cosmos DB
+func (l *AccessLog) GetPartitionKey() string {
+	return l.OrganizationID
+}
+
+// ServiceKeyUsage aggregates the access validation decisions made about a service key
+type ServiceKeyUsage struct {
+	ID             string     `json:"id" cosmosdb:"id"`
+	PartitionKey   string     `json:"-" cosmosdb:"_partitionKey"`
+	EntityType     string     `json:"entity_type"` // "service_key_usage" discriminator
+	OrganizationID string     `json:"organization_id"`
+	ServiceKeyID   string     `json:"service_key_id"`
+	AllowedCount   int64      `json:"allowed_count"`
+	DeniedCount    int64      `json:"denied_count"`
+	LastUsedAt     *time.Time `json:"last_used_at"`   // The last time the key was allowed access
+	LastDeniedAt   *time.Time `json:"last_denied_at"` // The last time the key was denied access
+}
+
+// GetPartitionKey returns the partition key for Cosmos DB
+func (u *ServiceKeyUsage) GetPartitionKey() string {
+	return u.OrganizationID
+}
+
+// ServiceKeyUsageID returns the ID of a service key's usage document
+func ServiceKeyUsageID(serviceKeyID string) string {
+	return "usage-" + serviceKeyID
+}
+
+// Add counts an access log record about the same service key
+func (u *ServiceKeyUsage) Add(log *AccessLog) {
+	createdAt := log.CreatedAt
+	if log.Allowed {
+		u.AllowedCount++
+		if u.LastUsedAt == nil || createdAt.After(*u.LastUsedAt) {
+			u.LastUsedAt = &createdAt
+		}
+		return
+	}
+	u.DeniedCount++
+	if u.LastDeniedAt == nil || createdAt.After(*u.LastDeniedAt) {
+		u.LastDeniedAt = &createdAt
+	}
+}
@@ -1,6 +1,27 @@
 package types
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+// ApiKeyScope is an operation an API key may call
+type ApiKeyScope string
+
+const (
+	// ApiKeyScopeValidateAccess allows validating service keys
+	ApiKeyScopeValidateAccess ApiKeyScope = "access:validate"
+	// ApiKeyScopeWatchServiceKeys allows streaming the changes to every service key in the organization,
+	// whichever applications they grant access to
+	ApiKeyScopeWatchServiceKeys ApiKeyScope = "service_keys:watch"
+	// ApiKeyScopeAdmin allows every operation
+	ApiKeyScopeAdmin ApiKeyScope = "admin"
+)
+
+// IsValid reports whether s is a known scope
+func (s ApiKeyScope) IsValid() bool {
+	return s == ApiKeyScopeValidateAccess || s == ApiKeyScopeWatchServiceKeys || s == ApiKeyScopeAdmin
+}
 
 // ApiKey represents token that are used to authenticate requests to Baluster itself
 type ApiKey struct {
@@ -17,6 +38,20 @@ type ApiKey struct {
 	CreatedByUsername string     `json:"created_by_username"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Scopes are the operations the key may call.
+	// ApplicationIDs are the applications it may query besides ApplicationID.
+	Scopes         []ApiKeyScope `json:"scopes,omitempty"`
+	ApplicationIDs []string      `json:"application_ids,omitempty"`
+
+	// PreviousTokenValue is the hash replaced by the last rotation.
+	// It stays valid until PreviousTokenExpiresAt so callers can move to the new token.
+	PreviousTokenValue     string     `json:"previous_token_value,omitempty"`
+	PreviousTokenExpiresAt *time.Time `json:"previous_token_expires_at,omitempty"`
+
+	// A revoked key is kept for forensics but never validates again
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
 }
 
 func (t *ApiKey) GetPartitionKey() string {
@@ -29,3 +64,41 @@ func (t *ApiKey) IsExpired() bool {
 	}
 	return t.ExpiresAt.Before(time.Now())
 }
+
+// GetScopes returns the key's scopes.
+// Keys created before scopes existed could only validate service keys, so they keep that scope.
+func (t *ApiKey) GetScopes() []ApiKeyScope {
+	if len(t.Scopes) == 0 {
+		return []ApiKeyScope{ApiKeyScopeValidateAccess}
+	}
+	return t.Scopes
+}
+
+// HasScope checks if the key may call an operation, the admin scope allows every operation
+func (t *ApiKey) HasScope(scope ApiKeyScope) bool {
+	scopes := t.GetScopes()
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ApiKeyScopeAdmin)
+}
+
+// AllowsApplication checks if the key may query an application
+func (t *ApiKey) AllowsApplication(applicationID string) bool {
+	if applicationID == "" {
+		return false
+	}
+	return t.ApplicationID == applicationID || slices.Contains(t.ApplicationIDs, applicationID)
+}
+
+func (t *ApiKey) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// AcceptsToken checks if a hashed token is the current token or the previous token within its grace period
+func (t *ApiKey) AcceptsToken(hashed string) bool {
+	if hashed == "" {
+		return false
+	}
+	if t.TokenValue == hashed {
+		return true
+	}
+	return t.PreviousTokenValue == hashed && t.PreviousTokenExpiresAt != nil && time.Now().Before(*t.PreviousTokenExpiresAt)
+}
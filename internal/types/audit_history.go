@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 // AuditAction represents the type of action performed on an entity
 type AuditAction string
@@ -9,8 +12,45 @@ const (
 	AuditActionCreated AuditAction = "created"
 	AuditActionUpdated AuditAction = "updated"
 	AuditActionDeleted AuditAction = "deleted"
+	AuditActionRotated AuditAction = "rotated"
+	AuditActionRevoked AuditAction = "revoked"
+)
+
+// AuditActions lists every audit action
+var AuditActions = []AuditAction{
+	AuditActionCreated,
+	AuditActionUpdated,
+	AuditActionDeleted,
+	AuditActionRotated,
+	AuditActionRevoked,
+}
+
+// IsValid reports whether the action is a known audit action
+func (a AuditAction) IsValid() bool {
+	return slices.Contains(AuditActions, a)
+}
+
+// AuditResourceType is the kind of entity an audit history record is about
+type AuditResourceType string
+
+const (
+	AuditResourceServiceKey  AuditResourceType = "service_key"
+	AuditResourceApiKey      AuditResourceType = "api_key"
+	AuditResourceApplication AuditResourceType = "application"
 )
 
+// AuditResourceTypes lists every audited kind of entity
+var AuditResourceTypes = []AuditResourceType{
+	AuditResourceServiceKey,
+	AuditResourceApiKey,
+	AuditResourceApplication,
+}
+
+// IsValid reports whether the resource type is a known kind of audited entity
+func (t AuditResourceType) IsValid() bool {
+	return slices.Contains(AuditResourceTypes, t)
+}
+
 // AuditHistory represents an audit history record for tracking entity changes
 // These records are stored in the same container as their respective entity
 // with a discriminator field (EntityType) to differentiate them
@@ -20,14 +60,75 @@ type AuditHistory struct {
 	EntityType        string      `json:"entity_type"` // "audit_history" discriminator
 	EntityID          string      `json:"entity_id"`   // ID of the entity being audited
 	OrganizationID    string      `json:"organization_id"`
-	Action            AuditAction `json:"action"` // created, updated, deleted
+	Action            AuditAction `json:"action"` // created, updated, deleted, rotated, revoked
 	CreatedByUserID   string      `json:"created_by_user_id"`
 	CreatedByGitHubID string      `json:"created_by_github_id"`
 	CreatedByUsername string      `json:"created_by_username"`
 	CreatedAt         time.Time   `json:"created_at"`
+
+	// Changes lists the fields a write changed, it is empty for creates and deletes.
+	// Token values are never part of it.
+	Changes []AuditChange `json:"changes,omitempty"`
+
+	// The request that made the change, empty for changes made outside a request
+	RequestID string `json:"request_id,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// The record's place in its organization's audit chain for its kind of entity.
+	// Hash covers the record and PreviousHash, the Hash of the record before it.
+	// Records written before the chain existed have a zero Sequence.
+	Sequence     int64  `json:"sequence,omitempty"`
+	PreviousHash string `json:"previous_hash,omitempty"`
+	Hash         string `json:"hash,omitempty"`
+}
+
+// AuditChange is a field changed by a write.
+// Scalar fields carry their Before and After values, lists carry the Added and Removed items.
+type AuditChange struct {
+	Field   string   `json:"field"` // e.g. "name" or "applications.<application_id>.permissions"
+	Before  any      `json:"before,omitempty"`
+	After   any      `json:"after,omitempty"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
 }
 
 // GetPartitionKey returns the partition key for Cosmos DB (organization_id)
 func (a *AuditHistory) GetPartitionKey() string {
 	return a.OrganizationID
 }
+
+// AuditChainHead is the last record of an organization's audit chain for one kind of entity.
+// It is written together with every record added to the chain, so deleting records from
+// the end of the chain leaves the head pointing past them.
+type AuditChainHead struct {
+	ID             string    `json:"id" cosmosdb:"id"`
+	PartitionKey   string    `json:"-" cosmosdb:"_partitionKey"`
+	EntityType     string    `json:"entity_type"` // "audit_chain_head" discriminator
+	OrganizationID string    `json:"organization_id"`
+	Sequence       int64     `json:"sequence"` // Zero while the chain is empty
+	Hash           string    `json:"hash"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// GetPartitionKey returns the partition key for Cosmos DB (organization_id)
+func (h *AuditChainHead) GetPartitionKey() string {
+	return h.OrganizationID
+}
+
+// AuditCheckpoint is a signed copy of the heads of an organization's audit chains.
+// Verifying the chains against an older checkpoint proves the history it covers was not rewritten since.
+type AuditCheckpoint struct {
+	OrganizationID string                `json:"organization_id"`
+	Chains         []AuditCheckpointHead `json:"chains"`
+	CreatedAt      time.Time             `json:"created_at"`
+	PublicKey      string                `json:"public_key"` // Base64 Ed25519 public key of the signer
+	Signature      string                `json:"signature"`  // Base64 Ed25519 signature
+}
+
+// AuditCheckpointHead is the head of one audit chain in a checkpoint
+type AuditCheckpointHead struct {
+	ResourceType AuditResourceType `json:"resource_type"`
+	Sequence     int64             `json:"sequence"`
+	Hash         string            `json:"hash"`
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// InvitationStatus is the state of an organization invitation
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusAccepted InvitationStatus = "accepted"
+	InvitationStatusDeclined InvitationStatus = "declined"
+)
+
+// Invitation invites a GitHub user to join an organization with a role.
+// Invitations are stored next to the organization and its members.
+type Invitation struct {
+	ID                string           `json:"id" cosmosdb:"id"`
+	PartitionKey      string           `json:"-" cosmosdb:"_partitionKey"`
+	EntityType        string           `json:"entity_type"` // "invitation" discriminator
+	OrganizationID    string           `json:"organization_id"`
+	GitHubUsername    string           `json:"github_username"`
+	Role              Role             `json:"role"`
+	Status            InvitationStatus `json:"status"`
+	InvitedByUserID   string           `json:"invited_by_user_id"`
+	InvitedByGitHubID string           `json:"invited_by_github_id"`
+	InvitedByUsername string           `json:"invited_by_username"`
+	ExpiresAt         time.Time        `json:"expires_at"`
+	RespondedAt       *time.Time       `json:"responded_at"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// GetPartitionKey returns the partition key for Cosmos DB
+func (i *Invitation) GetPartitionKey() string {
+	return i.OrganizationID
+}
+
+// IsExpired checks if the invitation can no longer be answered
+func (i *Invitation) IsExpired() bool {
+	return i.ExpiresAt.Before(time.Now())
+}
+
+// IsFor checks if the invitation was sent to the GitHub user.
+// GitHub usernames are case-insensitive.
+func (i *Invitation) IsFor(username string) bool {
+	return strings.EqualFold(i.GitHubUsername, username)
+}
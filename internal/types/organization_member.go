@@ -2,6 +2,39 @@ package types
 
 import "time"
 
+// Role is the role of a member within an organization
+type Role string
+
+const (
+	// RoleOwner has full control of the organization, including its membership
+	RoleOwner Role = "owner"
+	// RoleAdmin manages service keys and API keys
+	RoleAdmin Role = "admin"
+	// RoleDeveloper manages applications
+	RoleDeveloper Role = "developer"
+	// RoleViewer has read-only access
+	RoleViewer Role = "viewer"
+)
+
+// roleRank orders roles from least to most privileged
+var roleRank = map[Role]int{
+	RoleViewer:    1,
+	RoleDeveloper: 2,
+	RoleAdmin:     3,
+	RoleOwner:     4,
+}
+
+// IsValid reports whether r is a known role
+func (r Role) IsValid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// Satisfies reports whether r grants at least the privileges of the required role
+func (r Role) Satisfies(required Role) bool {
+	return r.IsValid() && required.IsValid() && roleRank[r] >= roleRank[required]
+}
+
 // OrganizationMember represents a membership relationship between a user and an organization
 type OrganizationMember struct {
 	ID             string    `json:"id" cosmosdb:"id"`
@@ -9,6 +42,7 @@ type OrganizationMember struct {
 	EntityType     string    `json:"entity_type"` // "organization_member" discriminator
 	OrganizationID string    `json:"organization_id"`
 	UserID         string    `json:"user_id"`
+	Role           Role      `json:"role,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 }
 
@@ -22,3 +56,11 @@ func (om *OrganizationMember) GetID() string {
 	return om.OrganizationID + "_" + om.UserID
 }
 
+// GetRole returns the member's role.
+// Members recorded before roles existed could only be organization creators, so they are owners.
+func (om *OrganizationMember) GetRole() Role {
+	if om.Role == "" {
+		return RoleOwner
+	}
+	return om.Role
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	// PermissionSeparator separates the segments of a hierarchical permission, e.g. orders:read
+	PermissionSeparator = ":"
+	// PermissionWildcard matches any segment, or every remaining segment when it comes last
+	PermissionWildcard = "*"
+)
+
+// IsWildcardPermission checks if a granted permission has a wildcard segment
+func IsWildcardPermission(grant string) bool {
+	for segment := range strings.SplitSeq(grant, PermissionSeparator) {
+		if segment == PermissionWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPermission checks if a granted permission covers a required permission.
+// A wildcard segment matches any single segment, and a trailing wildcard matches
+// one or more remaining segments, so orders:* covers orders:read and orders:read:own
+// and * covers every permission. A wildcard never matches an empty segment, and
+// grants without a wildcard must match exactly.
+func MatchPermission(grant, permission string) bool {
+	if grant == permission {
+		return true
+	}
+	if grant == "" || permission == "" {
+		return false
+	}
+
+	grantSegments := strings.Split(grant, PermissionSeparator)
+	permissionSegments := strings.Split(permission, PermissionSeparator)
+	for i, segment := range grantSegments {
+		if i >= len(permissionSegments) {
+			return false
+		}
+		if segment == PermissionWildcard {
+			if i == len(grantSegments)-1 {
+				return !slices.Contains(permissionSegments[i:], "")
+			}
+			if permissionSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != permissionSegments[i] {
+			return false
+		}
+	}
+	return len(grantSegments) == len(permissionSegments)
+}
@@ -0,0 +1,78 @@
+package types
+
+import "testing"
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		grant      string
+		permission string
+		want       bool
+	}{
+		// Exact grants
+		{"orders:read", "orders:read", true},
+		{"orders:read", "orders:write", false},
+		{"orders", "orders", true},
+
+		// Wildcard segment
+		{"orders:*:own", "orders:read:own", true},
+		{"orders:*:own", "orders:read:all", false},
+		{"*:read", "orders:read", true},
+		{"*:read", "orders:write", false},
+
+		// Trailing wildcard
+		{"orders:*", "orders:read", true},
+		{"orders:*", "orders:read:own", true},
+		{"orders:*", "invoices:read", false},
+
+		// Bare wildcard
+		{"*", "orders", true},
+		{"*", "orders:read:own", true},
+		{"*", "", false},
+
+		// Length mismatches
+		{"orders:*", "orders", false},
+		{"orders:read", "orders", false},
+		{"orders", "orders:read", false},
+		{"orders:*:own", "orders:read", false},
+		{"orders:*:own", "orders:read:own:extra", false},
+
+		// Empty segments
+		{"", "", true},
+		{"", "orders", false},
+		{"orders", "", false},
+		{"orders:*", "orders:", false},
+		{"orders:*", "orders:read:", false},
+		{"orders:*:own", "orders::own", false},
+		{"*", ":read", false},
+		{"orders:", "orders:read", false},
+		{"orders::read", "orders::read", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.grant+"/"+tt.permission, func(t *testing.T) {
+			if got := MatchPermission(tt.grant, tt.permission); got != tt.want {
+				t.Errorf("MatchPermission(%q, %q) = %v, want %v", tt.grant, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsWildcardPermission(t *testing.T) {
+	tests := []struct {
+		grant string
+		want  bool
+	}{
+		{"*", true},
+		{"orders:*", true},
+		{"orders:*:own", true},
+		{"orders:read", false},
+		{"orders:read*", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsWildcardPermission(tt.grant); got != tt.want {
+			t.Errorf("IsWildcardPermission(%q) = %v, want %v", tt.grant, got, tt.want)
+		}
+	}
+}
@@ -5,13 +5,23 @@ import (
 	"time"
 )
 
-// ApplicationAccess defines which permissions a service key has for an application
+// ApplicationAccess defines which permissions a service key has for an application.
+// The application is identified by ApplicationID, ApplicationName is a copy of its
+// current name that is kept up to date when the application is renamed.
 type ApplicationAccess struct {
 	ApplicationID   string   `json:"application_id"`
 	ApplicationName string   `json:"application_name"`
 	Permissions     []string `json:"permissions"`
 }
 
+// HasPermission checks if the grant includes a permission,
+// either directly or through a wildcard grant such as orders:*
+func (a *ApplicationAccess) HasPermission(permission string) bool {
+	return slices.ContainsFunc(a.Permissions, func(grant string) bool {
+		return MatchPermission(grant, permission)
+	})
+}
+
 // ServiceKey represents a token that grants access to multiple applications with specific permissions
 type ServiceKey struct {
 	ID                string              `json:"id" cosmosdb:"id"`
@@ -27,6 +37,11 @@ type ServiceKey struct {
 	CreatedByUsername string              `json:"created_by_username"`
 	CreatedAt         time.Time           `json:"created_at"`
 	UpdatedAt         time.Time           `json:"updated_at"`
+
+	// PreviousTokenValue is the hash replaced by the last rotation.
+	// It stays valid until PreviousTokenExpiresAt so callers can move to the new token.
+	PreviousTokenValue     string     `json:"previous_token_value,omitempty"`
+	PreviousTokenExpiresAt *time.Time `json:"previous_token_expires_at,omitempty"`
 }
 
 func (t *ServiceKey) GetPartitionKey() string {
@@ -40,7 +55,28 @@ func (t *ServiceKey) IsExpired() bool {
 	return t.ExpiresAt.Before(time.Now())
 }
 
-// HasAccessToApplication checks if the service key has access to a specific application
+// AcceptsToken checks if a hashed token is the current token or the previous token within its grace period
+func (t *ServiceKey) AcceptsToken(hashed string) bool {
+	if hashed == "" {
+		return false
+	}
+	if t.TokenValue == hashed {
+		return true
+	}
+	return t.PreviousTokenValue == hashed && t.PreviousTokenExpiresAt != nil && time.Now().Before(*t.PreviousTokenExpiresAt)
+}
+
+// ApplicationAccessByID returns the service key's grant for an application ID
+func (t *ServiceKey) ApplicationAccessByID(applicationID string) *ApplicationAccess {
+	for _, app := range t.Applications {
+		if app.ApplicationID == applicationID {
+			return &app
+		}
+	}
+	return nil
+}
+
+// HasAccessToApplication checks if the service key has access to an application by its current name
 func (t *ServiceKey) HasAccessToApplication(applicationName string) *ApplicationAccess {
 	for _, app := range t.Applications {
 		if app.ApplicationName == applicationName {
@@ -50,11 +86,12 @@ func (t *ServiceKey) HasAccessToApplication(applicationName string) *Application
 	return nil
 }
 
-// HasPermission checks if the service key has a specific permission for an application
+// HasPermission checks if the service key has a specific permission for an application,
+// either granted directly or through a wildcard grant such as orders:*
 func (t *ServiceKey) HasPermission(applicationName string, permission string) bool {
 	access := t.HasAccessToApplication(applicationName)
 	if access == nil {
 		return false
 	}
-	return slices.Contains(access.Permissions, permission)
+	return access.HasPermission(permission)
 }
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"slices"
+	"time"
+)
+
+// WebhookEvent is the kind of change a webhook is notified about
+type WebhookEvent string
+
+const (
+	WebhookEventServiceKeyCreated  WebhookEvent = "service_key.created"
+	WebhookEventServiceKeyUpdated  WebhookEvent = "service_key.updated"
+	WebhookEventServiceKeyRotated  WebhookEvent = "service_key.rotated"
+	WebhookEventServiceKeyDeleted  WebhookEvent = "service_key.deleted"
+	WebhookEventServiceKeyExpiring WebhookEvent = "service_key.expiring"
+	WebhookEventApiKeyCreated      WebhookEvent = "api_key.created"
+	WebhookEventApiKeyUpdated      WebhookEvent = "api_key.updated"
+	WebhookEventApiKeyRotated      WebhookEvent = "api_key.rotated"
+	WebhookEventApiKeyRevoked      WebhookEvent = "api_key.revoked"
+	WebhookEventApiKeyDeleted      WebhookEvent = "api_key.deleted"
+	WebhookEventApiKeyExpiring     WebhookEvent = "api_key.expiring"
+	WebhookEventApplicationCreated WebhookEvent = "application.created"
+	WebhookEventApplicationUpdated WebhookEvent = "application.updated"
+	WebhookEventApplicationDeleted WebhookEvent = "application.deleted"
+
+	// WebhookEventTest is sent on request to check an endpoint, it cannot be subscribed to
+	WebhookEventTest WebhookEvent = "webhook.test"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []WebhookEvent{
+	WebhookEventServiceKeyCreated,
+	WebhookEventServiceKeyUpdated,
+	WebhookEventServiceKeyRotated,
+	WebhookEventServiceKeyDeleted,
+	WebhookEventServiceKeyExpiring,
+	WebhookEventApiKeyCreated,
+	WebhookEventApiKeyUpdated,
+	WebhookEventApiKeyRotated,
+	WebhookEventApiKeyRevoked,
+	WebhookEventApiKeyDeleted,
+	WebhookEventApiKeyExpiring,
+	WebhookEventApplicationCreated,
+	WebhookEventApplicationUpdated,
+	WebhookEventApplicationDeleted,
+}
+
+// IsValid reports whether e is an event a webhook can subscribe to
+func (e WebhookEvent) IsValid() bool {
+	return slices.Contains(WebhookEvents, e)
+}
+
+// Webhook is an endpoint that receives signed events about an organization's keys and applications
+type Webhook struct {
+	ID                string         `json:"id" cosmosdb:"id"`
+	PartitionKey      string         `json:"-" cosmosdb:"_partitionKey"`
+	EntityType        string         `json:"entity_type"` // "webhook" discriminator
+	OrganizationID    string         `json:"organization_id"`
+	URL               string         `json:"url"`
+	Description       string         `json:"description"`
+	Events            []WebhookEvent `json:"events"` // Empty subscribes to every event
+	Active            bool           `json:"active"`
+	CreatedByUserID   string         `json:"created_by_user_id"`
+	CreatedByGitHubID string         `json:"created_by_github_id"`
+	CreatedByUsername string         `json:"created_by_username"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+
+	// Secret signs every delivery. It is kept as is since signing needs it,
+	// and is only shown when the webhook is created.
+	Secret string `json:"secret"`
+}
+
+// GetPartitionKey returns the partition key for Cosmos DB
+func (w *Webhook) GetPartitionKey() string {
+	return w.OrganizationID
+}
+
+// Subscribes checks if an active webhook should receive an event
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	if !w.Active {
+		return false
+	}
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery records an event sent to a webhook and the outcome of its latest attempt.
+// Deliveries are stored next to their webhook and kept after it is deleted.
+type WebhookDelivery struct {
+	ID             string                `json:"id" cosmosdb:"id"`
+	PartitionKey   string                `json:"-" cosmosdb:"_partitionKey"`
+	EntityType     string                `json:"entity_type"` // "webhook_delivery" discriminator
+	OrganizationID string                `json:"organization_id"`
+	WebhookID      string                `json:"webhook_id"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        string                `json:"payload"` // The JSON body, the same for every attempt
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"response_status"` // 0 when no response was received
+	Error          string                `json:"error,omitempty"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// GetPartitionKey returns the partition key for Cosmos DB
+func (d *WebhookDelivery) GetPartitionKey() string {
+	return d.OrganizationID
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	ID             string             `json:"id"` // The delivery ID, the same for every attempt
+	Event          types.WebhookEvent `json:"event"`
+	OrganizationID string             `json:"organization_id"`
+	OccurredAt     time.Time          `json:"occurred_at"`
+	Actor          *Actor             `json:"actor,omitempty"` // Not set for events nobody triggered
+	Data           any                `json:"data"`
+}
+
+// Actor is the user whose change triggered an event
+type Actor struct {
+	UserID   string `json:"user_id"`
+	GitHubID string `json:"github_id"`
+	Username string `json:"username"`
+}
+
+// ServiceKeyData is the data of service_key events, it never carries token hashes
+type ServiceKeyData struct {
+	ID           string                    `json:"id"`
+	Name         string                    `json:"name"`
+	Applications []types.ApplicationAccess `json:"applications"`
+	ExpiresAt    *time.Time                `json:"expires_at"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+}
+
+// ApiKeyData is the data of api_key events, it never carries token hashes
+type ApiKeyData struct {
+	ID             string              `json:"id"`
+	Name           string              `json:"name"`
+	ApplicationID  string              `json:"application_id"`
+	ApplicationIDs []string            `json:"application_ids"`
+	Scopes         []types.ApiKeyScope `json:"scopes"`
+	ExpiresAt      *time.Time          `json:"expires_at"`
+	RevokedAt      *time.Time          `json:"revoked_at,omitempty"`
+	RevokedReason  string              `json:"revoked_reason,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+// ApplicationData is the data of application events
+type ApplicationData struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TestData is the data of webhook.test events
+type TestData struct {
+	WebhookID string `json:"webhook_id"`
+	Message   string `json:"message"`
+}
+
+func newServiceKeyData(serviceKey *types.ServiceKey) *ServiceKeyData {
+	return &ServiceKeyData{
+		ID:           serviceKey.ID,
+		Name:         serviceKey.Name,
+		Applications: serviceKey.Applications,
+		ExpiresAt:    serviceKey.ExpiresAt,
+		CreatedAt:    serviceKey.CreatedAt,
+		UpdatedAt:    serviceKey.UpdatedAt,
+	}
+}
+
+func newApiKeyData(apiKey *types.ApiKey) *ApiKeyData {
+	return &ApiKeyData{
+		ID:             apiKey.ID,
+		Name:           apiKey.Name,
+		ApplicationID:  apiKey.ApplicationID,
+		ApplicationIDs: apiKey.ApplicationIDs,
+		Scopes:         apiKey.GetScopes(),
+		ExpiresAt:      apiKey.ExpiresAt,
+		RevokedAt:      apiKey.RevokedAt,
+		RevokedReason:  apiKey.RevokedReason,
+		CreatedAt:      apiKey.CreatedAt,
+		UpdatedAt:      apiKey.UpdatedAt,
+	}
+}
+
+func newApplicationData(app *types.Application) *ApplicationData {
+	return &ApplicationData{
+		ID:          app.ID,
+		Name:        app.Name,
+		Description: app.Description,
+		Permissions: app.Permissions,
+		CreatedAt:   app.CreatedAt,
+		UpdatedAt:   app.UpdatedAt,
+	}
+}
@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Baluster-Event"
+	HeaderDelivery  = "X-Baluster-Delivery"
+	HeaderTimestamp = "X-Baluster-Timestamp"
+	HeaderSignature = "X-Baluster-Signature"
+)
+
+// signaturePrefix names the algorithm in HeaderSignature
+const signaturePrefix = "sha256="
+
+// ErrInvalidSignature is returned by Verify when a delivery was not signed with the secret
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the HeaderSignature value for a body sent at timestamp, a Unix time in seconds.
+// The signature is an HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook's secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a delivery's HeaderTimestamp and HeaderSignature values against its body.
+// Deliveries signed more than tolerance away from now are rejected so they cannot be replayed.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, seconds, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
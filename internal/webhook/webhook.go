@@ -0,0 +1,591 @@
+// Package webhook delivers signed events about an organization's keys and applications
+// to the webhook endpoints registered for it.
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// Config configures how events are delivered
+type Config struct {
+	Workers        int           // Deliveries made at the same time
+	QueueSize      int           // Deliveries waiting for a worker, new events are dropped when it is full
+	MaxAttempts    int           // Attempts per delivery, including the first one
+	InitialBackoff time.Duration // Wait before the first retry, doubled for every further retry
+	MaxBackoff     time.Duration // Longest wait between two attempts
+	Timeout        time.Duration // How long an endpoint has to respond
+
+	ExpiryWindow       time.Duration // How long before they expire keys are reported as expiring
+	ExpiryScanInterval time.Duration // How often keys are checked for expiry, 0 disables the check
+
+	// allowPrivateAddresses lets tests deliver to servers listening on loopback
+	allowPrivateAddresses bool
+}
+
+// DefaultConfig returns the configuration used when a field is left at zero
+func DefaultConfig() Config {
+	return Config{
+		Workers:        4,
+		QueueSize:      1000,
+		MaxAttempts:    5,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     time.Hour,
+		Timeout:        10 * time.Second,
+		ExpiryWindow:   7 * 24 * time.Hour,
+	}
+}
+
+// maxResponseBody bounds how much of a response is read before the connection is reused
+const maxResponseBody = 64 << 10
+
+// Dispatcher sends events to webhooks in the background and records every delivery.
+// Failed attempts are retried with exponential backoff until MaxAttempts is reached.
+// Deliveries still pending when the dispatcher is closed are picked up again by the next
+// dispatcher, so a receiver may see a delivery twice and should deduplicate on its ID.
+// Each attempt is claimed in storage before it is made, so when every replica runs a
+// dispatcher over the same storage only one of them makes it.
+type Dispatcher struct {
+	repos  *storage.Repositories
+	config Config
+	client *http.Client
+
+	jobs chan *types.WebhookDelivery
+	done chan struct{}
+
+	// mu guards closed so no goroutine is added to wg once Close started waiting
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher starts the delivery workers, queues the deliveries left pending by an earlier dispatcher
+// and starts the expiry check when ExpiryScanInterval is set. Call Close to stop them.
+func NewDispatcher(repos *storage.Repositories, config Config) *Dispatcher {
+	defaults := DefaultConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaults.InitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.ExpiryWindow <= 0 {
+		config.ExpiryWindow = defaults.ExpiryWindow
+	}
+
+	// Deliveries go straight to the endpoint, a proxy would connect to addresses the dialer never sees
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if !config.allowPrivateAddresses {
+		transport.DialContext = publicDialer(config.Timeout).DialContext
+	}
+
+	d := &Dispatcher{
+		repos:  repos,
+		config: config,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			// A redirect could send the signed payload somewhere the organization did not register
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		jobs: make(chan *types.WebhookDelivery, config.QueueSize),
+		done: make(chan struct{}),
+	}
+
+	for range config.Workers {
+		d.spawn(d.work)
+	}
+	startedAt := time.Now()
+	d.spawn(func() {
+		if err := d.resumePending(context.Background(), startedAt); err != nil {
+			slog.Error("failed to resume pending webhook deliveries", "error", err)
+		}
+	})
+	if config.ExpiryScanInterval > 0 {
+		d.spawn(func() {
+			d.scanExpiringEvery(config.ExpiryScanInterval)
+		})
+	}
+
+	return d
+}
+
+// Close stops the workers once they finish their current attempt, it is safe to call more than once
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.done)
+	}
+	d.mu.Unlock()
+
+	d.wg.Wait()
+}
+
+// spawn runs f in a goroutine Close waits for, and does nothing once the dispatcher is closed
+func (d *Dispatcher) spawn(f func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		f()
+	}()
+}
+
+// Changed queues a delivery of a service key, API key or application change
+// to every webhook of the organization that subscribes to it
+func (d *Dispatcher) Changed(ctx context.Context, change storage.Change) {
+	var data any
+	switch entity := change.Entity.(type) {
+	case *types.ServiceKey:
+		data = newServiceKeyData(entity)
+	case *types.ApiKey:
+		data = newApiKeyData(entity)
+	case *types.Application:
+		data = newApplicationData(entity)
+	default:
+		return
+	}
+
+	event := types.WebhookEvent(change.EntityType + "." + string(change.Action))
+	if !event.IsValid() {
+		return
+	}
+
+	// The entity is encoded right away since the writer may change it once Changed returns
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("failed to encode webhook event", "event", event, "error", err)
+		return
+	}
+
+	payload := Payload{
+		Event:          event,
+		OrganizationID: change.OrganizationID,
+		OccurredAt:     time.Now(),
+		Actor: &Actor{
+			UserID:   change.UserID,
+			GitHubID: change.GitHubID,
+			Username: change.Username,
+		},
+		Data: json.RawMessage(encoded),
+	}
+
+	// Finding the subscribed webhooks reads storage, so it happens off the writer's goroutine
+	d.spawn(func() {
+		d.publish(context.WithoutCancel(ctx), payload)
+	})
+}
+
+// SendTest sends a webhook.test event to a webhook once, without retrying, and records the delivery
+func (d *Dispatcher) SendTest(ctx context.Context, webhook *types.Webhook) (*types.WebhookDelivery, error) {
+	payload := Payload{
+		Event:          types.WebhookEventTest,
+		OrganizationID: webhook.OrganizationID,
+		OccurredAt:     time.Now(),
+		Data: &TestData{
+			WebhookID: webhook.ID,
+			Message:   "This is a test event sent from Baluster",
+		},
+	}
+
+	delivery, err := d.record(ctx, webhook, storage.GenerateID(), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	d.attempt(ctx, webhook, delivery, false)
+	return delivery, nil
+}
+
+// ScanExpiring reports every key expiring within the expiry window to the webhooks subscribed
+// to expiring events. Each key is reported once per expiry date, so extending a key and letting
+// it near its new expiry reports it again.
+func (d *Dispatcher) ScanExpiring(ctx context.Context) error {
+	orgs, err := d.repos.Organizations.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	now := time.Now()
+	until := now.Add(d.config.ExpiryWindow)
+	for _, org := range orgs {
+		webhooks, err := d.repos.Webhooks.ListByOrganization(ctx, org.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list webhooks: %w", err)
+		}
+
+		if subscribed := subscribers(webhooks, types.WebhookEventServiceKeyExpiring); len(subscribed) > 0 {
+			serviceKeys, err := d.repos.ServiceKeys.ListByOrganization(ctx, org.ID)
+			if err != nil {
+				return fmt.Errorf("failed to list service keys: %w", err)
+			}
+			for _, serviceKey := range serviceKeys {
+				if expiresWithin(serviceKey.ExpiresAt, now, until) {
+					d.publishExpiring(ctx, subscribed, types.WebhookEventServiceKeyExpiring, org.ID, serviceKey.ID, *serviceKey.ExpiresAt, newServiceKeyData(serviceKey))
+				}
+			}
+		}
+
+		if subscribed := subscribers(webhooks, types.WebhookEventApiKeyExpiring); len(subscribed) > 0 {
+			apiKeys, err := d.repos.ApiKeys.ListByOrganization(ctx, org.ID)
+			if err != nil {
+				return fmt.Errorf("failed to list API keys: %w", err)
+			}
+			for _, apiKey := range apiKeys {
+				if !apiKey.IsRevoked() && expiresWithin(apiKey.ExpiresAt, now, until) {
+					d.publishExpiring(ctx, subscribed, types.WebhookEventApiKeyExpiring, org.ID, apiKey.ID, *apiKey.ExpiresAt, newApiKeyData(apiKey))
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// publish records a delivery of payload for every webhook subscribed to its event and queues it
+func (d *Dispatcher) publish(ctx context.Context, payload Payload) {
+	webhooks, err := d.repos.Webhooks.ListByOrganization(ctx, payload.OrganizationID)
+	if err != nil {
+		slog.Error("failed to list webhooks", "organization_id", payload.OrganizationID, "error", err)
+		return
+	}
+
+	for _, webhook := range subscribers(webhooks, payload.Event) {
+		delivery, err := d.record(ctx, webhook, storage.GenerateID(), payload)
+		if err != nil {
+			slog.Error("failed to record webhook delivery", "webhook_id", webhook.ID, "event", payload.Event, "error", err)
+			continue
+		}
+		d.enqueue(delivery)
+	}
+}
+
+// publishExpiring reports an expiring key to every subscribed webhook that was not told about this expiry yet.
+// The delivery ID is derived from the webhook, the key and its expiry, so earlier reports are found in the log.
+func (d *Dispatcher) publishExpiring(ctx context.Context, webhooks []*types.Webhook, event types.WebhookEvent, organizationID, entityID string, expiresAt time.Time, data any) {
+	payload := Payload{
+		Event:          event,
+		OrganizationID: organizationID,
+		OccurredAt:     time.Now(),
+		Data:           data,
+	}
+
+	for _, webhook := range webhooks {
+		id := expiringDeliveryID(webhook.ID, entityID, expiresAt)
+		if _, err := d.repos.Webhooks.GetDelivery(ctx, organizationID, id); !errors.Is(err, storage.ErrNotFound) {
+			if err != nil {
+				slog.Error("failed to read webhook delivery", "webhook_id", webhook.ID, "error", err)
+			}
+			continue
+		}
+
+		delivery, err := d.record(ctx, webhook, id, payload)
+		if err != nil {
+			slog.Error("failed to record webhook delivery", "webhook_id", webhook.ID, "event", event, "error", err)
+			continue
+		}
+		d.enqueue(delivery)
+	}
+}
+
+// record stores a pending delivery of payload to a webhook
+func (d *Dispatcher) record(ctx context.Context, webhook *types.Webhook, id string, payload Payload) (*types.WebhookDelivery, error) {
+	payload.ID = id
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	now := time.Now()
+	delivery := &types.WebhookDelivery{
+		ID:             id,
+		OrganizationID: webhook.OrganizationID,
+		WebhookID:      webhook.ID,
+		Event:          payload.Event,
+		Payload:        string(body),
+		Status:         types.WebhookDeliveryStatusPending,
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := d.repos.Webhooks.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// enqueue hands a delivery to the workers, dropping it when the queue is full
+func (d *Dispatcher) enqueue(delivery *types.WebhookDelivery) {
+	select {
+	case d.jobs <- delivery:
+	case <-d.done:
+	default:
+		slog.Warn("webhook queue is full, dropping delivery", "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID)
+	}
+}
+
+// retry queues a delivery again once its backoff has passed
+func (d *Dispatcher) retry(delivery *types.WebhookDelivery, after time.Duration) {
+	d.spawn(func() {
+		timer := time.NewTimer(after)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-d.done:
+			return
+		}
+
+		select {
+		case d.jobs <- delivery:
+		case <-d.done:
+		}
+	})
+}
+
+// resumePending queues every delivery recorded before a time that is still pending in the log,
+// each at its next attempt time. They were left behind by a dispatcher that stopped before finishing them.
+func (d *Dispatcher) resumePending(ctx context.Context, before time.Time) error {
+	orgs, err := d.repos.Organizations.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	for _, org := range orgs {
+		deliveries, err := d.repos.Webhooks.ListPendingDeliveries(ctx, org.ID, d.config.QueueSize)
+		if err != nil {
+			return fmt.Errorf("failed to list pending webhook deliveries: %w", err)
+		}
+		for _, delivery := range deliveries {
+			// Deliveries recorded since are already queued by this dispatcher
+			if !delivery.CreatedAt.Before(before) {
+				continue
+			}
+
+			var wait time.Duration
+			if delivery.NextAttemptAt != nil {
+				wait = time.Until(*delivery.NextAttemptAt)
+			}
+			d.retry(delivery, max(wait, 0))
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) work() {
+	for {
+		select {
+		case delivery := <-d.jobs:
+			d.deliver(delivery)
+		case <-d.done:
+			return
+		}
+	}
+}
+
+// deliver makes the next attempt of a queued delivery against the webhook's current URL and secret
+func (d *Dispatcher) deliver(delivery *types.WebhookDelivery) {
+	ctx := context.Background()
+
+	webhook, err := d.repos.Webhooks.Get(ctx, delivery.OrganizationID, delivery.WebhookID)
+	if err == nil && !webhook.Active {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			d.finish(ctx, delivery, types.WebhookDeliveryStatusFailed, 0, "webhook was deleted or disabled")
+			return
+		}
+		slog.Error("failed to read webhook", "webhook_id", delivery.WebhookID, "error", err)
+		d.retry(delivery, d.config.InitialBackoff)
+		return
+	}
+
+	d.attempt(ctx, webhook, delivery, true)
+}
+
+// attempt claims the next attempt of a delivery, sends it and records the outcome.
+// When retry is set a failed attempt is queued again until MaxAttempts is reached.
+func (d *Dispatcher) attempt(ctx context.Context, webhook *types.Webhook, delivery *types.WebhookDelivery, retry bool) {
+	claimed, err := d.claim(ctx, delivery)
+	if err != nil {
+		slog.Error("failed to claim webhook delivery", "delivery_id", delivery.ID, "error", err)
+		if retry {
+			d.retry(delivery, d.config.InitialBackoff)
+		}
+		return
+	}
+	if !claimed {
+		// Another dispatcher made this attempt or finished the delivery
+		return
+	}
+
+	status, err := d.send(ctx, webhook, delivery)
+	if err == nil {
+		d.finish(ctx, delivery, types.WebhookDeliveryStatusSucceeded, status, "")
+		return
+	}
+
+	slog.Warn("webhook delivery attempt failed", "delivery_id", delivery.ID, "webhook_id", webhook.ID, "attempt", delivery.Attempts, "error", err)
+
+	if !retry || delivery.Attempts >= d.config.MaxAttempts {
+		d.finish(ctx, delivery, types.WebhookDeliveryStatusFailed, status, err.Error())
+		return
+	}
+
+	backoff := d.backoff(delivery.Attempts)
+	nextAttemptAt := time.Now().Add(backoff)
+	delivery.ResponseStatus = status
+	delivery.Error = err.Error()
+	delivery.NextAttemptAt = &nextAttemptAt
+	delivery.UpdatedAt = time.Now()
+	if err := d.repos.Webhooks.UpdateDelivery(ctx, delivery); err != nil {
+		slog.Error("failed to record webhook delivery attempt", "delivery_id", delivery.ID, "error", err)
+	}
+
+	d.retry(delivery, backoff)
+}
+
+// claim records in storage that this dispatcher makes the delivery's next attempt, and returns false when
+// another dispatcher claimed it or finished the delivery first. The claim moves the next attempt past the
+// time the attempt may take, so a dispatcher started later takes over a claim whose dispatcher stopped.
+func (d *Dispatcher) claim(ctx context.Context, delivery *types.WebhookDelivery) (bool, error) {
+	now := time.Now()
+	lapsesAt := now.Add(2 * d.config.Timeout)
+	claimed := *delivery
+	claimed.Attempts++
+	claimed.NextAttemptAt = &lapsesAt
+	claimed.UpdatedAt = now
+
+	ok, err := d.repos.Webhooks.ClaimDelivery(ctx, &claimed, delivery.Attempts)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	*delivery = claimed
+	return true, nil
+}
+
+// finish records the final outcome of a delivery
+func (d *Dispatcher) finish(ctx context.Context, delivery *types.WebhookDelivery, status types.WebhookDeliveryStatus, responseStatus int, message string) {
+	delivery.Status = status
+	delivery.ResponseStatus = responseStatus
+	delivery.Error = message
+	delivery.NextAttemptAt = nil
+	delivery.UpdatedAt = time.Now()
+	if err := d.repos.Webhooks.UpdateDelivery(ctx, delivery); err != nil {
+		slog.Error("failed to record webhook delivery outcome", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send posts the signed payload and returns the response status, failing for anything but a 2xx
+func (d *Dispatcher) send(ctx context.Context, webhook *types.Webhook, delivery *types.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Baluster-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns how long to wait after a failed attempt
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.config.InitialBackoff
+	for i := 1; i < attempts && backoff < d.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.config.MaxBackoff)
+}
+
+func (d *Dispatcher) scanExpiringEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.ScanExpiring(context.Background()); err != nil {
+			slog.Error("failed to check keys for expiry", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.done:
+			return
+		}
+	}
+}
+
+// subscribers returns the webhooks subscribed to an event
+func subscribers(webhooks []*types.Webhook, event types.WebhookEvent) []*types.Webhook {
+	var subscribed []*types.Webhook
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	return subscribed
+}
+
+func expiresWithin(expiresAt *time.Time, now, until time.Time) bool {
+	return expiresAt != nil && expiresAt.After(now) && !expiresAt.After(until)
+}
+
+func expiringDeliveryID(webhookID, entityID string, expiresAt time.Time) string {
+	hash := sha256.Sum256([]byte(webhookID + "|" + entityID + "|" + strconv.FormatInt(expiresAt.UnixNano(), 10)))
+	return "expiring-" + hex.EncodeToString(hash[:16])
+}
@@ -0,0 +1,442 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianfromlife/baluster/internal/storage"
+	"github.com/brianfromlife/baluster/internal/storage/memory"
+	"github.com/brianfromlife/baluster/internal/types"
+)
+
+// receiver is a webhook endpoint that verifies signatures and fails the first requests it gets
+type receiver struct {
+	t      *testing.T
+	secret string
+	fail   int
+
+	mu       sync.Mutex
+	payloads []Payload
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := Verify(rc.secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute); err != nil {
+		rc.t.Errorf("expected a valid signature: %v", err)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.fail > 0 {
+		rc.fail--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		rc.t.Errorf("failed to decode payload: %v", err)
+	}
+	if r.Header.Get(HeaderDelivery) != payload.ID || r.Header.Get(HeaderEvent) != string(payload.Event) {
+		rc.t.Errorf("expected the headers to match the payload")
+	}
+	rc.payloads = append(rc.payloads, payload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newTestDispatcher(t *testing.T, maxAttempts int) (*storage.Repositories, *Dispatcher) {
+	repos := memory.NewRepositories(memory.NewStore())
+	dispatcher := NewDispatcher(repos, Config{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+		Timeout:        time.Second,
+		ExpiryWindow:   7 * 24 * time.Hour,
+
+		allowPrivateAddresses: true,
+	})
+	t.Cleanup(dispatcher.Close)
+	storage.WithChangeListener(repos, dispatcher)
+	return repos, dispatcher
+}
+
+func createWebhook(t *testing.T, repos *storage.Repositories, id, url string, events ...types.WebhookEvent) *types.Webhook {
+	webhook := &types.Webhook{
+		ID:             id,
+		OrganizationID: "org-1",
+		URL:            url,
+		Events:         events,
+		Active:         true,
+		Secret:         "secret-" + id,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := repos.Webhooks.Create(context.Background(), webhook); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	return webhook
+}
+
+// waitForDeliveries waits until a webhook has n deliveries that are no longer pending
+func waitForDeliveries(t *testing.T, repos *storage.Repositories, webhookID string, n int) []*types.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := repos.Webhooks.ListDeliveries(context.Background(), "org-1", webhookID, 100)
+		if err != nil {
+			t.Fatalf("failed to list deliveries: %v", err)
+		}
+		finished := 0
+		for _, delivery := range deliveries {
+			if delivery.Status != types.WebhookDeliveryStatusPending {
+				finished++
+			}
+		}
+		if finished >= n {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d finished deliveries, got %+v", n, deliveries)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcherRetriesUntilDelivered(t *testing.T) {
+	ctx := context.Background()
+	repos, _ := newTestDispatcher(t, 3)
+
+	rc := &receiver{t: t, secret: "secret-wh-1", fail: 1}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	createWebhook(t, repos, "wh-1", server.URL, types.WebhookEventServiceKeyCreated)
+	// Only subscribed to application events, so it never hears about the service key
+	other := createWebhook(t, repos, "wh-2", server.URL, types.WebhookEventApplicationCreated)
+
+	serviceKey := &types.ServiceKey{ID: "sk-1", OrganizationID: "org-1", Name: "billing", TokenValue: "token-1"}
+	if err := repos.ServiceKeys.Create(ctx, serviceKey, "user-1", "github-1", "octocat"); err != nil {
+		t.Fatalf("failed to create service key: %v", err)
+	}
+
+	deliveries := waitForDeliveries(t, repos, "wh-1", 1)
+	delivery := deliveries[0]
+	if delivery.Status != types.WebhookDeliveryStatusSucceeded || delivery.Attempts != 2 || delivery.ResponseStatus != http.StatusNoContent {
+		t.Errorf("expected a delivery that succeeded on the second attempt, got %+v", delivery)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.payloads) != 1 {
+		t.Fatalf("expected 1 delivered payload, got %d", len(rc.payloads))
+	}
+	payload := rc.payloads[0]
+	if payload.Event != types.WebhookEventServiceKeyCreated || payload.ID != delivery.ID || payload.Actor == nil || payload.Actor.Username != "octocat" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if strings.Contains(delivery.Payload, storage.HashToken("token-1")) {
+		t.Errorf("expected the payload not to carry the token hash")
+	}
+
+	if deliveries, _ := repos.Webhooks.ListDeliveries(ctx, "org-1", other.ID, 10); len(deliveries) != 0 {
+		t.Errorf("expected no deliveries to an unsubscribed webhook, got %d", len(deliveries))
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	repos, _ := newTestDispatcher(t, 2)
+
+	rc := &receiver{t: t, secret: "secret-wh-1", fail: 10}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	createWebhook(t, repos, "wh-1", server.URL)
+
+	app := &types.Application{ID: "app-1", OrganizationID: "org-1", Name: "billing"}
+	if err := repos.Applications.Create(ctx, app, "user-1", "github-1", "octocat"); err != nil {
+		t.Fatalf("failed to create application: %v", err)
+	}
+
+	delivery := waitForDeliveries(t, repos, "wh-1", 1)[0]
+	if delivery.Status != types.WebhookDeliveryStatusFailed || delivery.Attempts != 2 || delivery.ResponseStatus != http.StatusInternalServerError || delivery.Error == "" {
+		t.Errorf("expected a failed delivery after 2 attempts, got %+v", delivery)
+	}
+}
+
+func TestSendTest(t *testing.T) {
+	repos, dispatcher := newTestDispatcher(t, 3)
+
+	rc := &receiver{t: t, secret: "secret-wh-1", fail: 1}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	webhook := createWebhook(t, repos, "wh-1", server.URL)
+
+	// A test event is sent once and reports the failure instead of retrying
+	delivery, err := dispatcher.SendTest(context.Background(), webhook)
+	if err != nil {
+		t.Fatalf("failed to send test event: %v", err)
+	}
+	if delivery.Status != types.WebhookDeliveryStatusFailed || delivery.Attempts != 1 {
+		t.Errorf("expected a single failed attempt, got %+v", delivery)
+	}
+
+	delivery, err = dispatcher.SendTest(context.Background(), webhook)
+	if err != nil {
+		t.Fatalf("failed to send test event: %v", err)
+	}
+	if delivery.Status != types.WebhookDeliveryStatusSucceeded || delivery.Event != types.WebhookEventTest {
+		t.Errorf("expected a delivered test event, got %+v", delivery)
+	}
+}
+
+func TestDispatcherResumesPendingDeliveries(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+
+	rc := &receiver{t: t, secret: "secret-wh-1"}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	org := &types.Organization{ID: "org-1", Name: "Test Org"}
+	if err := repos.Organizations.Create(ctx, org); err != nil {
+		t.Fatalf("failed to create organization: %v", err)
+	}
+	createWebhook(t, repos, "wh-1", server.URL)
+
+	// A delivery a previous dispatcher stopped before finishing
+	nextAttemptAt := time.Now().Add(-time.Minute)
+	pending := &types.WebhookDelivery{
+		ID:             "whd-1",
+		OrganizationID: "org-1",
+		WebhookID:      "wh-1",
+		Event:          types.WebhookEventApplicationCreated,
+		Payload:        `{"id":"whd-1","event":"application.created"}`,
+		Status:         types.WebhookDeliveryStatusPending,
+		Attempts:       1,
+		NextAttemptAt:  &nextAttemptAt,
+		CreatedAt:      time.Now().Add(-time.Hour),
+		UpdatedAt:      time.Now().Add(-time.Hour),
+	}
+	if err := repos.Webhooks.CreateDelivery(ctx, pending); err != nil {
+		t.Fatalf("failed to create delivery: %v", err)
+	}
+
+	dispatcher := NewDispatcher(repos, Config{
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		Timeout:        time.Second,
+
+		allowPrivateAddresses: true,
+	})
+	defer dispatcher.Close()
+
+	delivery := waitForDeliveries(t, repos, "wh-1", 1)[0]
+	if delivery.Status != types.WebhookDeliveryStatusSucceeded || delivery.Attempts != 2 {
+		t.Errorf("expected the pending delivery to succeed on its second attempt, got %+v", delivery)
+	}
+}
+
+func TestDispatchersOnSeveralReplicasDeliverOnce(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+
+	rc := &receiver{t: t, secret: "secret-wh-1", fail: 1}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	org := &types.Organization{ID: "org-1", Name: "Test Org"}
+	if err := repos.Organizations.Create(ctx, org); err != nil {
+		t.Fatalf("failed to create organization: %v", err)
+	}
+	createWebhook(t, repos, "wh-1", server.URL)
+
+	nextAttemptAt := time.Now().Add(-time.Minute)
+	pending := &types.WebhookDelivery{
+		ID:             "whd-1",
+		OrganizationID: "org-1",
+		WebhookID:      "wh-1",
+		Event:          types.WebhookEventApplicationCreated,
+		Payload:        `{"id":"whd-1","event":"application.created"}`,
+		Status:         types.WebhookDeliveryStatusPending,
+		NextAttemptAt:  &nextAttemptAt,
+		CreatedAt:      time.Now().Add(-time.Hour),
+		UpdatedAt:      time.Now().Add(-time.Hour),
+	}
+	if err := repos.Webhooks.CreateDelivery(ctx, pending); err != nil {
+		t.Fatalf("failed to create delivery: %v", err)
+	}
+
+	// Every replica resumes the same pending delivery, each attempt must still be made once
+	var dispatchers []*Dispatcher
+	for range 3 {
+		dispatchers = append(dispatchers, NewDispatcher(repos, Config{
+			MaxAttempts:    3,
+			InitialBackoff: 10 * time.Millisecond,
+			Timeout:        time.Second,
+
+			allowPrivateAddresses: true,
+		}))
+	}
+
+	delivery := waitForDeliveries(t, repos, "wh-1", 1)[0]
+	for _, dispatcher := range dispatchers {
+		dispatcher.Close()
+	}
+	if delivery.Status != types.WebhookDeliveryStatusSucceeded || delivery.Attempts != 2 {
+		t.Errorf("expected the delivery to succeed on its second attempt, got %+v", delivery)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.payloads) != 1 {
+		t.Errorf("expected the receiver to get the delivery once, got %d", len(rc.payloads))
+	}
+}
+
+func TestDispatcherCloseWhileChanging(t *testing.T) {
+	repos, dispatcher := newTestDispatcher(t, 1)
+
+	// Changes racing with Close are either published or dropped, never added to a finished wait
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app := &types.Application{ID: fmt.Sprintf("app-%d", i), OrganizationID: "org-1", Name: "billing"}
+			if err := repos.Applications.Create(context.Background(), app, "user-1", "github-1", "octocat"); err != nil {
+				t.Errorf("failed to create application: %v", err)
+			}
+		}()
+	}
+	dispatcher.Close()
+	wg.Wait()
+	dispatcher.Close()
+}
+
+func TestScanExpiring(t *testing.T) {
+	ctx := context.Background()
+	repos, dispatcher := newTestDispatcher(t, 1)
+
+	rc := &receiver{t: t, secret: "secret-wh-1"}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	org := &types.Organization{ID: "org-1", Name: "Test Org"}
+	if err := repos.Organizations.Create(ctx, org); err != nil {
+		t.Fatalf("failed to create organization: %v", err)
+	}
+	createWebhook(t, repos, "wh-1", server.URL, types.WebhookEventApiKeyExpiring)
+
+	soon := time.Now().Add(24 * time.Hour)
+	later := time.Now().Add(30 * 24 * time.Hour)
+	for _, apiKey := range []*types.ApiKey{
+		{ID: "ak-soon", OrganizationID: "org-1", TokenValue: "token-1", ExpiresAt: &soon},
+		{ID: "ak-later", OrganizationID: "org-1", TokenValue: "token-2", ExpiresAt: &later},
+		{ID: "ak-never", OrganizationID: "org-1", TokenValue: "token-3"},
+	} {
+		if err := repos.ApiKeys.Create(ctx, apiKey, "user-1", "github-1", "octocat"); err != nil {
+			t.Fatalf("failed to create API key: %v", err)
+		}
+	}
+
+	// Scanning again must not report the same expiry twice
+	for range 2 {
+		if err := dispatcher.ScanExpiring(ctx); err != nil {
+			t.Fatalf("failed to scan for expiring keys: %v", err)
+		}
+	}
+
+	deliveries := waitForDeliveries(t, repos, "wh-1", 1)
+	if len(deliveries) != 1 || deliveries[0].Event != types.WebhookEventApiKeyExpiring || !strings.Contains(deliveries[0].Payload, `"id":"ak-soon"`) {
+		t.Errorf("expected one expiring event for ak-soon, got %+v", deliveries)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"webhook.test"}`)
+	now := time.Now().Unix()
+	signature := Sign("secret", now, body)
+	timestamp := strconv.FormatInt(now, 10)
+
+	if err := Verify("secret", timestamp, signature, body, time.Minute); err != nil {
+		t.Errorf("expected a valid signature: %v", err)
+	}
+	if err := Verify("other", timestamp, signature, body, time.Minute); err == nil {
+		t.Errorf("expected another secret to be rejected")
+	}
+	if err := Verify("secret", timestamp, signature, []byte(`{"event":"api_key.deleted"}`), time.Minute); err == nil {
+		t.Errorf("expected a changed body to be rejected")
+	}
+
+	old := now - 600
+	if err := Verify("secret", strconv.FormatInt(old, 10), Sign("secret", old, body), body, time.Minute); err == nil {
+		t.Errorf("expected an old delivery to be rejected")
+	}
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublicAddress(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("IsPublicAddress(%s) = %v, expected %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestSendRefusesPrivateAddresses(t *testing.T) {
+	repos := memory.NewRepositories(memory.NewStore())
+	dispatcher := NewDispatcher(repos, Config{MaxAttempts: 1, Timeout: time.Second})
+	t.Cleanup(dispatcher.Close)
+
+	rc := &receiver{t: t, secret: "secret-wh-1"}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	// The webhook was saved before its host started resolving to loopback, the dial is still refused
+	webhook := createWebhook(t, repos, "wh-1", server.URL)
+	delivery, err := dispatcher.SendTest(context.Background(), webhook)
+	if err != nil {
+		t.Fatalf("failed to send test event: %v", err)
+	}
+	if delivery.Status != types.WebhookDeliveryStatusFailed || !strings.Contains(delivery.Error, ErrForbiddenAddress.Error()) {
+		t.Errorf("expected the delivery to be refused, got %+v", delivery)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.payloads) != 0 {
+		t.Errorf("expected nothing to reach the server, got %d payloads", len(rc.payloads))
+	}
+}